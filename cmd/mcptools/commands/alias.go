@@ -1,13 +1,36 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/f/mcptools/pkg/alias"
+	"github.com/f/mcptools/pkg/jsonutils"
 	"github.com/spf13/cobra"
 )
 
+// alias add flags.
+const (
+	FlagEnv        = "--env"
+	FlagCwd        = "--cwd"
+	FlagHeaders    = "--headers"
+	FlagCredential = "--credential"
+)
+
+// hiddenValue replaces the values of the environment variables and headers of the
+// aliases that alias list does not show.
+const hiddenValue = "***"
+
+func init() {
+	// Aliases saved by earlier versions quote the arguments of their command strings
+	alias.SplitCommand = ParseCommandString
+	alias.JoinCommand = QuoteCommandString
+}
+
 // AliasCmd creates the alias command.
 func AliasCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -24,9 +47,16 @@ Examples:
   # Add a new server alias
   mcp alias add myfs npx -y @modelcontextprotocol/server-filesystem ~/
 
+  # Add an alias with environment variables, or for a server at a URL
+  mcp alias add gh --env GITHUB_TOKEN=ghp_xxx npx -y @modelcontextprotocol/server-github
+  mcp alias add api --transport sse --credential env:API_TOKEN https://example.com/sse
+
   # List all registered server aliases
   mcp alias list
 
+  # Rewrite an aliases file saved by an earlier version
+  mcp alias migrate
+
   # Remove a server alias
   mcp alias remove myfs
 
@@ -37,21 +67,34 @@ Examples:
 	cmd.AddCommand(aliasAddCmd())
 	cmd.AddCommand(aliasListCmd())
 	cmd.AddCommand(aliasRemoveCmd())
+	cmd.AddCommand(aliasMigrateCmd())
 
 	return cmd
 }
 
 func aliasAddCmd() *cobra.Command {
 	addCmd := &cobra.Command{
-		Use:                "add [alias] [command args...]",
+		Use:                "add [alias] [flags] [command args... | URL]",
 		Short:              "Add a new MCP server alias",
 		DisableFlagParsing: true,
-		Long: `Add a new alias for an MCP server command.
+		Long: `Add a new alias for an MCP server command or URL.
 
 The alias will be registered and can be used in place of the server command.
+The flags of the alias come before the command, and -- ends them:
+
+  --env KEY=VALUE           Environment variable of the command (repeatable)
+  --cwd DIR                 Working directory of the command
+  --transport http|sse      Transport of the server at the URL
+  --headers KEY=VALUE       HTTP header of the server at the URL (repeatable)
+  --credential REFERENCE    Credential of the server at the URL, read when connecting
+                            from env:NAME or file:PATH and sent as a bearer token
+
+The credential itself is not stored in the aliases file, only where to read it.
 
 Example:
-  mcp alias add myfs npx -y @modelcontextprotocol/server-filesystem ~/`,
+  mcp alias add myfs npx -y @modelcontextprotocol/server-filesystem ~/
+  mcp alias add db --cwd ~/project --env DB_URL=postgres://localhost/db -- uvx mcp-server-postgres
+  mcp alias add api --headers X-Team=tools --credential file:~/.api-token https://example.com/mcp`,
 		Args: cobra.MinimumNArgs(2),
 		RunE: func(thisCmd *cobra.Command, args []string) error {
 			if len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort) {
@@ -60,52 +103,265 @@ Example:
 			}
 
 			aliasName := args[0]
-			serverCommand := strings.Join(args[1:], " ")
+			server, err := parseAliasServer(args[1:])
+			if err != nil {
+				return err
+			}
 
 			aliases, err := alias.Load()
 			if err != nil {
 				return fmt.Errorf("error loading aliases: %w", err)
 			}
 
-			aliases[aliasName] = alias.ServerAlias{
-				Command: serverCommand,
-			}
+			aliases[aliasName] = server
 
 			if saveErr := alias.Save(aliases); saveErr != nil {
 				return fmt.Errorf("error saving aliases: %w", saveErr)
 			}
 
-			fmt.Fprintf(thisCmd.OutOrStdout(), "Alias '%s' registered for command: %s\n", aliasName, serverCommand)
+			if isURLServer(server) {
+				fmt.Fprintf(thisCmd.OutOrStdout(), "Alias '%s' registered for server: %s\n", aliasName, server.Args[0])
+			} else {
+				fmt.Fprintf(thisCmd.OutOrStdout(), "Alias '%s' registered for command: %s\n", aliasName, QuoteCommandString(server.Args))
+			}
 			return nil
 		},
 	}
 	return addCmd
 }
 
+// parseAliasServer parses the flags and the command or URL of the alias add command.
+func parseAliasServer(args []string) (alias.ServerAlias, error) {
+	var server alias.ServerAlias
+	i := 0
+	for i < len(args) && strings.HasPrefix(args[i], "--") {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		if flag == "--" {
+			i++
+			break
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return server, fmt.Errorf("missing value for %s", flag)
+			}
+			value = args[i+1]
+			i++
+		}
+		i++
+
+		switch flag {
+		case FlagEnv:
+			key, envValue, err := parseKeyValue(value)
+			if err != nil {
+				return server, fmt.Errorf("invalid %s: %w", FlagEnv, err)
+			}
+			server.Env = mergeMaps(server.Env, map[string]string{key: envValue})
+		case FlagHeaders:
+			key, headerValue, err := parseKeyValue(value)
+			if err != nil {
+				return server, fmt.Errorf("invalid %s: %w", FlagHeaders, err)
+			}
+			server.Headers = mergeMaps(server.Headers, map[string]string{key: headerValue})
+		case FlagCwd:
+			dir, err := filepath.Abs(expandPath(value))
+			if err != nil {
+				return server, fmt.Errorf("invalid %s: %w", FlagCwd, err)
+			}
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				return server, fmt.Errorf("invalid %s: %s is not a directory", FlagCwd, value)
+			}
+			server.Cwd = dir
+		case FlagTransport:
+			if value != TransportHTTP && value != TransportSSE {
+				return server, fmt.Errorf("invalid transport option: %s (supported: http, sse)", value)
+			}
+			server.Transport = value
+		case FlagCredential:
+			if _, _, err := parseCredentialReference(value); err != nil {
+				return server, err
+			}
+			server.Credential = value
+		default:
+			return server, fmt.Errorf("unknown flag %s, the flags of the alias come before the command", flag)
+		}
+	}
+
+	server.Args = args[i:]
+	if len(server.Args) == 0 {
+		return server, fmt.Errorf("the command or URL of the server is required")
+	}
+	if isURLServer(server) {
+		if server.Env != nil || server.Cwd != "" {
+			return server, fmt.Errorf("%s and %s are only supported by servers started with a command", FlagEnv, FlagCwd)
+		}
+	} else if server.Transport != "" || server.Headers != nil || server.Credential != "" {
+		return server, fmt.Errorf("%s, %s and %s are only supported by servers at a URL", FlagTransport, FlagHeaders, FlagCredential)
+	}
+	return server, nil
+}
+
+// parseKeyValue parses the KEY=VALUE of a single --env or --headers flag. The value is
+// kept as it is, since it can hold commas, equal signs and spaces.
+func parseKeyValue(option string) (string, string, error) {
+	key, value, found := strings.Cut(option, "=")
+	if !found || strings.TrimSpace(key) == "" {
+		return "", "", fmt.Errorf("%q is not KEY=VALUE", option)
+	}
+	return strings.TrimSpace(key), value, nil
+}
+
+// mergeMaps adds the entries of a map to another one, which is created when it is nil.
+func mergeMaps(to, from map[string]string) map[string]string {
+	if to == nil {
+		to = map[string]string{}
+	}
+	for key, value := range from {
+		to[key] = value
+	}
+	return to
+}
+
+// aliasSettings returns the settings of an alias besides its command, with the names
+// of its environment variables and headers, and their values when they are shown.
+func aliasSettings(server alias.ServerAlias, showSecrets bool) string {
+	var settings []string
+	if len(server.Env) > 0 {
+		settings = append(settings, "env "+settingValues(server.Env, showSecrets))
+	}
+	if server.Cwd != "" {
+		settings = append(settings, "cwd "+server.Cwd)
+	}
+	if server.Transport != "" {
+		settings = append(settings, "transport "+server.Transport)
+	}
+	if len(server.Headers) > 0 {
+		settings = append(settings, "headers "+settingValues(server.Headers, showSecrets))
+	}
+	if server.Credential != "" {
+		settings = append(settings, "credential "+server.Credential)
+	}
+	return strings.Join(settings, ", ")
+}
+
+// settingValues returns the keys of environment variables or headers, with their values
+// when they are shown.
+func settingValues(values map[string]string, showSecrets bool) string {
+	keys := sortedMapKeys(values)
+	if !showSecrets {
+		return strings.Join(keys, ",")
+	}
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+values[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// hideSecrets returns a copy of the aliases with the values of their environment
+// variables and headers replaced, since they can be secrets.
+func hideSecrets(aliases alias.Aliases) alias.Aliases {
+	hidden := make(alias.Aliases, len(aliases))
+	for name, server := range aliases {
+		server.Env = hideValues(server.Env)
+		server.Headers = hideValues(server.Headers)
+		hidden[name] = server
+	}
+	return hidden
+}
+
+// hideValues returns a copy of a map with its values replaced by hiddenValue.
+func hideValues(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	hidden := make(map[string]string, len(values))
+	for key := range values {
+		hidden[key] = hiddenValue
+	}
+	return hidden
+}
+
 func aliasListCmd() *cobra.Command {
-	return &cobra.Command{
+	var showSecrets bool
+	listCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all registered MCP server aliases",
+		Long: `List the registered aliases for MCP servers.
+
+The values of the environment variables and headers of the aliases are hidden in
+every format, since they can be secrets. --show-secrets shows them.
+
+Example:
+  mcp alias list
+  mcp alias list -f json --show-secrets`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			// Load existing aliases
 			aliases, err := alias.Load()
 			if err != nil {
 				return fmt.Errorf("error loading aliases: %w", err)
 			}
+			if !showSecrets {
+				aliases = hideSecrets(aliases)
+			}
 
 			if len(aliases) == 0 {
 				fmt.Fprintln(cmd.OutOrStdout(), "No aliases registered.")
 				return nil
 			}
 
-			fmt.Fprintln(cmd.OutOrStdout(), "Registered MCP server aliases:")
-			for name, a := range aliases {
-				fmt.Fprintf(cmd.OutOrStdout(), "  %s: %s\n", name, a.Command)
+			names := make([]string, 0, len(aliases))
+			for name := range aliases {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			switch {
+			case QueryOption != "" || TemplateOption != "":
+				output, err := FormatOutput(aliases)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), output)
+			case jsonutils.ParseFormat(FormatOption) == jsonutils.FormatTable:
+				fmt.Fprintln(cmd.OutOrStdout(), "Registered MCP server aliases:")
+				for _, name := range names {
+					line := fmt.Sprintf("  %s: %s", name, QuoteCommandString(aliases[name].Args))
+					if settings := aliasSettings(aliases[name], showSecrets); settings != "" {
+						line += " (" + settings + ")"
+					}
+					fmt.Fprintln(cmd.OutOrStdout(), line)
+				}
+			case jsonutils.ParseFormat(FormatOption) == jsonutils.FormatNDJSON:
+				// One alias per line, with its name
+				for _, name := range names {
+					line, err := json.Marshal(struct {
+						Name string `json:"name"`
+						alias.ServerAlias
+					}{name, aliases[name]})
+					if err != nil {
+						return fmt.Errorf("error formatting output: %w", err)
+					}
+					fmt.Fprintln(cmd.OutOrStdout(), string(line))
+				}
+			case jsonutils.ParseFormat(FormatOption) == jsonutils.FormatMarkdown:
+				rows := make([][]string, 0, len(names))
+				for _, name := range names {
+					rows = append(rows, []string{name, "`" + QuoteCommandString(aliases[name].Args) + "`", aliasSettings(aliases[name], showSecrets)})
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), jsonutils.MarkdownTable([]string{"Alias", "Command", "Settings"}, rows))
+			default:
+				output, err := jsonutils.Format(aliases, FormatOption)
+				if err != nil {
+					return fmt.Errorf("error formatting output: %w", err)
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), output)
 			}
 
 			return nil
 		},
 	}
+	listCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Show the values of the environment variables and headers")
+	return listCmd
 }
 
 func aliasRemoveCmd() *cobra.Command {
@@ -145,3 +401,36 @@ Example:
 		},
 	}
 }
+
+func aliasMigrateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate the aliases saved by earlier versions",
+		Long: `Rewrite an aliases file saved by an earlier version, whose aliases store a
+command string, with the arguments of the commands.
+
+The aliases of earlier versions are read as they are, and the file is also migrated
+by alias add and alias remove. The original file is kept as aliases.json.bak.
+
+Example:
+  mcp alias migrate`,
+		Args: cobra.NoArgs,
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			migrated, err := alias.Migrate()
+			if err != nil {
+				return fmt.Errorf("error migrating aliases: %w", err)
+			}
+			if !migrated {
+				fmt.Fprintln(thisCmd.OutOrStdout(), "No aliases to migrate.")
+				return nil
+			}
+
+			configPath, err := alias.GetConfigPath()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(thisCmd.OutOrStdout(), "Aliases migrated, the original file is kept as %s.bak\n", configPath)
+			return nil
+		},
+	}
+}
@@ -3,11 +3,23 @@ package commands
 import (
 	"bytes"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/f/mcptools/pkg/alias"
 )
 
+// resetOutputOptions sets the output options to their defaults for a test, and restores
+// them when it ends.
+func resetOutputOptions(t *testing.T) {
+	t.Helper()
+	format, query, template := FormatOption, QueryOption, TemplateOption
+	t.Cleanup(func() { FormatOption, QueryOption, TemplateOption = format, query, template })
+	FormatOption, QueryOption, TemplateOption = "table", "", ""
+}
+
 func TestAliasCommands(t *testing.T) {
 	// Create a temporary directory for test files
 	tmpDir := t.TempDir()
@@ -17,6 +29,7 @@ func TestAliasCommands(t *testing.T) {
 
 	// Test alias add command
 	t.Run("add", func(t *testing.T) {
+		resetOutputOptions(t)
 		cmd := aliasAddCmd()
 		buf := new(bytes.Buffer)
 		cmd.SetOut(buf)
@@ -43,13 +56,14 @@ func TestAliasCommands(t *testing.T) {
 		serverAlias, exists := aliases["myalias"]
 		if !exists {
 			t.Error("Alias 'myalias' was not saved")
-		} else if serverAlias.Command != "echo hello" {
-			t.Errorf("Incorrect command stored. Expected 'echo hello', got '%s'", serverAlias.Command)
+		} else if !reflect.DeepEqual(serverAlias.Args, []string{"echo", "hello"}) {
+			t.Errorf("Incorrect command stored. Expected [echo hello], got %q", serverAlias.Args)
 		}
 	})
 
 	// Test alias list command
 	t.Run("list", func(t *testing.T) {
+		resetOutputOptions(t)
 		// First verify the alias exists
 		aliases, err := alias.Load()
 		if err != nil {
@@ -59,7 +73,7 @@ func TestAliasCommands(t *testing.T) {
 		if _, exists := aliases["myalias"]; !exists {
 			t.Log("Alias 'myalias' not found, adding it for list test")
 			// Add it back if missing
-			aliases["myalias"] = alias.ServerAlias{Command: "echo hello"}
+			aliases["myalias"] = alias.ServerAlias{Args: []string{"echo", "hello"}}
 			err = alias.Save(aliases)
 			if err != nil {
 				t.Fatalf("Failed to save alias for test: %v", err)
@@ -87,8 +101,51 @@ func TestAliasCommands(t *testing.T) {
 		}
 	})
 
+	// Test that alias list hides the values of environment variables and headers
+	t.Run("list_secrets", func(t *testing.T) {
+		resetOutputOptions(t)
+		aliases, err := alias.Load()
+		if err != nil {
+			t.Fatalf("Failed to load aliases: %v", err)
+		}
+		aliases["gh"] = alias.ServerAlias{Args: []string{"npx", "server-github"}, Env: map[string]string{"GITHUB_TOKEN": "ghp_secret"}}
+		if err := alias.Save(aliases); err != nil {
+			t.Fatalf("Failed to save aliases: %v", err)
+		}
+		defer func() {
+			delete(aliases, "gh")
+			_ = alias.Save(aliases)
+		}()
+
+		for _, format := range []string{"table", "json", "yaml", "ndjson", "markdown"} {
+			FormatOption = format
+			cmd := aliasListCmd()
+			buf := new(bytes.Buffer)
+			cmd.SetOut(buf)
+			cmd.SetArgs([]string{})
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("list command failed: %v", err)
+			}
+			assertContains(t, buf.String(), "GITHUB_TOKEN")
+			if strings.Contains(buf.String(), "ghp_secret") {
+				t.Errorf("list in %s format shows the secret: %s", format, buf.String())
+			}
+		}
+
+		FormatOption = "json"
+		cmd := aliasListCmd()
+		buf := new(bytes.Buffer)
+		cmd.SetOut(buf)
+		cmd.SetArgs([]string{"--show-secrets"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("list command failed: %v", err)
+		}
+		assertContains(t, buf.String(), `"GITHUB_TOKEN":"ghp_secret"`)
+	})
+
 	// Test alias remove command
 	t.Run("remove", func(t *testing.T) {
+		resetOutputOptions(t)
 		// First verify the alias exists
 		aliases, err := alias.Load()
 		if err != nil {
@@ -98,7 +155,7 @@ func TestAliasCommands(t *testing.T) {
 		if _, exists := aliases["myalias"]; !exists {
 			t.Log("Alias 'myalias' not found, adding it for remove test")
 			// Add it back if missing
-			aliases["myalias"] = alias.ServerAlias{Command: "echo hello"}
+			aliases["myalias"] = alias.ServerAlias{Args: []string{"echo", "hello"}}
 			err = alias.Save(aliases)
 			if err != nil {
 				t.Fatalf("Failed to save alias for test: %v", err)
@@ -136,6 +193,7 @@ func TestAliasCommands(t *testing.T) {
 
 	// Test remove non-existent alias
 	t.Run("remove_nonexistent", func(t *testing.T) {
+		resetOutputOptions(t)
 		// Setup cmd
 		cmd := aliasRemoveCmd()
 		cmd.SetArgs([]string{"nonexistent"})
@@ -149,6 +207,7 @@ func TestAliasCommands(t *testing.T) {
 
 	// Test main alias command
 	t.Run("main_command_help", func(t *testing.T) {
+		resetOutputOptions(t)
 		cmd := AliasCmd()
 		buf := new(bytes.Buffer)
 		cmd.SetOut(buf)
@@ -165,3 +224,181 @@ func TestAliasCommands(t *testing.T) {
 		}
 	})
 }
+
+func TestParseAliasServer(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		want    alias.ServerAlias
+		name    string
+		wantErr string
+		args    []string
+	}{
+		{
+			name: "command with spaces",
+			args: []string{"python3", "my server.py"},
+			want: alias.ServerAlias{Args: []string{"python3", "my server.py"}},
+		},
+		{
+			name: "command with env and cwd",
+			args: []string{"--env", "A=1", "--env", "B=2", "--env=C=x=y", "--cwd", dir, "--", "npx", "-y", "server"},
+			want: alias.ServerAlias{
+				Env:  map[string]string{"A": "1", "B": "2", "C": "x=y"},
+				Cwd:  dir,
+				Args: []string{"npx", "-y", "server"},
+			},
+		},
+		{
+			name: "URL with transport, headers and credential",
+			args: []string{"--transport", "sse", "--headers", "X-Team=tools", "--credential", "env:API_TOKEN", "https://example.com/sse"},
+			want: alias.ServerAlias{
+				Headers:    map[string]string{"X-Team": "tools"},
+				Args:       []string{"https://example.com/sse"},
+				Transport:  "sse",
+				Credential: "env:API_TOKEN",
+			},
+		},
+		{
+			name: "env value with commas and equal signs",
+			args: []string{"--env", "DB_URL=postgres://h/db?sslmode=require,connect_timeout=5", "--", "echo", "hi"},
+			want: alias.ServerAlias{
+				Env:  map[string]string{"DB_URL": "postgres://h/db?sslmode=require,connect_timeout=5"},
+				Args: []string{"echo", "hi"},
+			},
+		},
+		{
+			name: "header value with commas and spaces",
+			args: []string{"--headers", "Accept-Language=en, fr", "--headers=X-Filter=a=1,b=2", "https://example.com/mcp"},
+			want: alias.ServerAlias{
+				Headers: map[string]string{"Accept-Language": "en, fr", "X-Filter": "a=1,b=2"},
+				Args:    []string{"https://example.com/mcp"},
+			},
+		},
+		{
+			name:    "env without value",
+			args:    []string{"--env", "DEBUG", "npx", "server"},
+			wantErr: `invalid --env: "DEBUG" is not KEY=VALUE`,
+		},
+		{
+			name:    "headers of a command",
+			args:    []string{"--headers", "X-Team=tools", "npx", "server"},
+			wantErr: "only supported by servers at a URL",
+		},
+		{
+			name:    "env of a URL",
+			args:    []string{"--env", "A=1", "http://localhost:3000/mcp"},
+			wantErr: "only supported by servers started with a command",
+		},
+		{
+			name:    "invalid credential",
+			args:    []string{"--credential", "API_TOKEN", "http://localhost:3000/mcp"},
+			wantErr: "invalid credential API_TOKEN",
+		},
+		{
+			name:    "missing directory",
+			args:    []string{"--cwd", filepath.Join(dir, "missing"), "npx", "server"},
+			wantErr: "is not a directory",
+		},
+		{
+			name:    "unknown flag",
+			args:    []string{"--verbose", "npx", "server"},
+			wantErr: "unknown flag --verbose",
+		},
+		{
+			name:    "no command",
+			args:    []string{"--cwd", dir},
+			wantErr: "the command or URL of the server is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAliasServer(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseAliasServer() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAliasServer() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAliasServer() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAliasMigration(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configPath, err := alias.GetConfigPath()
+	if err != nil {
+		t.Fatalf("GetConfigPath() error = %v", err)
+	}
+	legacy := `{"fs": {"command": "npx -y server-filesystem '/my documents'"}, "new": {"args": ["echo"]}}`
+	if err := os.WriteFile(configPath, []byte(legacy), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	aliases, err := alias.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := alias.ServerAlias{Args: []string{"npx", "-y", "server-filesystem", "/my documents"}}
+	if !reflect.DeepEqual(aliases["fs"], want) {
+		t.Errorf("migrated alias = %+v, want %+v", aliases["fs"], want)
+	}
+	if server, found := alias.Get("fs"); !found || !reflect.DeepEqual(server, want) {
+		t.Errorf("Get() = %+v, %v, want %+v", server, found, want)
+	}
+	if command, found := alias.GetServerCommand("fs"); !found || command != "npx -y server-filesystem '/my documents'" { //nolint:staticcheck // the deprecated function is still supported
+		t.Errorf("GetServerCommand() = %q, %v", command, found)
+	}
+
+	// Reading the aliases does not change the file
+	saved, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	assertEquals(t, string(saved), legacy)
+	if _, err := os.Stat(configPath + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("aliases file was backed up by Load: %v", err)
+	}
+
+	// The migrated file is saved by alias migrate, and the legacy one kept
+	cmd := aliasMigrateCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("migrate command failed: %v", err)
+	}
+	assertContains(t, buf.String(), "Aliases migrated")
+	saved, err = os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(saved), `"command"`) {
+		t.Errorf("aliases file was not migrated: %s", saved)
+	}
+	backup, err := os.ReadFile(configPath + ".bak")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	assertEquals(t, string(backup), legacy)
+
+	// A migrated file is left as it is
+	buf.Reset()
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("migrate command failed: %v", err)
+	}
+	assertContains(t, buf.String(), "No aliases to migrate.")
+	backup, err = os.ReadFile(configPath + ".bak")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	assertEquals(t, string(backup), legacy)
+}
@@ -0,0 +1,403 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+// batch flags.
+const (
+	FlagParallel = "--parallel"
+	FlagContinue = "--continue"
+)
+
+// BatchCall is a single call of a batch file.
+type BatchCall struct {
+	Params map[string]any `json:"params,omitempty"`
+	ID     any            `json:"id,omitempty"`
+	Type   string         `json:"type,omitempty"`
+	Name   string         `json:"name"`
+	URI    string         `json:"uri,omitempty"`
+	Line   int            `json:"-"`
+}
+
+// BatchCmd creates the batch command.
+func BatchCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "batch calls.jsonl [command args...]",
+		Short: "Run a file of tool, resource and prompt calls over one server session",
+		Long: `Run a file of calls over a single connection to an MCP server.
+
+Every line of the file is a JSON object describing one call:
+
+  {"type": "tool", "name": "read_file", "params": {"path": "README.md"}}
+  {"type": "resource", "name": "test://static/resource/1"}
+  {"type": "prompt", "name": "simple_prompt", "params": {"topic": "go"}, "id": "p1"}
+
+The type defaults to "tool". An optional id is copied to the result. Empty lines and
+lines starting with # are skipped. Use - as the file name to read calls from stdin.
+
+By default calls run one at a time and the batch stops at the first failed call,
+including tool results with isError set. Use --continue to run every call and
+--parallel N to run up to N calls at once.
+
+With --format ndjson every call writes one line with the server, method, name, id,
+duration and result or error, as soon as it finishes. The table format prints a
+summary of all calls, and the other formats print the list of results.`,
+		Example: `  mcp batch calls.jsonl npx -y @modelcontextprotocol/server-filesystem ~
+  mcp batch calls.jsonl -f ndjson --parallel 4 --continue npx -y @modelcontextprotocol/server-everything
+  cat calls.jsonl | mcp batch - fs`,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			if len(args) == 0 || (len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort)) {
+				return thisCmd.Help()
+			}
+
+			file, parallel, continueOnError, parsedArgs, err := parseBatchArgs(args)
+			if err != nil {
+				return err
+			}
+			if file == "" || len(parsedArgs) == 0 {
+				return fmt.Errorf("a calls file and a server command are required\nExample: mcp batch calls.jsonl npx -y @modelcontextprotocol/server-filesystem ~")
+			}
+
+			calls, err := readBatchFile(file, thisCmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+
+			mcpClient, err := CreateClientFunc(parsedArgs)
+			if err != nil {
+				return err
+			}
+
+			runner := &batchRunner{
+				cmd:             thisCmd,
+				client:          mcpClient,
+				server:          strings.Join(parsedArgs, " "),
+				parallel:        parallel,
+				continueOnError: continueOnError,
+				ndjson:          jsonutils.ParseFormat(FormatOption) == jsonutils.FormatNDJSON,
+			}
+			start := time.Now()
+			records, failed := runner.run(calls)
+
+			if !runner.ndjson {
+				if err := printBatchResults(thisCmd, calls, records, time.Since(start)); err != nil {
+					return err
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d calls failed", failed, len(calls))
+			}
+			return nil
+		},
+	}
+}
+
+// parseBatchArgs parses the arguments of the batch command and returns the calls file,
+// the parallelism, whether to continue on errors, and the server command.
+func parseBatchArgs(args []string) (string, int, bool, []string, error) {
+	var file string
+	var parsedArgs []string
+	parallel := 1
+	continueOnError := false
+
+	i := 0
+	for i < len(args) {
+		switch {
+		case (args[i] == FlagFormat || args[i] == FlagFormatShort) && i+1 < len(args):
+			FormatOption = args[i+1]
+			i += 2
+		case args[i] == FlagQuery && i+1 < len(args):
+			QueryOption = args[i+1]
+			i += 2
+		case args[i] == FlagTemplate && i+1 < len(args):
+			TemplateOption = args[i+1]
+			i += 2
+		case args[i] == FlagParallel && i+1 < len(args):
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 1 {
+				return "", 0, false, nil, fmt.Errorf("invalid value for %s: %s", FlagParallel, args[i+1])
+			}
+			parallel = n
+			i += 2
+		case args[i] == FlagContinue:
+			continueOnError = true
+			i++
+		case args[i] == FlagTransport && i+1 < len(args):
+			setTransportFlag(args[i+1])
+			i += 2
+		case isClientFlag(args[i]) && i+1 < len(args):
+			setClientFlag(args[i], args[i+1])
+			i += 2
+		case args[i] == FlagServerLogs:
+			ShowServerLogs = true
+			i++
+		case args[i] == FlagAuthUser && i+1 < len(args):
+			AuthUser = args[i+1]
+			i += 2
+		case args[i] == FlagAuthHeader && i+1 < len(args):
+			AuthHeader = args[i+1]
+			i += 2
+		case file == "":
+			file = args[i]
+			i++
+		default:
+			parsedArgs = append(parsedArgs, args[i])
+			i++
+		}
+	}
+
+	return file, parallel, continueOnError, parsedArgs, nil
+}
+
+// readBatchFile reads the calls of a batch file, or of stdin when the name is "-".
+func readBatchFile(name string, stdin io.Reader) ([]BatchCall, error) {
+	reader := stdin
+	if name != "-" {
+		f, err := os.Open(name) // #nosec G304 - the calls file is given by the user
+		if err != nil {
+			return nil, fmt.Errorf("error opening calls file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		reader = f
+	}
+
+	var calls []BatchCall
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var call BatchCall
+		decoder := json.NewDecoder(strings.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&call); err != nil {
+			return nil, fmt.Errorf("line %d: invalid call: %w", lineNumber, err)
+		}
+		if call.Type == "" {
+			call.Type = EntityTypeTool
+		}
+		if call.Name == "" && call.Type == EntityTypeRes {
+			call.Name = call.URI
+		}
+		if call.Name == "" {
+			return nil, fmt.Errorf("line %d: name is required", lineNumber)
+		}
+		if call.Type != EntityTypeTool && call.Type != EntityTypeRes && call.Type != EntityTypePrompt {
+			return nil, fmt.Errorf("line %d: unsupported type %q, use tool, resource or prompt", lineNumber, call.Type)
+		}
+		call.Line = lineNumber
+		calls = append(calls, call)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading calls file: %w", err)
+	}
+	if len(calls) == 0 {
+		return nil, errors.New("the calls file has no calls")
+	}
+
+	return calls, nil
+}
+
+// batchRunner runs the calls of a batch over one client.
+type batchRunner struct {
+	cmd             *cobra.Command
+	client          *client.Client
+	server          string
+	outputMu        sync.Mutex
+	parallel        int
+	continueOnError bool
+	ndjson          bool
+}
+
+// run executes the calls and returns a record for every call that ran, indexed like
+// calls, and the number of failed calls. Unless continueOnError is set, no new calls
+// are started after a call fails.
+func (r *batchRunner) run(calls []BatchCall) ([]*CallRecord, int) {
+	records := make([]*CallRecord, len(calls))
+	var failed int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	stopped := false
+	sem := make(chan struct{}, r.parallel)
+
+	for i, call := range calls {
+		sem <- struct{}{}
+		mu.Lock()
+		stop := stopped
+		mu.Unlock()
+		if stop {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, call BatchCall) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			record := r.execute(call)
+
+			mu.Lock()
+			records[i] = record
+			if recordFailed(record) {
+				failed++
+				if !r.continueOnError {
+					stopped = true
+				}
+			}
+			mu.Unlock()
+
+			if r.ndjson {
+				r.outputMu.Lock()
+				if err := printCallRecord(r.cmd, *record); err != nil {
+					fmt.Fprintf(os.Stderr, "%v\n", err)
+				}
+				r.outputMu.Unlock()
+			}
+		}(i, call)
+	}
+	wg.Wait()
+
+	return records, failed
+}
+
+// execute runs a single call and returns its record.
+func (r *batchRunner) execute(call BatchCall) *CallRecord {
+	record := &CallRecord{Server: r.server, Name: call.Name, ID: call.ID}
+	start := time.Now()
+	ctx := context.Background()
+
+	var resp any
+	var err error
+	switch call.Type {
+	case EntityTypeTool:
+		record.Method = "tools/call"
+		request := mcp.CallToolRequest{}
+		request.Params.Name = call.Name
+		request.Params.Arguments = call.Params
+		resp, err = r.client.CallTool(ctx, request)
+	case EntityTypeRes:
+		record.Method = "resources/read"
+		request := mcp.ReadResourceRequest{}
+		request.Params.URI = call.Name
+		resp, err = r.client.ReadResource(ctx, request)
+	case EntityTypePrompt:
+		record.Method = "prompts/get"
+		request := mcp.GetPromptRequest{}
+		request.Params.Name = call.Name
+		request.Params.Arguments = promptArguments(call.Params)
+		resp, err = r.client.GetPrompt(ctx, request)
+	}
+
+	record.complete(start, ConvertJSONToMap(resp), err)
+	return record
+}
+
+// recordFailed reports whether a call returned an error or a tool result with isError set.
+func recordFailed(record *CallRecord) bool {
+	if record.Error != "" {
+		return true
+	}
+	result, _ := record.Result.(map[string]any)
+	isError, _ := result["isError"].(bool)
+	return isError
+}
+
+// promptArguments converts params to prompt arguments, which are strings: other
+// values are passed as JSON.
+func promptArguments(params map[string]any) map[string]string {
+	arguments := make(map[string]string, len(params))
+	for k, v := range params {
+		if s, ok := v.(string); ok {
+			arguments[k] = s
+		} else {
+			arguments[k] = string(mustJSON(v))
+		}
+	}
+	return arguments
+}
+
+// mustJSON encodes a value that is known to be valid JSON.
+func mustJSON(v any) []byte {
+	data, _ := json.Marshal(v)
+	return data
+}
+
+// printBatchResults prints a summary table of the calls in the table format, and the
+// list of records in the other formats.
+func printBatchResults(cmd *cobra.Command, calls []BatchCall, records []*CallRecord, elapsed time.Duration) error {
+	var ran []CallRecord
+	for _, record := range records {
+		if record != nil {
+			ran = append(ran, *record)
+		}
+	}
+
+	if QueryOption != "" || TemplateOption != "" || jsonutils.ParseFormat(FormatOption) != jsonutils.FormatTable {
+		output, err := FormatOutput(ran)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), output)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LINE\tTYPE\tNAME\tSTATUS\tDURATION\tERROR")
+	var failed int
+	for i, record := range records {
+		if record == nil {
+			continue
+		}
+		status := "ok"
+		if recordFailed(record) {
+			status = "failed"
+			failed++
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%.1fms\t%s\n",
+			calls[i].Line, calls[i].Type, record.Name, status, record.DurationMs, firstLine(record.Error))
+	}
+	_ = w.Flush()
+
+	summary := fmt.Sprintf("\n%d call", len(ran))
+	if len(ran) != 1 {
+		summary += "s"
+	}
+	summary += fmt.Sprintf(", %d failed", failed)
+	if skipped := len(calls) - len(ran); skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped", skipped)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%s in %.1fms\n", summary, float64(elapsed.Microseconds())/1000)
+	return nil
+}
+
+// firstLine returns the first line of a message.
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+	return line
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupBatchMockClient mocks a server whose tools fail when their name starts with "fail".
+func setupBatchMockClient(t *testing.T, called *[]string) func() {
+	t.Helper()
+	return setupMockClient(func(method string, params any) (map[string]any, error) {
+		data, _ := json.Marshal(params)
+		var p struct {
+			Name string `json:"name"`
+			URI  string `json:"uri"`
+		}
+		_ = json.Unmarshal(data, &p)
+		*called = append(*called, method+" "+p.Name+p.URI)
+
+		switch {
+		case strings.HasPrefix(p.Name, "fail"):
+			return nil, fmt.Errorf("tool not found: %s", p.Name)
+		case method == "resources/read":
+			return map[string]any{"contents": []any{map[string]any{"uri": p.URI, "text": "data"}}}, nil
+		default:
+			return map[string]any{"content": []any{map[string]any{"type": "text", "text": "ok " + p.Name}}}, nil
+		}
+	})
+}
+
+func writeCallsFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "calls.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write calls file: %v", err)
+	}
+	return path
+}
+
+func TestBatchCmd_StopsOnError(t *testing.T) {
+	originalFormat := FormatOption
+	defer func() { FormatOption = originalFormat }()
+
+	var called []string
+	defer setupBatchMockClient(t, &called)()
+
+	file := writeCallsFile(t, `# comment
+{"name": "first"}
+
+{"name": "fail_second"}
+{"type": "resource", "uri": "test://third"}
+`)
+
+	cmd := BatchCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{file, "-f", "table", "server", "arg"})
+
+	err := cmd.Execute()
+	if err == nil || err.Error() != "1 of 3 calls failed" {
+		t.Errorf("Expected failed calls error, got %v", err)
+	}
+	if len(called) != 2 {
+		t.Errorf("Expected the batch to stop after the failed call, got calls %v", called)
+	}
+
+	output := buf.String()
+	assertContains(t, output, "tool not found: fail_second")
+	assertContains(t, output, "2 calls, 1 failed, 1 skipped")
+}
+
+func TestBatchCmd_ContinueNDJSON(t *testing.T) {
+	originalFormat := FormatOption
+	defer func() { FormatOption = originalFormat }()
+
+	var called []string
+	defer setupBatchMockClient(t, &called)()
+
+	file := writeCallsFile(t, `{"name": "fail_first", "id": "a"}
+{"type": "resource", "name": "test://second", "id": 2}
+{"name": "third", "params": {"x": 1}}
+`)
+
+	cmd := BatchCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{file, "--continue", "--parallel", "2", "-f", "ndjson", "server"})
+
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for the failed call")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected one line per call, got %q", buf.String())
+	}
+
+	records := map[string]CallRecord{}
+	for _, line := range lines {
+		var record CallRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid record %q: %v", line, err)
+		}
+		records[record.Name] = record
+	}
+
+	if !strings.Contains(records["fail_first"].Error, "tool not found: fail_first") || records["fail_first"].ID != "a" {
+		t.Errorf("Unexpected error record %+v", records["fail_first"])
+	}
+	if records["test://second"].Method != "resources/read" || records["test://second"].ID != float64(2) {
+		t.Errorf("Unexpected resource record %+v", records["test://second"])
+	}
+	if records["third"].Method != "tools/call" || records["third"].Server != "server" || records["third"].Result == nil {
+		t.Errorf("Unexpected tool record %+v", records["third"])
+	}
+}
+
+func TestReadBatchFile_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{name: "invalid JSON", content: "{\"name\": \n", message: "line 1: invalid call"},
+		{name: "unknown field", content: `{"name": "a", "args": {}}`, message: `unknown field "args"`},
+		{name: "missing name", content: "{\"name\": \"a\"}\n{\"type\": \"tool\"}", message: "line 2: name is required"},
+		{name: "unsupported type", content: `{"type": "sampling", "name": "a"}`, message: `unsupported type "sampling"`},
+		{name: "no calls", content: "# nothing\n", message: "has no calls"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readBatchFile("-", strings.NewReader(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
@@ -24,11 +24,23 @@ func parseCallArgs(cmdArgs []string) (string, []string) {
 		case (cmdArgs[i] == FlagFormat || cmdArgs[i] == FlagFormatShort) && i+1 < len(cmdArgs):
 			FormatOption = cmdArgs[i+1]
 			i += 2
+		case cmdArgs[i] == FlagQuery && i+1 < len(cmdArgs):
+			QueryOption = cmdArgs[i+1]
+			i += 2
+		case cmdArgs[i] == FlagTemplate && i+1 < len(cmdArgs):
+			TemplateOption = cmdArgs[i+1]
+			i += 2
+		case cmdArgs[i] == FlagOutputDir && i+1 < len(cmdArgs):
+			OutputDir = cmdArgs[i+1]
+			i += 2
+		case cmdArgs[i] == FlagOpen:
+			OpenOutput = true
+			i++
 		case (cmdArgs[i] == FlagParams || cmdArgs[i] == FlagParamsShort) && i+1 < len(cmdArgs):
 			ParamsString = cmdArgs[i+1]
 			i += 2
 		case (cmdArgs[i] == FlagTransport) && i+1 < len(cmdArgs):
-			TransportOption = cmdArgs[i+1]
+			setTransportFlag(cmdArgs[i+1])
 			i += 2
 		case (cmdArgs[i] == FlagAuthUser) && i+1 < len(cmdArgs):
 			AuthUser = cmdArgs[i+1]
@@ -36,6 +48,9 @@ func parseCallArgs(cmdArgs []string) (string, []string) {
 		case (cmdArgs[i] == FlagAuthHeader) && i+1 < len(cmdArgs):
 			AuthHeader = cmdArgs[i+1]
 			i += 2
+		case isClientFlag(cmdArgs[i]) && i+1 < len(cmdArgs):
+			setClientFlag(cmdArgs[i], cmdArgs[i+1])
+			i += 2
 		case !entityExtracted:
 			entityName = cmdArgs[i]
 			entityExtracted = true
@@ -155,6 +170,13 @@ func CallCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
+			if execErr == nil {
+				if saveErr := saveAndOpenContent(resp, entityName); saveErr != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", saveErr)
+					os.Exit(1)
+				}
+			}
+
 			if formatErr := FormatAndPrintResponse(thisCmd, resp, execErr); formatErr != nil {
 				fmt.Fprintf(os.Stderr, "%v\n", formatErr)
 				os.Exit(1)
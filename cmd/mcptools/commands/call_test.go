@@ -2,6 +2,8 @@ package commands
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -99,3 +101,110 @@ func TestCallCmdRun_Resource(t *testing.T) {
 	expectedOutput := `{"contents":[{"mimeType":"text/plain","text":"bar","uri":"test://foo"}]}`
 	assertContains(t, output, expectedOutput)
 }
+
+func TestCallCmdRun_OutputDir(t *testing.T) {
+	originalFormat, originalOutputDir := FormatOption, OutputDir
+	defer func() { FormatOption, OutputDir = originalFormat, originalOutputDir }()
+
+	mockResponse := map[string]any{
+		"content": []any{
+			map[string]any{"type": "text", "text": "Here is the chart"},
+			map[string]any{"type": "image", "mimeType": "image/png", "data": "iVBORw0KGgo="},
+			map[string]any{"type": "audio", "mimeType": "audio/wav", "data": "UklGRg=="},
+		},
+	}
+
+	cleanup := setupMockClient(func(_ string, _ any) (map[string]any, error) {
+		return mockResponse, nil
+	})
+	defer cleanup()
+
+	dir := filepath.Join(t.TempDir(), "out")
+	cmd := CallCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"chart/render", "--output-dir", dir, "-f", "table", "server", "arg"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+
+	imagePath := filepath.Join(dir, "chart_render-1.png")
+	audioPath := filepath.Join(dir, "chart_render-2.wav")
+	output := buf.String()
+	assertContains(t, output, "[IMAGE image/png] saved to "+imagePath)
+	assertContains(t, output, "[AUDIO audio/wav] saved to "+audioPath)
+
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		t.Fatalf("Expected image to be saved: %v", err)
+	}
+	if string(data) != "\x89PNG\r\n\x1a\n" {
+		t.Errorf("Unexpected image data %q", data)
+	}
+	if _, err := os.Stat(audioPath); err != nil {
+		t.Errorf("Expected audio to be saved: %v", err)
+	}
+}
+
+func TestSaveBinaryContent_ResourceBlob(t *testing.T) {
+	dir := t.TempDir()
+	newResponse := func() map[string]any {
+		return map[string]any{
+			"contents": []any{
+				map[string]any{"uri": "file:///docs/report.pdf", "mimeType": "application/pdf", "blob": "JVBERg=="},
+				map[string]any{"uri": "file:///docs/readme", "mimeType": "text/plain", "text": "hello"},
+			},
+		}
+	}
+
+	// Resources keep their file name, and existing files are not overwritten
+	for _, name := range []string{"report.pdf", "report-2.pdf"} {
+		resp := newResponse()
+		paths, openable, err := saveBinaryContent(resp, dir, "file:///docs/report.pdf")
+		if err != nil {
+			t.Fatalf("saveBinaryContent() error = %v", err)
+		}
+
+		expected := filepath.Join(dir, name)
+		if len(paths) != 1 || paths[0] != expected {
+			t.Errorf("Expected paths [%s], got %v", expected, paths)
+		}
+		if len(openable) != 1 || openable[0] != expected {
+			t.Errorf("Expected openable paths [%s], got %v", expected, openable)
+		}
+
+		contents := resp["contents"].([]any)
+		blob := contents[0].(map[string]any)
+		if blob["file"] != expected || blob["blob"] != nil {
+			t.Errorf("Expected blob to be replaced with the file path, got %v", blob)
+		}
+		if contents[1].(map[string]any)["text"] != "hello" {
+			t.Errorf("Expected text contents to be kept, got %v", contents[1])
+		}
+	}
+}
+
+func TestSaveBinaryContent_ExtensionFromMIMEType(t *testing.T) {
+	dir := t.TempDir()
+	resp := map[string]any{
+		"contents": []any{
+			map[string]any{"uri": "file:///tmp/chart.command", "mimeType": "image/png", "blob": "iVBORw0KGgo="},
+			map[string]any{"uri": "file:///tmp/run.desktop", "mimeType": "application/x-unknown", "blob": "W0Rlc2t0b3Bd"},
+			map[string]any{"uri": "file:///tmp/notes.bat", "mimeType": "text/plain", "blob": "aGVsbG8="},
+			map[string]any{"uri": "file:///tmp/app.js", "mimeType": "text/javascript", "blob": "YWxlcnQoMSk="},
+		},
+	}
+
+	// The server chooses the URI, so only its stem is kept, and only safe types are opened
+	paths, openable, err := saveBinaryContent(resp, dir, "file:///tmp")
+	if err != nil {
+		t.Fatalf("saveBinaryContent() error = %v", err)
+	}
+	chart, run, notes := filepath.Join(dir, "chart.png"), filepath.Join(dir, "run.bin"), filepath.Join(dir, "notes.txt")
+	if len(paths) != 4 || paths[0] != chart || paths[1] != run || paths[2] != notes {
+		t.Errorf("Expected paths [%s %s %s ...], got %v", chart, run, notes, paths)
+	}
+	if len(openable) != 2 || openable[0] != chart || openable[1] != notes {
+		t.Errorf("Expected openable paths [%s %s], got %v", chart, notes, openable)
+	}
+}
@@ -0,0 +1,247 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/f/mcptools/pkg/alias"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/term"
+)
+
+// client flags.
+const (
+	FlagRoot              = "--root"
+	FlagSamplingResponder = "--sampling-responder"
+	FlagProtocolVersion   = "--protocol-version"
+)
+
+// samplingPrompt is the sampling responder that asks for the replies in the terminal.
+const samplingPrompt = "prompt"
+
+// server to client request methods that mcp-go does not handle.
+const (
+	methodListRoots         = "roots/list"
+	methodElicitationCreate = "elicitation/create"
+)
+
+// elicitInput answers the elicitation requests of the server. The web command sets it
+// to show them as forms in the browser. When it is nil they are asked in the terminal,
+// if there is one.
+var elicitInput elicitationHandler
+
+// terminal asks the questions of the sampling and elicitation requests of the servers.
+var terminal = sync.OnceValue(func() *terminalPrompter {
+	return &terminalPrompter{in: bufio.NewReader(os.Stdin), out: os.Stderr}
+})
+
+// isClientFlag reports whether the flag configures the client, with setClientFlag.
+// These flags all take a value.
+func isClientFlag(flag string) bool {
+	return flag == FlagRoot || flag == FlagSamplingResponder || flag == FlagProtocolVersion
+}
+
+// setClientFlag sets the option of a client flag.
+func setClientFlag(flag, value string) {
+	switch flag {
+	case FlagRoot:
+		RootsOption = append(RootsOption, value)
+	case FlagSamplingResponder:
+		SamplingResponder = value
+	case FlagProtocolVersion:
+		ProtocolVersionOption = value
+	}
+}
+
+// clientRoots returns the roots of the directories of RootsOption, with file URIs.
+func clientRoots(dirs []string) ([]mcp.Root, error) {
+	roots := make([]mcp.Root, 0, len(dirs))
+	for _, dir := range dirs {
+		path, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid root %s: %w", dir, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid root %s: %w", dir, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("invalid root %s: not a directory", dir)
+		}
+
+		// Windows paths such as C:\dir become file:///C:/dir
+		uriPath := filepath.ToSlash(path)
+		if !strings.HasPrefix(uriPath, "/") {
+			uriPath = "/" + uriPath
+		}
+		roots = append(roots, mcp.Root{
+			URI:  (&url.URL{Scheme: "file", Path: uriPath}).String(),
+			Name: filepath.Base(path),
+		})
+	}
+	return roots, nil
+}
+
+// newStdioClient creates the client of a server started with a command, with the
+// client features of the flags: the roots, the sampling responder and the elicitation
+// forms. It also returns the stderr of the server.
+func newStdioClient(server alias.ServerAlias) (*client.Client, io.Reader, error) {
+	stdio := transport.NewStdioWithOptions(server.Args[0], nil, server.Args[1:],
+		transport.WithCommandFunc(func(ctx context.Context, _ string, _, _ []string) (*exec.Cmd, error) {
+			return serverCommand(ctx, server), nil
+		}))
+	features := &featureTransport{Stdio: stdio, elicit: elicitInput}
+
+	if len(RootsOption) > 0 {
+		roots, err := clientRoots(RootsOption)
+		if err != nil {
+			return nil, nil, err
+		}
+		features.roots = roots
+	}
+	if features.elicit == nil && term.IsTerminal(int(os.Stdin.Fd())) {
+		features.elicit = terminal().elicit
+	}
+
+	var options []client.ClientOption
+	if SamplingResponder != "" {
+		responder, err := newSamplingResponder(SamplingResponder)
+		if err != nil {
+			return nil, nil, err
+		}
+		options = append(options, client.WithSamplingHandler(responder))
+	}
+
+	c := client.NewClient(features, options...)
+	// The client is started like the HTTP clients, so that the notifications of the
+	// server reach the handlers registered with OnNotification
+	if err := c.Start(context.Background()); err != nil {
+		return nil, nil, err
+	}
+	return c, stdio.Stderr(), nil
+}
+
+// featureTransport is a stdio transport that answers the requests of the server that
+// the client of mcp-go does not handle, roots/list and elicitation/create, and adds
+// their capabilities to the initialize request. The other requests of the server,
+// such as sampling/createMessage, are left to the client.
+type featureTransport struct {
+	*transport.Stdio
+	elicit elicitationHandler
+	roots  []mcp.Root
+}
+
+// capabilities returns the client capabilities of the features that are enabled.
+func (t *featureTransport) capabilities() map[string]any {
+	capabilities := map[string]any{}
+	if t.roots != nil {
+		capabilities["roots"] = map[string]any{}
+	}
+	if t.elicit != nil {
+		capabilities["elicitation"] = map[string]any{}
+	}
+	return capabilities
+}
+
+// SendRequest sends a request to the server, adding the capabilities of the features
+// to the initialize request.
+func (t *featureTransport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	if request.Method == string(mcp.MethodInitialize) {
+		params, err := addCapabilities(request.Params, t.capabilities())
+		if err != nil {
+			return nil, err
+		}
+		request.Params = params
+	}
+	return t.Stdio.SendRequest(ctx, request)
+}
+
+// SetRequestHandler sets the handler of the requests of the server that the features
+// do not answer.
+func (t *featureTransport) SetRequestHandler(handler transport.RequestHandler) {
+	t.Stdio.SetRequestHandler(func(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+		var result any
+		switch {
+		case request.Method == methodListRoots && t.roots != nil:
+			result = mcp.ListRootsResult{Roots: t.roots}
+		case request.Method == methodElicitationCreate && t.elicit != nil:
+			var params elicitationRequest
+			if err := remarshal(request.Params, &params); err != nil {
+				return nil, fmt.Errorf("invalid elicitation request: %w", err)
+			}
+			elicitation, err := t.elicit(ctx, params)
+			if err != nil {
+				return nil, err
+			}
+			result = elicitation
+		default:
+			return handler(ctx, request)
+		}
+
+		resultJSON, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		return &transport.JSONRPCResponse{JSONRPC: mcp.JSONRPC_VERSION, ID: request.ID, Result: resultJSON}, nil
+	})
+}
+
+// addCapabilities adds client capabilities to the params of an initialize request.
+func addCapabilities(params any, capabilities map[string]any) (map[string]any, error) {
+	var merged map[string]any
+	if err := remarshal(params, &merged); err != nil {
+		return nil, err
+	}
+	if merged == nil {
+		merged = map[string]any{}
+	}
+	existing, _ := merged["capabilities"].(map[string]any)
+	if existing == nil {
+		existing = map[string]any{}
+	}
+	for name, capability := range capabilities {
+		existing[name] = capability
+	}
+	merged["capabilities"] = existing
+	return merged, nil
+}
+
+// remarshal converts a value to another type through JSON.
+func remarshal(from, to any) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, to)
+}
+
+// terminalPrompter asks the user questions in the terminal. The questions of one
+// request are asked together, as the requests of the server can arrive at once.
+type terminalPrompter struct {
+	in    *bufio.Reader
+	out   io.Writer
+	mutex sync.Mutex
+}
+
+// ask prints the question and returns the line typed as the answer. It returns false
+// when the input has ended.
+func (p *terminalPrompter) ask(question string) (string, bool) {
+	fmt.Fprint(p.out, question)
+	line, err := p.in.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(p.out)
+		return "", false
+	}
+	return strings.TrimRight(line, "\r\n"), true
+}
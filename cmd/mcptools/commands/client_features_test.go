@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestClientFeatureFlags(t *testing.T) {
+	defer func(roots []string, responder string) {
+		RootsOption, SamplingResponder = roots, responder
+	}(RootsOption, SamplingResponder)
+	RootsOption, SamplingResponder = nil, ""
+
+	parsedArgs := ProcessFlags([]string{"--root", "src", "--sampling-responder", "prompt", "--root", "docs", "npx", "server"})
+	assertEquals(t, strings.Join(parsedArgs, " "), "npx server")
+	assertEquals(t, strings.Join(RootsOption, ","), "src,docs")
+	assertEquals(t, SamplingResponder, "prompt")
+}
+
+func TestClientRoots(t *testing.T) {
+	dir := t.TempDir()
+	roots, err := clientRoots([]string{dir})
+	if err != nil {
+		t.Fatalf("clientRoots() error = %v", err)
+	}
+	expected := []mcp.Root{{URI: "file://" + filepath.ToSlash(dir), Name: filepath.Base(dir)}}
+	if !reflect.DeepEqual(roots, expected) {
+		t.Errorf("clientRoots() = %v, want %v", roots, expected)
+	}
+
+	file := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(file, []byte("text"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, root := range []string{file, filepath.Join(dir, "missing")} {
+		if _, err := clientRoots([]string{root}); err == nil {
+			t.Errorf("clientRoots(%s) should fail", root)
+		}
+	}
+}
+
+func TestAddCapabilities(t *testing.T) {
+	features := &featureTransport{roots: []mcp.Root{}, elicit: terminal().elicit}
+	params := map[string]any{
+		"protocolVersion": "2024-11-05",
+		"capabilities":    map[string]any{"sampling": map[string]any{}},
+	}
+
+	merged, err := addCapabilities(params, features.capabilities())
+	if err != nil {
+		t.Fatalf("addCapabilities() error = %v", err)
+	}
+	assertEquals(t, merged["protocolVersion"].(string), "2024-11-05")
+	expected := map[string]any{"sampling": map[string]any{}, "roots": map[string]any{}, "elicitation": map[string]any{}}
+	if !reflect.DeepEqual(merged["capabilities"], expected) {
+		t.Errorf("addCapabilities() capabilities = %v, want %v", merged["capabilities"], expected)
+	}
+
+	if capabilities := (&featureTransport{}).capabilities(); len(capabilities) != 0 {
+		t.Errorf("capabilities() without features = %v", capabilities)
+	}
+}
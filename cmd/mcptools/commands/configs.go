@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/f/mcptools/pkg/jsonutils"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 	"golang.org/x/text/cases"
@@ -36,6 +37,8 @@ const (
 	formatJSON      = "json"
 	formatPretty    = "pretty"
 	formatTable     = "table"
+	formatYAML      = "yaml"
+	formatMarkdown  = "markdown"
 
 	// File permissions.
 	dirPermissions  = 0o750
@@ -629,6 +632,90 @@ func formatColoredGroupedServers(servers []ServerConfig) string {
 	return buf.String()
 }
 
+// formatServers formats the servers found in configuration files according to
+// FormatOption. Table and pretty show the colored grouped display, json the
+// servers grouped by source, yaml and markdown the servers of every source,
+// ndjson one server per line, and any other format the full server data as
+// JSON. A query or template is applied to the full server data.
+func formatServers(servers []ServerConfig) (string, error) {
+	switch format := strings.ToLower(FormatOption); {
+	case QueryOption != "" || TemplateOption != "":
+		return FormatOutput(servers)
+	case format == formatTable || format == formatPretty:
+		return formatColoredGroupedServers(servers), nil
+	case format == formatJSON:
+		return formatSourceGroupedJSON(servers), nil
+	case jsonutils.ParseFormat(format) == jsonutils.FormatYAML:
+		return jsonutils.Format(servers, formatYAML)
+	case jsonutils.ParseFormat(format) == jsonutils.FormatMarkdown:
+		return formatMarkdownServers(servers), nil
+	case jsonutils.ParseFormat(format) == jsonutils.FormatNDJSON:
+		return jsonutils.Format(servers, string(jsonutils.FormatNDJSON))
+	default:
+		output, err := json.MarshalIndent(servers, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(output), nil
+	}
+}
+
+// formatMarkdownServers formats servers as a markdown table for every source.
+func formatMarkdownServers(servers []ServerConfig) string {
+	if len(servers) == 0 {
+		return "No MCP servers found"
+	}
+
+	serversBySource := make(map[string][]ServerConfig)
+	var sourceOrder []string
+	for _, server := range servers {
+		if _, exists := serversBySource[server.Source]; !exists {
+			sourceOrder = append(sourceOrder, server.Source)
+		}
+		serversBySource[server.Source] = append(serversBySource[server.Source], server)
+	}
+	sort.Strings(sourceOrder)
+
+	sections := make([]string, 0, len(sourceOrder))
+	for _, source := range sourceOrder {
+		sourceServers := serversBySource[source]
+		sort.Slice(sourceServers, func(i, j int) bool {
+			return sourceServers[i].Name < sourceServers[j].Name
+		})
+
+		rows := make([][]string, 0, len(sourceServers))
+		for _, server := range sourceServers {
+			serverType := server.Type
+			target := server.URL
+			if target == "" {
+				target = strings.TrimSpace(server.Command + " " + strings.Join(quoteArgs(server.Args), " "))
+			}
+			if serverType == "" {
+				serverType = map[bool]string{true: "sse", false: "stdio"}[server.URL != ""]
+			}
+			rows = append(rows, []string{server.Name, serverType, "`" + target + "`"})
+		}
+
+		sections = append(sections, "### "+source+"\n\n"+
+			jsonutils.MarkdownTable([]string{"Server", "Type", "Command / URL"}, rows))
+	}
+
+	return strings.Join(sections, "\n\n")
+}
+
+// quoteArgs quotes the arguments that contain spaces.
+func quoteArgs(args []string) []string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if strings.Contains(arg, " ") && !strings.HasPrefix(arg, "\"") {
+			quoted[i] = "\"" + arg + "\""
+		} else {
+			quoted[i] = arg
+		}
+	}
+	return quoted
+}
+
 // scanForServers scans various configuration files for MCP servers.
 func scanForServers() ([]ServerConfig, error) {
 	homeDir, err := os.UserHomeDir()
@@ -881,28 +968,13 @@ VS Code, VS Code Insiders, Windsurf, Cursor, Claude Desktop, Claude Code`,
 				return
 			}
 
-			// Table format (default) now uses the colored grouped display
-			if strings.ToLower(FormatOption) == "table" || strings.ToLower(FormatOption) == "pretty" {
-				output := formatColoredGroupedServers(servers)
-				fmt.Fprintln(cmd.OutOrStdout(), output)
-				return
-			}
-
-			// For JSON format, use the grouped display
-			if strings.ToLower(FormatOption) == "json" {
-				output := formatSourceGroupedJSON(servers)
-				fmt.Fprintln(cmd.OutOrStdout(), output)
-				return
-			}
-
-			// For other formats, use the full server data
-			output, err := json.MarshalIndent(servers, "", "  ")
+			output, err := formatServers(servers)
 			if err != nil {
 				fmt.Fprintf(cmd.ErrOrStderr(), "Error formatting output: %v\n", err)
 				return
 			}
 
-			fmt.Fprintln(cmd.OutOrStdout(), string(output))
+			fmt.Fprintln(cmd.OutOrStdout(), output)
 		},
 	}
 
@@ -1012,27 +1084,13 @@ VS Code, VS Code Insiders, Windsurf, Cursor, Claude Desktop, Claude Code`,
 				return
 			}
 
-			// Output based on format
-			if strings.ToLower(FormatOption) == formatTable || strings.ToLower(FormatOption) == formatPretty {
-				output := formatColoredGroupedServers(servers)
-				fmt.Fprintln(cmd.OutOrStdout(), output)
-				return
-			}
-
-			if strings.ToLower(FormatOption) == formatJSON {
-				output := formatSourceGroupedJSON(servers)
-				fmt.Fprintln(cmd.OutOrStdout(), output)
-				return
-			}
-
-			// For other formats, use the full server data
-			output, err := json.MarshalIndent(servers, "", "  ")
+			output, err := formatServers(servers)
 			if err != nil {
 				fmt.Fprintf(cmd.ErrOrStderr(), "Error formatting output: %v\n", err)
 				return
 			}
 
-			fmt.Fprintln(cmd.OutOrStdout(), string(output))
+			fmt.Fprintln(cmd.OutOrStdout(), output)
 		},
 	}
 
@@ -0,0 +1,249 @@
+package commands
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// contentExtensions maps common MIME types of binary content to file extensions,
+// since the system MIME database does not know all of them on every platform.
+var contentExtensions = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"image/svg+xml":   ".svg",
+	"image/bmp":       ".bmp",
+	"image/tiff":      ".tiff",
+	"image/x-icon":    ".ico",
+	"audio/wav":       ".wav",
+	"audio/x-wav":     ".wav",
+	"audio/wave":      ".wav",
+	"audio/mpeg":      ".mp3",
+	"audio/mp3":       ".mp3",
+	"audio/ogg":       ".ogg",
+	"audio/webm":      ".webm",
+	"audio/flac":      ".flac",
+	"audio/aac":       ".aac",
+	"audio/mp4":       ".m4a",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+	"text/plain":      ".txt",
+	"text/markdown":   ".md",
+	"text/csv":        ".csv",
+}
+
+// contentSaver writes binary contents of a response into a directory.
+type contentSaver struct {
+	dir      string
+	name     string
+	paths    []string
+	openable []string
+}
+
+// saveBinaryContent decodes the base64 image, audio and blob contents of a response
+// into files in dir, named after the entity and the MIME type. The data of every saved
+// item is replaced with a "file" field holding the path of the file, so outputs show
+// where it went. It returns the paths of the saved files in order, and those of the
+// files that are safe to open.
+func saveBinaryContent(resp map[string]any, dir, entityName string) ([]string, []string, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, nil, fmt.Errorf("error creating output directory: %w", err)
+	}
+
+	saver := &contentSaver{dir: dir, name: sanitizeFileName(entityName)}
+
+	// Tool results
+	if err := saver.saveItems(resp["content"]); err != nil {
+		return saver.paths, saver.openable, err
+	}
+
+	// Resource contents
+	if err := saver.saveItems(resp["contents"]); err != nil {
+		return saver.paths, saver.openable, err
+	}
+
+	// Prompt messages
+	messages, _ := resp["messages"].([]any)
+	for _, m := range messages {
+		message, ok := m.(map[string]any)
+		if !ok {
+			continue
+		}
+		if err := saver.saveItems([]any{message["content"]}); err != nil {
+			return saver.paths, saver.openable, err
+		}
+	}
+
+	return saver.paths, saver.openable, nil
+}
+
+// saveItems saves every binary item of a content list.
+func (s *contentSaver) saveItems(items any) error {
+	list, _ := items.([]any)
+	for _, i := range list {
+		item, ok := i.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		// Embedded resources carry their contents in a nested object
+		if resource, isResource := item["resource"].(map[string]any); isResource {
+			item = resource
+		}
+
+		field := "blob"
+		if item["type"] == "image" || item["type"] == "audio" {
+			field = "data"
+		}
+		data, hasData := item[field].(string)
+		if !hasData {
+			continue
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return fmt.Errorf("error decoding %s content: %w", field, err)
+		}
+
+		mimeType, _ := item["mimeType"].(string)
+		uri, _ := item["uri"].(string)
+		filePath := uniqueFilePath(filepath.Join(s.dir, s.fileName(uri, mimeType)))
+		if err := os.WriteFile(filePath, decoded, 0o600); err != nil {
+			return fmt.Errorf("error saving content: %w", err)
+		}
+
+		delete(item, field)
+		item["file"] = filePath
+		s.paths = append(s.paths, filePath)
+		if openableContent(mimeType) {
+			s.openable = append(s.openable, filePath)
+		}
+	}
+	return nil
+}
+
+// fileName returns the name of the file for an item. Resources keep the name in
+// their URI when it has an extension, other items are named after the entity. The
+// extension always comes from the MIME type, as the one of the URI is chosen by the
+// server and could make the file run when it is opened.
+func (s *contentSaver) fileName(uri, mimeType string) string {
+	ext := extensionForMIMEType(mimeType)
+	if base := path.Base(uri); uri != "" && path.Ext(base) != "" {
+		return sanitizeFileName(strings.TrimSuffix(base, path.Ext(base))) + ext
+	}
+
+	return fmt.Sprintf("%s-%d%s", s.name, len(s.paths)+1, ext)
+}
+
+// openableContent reports whether content of a MIME type is safe to open with the
+// default application: images, audio, PDF documents, and text with a known extension,
+// since the system MIME database gives other text types the extension of a script.
+func openableContent(mimeType string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+	kind, _, _ := strings.Cut(mediaType, "/")
+	switch {
+	case kind == "image" || kind == "audio" || mediaType == "application/pdf":
+		return true
+	case kind == "text":
+		_, known := contentExtensions[mediaType]
+		return known
+	}
+	return false
+}
+
+// extensionForMIMEType returns a file extension for a MIME type, or ".bin" if it is
+// not known.
+func extensionForMIMEType(mimeType string) string {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return ".bin"
+	}
+	if ext, ok := contentExtensions[mediaType]; ok {
+		return ext
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+// sanitizeFileName replaces characters that are not safe in file names.
+func sanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	name = strings.TrimLeft(name, ".")
+	if name == "" {
+		return "content"
+	}
+	return name
+}
+
+// uniqueFilePath adds a numeric suffix to a path until it does not exist.
+func uniqueFilePath(filePath string) string {
+	ext := filepath.Ext(filePath)
+	base := strings.TrimSuffix(filePath, ext)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			return filePath
+		}
+		filePath = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+}
+
+// openFile opens a file with the default application of the system.
+func openFile(filePath string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", filePath) // #nosec G204 - opening a file the user asked to save
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", filePath) // #nosec G204 - opening a file the user asked to save
+	default:
+		cmd = exec.Command("xdg-open", filePath) // #nosec G204 - opening a file the user asked to save
+	}
+	return cmd.Start()
+}
+
+// saveAndOpenContent saves the binary contents of a response when OutputDir is set,
+// and opens the saved files that are safe to open when OpenOutput is set.
+func saveAndOpenContent(resp map[string]any, entityName string) error {
+	if OutputDir == "" {
+		return nil
+	}
+
+	paths, openable, err := saveBinaryContent(resp, OutputDir, entityName)
+	if err != nil {
+		return err
+	}
+
+	if OpenOutput {
+		for _, p := range paths {
+			if !slices.Contains(openable, p) {
+				fmt.Fprintf(os.Stderr, "Warning: not opening %s, only images, audio, text and PDF files are opened\n", p)
+				continue
+			}
+			if openErr := openFile(p); openErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not open %s: %v\n", p, openErr)
+			}
+		}
+	}
+	return nil
+}
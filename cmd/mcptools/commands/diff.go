@@ -0,0 +1,186 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/f/mcptools/pkg/jsonutils"
+)
+
+// maxDiffCells limits the size of the table used to find the common lines of two
+// texts. Larger texts are shown as removed and added as a whole.
+const maxDiffCells = 4_000_000
+
+// diffOp is a line of a diff: kept (' '), removed ('-') or added ('+'). The line
+// numbers are the 0-based positions in the old and new texts before the line.
+type diffOp struct {
+	text    string
+	oldLine int
+	newLine int
+	kind    byte
+}
+
+// unifiedDiff returns the differences between two texts in the unified diff format,
+// with the given number of context lines around every change, or an empty string
+// when the texts are equal.
+func unifiedDiff(oldText, newText string, context int) string {
+	if oldText == newText {
+		return ""
+	}
+	ops := diffLines(splitLines(oldText), splitLines(newText))
+
+	var b strings.Builder
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Extend the hunk while the next change is within the context lines
+		start := max(i-context, 0)
+		end := i
+		for j := i; j < len(ops) && j <= end+2*context; j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			}
+		}
+		end = min(end+context+1, len(ops))
+
+		oldCount, newCount := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(ops[start].oldLine, oldCount), hunkRange(ops[start].newLine, newCount))
+		for _, op := range ops[start:end] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.text)
+			b.WriteByte('\n')
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// hunkRange formats the start and length of a hunk. Lines are numbered from 1, and
+// an empty range starts at the line before it.
+func hunkRange(line, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line)
+	}
+	return fmt.Sprintf("%d,%d", line+1, count)
+}
+
+// splitLines splits a text into lines, without the final newline.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines returns the operations that turn the old lines into the new ones, using
+// the longest common subsequence of the lines that differ.
+func diffLines(oldLines, newLines []string) []diffOp {
+	var ops []diffOp
+	oldLine, newLine := 0, 0
+	keep := func(text string) {
+		ops = append(ops, diffOp{text: text, oldLine: oldLine, newLine: newLine, kind: ' '})
+		oldLine++
+		newLine++
+	}
+	remove := func(text string) {
+		ops = append(ops, diffOp{text: text, oldLine: oldLine, newLine: newLine, kind: '-'})
+		oldLine++
+	}
+	add := func(text string) {
+		ops = append(ops, diffOp{text: text, oldLine: oldLine, newLine: newLine, kind: '+'})
+		newLine++
+	}
+
+	// The common start and end are kept without being compared line by line
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+	for _, line := range oldLines[:prefix] {
+		keep(line)
+	}
+	a := oldLines[prefix : len(oldLines)-suffix]
+	b := newLines[prefix : len(newLines)-suffix]
+
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			remove(line)
+		}
+		for _, line := range b {
+			add(line)
+		}
+	} else {
+		// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+		lcs := make([][]int, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+
+		i, j := 0, 0
+		for i < len(a) || j < len(b) {
+			switch {
+			case i < len(a) && j < len(b) && a[i] == b[j]:
+				keep(a[i])
+				i++
+				j++
+			case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+				remove(a[i])
+				i++
+			default:
+				add(b[j])
+				j++
+			}
+		}
+	}
+
+	for _, line := range oldLines[len(oldLines)-suffix:] {
+		keep(line)
+	}
+	return ops
+}
+
+// colorizeDiff colors the removed lines red, the added lines green and the hunk
+// headers cyan when colors are enabled.
+func colorizeDiff(diff string, useColors bool) string {
+	if !useColors {
+		return diff
+	}
+	lines := strings.SplitAfter(diff, "\n")
+	for i, line := range lines {
+		text := strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(text, "@@"):
+			lines[i] = colorize(text, jsonutils.ColorCyan, true) + line[len(text):]
+		case strings.HasPrefix(text, "-"):
+			lines[i] = colorize(text, jsonutils.ColorRed, true) + line[len(text):]
+		case strings.HasPrefix(text, "+"):
+			lines[i] = colorize(text, jsonutils.ColorGreen, true) + line[len(text):]
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,738 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/f/mcptools/pkg/alias"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+// statuses of the doctor checks.
+const (
+	checkOK   = "ok"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// maxReportedLines is the number of stdout and stderr lines shown in the report.
+const maxReportedLines = 5
+
+// exitGracePeriod is how long a failed request waits for the server to exit, so that a
+// server that exits is told apart from one that fails otherwise.
+const exitGracePeriod = 2 * time.Second
+
+// errServerExited is returned when the server exits before answering a request.
+var errServerExited = errors.New("the server exited")
+
+// doctorCheck is the result of one check of the doctor command, with a hint on how to
+// fix it when it did not pass.
+type doctorCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+// doctorReport is the report of the doctor command.
+type doctorReport struct {
+	Server    *mcp.InitializeResult `json:"server,omitempty"`
+	TimingsMs map[string]float64    `json:"timingsMs"`
+	Transport string                `json:"transport"`
+	Checks    []doctorCheck         `json:"checks"`
+}
+
+// add adds a check to the report.
+func (r *doctorReport) add(name, status, detail, hint string) {
+	r.Checks = append(r.Checks, doctorCheck{Name: name, Status: status, Detail: detail, Hint: hint})
+}
+
+// time records the duration of a step that started at start, and returns it.
+func (r *doctorReport) time(step string, start time.Time) time.Duration {
+	elapsed := time.Since(start)
+	r.TimingsMs[step] = float64(elapsed.Microseconds()) / 1000
+	return elapsed
+}
+
+// failures returns the number of checks that failed.
+func (r *doctorReport) failures() int {
+	failures := 0
+	for _, check := range r.Checks {
+		if check.Status == checkFail {
+			failures++
+		}
+	}
+	return failures
+}
+
+// DoctorCmd creates the doctor command.
+func DoctorCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor [command args...]",
+		Short: "Check the connection to an MCP server and explain what goes wrong",
+		Long: `Connect to an MCP server and report how it behaves: the transport, the time it
+takes to start and to answer the initialize request, its name, version, protocol
+version and instructions, the capabilities it declares against the lists it actually
+answers, the number of its tools, prompts and resources, the problems of their
+schemas, and what it writes on stdout and stderr besides JSON-RPC messages.
+
+When the connection fails, doctor explains the common causes: a command that is not
+installed, logs printed on stdout, a server that requires authentication, or a
+server that uses another transport.
+
+The command exits with an error when a check fails.`,
+		Example: `  mcp doctor npx -y @modelcontextprotocol/server-everything
+  mcp doctor fs
+  mcp doctor --transport sse http://localhost:3000/sse
+  mcp doctor -f json http://localhost:3000/mcp`,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			if len(args) == 0 || (len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort)) {
+				return thisCmd.Help()
+			}
+
+			parsedArgs := ProcessFlags(args)
+			if len(parsedArgs) == 0 {
+				return ErrCommandRequired
+			}
+
+			server := resolveServer(parsedArgs)
+			if len(server.Args) == 0 {
+				return ErrCommandRequired
+			}
+
+			report := runDoctor(server)
+			if err := FormatAndPrintResponse(thisCmd, ConvertJSONToMap(report), nil); err != nil {
+				return err
+			}
+			if failures := report.failures(); failures > 0 {
+				return fmt.Errorf("%d of %d checks failed", failures, len(report.Checks))
+			}
+			return nil
+		},
+	}
+}
+
+// runDoctor connects to the server and checks it.
+func runDoctor(server alias.ServerAlias) *doctorReport {
+	report := &doctorReport{TimingsMs: map[string]float64{}}
+	if isURLServer(server) {
+		report.Transport = serverTransport(server)
+		doctorHTTP(report, server)
+	} else {
+		report.Transport = "stdio"
+		doctorStdio(report, server)
+	}
+	return report
+}
+
+// doctorHTTP checks a server at an HTTP URL.
+func doctorHTTP(report *doctorReport, server alias.ServerAlias) {
+	serverURL := server.Args[0]
+	_, cleanURL, _ := buildAuthHeader(serverURL)
+	report.add("transport", checkOK, report.Transport+" "+cleanURL, "")
+
+	versionHeader := &protocolVersionHeader{}
+	c, err := newHTTPClient(server, versionHeader)
+	if err != nil {
+		report.add("connect", checkFail, err.Error(), "")
+		return
+	}
+	defer c.Close() //nolint:errcheck
+
+	start := time.Now()
+	if err := runWithTimeout(func() error { return c.Start(context.Background()) }, nil); err != nil {
+		report.add("connect", checkFail, err.Error(), explainFailure(report.Transport, serverURL, err, nil))
+		return
+	}
+	// The streamable HTTP transport only connects with the initialize request
+	if report.Transport == TransportSSE {
+		report.add("connect", checkOK, "connected in "+formatDuration(report.time("connect", start)), "")
+	}
+
+	if !doctorInitialize(report, c, nil, serverURL) {
+		return
+	}
+	versionHeader.version.Store(report.Server.ProtocolVersion)
+	diagnoseServer(report, c)
+}
+
+// doctorStdio checks a server started with a command.
+func doctorStdio(report *doctorReport, server alias.ServerAlias) {
+	report.add("transport", checkOK, "stdio "+QuoteCommandString(server.Args), "")
+
+	start := time.Now()
+	process, err := startServerProcess(server)
+	if err != nil {
+		report.add("startup", checkFail, err.Error(), explainFailure("stdio", server.Args[0], err, nil))
+		return
+	}
+	report.add("startup", checkOK, "process started in "+formatDuration(report.time("start", start)), "")
+
+	c := client.NewClient(process.transport)
+	if err := c.Start(context.Background()); err != nil {
+		process.waitExited(exitGracePeriod)
+		hint := explainFailure("stdio", server.Args[0], err, process)
+		if hint == "" {
+			hint = "the client could not exchange messages with the server, check that the command starts an MCP server on stdio"
+		}
+		report.add("handshake", checkFail, err.Error(), hint)
+		process.stop(c)
+		process.report(report)
+		return
+	}
+
+	if doctorInitialize(report, c, process, server.Args[0]) {
+		diagnoseServer(report, c)
+	}
+	process.stop(c)
+	process.report(report)
+}
+
+// doctorInitialize sends the initialize request, and adds the server to the report.
+// It returns false when the server did not answer.
+func doctorInitialize(report *doctorReport, c *client.Client, process *serverProcess, target string) bool {
+	start := time.Now()
+	var result *mcp.InitializeResult
+	err := runWithTimeout(func() error {
+		var err error
+		result, err = initializeClient(context.Background(), c)
+		return err
+	}, process.exitedChannel())
+	if err != nil {
+		// A server that exits can fail the request with a write or read error before its
+		// exit is seen, so the failure is only explained once it had the time to exit
+		process.waitExited(exitGracePeriod)
+		report.add("handshake", checkFail, err.Error(), explainFailure(report.Transport, target, err, process))
+		return false
+	}
+	report.add("handshake", checkOK, "initialize answered in "+formatDuration(report.time("initialize", start)), "")
+	report.Server = result
+	return true
+}
+
+// runWithTimeout runs fn and returns its error. It gives up after initializeTimeout,
+// or when the exited channel of the server process is closed.
+func runWithTimeout(fn func() error, exited <-chan struct{}) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-exited:
+		return errServerExited
+	case <-time.After(initializeTimeout):
+		return fmt.Errorf("no answer after %s", initializeTimeout)
+	}
+}
+
+// diagnoseServer checks an initialized server: its latency, its info, the capabilities
+// it declares against the lists it answers, and the schemas of its lists.
+func diagnoseServer(report *doctorReport, c *client.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), initializeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := c.Ping(ctx); err != nil {
+		report.add("latency", checkWarn, "ping failed: "+err.Error(), "servers must answer ping requests")
+	} else {
+		report.add("latency", checkOK, "ping answered in "+formatDuration(report.time("ping", start)), "")
+	}
+
+	server := report.Server
+	serverDetail := fmt.Sprintf("%s %s, protocol version %s", server.ServerInfo.Name, server.ServerInfo.Version, server.ProtocolVersion)
+	if requested, _ := requestedProtocolVersion(); requested != server.ProtocolVersion {
+		serverDetail += fmt.Sprintf(" (%s requested)", requested)
+	}
+	if server.ServerInfo.Name == "" {
+		report.add("server", checkWarn, serverDetail, "the server should give its name in serverInfo")
+	} else {
+		report.add("server", checkOK, serverDetail, "")
+	}
+
+	if instructions := strings.TrimSpace(server.Instructions); instructions != "" {
+		report.add("instructions", checkOK, instructions, "")
+	} else {
+		report.add("instructions", checkOK, "none", "")
+	}
+
+	capabilities := ConvertJSONToMap(server.Capabilities)
+	if len(capabilities) == 0 {
+		report.add("capabilities", checkOK, "none", "")
+	} else {
+		report.add("capabilities", checkOK, strings.Join(capabilityNames(capabilities), ", "), "")
+	}
+
+	var problems []string
+	tools, _, err := listTools(ctx, c, "", true)
+	checkList(report, "tools", server.Capabilities.Tools != nil, len(tools), err, "")
+	problems = append(problems, toolSchemaProblems(tools)...)
+
+	prompts, _, err := listPrompts(ctx, c, "", true)
+	checkList(report, "prompts", server.Capabilities.Prompts != nil, len(prompts), err, "")
+	problems = append(problems, promptSchemaProblems(prompts)...)
+
+	resources, _, err := listResources(ctx, c, "", true)
+	templates, _, templatesErr := listTemplates(ctx, c, "", true)
+	var templatesDetail string
+	if templatesErr == nil && len(templates) > 0 {
+		templatesDetail = pluralize(len(templates), "template")
+	}
+	checkList(report, "resources", server.Capabilities.Resources != nil, len(resources), err, templatesDetail)
+	problems = append(problems, resourceSchemaProblems(resources, templates)...)
+
+	if len(problems) == 0 {
+		report.add("schemas", checkOK, "no problems", "")
+	} else {
+		report.add("schemas", checkWarn, pluralize(len(problems), "problem")+"\n"+strings.Join(problems, "\n"),
+			"clients may reject or misuse the tools, prompts and resources with these problems")
+	}
+}
+
+// capabilityNames returns the names of the capabilities, with their options that are
+// enabled, such as listChanged.
+func capabilityNames(capabilities map[string]any) []string {
+	names := make([]string, 0, len(capabilities))
+	for _, name := range sortedMapKeys(capabilities) {
+		options, _ := capabilities[name].(map[string]any)
+		var enabled []string
+		for _, option := range sortedMapKeys(options) {
+			if value, isBool := options[option].(bool); !isBool || value {
+				enabled = append(enabled, option)
+			}
+		}
+		if len(enabled) > 0 {
+			name += " (" + strings.Join(enabled, ", ") + ")"
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// checkList adds the check of a list, comparing the capability the server declares
+// with the answer of the list request.
+func checkList(report *doctorReport, list string, declared bool, count int, err error, extra string) {
+	detail := pluralize(count, strings.TrimSuffix(list, "s"))
+	if extra != "" {
+		detail += ", " + extra
+	}
+	switch {
+	case declared && err != nil:
+		report.add(list, checkFail, fmt.Sprintf("%s declared, but %s/list failed: %v", list, list, err),
+			fmt.Sprintf("a server that declares the %s capability must answer %s/list", list, list))
+	case declared:
+		report.add(list, checkOK, detail, "")
+	case err == nil && count > 0:
+		report.add(list, checkWarn, fmt.Sprintf("not declared, but %s/list answered with %s", list, detail),
+			fmt.Sprintf("the server should declare the %s capability, or clients will not list them", list))
+	default:
+		report.add(list, checkOK, "not supported", "")
+	}
+}
+
+// toolSchemaProblems returns the problems of the tools: missing and duplicate names,
+// and input schemas that are not JSON Schema objects.
+func toolSchemaProblems(tools []mcp.Tool) []string {
+	var problems []string
+	seen := map[string]bool{}
+	for i, tool := range tools {
+		if tool.Name == "" {
+			problems = append(problems, fmt.Sprintf("tool #%d has no name", i+1))
+			continue
+		}
+		if seen[tool.Name] {
+			problems = append(problems, fmt.Sprintf("tool %q is listed more than once", tool.Name))
+		}
+		seen[tool.Name] = true
+
+		schema := tool.InputSchema
+		if schema.Type != "object" {
+			problems = append(problems, fmt.Sprintf("tool %q: inputSchema has type %q, must be \"object\"", tool.Name, schema.Type))
+		}
+		for _, name := range sortedMapKeys(schema.Properties) {
+			if _, ok := schema.Properties[name].(map[string]any); !ok {
+				problems = append(problems, fmt.Sprintf("tool %q: property %q is not a schema", tool.Name, name))
+			}
+		}
+		for _, name := range schema.Required {
+			if _, ok := schema.Properties[name]; !ok {
+				problems = append(problems, fmt.Sprintf("tool %q: required property %q is not in properties", tool.Name, name))
+			}
+		}
+	}
+	return problems
+}
+
+// promptSchemaProblems returns the problems of the prompts: missing and duplicate
+// names of prompts and arguments.
+func promptSchemaProblems(prompts []mcp.Prompt) []string {
+	var problems []string
+	seen := map[string]bool{}
+	for i, prompt := range prompts {
+		if prompt.Name == "" {
+			problems = append(problems, fmt.Sprintf("prompt #%d has no name", i+1))
+			continue
+		}
+		if seen[prompt.Name] {
+			problems = append(problems, fmt.Sprintf("prompt %q is listed more than once", prompt.Name))
+		}
+		seen[prompt.Name] = true
+
+		arguments := map[string]bool{}
+		for j, argument := range prompt.Arguments {
+			switch {
+			case argument.Name == "":
+				problems = append(problems, fmt.Sprintf("prompt %q: argument #%d has no name", prompt.Name, j+1))
+			case arguments[argument.Name]:
+				problems = append(problems, fmt.Sprintf("prompt %q: argument %q is listed more than once", prompt.Name, argument.Name))
+			}
+			arguments[argument.Name] = true
+		}
+	}
+	return problems
+}
+
+// resourceSchemaProblems returns the problems of the resources and resource templates:
+// missing names and URI templates, and invalid and duplicate URIs.
+func resourceSchemaProblems(resources []mcp.Resource, templates []mcp.ResourceTemplate) []string {
+	var problems []string
+	seen := map[string]bool{}
+	for i, resource := range resources {
+		switch {
+		case resource.URI == "":
+			problems = append(problems, fmt.Sprintf("resource #%d has no URI", i+1))
+			continue
+		case !strings.Contains(resource.URI, ":"):
+			problems = append(problems, fmt.Sprintf("resource %q: URI has no scheme", resource.URI))
+		case seen[resource.URI]:
+			problems = append(problems, fmt.Sprintf("resource %q is listed more than once", resource.URI))
+		}
+		seen[resource.URI] = true
+		if resource.Name == "" {
+			problems = append(problems, fmt.Sprintf("resource %q has no name", resource.URI))
+		}
+	}
+
+	for i, template := range templates {
+		// mcp-go fails to list templates that are not valid
+		if template.URITemplate == nil || template.URITemplate.Template == nil || template.URITemplate.Raw() == "" {
+			problems = append(problems, fmt.Sprintf("resource template #%d has no URI template", i+1))
+			continue
+		}
+		if template.Name == "" {
+			problems = append(problems, fmt.Sprintf("resource template %q has no name", template.URITemplate.Raw()))
+		}
+	}
+	return problems
+}
+
+// serverProcess is a server started by the doctor command. Its stdout is split into
+// lines, the JSON ones go to the transport and the others are kept, with the lines of
+// its stderr, to explain what went wrong.
+type serverProcess struct {
+	err       error
+	cmd       *exec.Cmd
+	transport *transport.Stdio
+	messages  *io.PipeWriter
+	exited    chan struct{}
+	noise     []string
+	stderr    []string
+	mutex     sync.Mutex
+}
+
+// startServerProcess starts the server of the command.
+func startServerProcess(server alias.ServerAlias) (*serverProcess, error) {
+	cmd := serverCommand(context.Background(), server)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	messages, messagesWriter := io.Pipe()
+
+	p := &serverProcess{cmd: cmd, messages: messagesWriter, exited: make(chan struct{})}
+	stdout := &lineWriter{line: func(line string) {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)):
+			_, _ = messagesWriter.Write([]byte(trimmed + "\n"))
+		default:
+			p.record(&p.noise, line)
+		}
+	}}
+	stderr := &lineWriter{line: func(line string) { p.record(&p.stderr, line) }}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		err := cmd.Wait()
+		stdout.flush()
+		stderr.flush()
+		p.mutex.Lock()
+		p.err = err
+		p.mutex.Unlock()
+		_ = messagesWriter.Close()
+		close(p.exited)
+	}()
+
+	// The process is stopped by stop, so the transport has no stderr of its own
+	p.transport = transport.NewIO(messages, stdin, io.NopCloser(strings.NewReader("")))
+	return p, nil
+}
+
+// exitedChannel returns the channel closed when the process exits, and nil when there
+// is no process.
+func (p *serverProcess) exitedChannel() <-chan struct{} {
+	if p == nil {
+		return nil
+	}
+	return p.exited
+}
+
+// waitExited waits for the process to exit, for at most timeout, and reports whether
+// it exited.
+func (p *serverProcess) waitExited(timeout time.Duration) bool {
+	if p == nil {
+		return false
+	}
+	select {
+	case <-p.exited:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// hasExited reports whether the process has exited.
+func (p *serverProcess) hasExited() bool {
+	if p == nil {
+		return false
+	}
+	select {
+	case <-p.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// record adds a line to the lines of the process.
+func (p *serverProcess) record(lines *[]string, line string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	*lines = append(*lines, strings.TrimRight(line, "\r"))
+}
+
+// output returns the lines of stdout that are not JSON-RPC messages and the lines of
+// stderr recorded so far.
+func (p *serverProcess) output() ([]string, []string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]string(nil), p.noise...), append([]string(nil), p.stderr...)
+}
+
+// exitError returns the error of the process once it has exited.
+func (p *serverProcess) exitError() error {
+	if p == nil {
+		return nil
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.err
+}
+
+// stop closes the client, which closes the stdin of the server, and kills the server
+// when it does not exit. The messages that the transport did not read are dropped.
+func (p *serverProcess) stop(c *client.Client) {
+	_ = c.Close()
+	_ = p.messages.Close()
+	select {
+	case <-p.exited:
+	case <-time.After(2 * time.Second):
+		_ = p.cmd.Process.Kill()
+		<-p.exited
+	}
+}
+
+// report adds the checks of the output of the server to the report.
+func (p *serverProcess) report(report *doctorReport) {
+	noise, stderr := p.output()
+	if len(noise) == 0 {
+		report.add("stdout", checkOK, "only JSON-RPC messages", "")
+	} else {
+		report.add("stdout", checkFail, pluralize(len(noise), "line")+" besides the JSON-RPC messages"+shownLines(noise),
+			"MCP servers must only write JSON-RPC messages on stdout, and their logs on stderr")
+	}
+
+	// Servers can log on stderr, so its lines are only shown
+	if len(stderr) == 0 {
+		report.add("stderr", checkOK, "no output", "")
+	} else {
+		report.add("stderr", checkOK, pluralize(len(stderr), "line")+shownLines(stderr), "")
+	}
+}
+
+// lineWriter calls a function with every line written to it.
+type lineWriter struct {
+	line    func(string)
+	partial []byte
+}
+
+// Write splits the data into lines, keeping the last one until it ends.
+func (w *lineWriter) Write(data []byte) (int, error) {
+	w.partial = append(w.partial, data...)
+	for {
+		end := bytes.IndexByte(w.partial, '\n')
+		if end < 0 {
+			return len(data), nil
+		}
+		w.line(string(w.partial[:end]))
+		w.partial = w.partial[end+1:]
+	}
+}
+
+// flush calls the function with the last line when it does not end with a newline.
+func (w *lineWriter) flush() {
+	if len(w.partial) > 0 {
+		w.line(string(w.partial))
+		w.partial = nil
+	}
+}
+
+// explainFailure returns a hint on the cause of an error of the connection to a server,
+// for the common failures: a command that is not installed, a server that prints logs on
+// stdout, exits or does not answer, a server that requires authentication, or one that
+// uses another transport. It returns an empty string when it has no explanation.
+func explainFailure(transportName, target string, err error, process *serverProcess) string {
+	var noise, stderr []string
+	if process != nil {
+		noise, stderr = process.output()
+	}
+	status := httpStatus(err)
+	message := err.Error()
+
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		hint := fmt.Sprintf("%s is neither an alias nor a command found in PATH, check its spelling or install it", target)
+		if runtime := commandRuntimes[strings.TrimSuffix(target, ".exe")]; runtime != "" {
+			hint += fmt.Sprintf(", %s comes with %s", target, runtime)
+		}
+		return hint
+	case errors.Is(err, errServerExited) || errors.Is(err, io.EOF) || process.hasExited():
+		hint := "the server exited before answering"
+		if exitErr := process.exitError(); exitErr != nil {
+			hint = fmt.Sprintf("the server exited before answering (%v)", exitErr)
+		}
+		if len(stderr) > 0 {
+			hint += ", see its stderr for the cause"
+		}
+		return hint
+	case len(noise) > 0 && servesHTTP(noise):
+		return "the server seems to serve HTTP instead of stdio, start it and connect to its URL, with --transport sse for an SSE server"
+	case len(noise) > 0:
+		return "the server printed lines that are not JSON-RPC messages on stdout, they must be written on stderr"
+	case status == 401 || status == 403:
+		if AuthUser != "" || AuthHeader != "" {
+			return fmt.Sprintf("the server rejected the credentials (status %d), check %s or %s", status, FlagAuthUser, FlagAuthHeader)
+		}
+		return fmt.Sprintf("the server requires authentication (status %d), use %s user:password or %s \"Bearer TOKEN\"", status, FlagAuthUser, FlagAuthHeader)
+	case transportName == TransportHTTP && (status == 404 || status == 405 || strings.Contains(message, "unexpected content type")):
+		return fmt.Sprintf("%s is not a streamable HTTP endpoint, check its path (often /mcp), or use --transport sse for an SSE server (often /sse)", target)
+	case transportName == TransportSSE && (status == 400 || status == 404 || status == 405 || strings.Contains(message, "endpoint")):
+		return fmt.Sprintf("%s is not an SSE endpoint, check its path (often /sse), or use --transport http for a streamable HTTP server (often /mcp)", target)
+	case strings.Contains(message, "connection refused"):
+		return fmt.Sprintf("nothing listens at %s, check that the server is running and its port", target)
+	case strings.Contains(message, "no such host"):
+		return fmt.Sprintf("the host of %s is not found, check its spelling", target)
+	case strings.Contains(message, "certificate"):
+		return "the TLS certificate of the server is not trusted"
+	case strings.Contains(message, "protocol version"):
+		return fmt.Sprintf("the client and the server have no protocol version in common, try another one with %s", FlagProtocolVersion)
+	case strings.HasPrefix(message, "no answer") && transportName == "stdio":
+		return "the server did not answer the initialize request, it may still be installing packages (npx, uvx), " +
+			"expect arguments, or serve HTTP instead of stdio"
+	case strings.HasPrefix(message, "no answer"):
+		return "the server did not answer the initialize request, check that the URL is the one of an MCP endpoint"
+	}
+	return ""
+}
+
+// commandRuntimes are the runtimes that install the commands used to start servers.
+var commandRuntimes = map[string]string{
+	"npx":     "Node.js",
+	"node":    "Node.js",
+	"uvx":     "uv",
+	"uv":      "uv",
+	"python":  "Python",
+	"python3": "Python",
+	"docker":  "Docker",
+	"deno":    "Deno",
+	"bunx":    "Bun",
+}
+
+// httpStatus returns the HTTP status code in an error of the HTTP transports, or 0.
+func httpStatus(err error) int {
+	message := err.Error()
+	for _, prefix := range []string{"status code: ", "with status "} {
+		index := strings.Index(message, prefix)
+		if index < 0 {
+			continue
+		}
+		var status int
+		if _, err := fmt.Sscanf(message[index+len(prefix):], "%d", &status); err == nil {
+			return status
+		}
+	}
+	return 0
+}
+
+// servesHTTP reports whether the lines of a server say that it listens on a port.
+func servesHTTP(lines []string) bool {
+	for _, line := range lines {
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "http://") || strings.Contains(lower, "listening on") || strings.Contains(lower, "port ") {
+			return true
+		}
+	}
+	return false
+}
+
+// shownLines returns the lines of stdout or stderr shown in the report, the last ones
+// when there are too many.
+func shownLines(lines []string) string {
+	if len(lines) > maxReportedLines {
+		return fmt.Sprintf(", the last %d:\n%s", maxReportedLines, strings.Join(lines[len(lines)-maxReportedLines:], "\n"))
+	}
+	return ":\n" + strings.Join(lines, "\n")
+}
+
+// pluralize returns the count with the noun, in the plural when it is not 1.
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+// formatDuration formats a duration in milliseconds.
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d.Microseconds())/1000)
+}
@@ -0,0 +1,259 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/f/mcptools/pkg/alias"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestDiagnoseServer(t *testing.T) {
+	c := client.NewClient(&MockTransport{ExecuteFunc: func(method string, _ any) (map[string]any, error) {
+		switch method {
+		case "ping":
+			return map[string]any{}, nil
+		case "tools/list":
+			return map[string]any{"tools": []any{
+				map[string]any{"name": "echo", "inputSchema": map[string]any{"type": "object"}},
+				map[string]any{"name": "echo", "inputSchema": map[string]any{"type": "string"}},
+			}}, nil
+		case "prompts/list":
+			return map[string]any{"prompts": []any{map[string]any{"name": "greet"}}}, nil
+		}
+		return nil, fmt.Errorf("method not found")
+	}})
+	report := &doctorReport{TimingsMs: map[string]float64{}}
+	if !doctorInitialize(report, c, nil, "server") {
+		t.Fatalf("doctorInitialize() failed: %v", report.Checks)
+	}
+	diagnoseServer(report, c)
+	if _, ok := serverInitializeResult(c); ok {
+		t.Error("doctorInitialize() kept the initialize result of its client")
+	}
+
+	statuses := map[string]string{}
+	details := map[string]string{}
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+		details[check.Name] = check.Detail
+	}
+	want := map[string]string{
+		"handshake": checkOK,
+		"latency":   checkOK,
+		"server":    checkOK,
+		"tools":     checkOK,
+		"prompts":   checkWarn,
+		"resources": checkOK,
+		"schemas":   checkWarn,
+	}
+	for name, status := range want {
+		if statuses[name] != status {
+			t.Errorf("check %s = %s (%s), want %s", name, statuses[name], details[name], status)
+		}
+	}
+	assertEquals(t, details["server"], "mock-server 1.0.0, protocol version "+report.Server.ProtocolVersion)
+	assertEquals(t, details["tools"], "2 tools")
+	assertContains(t, details["prompts"], "not declared, but prompts/list answered with 1 prompt")
+	assertEquals(t, details["resources"], "not supported")
+	assertContains(t, details["schemas"], `tool "echo" is listed more than once`)
+	assertContains(t, details["schemas"], `tool "echo": inputSchema has type "string", must be "object"`)
+	if report.failures() != 0 {
+		t.Errorf("failures() = %d, want 0", report.failures())
+	}
+}
+
+func TestCheckList(t *testing.T) {
+	tests := []struct {
+		err        error
+		name       string
+		wantStatus string
+		wantDetail string
+		count      int
+		declared   bool
+	}{
+		{name: "declared", declared: true, count: 2, wantStatus: checkOK, wantDetail: "2 tools"},
+		{name: "declared but failing", declared: true, err: errors.New("boom"), wantStatus: checkFail, wantDetail: "tools declared, but tools/list failed: boom"},
+		{name: "answered but not declared", count: 1, wantStatus: checkWarn, wantDetail: "not declared, but tools/list answered with 1 tool"},
+		{name: "not supported", err: errors.New("method not found"), wantStatus: checkOK, wantDetail: "not supported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &doctorReport{}
+			checkList(report, "tools", tt.declared, tt.count, tt.err, "")
+			check := report.Checks[0]
+			if check.Status != tt.wantStatus || check.Detail != tt.wantDetail {
+				t.Errorf("checkList() = %s %q, want %s %q", check.Status, check.Detail, tt.wantStatus, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestSchemaProblems(t *testing.T) {
+	tools := []mcp.Tool{
+		{Name: "ok", InputSchema: mcp.ToolInputSchema{Type: "object", Properties: map[string]any{"a": map[string]any{"type": "string"}}, Required: []string{"a"}}},
+		{InputSchema: mcp.ToolInputSchema{Type: "object"}},
+		{Name: "bad", InputSchema: mcp.ToolInputSchema{Type: "object", Properties: map[string]any{"a": "string"}, Required: []string{"b"}}},
+	}
+	assertEquals(t, strings.Join(toolSchemaProblems(tools), "\n"), `tool #2 has no name
+tool "bad": property "a" is not a schema
+tool "bad": required property "b" is not in properties`)
+
+	prompts := []mcp.Prompt{
+		{Name: "p", Arguments: []mcp.PromptArgument{{Name: "x"}, {Name: "x"}, {}}},
+		{Name: "p"},
+	}
+	assertEquals(t, strings.Join(promptSchemaProblems(prompts), "\n"), `prompt "p": argument "x" is listed more than once
+prompt "p": argument #3 has no name
+prompt "p" is listed more than once`)
+
+	resources := []mcp.Resource{
+		{URI: "file:///a", Name: "a"},
+		{URI: "file:///a", Name: "a"},
+		{URI: "relative"},
+		{Name: "no uri"},
+	}
+	templates := []mcp.ResourceTemplate{mcp.NewResourceTemplate("file:///{path}", ""), {Name: "empty"}}
+	assertEquals(t, strings.Join(resourceSchemaProblems(resources, templates), "\n"), `resource "file:///a" is listed more than once
+resource "relative": URI has no scheme
+resource "relative" has no name
+resource #4 has no URI
+resource template "file:///{path}" has no name
+resource template #2 has no URI template`)
+}
+
+func TestExplainFailure(t *testing.T) {
+	defer func(user, header string) { AuthUser, AuthHeader = user, header }(AuthUser, AuthHeader)
+
+	tests := []struct {
+		err       error
+		name      string
+		transport string
+		target    string
+		authUser  string
+		want      string
+	}{
+		{
+			name:      "missing command",
+			transport: "stdio",
+			target:    "npx",
+			err:       &exec.Error{Name: "npx", Err: exec.ErrNotFound},
+			want:      "npx is neither an alias nor a command found in PATH, check its spelling or install it, npx comes with Node.js",
+		},
+		{
+			name:      "authentication required",
+			transport: TransportHTTP,
+			target:    "http://localhost/mcp",
+			err:       errors.New("transport error: request failed with status 401: unauthorized"),
+			want:      `the server requires authentication (status 401), use --auth-user user:password or --auth-header "Bearer TOKEN"`,
+		},
+		{
+			name:      "credentials rejected",
+			transport: TransportSSE,
+			target:    "http://localhost/sse",
+			authUser:  "user:wrong",
+			err:       errors.New("unexpected status code: 403"),
+			want:      "the server rejected the credentials (status 403), check --auth-user or --auth-header",
+		},
+		{
+			name:      "not a streamable HTTP endpoint",
+			transport: TransportHTTP,
+			target:    "http://localhost/sse",
+			err:       errors.New("request failed with status 405: method not allowed"),
+			want:      "http://localhost/sse is not a streamable HTTP endpoint, check its path (often /mcp), or use --transport sse for an SSE server (often /sse)",
+		},
+		{
+			name:      "not an SSE endpoint",
+			transport: TransportSSE,
+			target:    "http://localhost/mcp",
+			err:       errors.New("unexpected status code: 400"),
+			want:      "http://localhost/mcp is not an SSE endpoint, check its path (often /sse), or use --transport http for a streamable HTTP server (often /mcp)",
+		},
+		{
+			name:      "nothing listening",
+			transport: TransportHTTP,
+			target:    "http://localhost:1/mcp",
+			err:       errors.New("dial tcp 127.0.0.1:1: connect: connection refused"),
+			want:      "nothing listens at http://localhost:1/mcp, check that the server is running and its port",
+		},
+		{
+			name:      "unknown error",
+			transport: TransportHTTP,
+			target:    "http://localhost/mcp",
+			err:       errors.New("something else"),
+			want:      "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			AuthUser, AuthHeader = tt.authUser, ""
+			assertEquals(t, explainFailure(tt.transport, tt.target, tt.err, nil), tt.want)
+		})
+	}
+}
+
+func TestDoctorStdio(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	report := runDoctor(alias.ServerAlias{Args: []string{"sh", "-c", "echo 'Listening on port 3000'; echo 'missing module' >&2; exit 2"}})
+	checks := map[string]doctorCheck{}
+	for _, check := range report.Checks {
+		checks[check.Name] = check
+	}
+	if checks["handshake"].Status != checkFail {
+		t.Errorf("handshake status = %s, want %s", checks["handshake"].Status, checkFail)
+	}
+	assertContains(t, checks["handshake"].Hint, "the server exited before answering (exit status 2), see its stderr")
+	if checks["stdout"].Status != checkFail {
+		t.Errorf("stdout status = %s, want %s", checks["stdout"].Status, checkFail)
+	}
+	assertContains(t, checks["stdout"].Detail, "Listening on port 3000")
+	assertContains(t, checks["stderr"].Detail, "missing module")
+}
+
+func TestExplainFailureExitedServer(t *testing.T) {
+	// The transport can fail with a write error before the exit of the server is seen
+	process := &serverProcess{exited: make(chan struct{}), err: errors.New("exit status 2"), stderr: []string{"missing module"}}
+	err := errors.New("write |1: broken pipe")
+	if hint := explainFailure("stdio", "sh", err, process); hint != "" {
+		t.Errorf("explainFailure() of a running server = %q, want no hint", hint)
+	}
+	close(process.exited)
+	assertEquals(t, explainFailure("stdio", "sh", err, process), "the server exited before answering (exit status 2), see its stderr for the cause")
+}
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	w := &lineWriter{line: func(line string) { lines = append(lines, line) }}
+	_, _ = w.Write([]byte("first\nsec"))
+	_, _ = w.Write([]byte("ond\nthird"))
+	w.flush()
+	assertEquals(t, strings.Join(lines, "|"), "first|second|third")
+}
+
+func TestEndpointNotFound(t *testing.T) {
+	defer func(transport string) { TransportOption = transport }(TransportOption)
+	TransportOption = TransportHTTP
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	c, err := newHTTPClient(alias.ServerAlias{Args: []string{server.URL + "/mcp"}}, &protocolVersionHeader{})
+	if err != nil {
+		t.Fatalf("newHTTPClient() error = %v", err)
+	}
+	defer c.Close() //nolint:errcheck
+	if _, err := initializeClient(t.Context(), c); err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("initializeClient() error = %v, want a 404 error", err)
+	}
+}
@@ -0,0 +1,393 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// elicitation actions.
+const (
+	elicitationAccept  = "accept"
+	elicitationDecline = "decline"
+	elicitationCancel  = "cancel"
+)
+
+// errNoValue is returned by elicitationField.parse for an empty value without default.
+var errNoValue = errors.New("no value")
+
+// elicitationHandler answers an elicitation request of the server.
+type elicitationHandler func(ctx context.Context, request elicitationRequest) (*elicitationResult, error)
+
+// elicitationRequest is the params of an elicitation/create request, with which the
+// server asks the user for input. mcp-go has no types for elicitation yet.
+type elicitationRequest struct {
+	Message         string            `json:"message"`
+	RequestedSchema elicitationSchema `json:"requestedSchema"`
+}
+
+// elicitationResult is the answer of the user to an elicitation request.
+type elicitationResult struct {
+	Content map[string]any `json:"content,omitempty"`
+	Action  string         `json:"action"`
+}
+
+// elicitationSchema is the flat object schema of the requested input. Its properties
+// are read as a list of fields, as they are asked in order: the required fields first,
+// in the order of the schema, then the others by name.
+type elicitationSchema struct {
+	Fields   []elicitationField `json:"fields"`
+	Required []string           `json:"required,omitempty"`
+}
+
+// elicitationField is a property of the requested input, of a primitive type.
+type elicitationField struct {
+	Default     any      `json:"default,omitempty"`
+	Minimum     *float64 `json:"minimum,omitempty"`
+	Maximum     *float64 `json:"maximum,omitempty"`
+	MinLength   *int     `json:"minLength,omitempty"`
+	MaxLength   *int     `json:"maxLength,omitempty"`
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Format      string   `json:"format,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	EnumNames   []string `json:"enumNames,omitempty"`
+}
+
+// UnmarshalJSON reads the properties of the schema as its fields.
+func (s *elicitationSchema) UnmarshalJSON(data []byte) error {
+	var schema struct {
+		Properties map[string]elicitationField `json:"properties"`
+		Required   []string                    `json:"required"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return err
+	}
+
+	s.Fields, s.Required = nil, schema.Required
+	for name, field := range schema.Properties {
+		field.Name = name
+		s.Fields = append(s.Fields, field)
+	}
+	rank := func(name string) int {
+		if i := slices.Index(s.Required, name); i >= 0 {
+			return i
+		}
+		return len(s.Required)
+	}
+	slices.SortFunc(s.Fields, func(a, b elicitationField) int {
+		if order := rank(a.Name) - rank(b.Name); order != 0 {
+			return order
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return nil
+}
+
+// content returns the content of the values typed for the fields, or an error naming
+// the first invalid or missing value.
+func (s elicitationSchema) content(values map[string]string) (map[string]any, error) {
+	content := map[string]any{}
+	for _, field := range s.Fields {
+		value, err := field.parse(values[field.Name])
+		switch {
+		case errors.Is(err, errNoValue) && slices.Contains(s.Required, field.Name):
+			return nil, fmt.Errorf("%s is required", field.label())
+		case errors.Is(err, errNoValue):
+		case err != nil:
+			return nil, fmt.Errorf("%s: %w", field.label(), err)
+		default:
+			content[field.Name] = value
+		}
+	}
+	return content, nil
+}
+
+// label returns the title of the field, or its name.
+func (f elicitationField) label() string {
+	if f.Title != "" {
+		return f.Title
+	}
+	return f.Name
+}
+
+// hint describes the values the field accepts.
+func (f elicitationField) hint() string {
+	switch {
+	case len(f.Enum) > 0:
+		return strings.Join(f.Enum, "/")
+	case f.Type == "boolean":
+		return "y/n"
+	case f.Format != "":
+		return f.Format
+	}
+	return f.Type
+}
+
+// parse returns the value of the text typed for the field. An empty text is the
+// default value of the field, or errNoValue when it has none.
+func (f elicitationField) parse(text string) (any, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		if f.Default != nil {
+			return f.Default, nil
+		}
+		return nil, errNoValue
+	}
+
+	if len(f.Enum) > 0 {
+		for i, value := range f.Enum {
+			if text == value || (i < len(f.EnumNames) && text == f.EnumNames[i]) {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("must be one of %s", strings.Join(f.Enum, ", "))
+	}
+
+	switch f.Type {
+	case "boolean":
+		switch strings.ToLower(text) {
+		case "y", "yes", "true":
+			return true, nil
+		case "n", "no", "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("must be yes or no")
+	case "number", "integer":
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a number")
+		}
+		if f.Type == "integer" && number != float64(int64(number)) {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		if f.Minimum != nil && number < *f.Minimum {
+			return nil, fmt.Errorf("must be at least %v", *f.Minimum)
+		}
+		if f.Maximum != nil && number > *f.Maximum {
+			return nil, fmt.Errorf("must be at most %v", *f.Maximum)
+		}
+		if f.Type == "integer" {
+			return int64(number), nil
+		}
+		return number, nil
+	}
+
+	length := utf8.RuneCountInString(text)
+	if f.MinLength != nil && length < *f.MinLength {
+		return nil, fmt.Errorf("must have at least %d characters", *f.MinLength)
+	}
+	if f.MaxLength != nil && length > *f.MaxLength {
+		return nil, fmt.Errorf("must have at most %d characters", *f.MaxLength)
+	}
+	return text, nil
+}
+
+// elicit shows the message of an elicitation request and asks for the value of every
+// field, until it is valid. The user can also decline or cancel the request.
+func (p *terminalPrompter) elicit(_ context.Context, request elicitationRequest) (*elicitationResult, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	fmt.Fprintf(p.out, "\n[elicitation] %s\n", request.Message)
+	if action := p.askAction(); action != elicitationAccept {
+		return &elicitationResult{Action: action}, nil
+	}
+
+	content := map[string]any{}
+	schema := request.RequestedSchema
+	for _, field := range schema.Fields {
+		value, ok := p.askField(field, slices.Contains(schema.Required, field.Name))
+		if !ok {
+			return &elicitationResult{Action: elicitationCancel}, nil
+		}
+		if value != nil {
+			content[field.Name] = value
+		}
+	}
+	return &elicitationResult{Action: elicitationAccept, Content: content}, nil
+}
+
+// askAction asks whether to accept, decline or cancel an elicitation request. The end
+// of the input cancels it.
+func (p *terminalPrompter) askAction() string {
+	for {
+		answer, ok := p.ask("Answer? [a]ccept, [d]ecline or [c]ancel: ")
+		if !ok {
+			return elicitationCancel
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "a", elicitationAccept:
+			return elicitationAccept
+		case "d", elicitationDecline:
+			return elicitationDecline
+		case "c", elicitationCancel:
+			return elicitationCancel
+		}
+	}
+}
+
+// askField asks for the value of a field until it is valid. The value is nil when an
+// optional field is left empty, and ok is false when the input has ended.
+func (p *terminalPrompter) askField(field elicitationField, required bool) (any, bool) {
+	if field.Description != "" {
+		fmt.Fprintf(p.out, "  %s\n", field.Description)
+	}
+	question := fmt.Sprintf("  %s (%s", field.label(), field.hint())
+	if required {
+		question += ", required"
+	}
+	if field.Default != nil {
+		question += fmt.Sprintf(", default %v", field.Default)
+	}
+	question += "): "
+
+	for {
+		text, ok := p.ask(question)
+		if !ok {
+			return nil, false
+		}
+		value, err := field.parse(text)
+		switch {
+		case errors.Is(err, errNoValue) && required:
+			fmt.Fprintf(p.out, "  %s is required\n", field.label())
+		case errors.Is(err, errNoValue):
+			return nil, true
+		case err != nil:
+			fmt.Fprintf(p.out, "  %s %v\n", field.label(), err)
+		default:
+			return value, true
+		}
+	}
+}
+
+// webElicitations passes the elicitation requests of the server to the browser, which
+// shows them as forms, and waits for the answers.
+type webElicitations struct {
+	pending map[int]*webElicitation
+	mutex   sync.Mutex
+	nextID  int
+}
+
+// webElicitation is an elicitation request waiting for its answer in the browser.
+type webElicitation struct {
+	answer  chan *elicitationResult
+	Request elicitationRequest `json:"request"`
+	ID      int                `json:"id"`
+}
+
+// newWebElicitations creates an empty list of elicitation requests.
+func newWebElicitations() *webElicitations {
+	return &webElicitations{pending: map[int]*webElicitation{}}
+}
+
+// elicit waits for the browser to answer the request.
+func (e *webElicitations) elicit(ctx context.Context, request elicitationRequest) (*elicitationResult, error) {
+	e.mutex.Lock()
+	e.nextID++
+	elicitation := &webElicitation{ID: e.nextID, Request: request, answer: make(chan *elicitationResult, 1)}
+	e.pending[elicitation.ID] = elicitation
+	e.mutex.Unlock()
+
+	defer func() {
+		e.mutex.Lock()
+		delete(e.pending, elicitation.ID)
+		e.mutex.Unlock()
+	}()
+
+	select {
+	case result := <-elicitation.answer:
+		return result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// handle lists the pending requests on GET, and answers one on POST, with the action
+// and the values typed in its form.
+func (e *webElicitations) handle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodGet {
+			e.mutex.Lock()
+			pending := make([]*webElicitation, 0, len(e.pending))
+			for _, elicitation := range e.pending {
+				pending = append(pending, elicitation)
+			}
+			e.mutex.Unlock()
+			slices.SortFunc(pending, func(a, b *webElicitation) int { return a.ID - b.ID })
+
+			//nolint:errcheck,gosec // No need to handle error from Encode in this context
+			json.NewEncoder(w).Encode(map[string]any{"result": pending})
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var answer struct {
+			Values map[string]string `json:"values"`
+			Action string            `json:"action"`
+			ID     int               `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&answer); err != nil {
+			writeElicitationError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+			return
+		}
+
+		e.mutex.Lock()
+		elicitation, found := e.pending[answer.ID]
+		e.mutex.Unlock()
+		if !found {
+			writeElicitationError(w, http.StatusNotFound, "The request was already answered")
+			return
+		}
+
+		result := &elicitationResult{Action: answer.Action}
+		switch answer.Action {
+		case elicitationAccept:
+			content, err := elicitation.Request.RequestedSchema.content(answer.Values)
+			if err != nil {
+				writeElicitationError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			result.Content = content
+		case elicitationDecline, elicitationCancel:
+		default:
+			writeElicitationError(w, http.StatusBadRequest, "Invalid action: "+answer.Action)
+			return
+		}
+
+		// The request is answered once, by the first valid answer
+		e.mutex.Lock()
+		_, found = e.pending[answer.ID]
+		delete(e.pending, answer.ID)
+		e.mutex.Unlock()
+		if !found {
+			writeElicitationError(w, http.StatusNotFound, "The request was already answered")
+			return
+		}
+		elicitation.answer <- result
+
+		//nolint:errcheck,gosec // No need to handle error from Encode in this context
+		json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}
+}
+
+// writeElicitationError writes an error response of the elicitation API.
+func writeElicitationError(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	//nolint:errcheck,gosec // No need to handle error from Encode in this context
+	json.NewEncoder(w).Encode(map[string]any{"error": message})
+}
@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testElicitation = `{
+	"message": "Who are you?",
+	"requestedSchema": {
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "title": "Name", "minLength": 2},
+			"age": {"type": "integer", "minimum": 0},
+			"color": {"type": "string", "enum": ["red", "blue"], "enumNames": ["Red", "Blue"]},
+			"subscribe": {"type": "boolean", "default": true}
+		},
+		"required": ["name"]
+	}
+}`
+
+func parseTestElicitation(t *testing.T) elicitationRequest {
+	t.Helper()
+	var request elicitationRequest
+	if err := json.Unmarshal([]byte(testElicitation), &request); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	return request
+}
+
+func TestElicitationSchema(t *testing.T) {
+	request := parseTestElicitation(t)
+	var names []string
+	for _, field := range request.RequestedSchema.Fields {
+		names = append(names, field.Name)
+	}
+	assertEquals(t, strings.Join(names, ","), "name,age,color,subscribe")
+
+	content, err := request.RequestedSchema.content(map[string]string{"name": "Ann", "age": "42", "color": "Blue"})
+	if err != nil {
+		t.Fatalf("content() error = %v", err)
+	}
+	expected := map[string]any{"name": "Ann", "age": int64(42), "color": "blue", "subscribe": true}
+	if !reflect.DeepEqual(content, expected) {
+		t.Errorf("content() = %v, want %v", content, expected)
+	}
+
+	for values, message := range map[string]string{
+		"":               "Name is required",
+		"name=A":         "Name: must have at least 2 characters",
+		"name=Ann,age=x": "age: must be a number",
+	} {
+		input := map[string]string{}
+		for _, value := range strings.Split(values, ",") {
+			if name, text, found := strings.Cut(value, "="); found {
+				input[name] = text
+			}
+		}
+		if _, err := request.RequestedSchema.content(input); err == nil || err.Error() != message {
+			t.Errorf("content(%v) error = %v, want %s", input, err, message)
+		}
+	}
+}
+
+func TestElicitationFieldParse(t *testing.T) {
+	minimum, maximum := 1.0, 10.0
+	tests := []struct {
+		expected any
+		field    elicitationField
+		text     string
+		wantErr  bool
+	}{
+		{field: elicitationField{Type: "string"}, text: " text ", expected: "text"},
+		{field: elicitationField{Type: "number"}, text: "1.5", expected: 1.5},
+		{field: elicitationField{Type: "integer"}, text: "1.5", wantErr: true},
+		{field: elicitationField{Type: "integer", Minimum: &minimum, Maximum: &maximum}, text: "11", wantErr: true},
+		{field: elicitationField{Type: "integer", Minimum: &minimum, Maximum: &maximum}, text: "10", expected: int64(10)},
+		{field: elicitationField{Type: "boolean"}, text: "Yes", expected: true},
+		{field: elicitationField{Type: "boolean"}, text: "maybe", wantErr: true},
+		{field: elicitationField{Type: "string", Enum: []string{"a", "b"}}, text: "c", wantErr: true},
+		{field: elicitationField{Type: "string", Default: "x"}, text: "", expected: "x"},
+		{field: elicitationField{Type: "string"}, text: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		value, err := tt.field.parse(tt.text)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(value, tt.expected) {
+			t.Errorf("parse(%q) of %+v = %v, %v", tt.text, tt.field, value, err)
+		}
+	}
+}
+
+func TestTerminalElicit(t *testing.T) {
+	request := parseTestElicitation(t)
+
+	out := new(bytes.Buffer)
+	input := "maybe\na\n\nAnn\n-1\n7\nGreen\nRed\nno\n"
+	prompter := &terminalPrompter{in: bufio.NewReader(strings.NewReader(input)), out: out}
+	result, err := prompter.elicit(context.Background(), request)
+	if err != nil {
+		t.Fatalf("elicit() error = %v", err)
+	}
+	expected := &elicitationResult{
+		Action:  elicitationAccept,
+		Content: map[string]any{"name": "Ann", "age": int64(7), "color": "red", "subscribe": false},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("elicit() = %+v, want %+v", result, expected)
+	}
+	assertContains(t, out.String(), "[elicitation] Who are you?")
+	assertContains(t, out.String(), "Name is required")
+	assertContains(t, out.String(), "age must be at least 0")
+
+	for input, action := range map[string]string{"d\n": elicitationDecline, "cancel\n": elicitationCancel, "a\nAnn\n": elicitationCancel} {
+		prompter = &terminalPrompter{in: bufio.NewReader(strings.NewReader(input)), out: out}
+		result, err := prompter.elicit(context.Background(), request)
+		if err != nil || result.Action != action || result.Content != nil {
+			t.Errorf("elicit() with input %q = %+v, %v", input, result, err)
+		}
+	}
+}
+
+func TestWebElicitations(t *testing.T) {
+	elicitations := newWebElicitations()
+	server := httptest.NewServer(elicitations.handle())
+	defer server.Close()
+
+	results := make(chan *elicitationResult, 1)
+	go func() {
+		result, _ := elicitations.elicit(context.Background(), parseTestElicitation(t))
+		results <- result
+	}()
+
+	var pending struct {
+		Result []struct {
+			ID int `json:"id"`
+		} `json:"result"`
+	}
+	for deadline := time.Now().Add(time.Second); len(pending.Result) == 0 && time.Now().Before(deadline); {
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatalf("GET error = %v", err)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&pending); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+	if len(pending.Result) != 1 {
+		t.Fatalf("pending elicitations = %+v", pending)
+	}
+
+	answer := func(body string) int {
+		resp, err := http.Post(server.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST error = %v", err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := answer(`{"id": 1, "action": "accept", "values": {}}`); status != http.StatusBadRequest {
+		t.Errorf("answer without the required value status = %d", status)
+	}
+	if status := answer(`{"id": 1, "action": "accept", "values": {"name": "Ann", "subscribe": "false"}}`); status != http.StatusOK {
+		t.Errorf("answer status = %d", status)
+	}
+
+	result := <-results
+	expected := &elicitationResult{Action: elicitationAccept, Content: map[string]any{"name": "Ann", "subscribe": false}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("elicit() = %+v, want %+v", result, expected)
+	}
+	if status := answer(`{"id": 1, "action": "cancel"}`); status != http.StatusNotFound {
+		t.Errorf("answer of an answered elicitation status = %d", status)
+	}
+}
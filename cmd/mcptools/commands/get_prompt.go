@@ -44,9 +44,18 @@ func GetPromptCmd() *cobra.Command {
 				case (cmdArgs[i] == FlagFormat || cmdArgs[i] == FlagFormatShort) && i+1 < len(cmdArgs):
 					FormatOption = cmdArgs[i+1]
 					i += 2
+				case cmdArgs[i] == FlagQuery && i+1 < len(cmdArgs):
+					QueryOption = cmdArgs[i+1]
+					i += 2
+				case cmdArgs[i] == FlagTemplate && i+1 < len(cmdArgs):
+					TemplateOption = cmdArgs[i+1]
+					i += 2
 				case (cmdArgs[i] == FlagParams || cmdArgs[i] == FlagParamsShort) && i+1 < len(cmdArgs):
 					ParamsString = cmdArgs[i+1]
 					i += 2
+				case isClientFlag(cmdArgs[i]) && i+1 < len(cmdArgs):
+					setClientFlag(cmdArgs[i], cmdArgs[i+1])
+					i += 2
 				case cmdArgs[i] == FlagServerLogs:
 					ShowServerLogs = true
 					i++
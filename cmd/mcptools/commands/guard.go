@@ -71,11 +71,25 @@ Entity types:
 			// Check if we're using an alias for the server command
 			if len(parsedArgs) == 1 {
 				aliasName := parsedArgs[0]
-				serverCmd, found := alias.GetServerCommand(aliasName)
+				server, found := alias.Get(aliasName)
 				if found {
-					fmt.Fprintf(os.Stderr, "Expanding alias '%s' to '%s'\n", aliasName, serverCmd)
+					if isURLServer(server) {
+						fmt.Fprintf(os.Stderr, "Error: alias '%s' is a server at a URL, guard only runs servers started with a command\n", aliasName)
+						os.Exit(1)
+					}
+					fmt.Fprintf(os.Stderr, "Expanding alias '%s' to '%s'\n", aliasName, QuoteCommandString(server.Args))
+					// The server inherits the environment and working directory of the guard
+					for name, value := range server.Env {
+						_ = os.Setenv(name, value)
+					}
+					if server.Cwd != "" {
+						if err := os.Chdir(expandPath(server.Cwd)); err != nil {
+							fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+							os.Exit(1)
+						}
+					}
 					// Replace the alias with the actual command
-					parsedArgs = ParseCommandString(serverCmd)
+					parsedArgs = server.Args
 				}
 			}
 
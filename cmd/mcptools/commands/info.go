@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// InfoCmd creates the info command.
+func InfoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "info [command args...]",
+		Short: "Show the server info, protocol version, capabilities and instructions of an MCP server",
+		Long: `Connect to an MCP server and show what it answered to the initialize request: its
+name and version, the negotiated protocol version, its capabilities and its
+instructions.
+
+The client requests the latest protocol version it supports, and the server answers
+with the same version or another one it supports. --protocol-version pins the version
+instead, and fails when the server does not support it.`,
+		Example: `  mcp info npx -y @modelcontextprotocol/server-everything
+  mcp info --protocol-version 2024-11-05 fs
+  mcp info -f json http://localhost:3000/mcp`,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			if len(args) == 0 || (len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort)) {
+				return thisCmd.Help()
+			}
+
+			parsedArgs := ProcessFlags(args)
+			mcpClient, err := CreateClientFunc(parsedArgs)
+			if err != nil {
+				return err
+			}
+			defer closeClient(mcpClient) //nolint:errcheck
+
+			result, ok := serverInitializeResult(mcpClient)
+			if !ok {
+				return fmt.Errorf("the client was not initialized")
+			}
+			return FormatAndPrintResponse(thisCmd, ConvertJSONToMap(result), nil)
+		},
+	}
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/f/mcptools/pkg/protocol"
+)
+
+func TestInfoCmd(t *testing.T) {
+	defer func(format string) { FormatOption = format }(FormatOption)
+
+	cleanup := setupMockClient(func(_ string, _ any) (map[string]any, error) {
+		return map[string]any{}, nil
+	})
+	defer cleanup()
+
+	cmd := InfoCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"-f", "json", "server"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+	assertContains(t, buf.String(), `"protocolVersion":"`+protocol.Latest+`"`)
+	assertContains(t, buf.String(), `"serverInfo":{"name":"mock-server","version":"1.0.0"}`)
+	assertContains(t, buf.String(), `"capabilities":{"tools":{}}`)
+}
+
+func TestCloseClientForgetsInitializeResult(t *testing.T) {
+	cleanup := setupMockClient(func(_ string, _ any) (map[string]any, error) {
+		return map[string]any{}, nil
+	})
+	defer cleanup()
+
+	c, err := CreateClientFunc([]string{"server"})
+	if err != nil {
+		t.Fatalf("CreateClientFunc() error = %v", err)
+	}
+	if _, ok := serverInitializeResult(c); !ok {
+		t.Fatal("serverInitializeResult() found no result for a new client")
+	}
+	if err := closeClient(c); err != nil {
+		t.Fatalf("closeClient() error = %v", err)
+	}
+	if _, ok := serverInitializeResult(c); ok {
+		t.Error("serverInitializeResult() still has the result of a closed client")
+	}
+}
+
+func TestCheckProtocolVersion(t *testing.T) {
+	defer func(version string) { ProtocolVersionOption = version }(ProtocolVersionOption)
+
+	tests := []struct {
+		name       string
+		pinned     string
+		requested  string
+		negotiated string
+		wantErr    bool
+	}{
+		{name: "same version", requested: protocol.Latest, negotiated: protocol.Latest},
+		{name: "older supported version", requested: protocol.Latest, negotiated: "2024-11-05"},
+		{name: "unsupported version", requested: protocol.Latest, negotiated: "2023-01-01", wantErr: true},
+		{name: "no version", requested: protocol.Latest, negotiated: "", wantErr: true},
+		{name: "pinned version", pinned: "2024-11-05", requested: "2024-11-05", negotiated: "2024-11-05"},
+		{name: "pinned version not supported by the server", pinned: "2024-11-05", requested: "2024-11-05", negotiated: "2025-03-26", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ProtocolVersionOption = tt.pinned
+			if err := checkProtocolVersion(tt.requested, tt.negotiated); (err != nil) != tt.wantErr {
+				t.Errorf("checkProtocolVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	ProtocolVersionOption = "1999-01-01"
+	if _, err := requestedProtocolVersion(); err == nil {
+		t.Errorf("requestedProtocolVersion() with an unsupported version should fail")
+	}
+	ProtocolVersionOption = "2025-03-26"
+	if version, err := requestedProtocolVersion(); err != nil || version != "2025-03-26" {
+		t.Errorf("requestedProtocolVersion() = %s, %v", version, err)
+	}
+}
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/f/mcptools/pkg/protocol"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// initializeTimeout is the time the server has to answer the initialize request.
+const initializeTimeout = 10 * time.Second
+
+// initializeResults keeps the initialize results of the clients created with
+// CreateClientFunc, as mcp-go only keeps the capabilities of the server. Results
+// are removed when the client is closed with closeClient.
+var initializeResults sync.Map
+
+// closeClient closes a client created with CreateClientFunc and forgets its
+// initialize result.
+func closeClient(c *client.Client) error {
+	initializeResults.Delete(c)
+	return c.Close()
+}
+
+// serverInitializeResult returns the initialize result of the server of a client
+// created with CreateClientFunc.
+func serverInitializeResult(c *client.Client) (*mcp.InitializeResult, bool) {
+	result, ok := initializeResults.Load(c)
+	if !ok {
+		return nil, false
+	}
+	return result.(*mcp.InitializeResult), true
+}
+
+// requestedProtocolVersion returns the protocol version requested in the initialize
+// request: the version of --protocol-version, or the latest one.
+func requestedProtocolVersion() (string, error) {
+	if ProtocolVersionOption == "" {
+		return protocol.Latest, nil
+	}
+	if !protocol.Supported(ProtocolVersionOption) {
+		return "", fmt.Errorf("unsupported protocol version %s (supported: %s)", ProtocolVersionOption, strings.Join(protocol.Versions, ", "))
+	}
+	return ProtocolVersionOption, nil
+}
+
+// checkProtocolVersion checks the protocol version the server answered. A server that
+// does not support the requested version answers with another one, which must be
+// supported by mcptools, and must be the requested one when it is pinned.
+func checkProtocolVersion(requested, negotiated string) error {
+	switch {
+	case negotiated == "":
+		return fmt.Errorf("the server did not answer with a protocol version")
+	case ProtocolVersionOption != "" && negotiated != requested:
+		return fmt.Errorf("the server does not support protocol version %s set with %s, it offered %s", requested, FlagProtocolVersion, negotiated)
+	case !protocol.Supported(negotiated):
+		return fmt.Errorf("the server offered protocol version %s, which mcptools does not support (supported: %s)", negotiated, strings.Join(protocol.Versions, ", "))
+	}
+	return nil
+}
+
+// initializeClient initializes the connection to the server, negotiating the
+// protocol version.
+func initializeClient(ctx context.Context, c *client.Client) (*mcp.InitializeResult, error) {
+	version, err := requestedProtocolVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = version
+	initRequest.Params.Capabilities = mcp.ClientCapabilities{}
+	initRequest.Params.ClientInfo = mcp.Implementation{
+		Name:    "mcptools",
+		Version: Version,
+	}
+	result, err := c.Initialize(ctx, initRequest)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkProtocolVersion(version, result.ProtocolVersion); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// protocolVersionHeader adds the negotiated protocol version to the requests of a
+// streamable HTTP client, once it is known.
+type protocolVersionHeader struct {
+	version atomic.Value
+}
+
+// headers returns the header of the negotiated version.
+func (h *protocolVersionHeader) headers(context.Context) map[string]string {
+	version, _ := h.version.Load().(string)
+	if version == "" {
+		return nil
+	}
+	return map[string]string{protocol.VersionHeader: version}
+}
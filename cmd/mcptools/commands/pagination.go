@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+// maxListPages limits the pages of a list that are followed, in case a server keeps
+// returning new cursors.
+const maxListPages = 1000
+
+// listPages lists a list of the server page by page, starting at cursor. The next
+// cursors are followed until the last page unless paginate is false, in which case
+// only one page is listed. It returns the items and the cursor of the next page,
+// which is empty once the last page was listed.
+//
+// A server that returns a cursor it already returned is an error, as following it
+// would never end.
+func listPages[T any](cursor mcp.Cursor, paginate bool, listPage func(mcp.Cursor) ([]T, mcp.Cursor, error)) ([]T, mcp.Cursor, error) {
+	var items []T
+	seen := map[mcp.Cursor]bool{cursor: true}
+	for pages := 1; ; pages++ {
+		pageItems, next, err := listPage(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		items = append(items, pageItems...)
+
+		switch {
+		case next == "" || !paginate:
+			return items, next, nil
+		case seen[next]:
+			return nil, "", fmt.Errorf("the server returned the cursor %q again after %d pages", next, pages)
+		case pages == maxListPages:
+			return nil, "", fmt.Errorf("the list has more than %d pages, use %s to list them one at a time", maxListPages, FlagNoPaginate)
+		}
+		seen[next] = true
+		cursor = next
+	}
+}
+
+// addNextCursor adds the cursor of the next page to the response of a list command
+// that listed a single page, and tells how to list the next page.
+func addNextCursor(cmd *cobra.Command, resp map[string]any, list string, next mcp.Cursor) {
+	if next == "" {
+		return
+	}
+	resp["nextCursor"] = next
+	fmt.Fprintf(cmd.ErrOrStderr(), "More %s are available, list the next page with %s %s\n", list, FlagCursor, QuoteCommandString([]string{string(next)}))
+}
+
+// listTools lists the tools of the server with listPages.
+func listTools(ctx context.Context, mcpClient *client.Client, cursor mcp.Cursor, paginate bool) ([]mcp.Tool, mcp.Cursor, error) {
+	return listPages(cursor, paginate, func(cursor mcp.Cursor) ([]mcp.Tool, mcp.Cursor, error) {
+		request := mcp.ListToolsRequest{}
+		request.Params.Cursor = cursor
+		result, err := mcpClient.ListToolsByPage(ctx, request)
+		if err != nil {
+			return nil, "", err
+		}
+		return result.Tools, result.NextCursor, nil
+	})
+}
+
+// listResources lists the resources of the server with listPages.
+func listResources(ctx context.Context, mcpClient *client.Client, cursor mcp.Cursor, paginate bool) ([]mcp.Resource, mcp.Cursor, error) {
+	return listPages(cursor, paginate, func(cursor mcp.Cursor) ([]mcp.Resource, mcp.Cursor, error) {
+		request := mcp.ListResourcesRequest{}
+		request.Params.Cursor = cursor
+		result, err := mcpClient.ListResourcesByPage(ctx, request)
+		if err != nil {
+			return nil, "", err
+		}
+		return result.Resources, result.NextCursor, nil
+	})
+}
+
+// listTemplates lists the resource templates of the server with listPages.
+func listTemplates(ctx context.Context, mcpClient *client.Client, cursor mcp.Cursor, paginate bool) ([]mcp.ResourceTemplate, mcp.Cursor, error) {
+	return listPages(cursor, paginate, func(cursor mcp.Cursor) ([]mcp.ResourceTemplate, mcp.Cursor, error) {
+		request := mcp.ListResourceTemplatesRequest{}
+		request.Params.Cursor = cursor
+		result, err := mcpClient.ListResourceTemplatesByPage(ctx, request)
+		if err != nil {
+			return nil, "", err
+		}
+		return result.ResourceTemplates, result.NextCursor, nil
+	})
+}
+
+// listPrompts lists the prompts of the server with listPages.
+func listPrompts(ctx context.Context, mcpClient *client.Client, cursor mcp.Cursor, paginate bool) ([]mcp.Prompt, mcp.Cursor, error) {
+	return listPages(cursor, paginate, func(cursor mcp.Cursor) ([]mcp.Prompt, mcp.Cursor, error) {
+		request := mcp.ListPromptsRequest{}
+		request.Params.Cursor = cursor
+		result, err := mcpClient.ListPromptsByPage(ctx, request)
+		if err != nil {
+			return nil, "", err
+		}
+		return result.Prompts, result.NextCursor, nil
+	})
+}
@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestListPages(t *testing.T) {
+	pages := map[mcp.Cursor]struct {
+		next  mcp.Cursor
+		items []string
+	}{
+		"":   {items: []string{"a", "b"}, next: "p2"},
+		"p2": {items: []string{"c"}, next: "p3"},
+		"p3": {items: []string{"d"}},
+	}
+	listPage := func(cursor mcp.Cursor) ([]string, mcp.Cursor, error) {
+		return pages[cursor].items, pages[cursor].next, nil
+	}
+
+	tests := []struct {
+		name     string
+		cursor   mcp.Cursor
+		expected []string
+		next     mcp.Cursor
+		paginate bool
+	}{
+		{name: "all pages", paginate: true, expected: []string{"a", "b", "c", "d"}},
+		{name: "from a cursor", cursor: "p2", paginate: true, expected: []string{"c", "d"}},
+		{name: "single page", paginate: false, expected: []string{"a", "b"}, next: "p2"},
+		{name: "single page from a cursor", cursor: "p2", paginate: false, expected: []string{"c"}, next: "p3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, next, err := listPages(tt.cursor, tt.paginate, listPage)
+			if err != nil {
+				t.Fatalf("listPages() error = %v", err)
+			}
+			if !reflect.DeepEqual(items, tt.expected) || next != tt.next {
+				t.Errorf("listPages() = %v, %q, want %v, %q", items, next, tt.expected, tt.next)
+			}
+		})
+	}
+
+	_, _, err := listPages("", true, func(mcp.Cursor) ([]string, mcp.Cursor, error) {
+		return []string{"a"}, "same", nil
+	})
+	if err == nil || !strings.Contains(err.Error(), `cursor "same" again`) {
+		t.Errorf("listPages() with a repeated cursor error = %v", err)
+	}
+}
+
+func TestToolsCmdPagination(t *testing.T) {
+	defer func(cursor string, noPaginate bool, format string) {
+		CursorOption, NoPaginate, FormatOption = cursor, noPaginate, format
+	}(CursorOption, NoPaginate, FormatOption)
+
+	var cursors []string
+	cleanup := setupMockClient(func(_ string, params any) (map[string]any, error) {
+		cursor, _ := ConvertJSONToMap(params)["cursor"].(string)
+		cursors = append(cursors, cursor)
+		if cursor == "" {
+			return map[string]any{"tools": []any{map[string]any{"name": "first"}}, "nextCursor": "page 2"}, nil
+		}
+		return map[string]any{"tools": []any{map[string]any{"name": "second"}}}, nil
+	})
+	defer cleanup()
+
+	cmd := ToolsCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"-f", "json", "server"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+	assertContains(t, buf.String(), `"name":"first"`)
+	assertContains(t, buf.String(), `"name":"second"`)
+	assertEquals(t, strings.Join(cursors, ","), ",page 2")
+
+	cursors = nil
+	cmd = ToolsCmd()
+	buf = new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetErr(stderr)
+	cmd.SetArgs([]string{"--no-paginate", "-f", "json", "server"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+	assertContains(t, buf.String(), `"nextCursor":"page 2"`)
+	assertContains(t, stderr.String(), "--cursor 'page 2'")
+	assertEquals(t, strings.Join(cursors, ","), "")
+
+	cursors = nil
+	NoPaginate = false
+	cmd = ToolsCmd()
+	buf = new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"--cursor", "page 2", "-f", "json", "server"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+	assertContains(t, buf.String(), `"name":"second"`)
+	if strings.Contains(buf.String(), "first") || strings.Contains(buf.String(), "nextCursor") {
+		t.Errorf("Output should only have the second page, got %s", buf.String())
+	}
+	assertEquals(t, strings.Join(cursors, ","), "page 2")
+}
@@ -31,14 +31,10 @@ func PromptsCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
-			resp, listErr := mcpClient.ListPrompts(context.Background(), mcp.ListPromptsRequest{})
+			prompts, nextCursor, listErr := listPrompts(context.Background(), mcpClient, mcp.Cursor(CursorOption), !NoPaginate)
 
-			var prompts []any
-			if listErr == nil && resp != nil {
-				prompts = ConvertJSONToSlice(resp.Prompts)
-			}
-
-			promptsMap := map[string]any{"prompts": prompts}
+			promptsMap := map[string]any{"prompts": ConvertJSONToSlice(prompts)}
+			addNextCursor(thisCmd, promptsMap, "prompts", nextCursor)
 			if formatErr := FormatAndPrintResponse(thisCmd, promptsMap, listErr); formatErr != nil {
 				fmt.Fprintf(os.Stderr, "%v\n", formatErr)
 				os.Exit(1)
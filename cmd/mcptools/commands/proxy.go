@@ -1,15 +1,15 @@
 package commands
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/f/mcptools/pkg/proxy"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 // ProxyCmd creates the proxy command.
@@ -20,7 +20,8 @@ func ProxyCmd() *cobra.Command {
 		Long: `Proxy MCP tool requests to shell scripts.
 
 This command allows you to register shell scripts as MCP tools and proxy MCP requests to them.
-The scripts will receive tool parameters as environment variables.
+The scripts will receive tool parameters as environment variables. Files, directory globs and
+command output can be exposed as resources, and markdown files as prompt templates.
 
 Examples:
   # Register a shell script as an MCP tool
@@ -29,12 +30,39 @@ Examples:
   # Register an inline command as an MCP tool
   mcp proxy tool add_operation "Adds a and b" "a:int,b:int" -e 'echo "total is $a + $b = $(($a+$b))"'
 
-  # Start a proxy server with the registered tools
-  mcp proxy start`,
+  # Expose a file, a directory glob or command output as MCP resources
+  mcp proxy resource readme --uri docs://readme --file ./README.md
+  mcp proxy resource notes --glob './notes/*.md'
+  mcp proxy resource log --uri 'logs://{service}' -e 'journalctl -u "$service" -n 100'
+
+  # Register a prompt template from a markdown file with front matter
+  mcp proxy prompt review ./prompts/review.md
+
+  # Turn every operation of an OpenAPI spec into a tool
+  mcp proxy openapi petstore.yaml --base-url https://petstore.example.com/v1
+
+  # Expose subcommands of an existing program, generated from its --help output
+  mcp proxy wrap git --subcommands status,log,diff
+
+  # Start a proxy server with the registered tools, resources and prompts
+  mcp proxy start
+
+  # Use a custom YAML or JSON config file, reloaded whenever it changes
+  mcp proxy start --config proxy.yaml
+
+  # Check a config file for errors
+  mcp proxy validate --config proxy.yaml`,
 	}
 
+	cmd.PersistentFlags().String("config", "", "Proxy config file (YAML or JSON, default $HOME/.mcpt/proxy_config.json)")
+
 	cmd.AddCommand(ProxyToolCmd())
+	cmd.AddCommand(ProxyResourceCmd())
+	cmd.AddCommand(ProxyPromptCmd())
+	cmd.AddCommand(ProxyOpenAPICmd())
+	cmd.AddCommand(ProxyWrapCmd())
 	cmd.AddCommand(ProxyStartCmd())
+	cmd.AddCommand(ProxyValidateCmd())
 
 	return cmd
 }
@@ -73,25 +101,30 @@ To unregister a tool, use the --unregister flag:
 			return cobra.RangeArgs(3, 4)(cmd, args)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, pathErr := proxyConfigPath(cmd)
+			if pathErr != nil {
+				return pathErr
+			}
+
 			unregister, _ := cmd.Flags().GetBool("unregister")
 			if unregister {
 				name := args[0]
 				// Load existing config
-				config, loadErr := LoadProxyConfig()
+				config, loadErr := proxy.LoadConfig(configPath)
 				if loadErr != nil {
 					return fmt.Errorf("error loading config: %w", loadErr)
 				}
 
 				// Check if tool exists
-				if _, exists := config[name]; !exists {
+				if _, exists := config.Tools[name]; !exists {
 					return fmt.Errorf("tool %s not found", name)
 				}
 
 				// Remove the tool from config
-				delete(config, name)
+				delete(config.Tools, name)
 
 				// Save updated config
-				if saveErr := SaveProxyConfig(config); saveErr != nil {
+				if saveErr := proxy.SaveConfig(configPath, config); saveErr != nil {
 					return fmt.Errorf("error saving config: %w", saveErr)
 				}
 
@@ -115,22 +148,36 @@ To unregister a tool, use the --unregister flag:
 				return fmt.Errorf("either script path or command (-e) must be provided")
 			}
 
+			// Scripts are stored with absolute paths so the config works from any directory
+			if scriptPath != "" && command == "" {
+				absPath, absErr := filepath.Abs(scriptPath)
+				if absErr != nil {
+					return fmt.Errorf("invalid script path: %w", absErr)
+				}
+				scriptPath = absPath
+			}
+
+			params, paramsErr := proxy.ParseParameters(parameters)
+			if paramsErr != nil {
+				return fmt.Errorf("invalid parameters: %w", paramsErr)
+			}
+
 			// Load existing config
-			config, loadErr := LoadProxyConfig()
+			config, loadErr := proxy.LoadConfig(configPath)
 			if loadErr != nil {
 				return fmt.Errorf("error loading config: %w", loadErr)
 			}
 
 			// Add the new tool to config
-			config[name] = map[string]string{
-				"description": description,
-				"parameters":  parameters,
-				"script":      scriptPath,
-				"command":     command,
+			config.Tools[name] = proxy.ToolConfig{
+				Description: description,
+				Parameters:  params,
+				Script:      scriptPath,
+				Command:     command,
 			}
 
 			// Save updated config
-			if saveErr := SaveProxyConfig(config); saveErr != nil {
+			if saveErr := proxy.SaveConfig(configPath, config); saveErr != nil {
 				return fmt.Errorf("error saving config: %w", saveErr)
 			}
 
@@ -144,91 +191,657 @@ To unregister a tool, use the --unregister flag:
 	return cmd
 }
 
+// ProxyResourceCmd creates the proxy resource command.
+func ProxyResourceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resource [name] [description]",
+		Short: "Expose a file, directory glob or command output as an MCP resource",
+		Long: `Expose a file, the files matching a glob or the output of a command as MCP resources.
+
+Exactly one of --file, --glob or -e must be given. A URI containing {variables}
+(RFC 6570) registers a resource template: variables are substituted into the
+--file path or passed to the command as environment variables.
+
+Glob resources list every matching file. Without --uri they use file:// URIs,
+otherwise the URI must contain {path} (or {+path}), the path relative to the glob.
+
+Examples:
+  mcp proxy resource readme "Project readme" --uri docs://readme --file ./README.md
+  mcp proxy resource notes --glob './notes/*.md' --uri 'notes://{+path}'
+  mcp proxy resource user --uri 'users://{id}' -e 'curl -s "https://api.example.com/users/$id"'
+  mcp proxy resource page --uri 'pages://{name}' --file './pages/{name}.md'
+
+To unregister a resource, use the --unregister flag:
+  mcp proxy resource --unregister readme`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			unregister, _ := cmd.Flags().GetBool("unregister")
+			if unregister {
+				if len(args) != 1 {
+					return fmt.Errorf("unregister requires exactly one argument: the resource name")
+				}
+				return nil
+			}
+			return cobra.RangeArgs(1, 2)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, pathErr := proxyConfigPath(cmd)
+			if pathErr != nil {
+				return pathErr
+			}
+
+			config, loadErr := proxy.LoadConfig(configPath)
+			if loadErr != nil {
+				return fmt.Errorf("error loading config: %w", loadErr)
+			}
+
+			name := args[0]
+			unregister, _ := cmd.Flags().GetBool("unregister")
+			if unregister {
+				if _, exists := config.Resources[name]; !exists {
+					return fmt.Errorf("resource %s not found", name)
+				}
+				delete(config.Resources, name)
+
+				if saveErr := proxy.SaveConfig(configPath, config); saveErr != nil {
+					return fmt.Errorf("error saving config: %w", saveErr)
+				}
+
+				fmt.Printf("Unregistered resource: %s\n", name)
+				return nil
+			}
+
+			resource := proxy.ResourceConfig{}
+			if len(args) > 1 {
+				resource.Description = args[1]
+			}
+			resource.URI, _ = cmd.Flags().GetString("uri")
+			resource.MimeType, _ = cmd.Flags().GetString("mime-type")
+			resource.Command, _ = cmd.Flags().GetString("execute")
+			file, _ := cmd.Flags().GetString("file")
+			glob, _ := cmd.Flags().GetString("glob")
+
+			// Paths are stored as absolute paths so the config works from any directory
+			if file != "" {
+				absPath, absErr := filepath.Abs(file)
+				if absErr != nil {
+					return fmt.Errorf("invalid file path: %w", absErr)
+				}
+				resource.File = absPath
+			}
+			if glob != "" {
+				absPath, absErr := filepath.Abs(glob)
+				if absErr != nil {
+					return fmt.Errorf("invalid glob: %w", absErr)
+				}
+				resource.Glob = absPath
+			}
+
+			if resource.URI == "" && resource.Glob == "" {
+				return fmt.Errorf("--uri is required unless --glob is used")
+			}
+			if _, err := proxy.NewResource(name, resource, ""); err != nil {
+				return err
+			}
+
+			config.Resources[name] = resource
+
+			if saveErr := proxy.SaveConfig(configPath, config); saveErr != nil {
+				return fmt.Errorf("error saving config: %w", saveErr)
+			}
+
+			fmt.Printf("Registered resource: %s\n", name)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("uri", "", "Resource URI or RFC 6570 URI template")
+	cmd.Flags().String("file", "", "File to serve, may contain {variables} of the URI template")
+	cmd.Flags().String("glob", "", "Glob pattern of files to serve")
+	cmd.Flags().StringP("execute", "e", "", "Command whose output is the resource content")
+	cmd.Flags().String("mime-type", "", "MIME type of the resource (detected from the file extension by default)")
+	cmd.Flags().Bool("unregister", false, "Unregister a resource")
+	return cmd
+}
+
+// ProxyPromptCmd creates the proxy prompt command.
+func ProxyPromptCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prompt [name] [file]",
+		Short: "Register a markdown file as an MCP prompt template",
+		Long: `Register a markdown file as an MCP prompt template.
+
+The file may start with a YAML front matter block declaring the description and
+arguments of the prompt. Arguments are substituted into {{name}} placeholders.
+Without declared arguments, every placeholder becomes a required argument.
+
+  ---
+  description: Review a file
+  arguments:
+    - name: file
+      description: File to review
+      required: true
+  ---
+  Please review {{file}} and point out bugs.
+
+Example:
+  mcp proxy prompt review ./prompts/review.md
+
+To unregister a prompt, use the --unregister flag:
+  mcp proxy prompt --unregister review`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			unregister, _ := cmd.Flags().GetBool("unregister")
+			if unregister {
+				if len(args) != 1 {
+					return fmt.Errorf("unregister requires exactly one argument: the prompt name")
+				}
+				return nil
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, pathErr := proxyConfigPath(cmd)
+			if pathErr != nil {
+				return pathErr
+			}
+
+			config, loadErr := proxy.LoadConfig(configPath)
+			if loadErr != nil {
+				return fmt.Errorf("error loading config: %w", loadErr)
+			}
+
+			name := args[0]
+			unregister, _ := cmd.Flags().GetBool("unregister")
+			if unregister {
+				if _, exists := config.Prompts[name]; !exists {
+					return fmt.Errorf("prompt %s not found", name)
+				}
+				delete(config.Prompts, name)
+
+				if saveErr := proxy.SaveConfig(configPath, config); saveErr != nil {
+					return fmt.Errorf("error saving config: %w", saveErr)
+				}
+
+				fmt.Printf("Unregistered prompt: %s\n", name)
+				return nil
+			}
+
+			absPath, absErr := filepath.Abs(args[1])
+			if absErr != nil {
+				return fmt.Errorf("invalid prompt file: %w", absErr)
+			}
+
+			description, _ := cmd.Flags().GetString("description")
+			prompt := proxy.PromptConfig{Description: description, File: absPath}
+
+			// Make sure the file exists and its front matter is valid
+			loaded, err := proxy.NewPrompt(name, prompt, "")
+			if err != nil {
+				return err
+			}
+
+			config.Prompts[name] = prompt
+
+			if saveErr := proxy.SaveConfig(configPath, config); saveErr != nil {
+				return fmt.Errorf("error saving config: %w", saveErr)
+			}
+
+			fmt.Printf("Registered prompt: %s (%d arguments)\n", name, len(loaded.Arguments))
+			return nil
+		},
+	}
+
+	cmd.Flags().String("description", "", "Prompt description (overrides the front matter description)")
+	cmd.Flags().Bool("unregister", false, "Unregister a prompt")
+	return cmd
+}
+
+// ProxyOpenAPICmd creates the proxy openapi command.
+func ProxyOpenAPICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "openapi [spec]",
+		Short: "Expose the operations of an OpenAPI spec as MCP tools",
+		Long: `Expose every operation of an OpenAPI 3 spec (YAML or JSON) as an MCP tool.
+
+Tools are named after the operationId of each operation. Path, query, header
+and cookie parameters become tool parameters, and the request body becomes a
+"body" parameter. Calls are executed as HTTP requests against --base-url (the
+first server of the spec by default) and return the response body.
+
+Credentials are read from environment variables when the proxy calls the API,
+so they are never stored in the config file.
+
+Examples:
+  mcp proxy openapi petstore.yaml --base-url https://petstore.example.com/v1
+  mcp proxy openapi github.yaml --name github --prefix gh_ --operations repos/get,issues/list-for-repo \
+    --bearer-token-env GITHUB_TOKEN
+  mcp proxy openapi internal.json --api-key-header X-Api-Key --api-key-env INTERNAL_API_KEY
+  mcp proxy openapi legacy.yaml --basic-user admin --basic-password-env LEGACY_PASSWORD
+
+To unregister an API, use the --unregister flag with its name:
+  mcp proxy openapi --unregister petstore`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, pathErr := proxyConfigPath(cmd)
+			if pathErr != nil {
+				return pathErr
+			}
+
+			config, loadErr := proxy.LoadConfig(configPath)
+			if loadErr != nil {
+				return fmt.Errorf("error loading config: %w", loadErr)
+			}
+
+			unregister, _ := cmd.Flags().GetBool("unregister")
+			if unregister {
+				name := args[0]
+				if _, exists := config.OpenAPI[name]; !exists {
+					return fmt.Errorf("openapi %s not found", name)
+				}
+				delete(config.OpenAPI, name)
+
+				if saveErr := proxy.SaveConfig(configPath, config); saveErr != nil {
+					return fmt.Errorf("error saving config: %w", saveErr)
+				}
+
+				fmt.Printf("Unregistered OpenAPI spec: %s\n", name)
+				return nil
+			}
+
+			specPath, absErr := filepath.Abs(args[0])
+			if absErr != nil {
+				return fmt.Errorf("invalid spec path: %w", absErr)
+			}
+
+			api := proxy.OpenAPIConfig{Spec: specPath}
+			api.BaseURL, _ = cmd.Flags().GetString("base-url")
+			api.Prefix, _ = cmd.Flags().GetString("prefix")
+			api.Operations, _ = cmd.Flags().GetStringSlice("operations")
+
+			headers, _ := cmd.Flags().GetStringArray("header")
+			for _, header := range headers {
+				name, value, found := strings.Cut(header, ":")
+				if !found {
+					return fmt.Errorf("invalid header %q, expected \"Name: value\"", header)
+				}
+				if api.Headers == nil {
+					api.Headers = make(map[string]string)
+				}
+				api.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+			}
+
+			auth, authErr := openAPIAuthFromFlags(cmd)
+			if authErr != nil {
+				return authErr
+			}
+			api.Auth = auth
+
+			// Make sure the spec can be turned into tools before saving it
+			tools, err := proxy.LoadOpenAPITools(api, "")
+			if err != nil {
+				return err
+			}
+
+			name, _ := cmd.Flags().GetString("name")
+			if name == "" {
+				name = strings.TrimSuffix(filepath.Base(specPath), filepath.Ext(specPath))
+			}
+			config.OpenAPI[name] = api
+
+			if saveErr := proxy.SaveConfig(configPath, config); saveErr != nil {
+				return fmt.Errorf("error saving config: %w", saveErr)
+			}
+
+			fmt.Printf("Registered OpenAPI spec %s with %d tools:\n", name, len(tools))
+			for _, tool := range tools {
+				fmt.Printf("  %s: %s\n", tool.Name, tool.Description)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("name", "", "Name of the API in the config (default: spec file name)")
+	cmd.Flags().String("base-url", "", "Base URL of the API (default: first server in the spec)")
+	cmd.Flags().String("prefix", "", "Prefix added to every tool name")
+	cmd.Flags().StringSlice("operations", nil, "Only expose these operation IDs (comma separated)")
+	cmd.Flags().StringArray("header", nil, "Header sent with every request, as \"Name: value\" (repeatable)")
+	cmd.Flags().String("bearer-token-env", "", "Environment variable holding a bearer token")
+	cmd.Flags().String("api-key-env", "", "Environment variable holding an API key")
+	cmd.Flags().String("api-key-header", "X-API-Key", "Header the API key is sent in")
+	cmd.Flags().String("basic-user", "", "User name for basic authentication")
+	cmd.Flags().String("basic-password-env", "", "Environment variable holding the basic authentication password")
+	cmd.Flags().Bool("unregister", false, "Unregister an API by name")
+	return cmd
+}
+
+// openAPIAuthFromFlags builds the authentication config from the openapi command flags.
+func openAPIAuthFromFlags(cmd *cobra.Command) (*proxy.OpenAPIAuth, error) {
+	bearerEnv, _ := cmd.Flags().GetString("bearer-token-env")
+	apiKeyEnv, _ := cmd.Flags().GetString("api-key-env")
+	apiKeyHeader, _ := cmd.Flags().GetString("api-key-header")
+	basicUser, _ := cmd.Flags().GetString("basic-user")
+	basicPasswordEnv, _ := cmd.Flags().GetString("basic-password-env")
+
+	var auth *proxy.OpenAPIAuth
+	count := 0
+	if bearerEnv != "" {
+		auth = &proxy.OpenAPIAuth{Type: "bearer", TokenEnv: bearerEnv}
+		count++
+	}
+	if apiKeyEnv != "" {
+		auth = &proxy.OpenAPIAuth{Type: "apiKey", Header: apiKeyHeader, TokenEnv: apiKeyEnv}
+		count++
+	}
+	if basicUser != "" || basicPasswordEnv != "" {
+		auth = &proxy.OpenAPIAuth{Type: "basic", Username: basicUser, PasswordEnv: basicPasswordEnv}
+		count++
+	}
+
+	if count > 1 {
+		return nil, fmt.Errorf("only one of --bearer-token-env, --api-key-env or basic authentication can be used")
+	}
+	return auth, nil
+}
+
+// ProxyWrapCmd creates the proxy wrap command.
+func ProxyWrapCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wrap [binary]",
+		Short: "Expose subcommands of a command line program as MCP tools",
+		Long: `Expose subcommands of an existing command line program as MCP tools.
+
+Each subcommand is described by a declarative manifest listing its flags and
+positional arguments with their types. Tool calls run the program directly,
+without a shell, with an argument list built from the tool arguments, so only
+the listed flags can be passed and positional values cannot start with "-".
+
+Without --manifest the manifest is generated from the --help output of each
+subcommand. Use --print to write the generated manifest to stdout, review or
+edit it, and register it with --manifest.
+
+A manifest looks like this:
+
+  binary: git
+  subcommands:
+    log:
+      description: Show commit logs
+      flags:
+        - flag: --max-count
+          type: int
+        - flag: --oneline
+          type: bool
+      positionals:
+        - name: paths
+          type: array
+
+Examples:
+  mcp proxy wrap git --subcommands status,log,diff
+  mcp proxy wrap kubectl --subcommands "get,describe,logs" --print > kubectl.yaml
+  mcp proxy wrap kubectl --manifest kubectl.yaml --prefix k8s_
+
+To unregister a program, use the --unregister flag with its name:
+  mcp proxy wrap --unregister git`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, pathErr := proxyConfigPath(cmd)
+			if pathErr != nil {
+				return pathErr
+			}
+
+			config, loadErr := proxy.LoadConfig(configPath)
+			if loadErr != nil {
+				return fmt.Errorf("error loading config: %w", loadErr)
+			}
+
+			unregister, _ := cmd.Flags().GetBool("unregister")
+			if unregister {
+				name := args[0]
+				if _, exists := config.Wrap[name]; !exists {
+					return fmt.Errorf("wrapped program %s not found", name)
+				}
+				delete(config.Wrap, name)
+
+				if saveErr := proxy.SaveConfig(configPath, config); saveErr != nil {
+					return fmt.Errorf("error saving config: %w", saveErr)
+				}
+
+				fmt.Printf("Unregistered wrapped program: %s\n", name)
+				return nil
+			}
+
+			subcommands, _ := cmd.Flags().GetStringSlice("subcommands")
+			manifestPath, _ := cmd.Flags().GetString("manifest")
+
+			var wrap proxy.WrapConfig
+			if manifestPath != "" {
+				data, readErr := os.ReadFile(manifestPath) // #nosec G304 - manifest path is provided by the user
+				if readErr != nil {
+					return fmt.Errorf("error reading manifest: %w", readErr)
+				}
+				if err := yaml.Unmarshal(data, &wrap); err != nil {
+					return fmt.Errorf("error parsing manifest: %w", err)
+				}
+				if len(subcommands) > 0 {
+					selected := make(map[string]proxy.SubcommandConfig, len(subcommands))
+					for _, subcommand := range subcommands {
+						sub, exists := wrap.Subcommands[subcommand]
+						if !exists {
+							return fmt.Errorf("subcommand %s not found in manifest", subcommand)
+						}
+						selected[subcommand] = sub
+					}
+					wrap.Subcommands = selected
+				}
+			} else {
+				if len(subcommands) == 0 {
+					return fmt.Errorf("--subcommands or --manifest is required")
+				}
+				generated, err := proxy.WrapFromHelp(args[0], subcommands)
+				if err != nil {
+					return err
+				}
+				wrap = generated
+			}
+			wrap.Binary = args[0]
+			if prefix, _ := cmd.Flags().GetString("prefix"); prefix != "" {
+				wrap.Prefix = prefix
+			}
+			if dir, _ := cmd.Flags().GetString("dir"); dir != "" {
+				absDir, absErr := filepath.Abs(dir)
+				if absErr != nil {
+					return fmt.Errorf("invalid directory: %w", absErr)
+				}
+				wrap.Dir = absDir
+			}
+
+			name, _ := cmd.Flags().GetString("name")
+			if name == "" {
+				name = strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+			}
+
+			// Make sure the manifest can be turned into tools before saving it
+			tools, err := proxy.LoadWrapTools(name, wrap, "")
+			if err != nil {
+				return err
+			}
+
+			if printManifest, _ := cmd.Flags().GetBool("print"); printManifest {
+				data, marshalErr := yaml.Marshal(wrap)
+				if marshalErr != nil {
+					return fmt.Errorf("error marshaling manifest: %w", marshalErr)
+				}
+				_, _ = cmd.OutOrStdout().Write(data)
+				return nil
+			}
+
+			if strings.ContainsRune(wrap.Binary, '/') || strings.ContainsRune(wrap.Binary, filepath.Separator) {
+				absBinary, absErr := filepath.Abs(wrap.Binary)
+				if absErr != nil {
+					return fmt.Errorf("invalid binary path: %w", absErr)
+				}
+				wrap.Binary = absBinary
+			}
+			config.Wrap[name] = wrap
+
+			if saveErr := proxy.SaveConfig(configPath, config); saveErr != nil {
+				return fmt.Errorf("error saving config: %w", saveErr)
+			}
+
+			fmt.Printf("Registered %s with %d tools:\n", name, len(tools))
+			for _, tool := range tools {
+				fmt.Printf("  %s: %s\n", tool.Name, tool.Description)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSlice("subcommands", nil, "Subcommands to expose (comma separated)")
+	cmd.Flags().String("manifest", "", "YAML manifest describing the subcommands (default: generated from --help)")
+	cmd.Flags().String("name", "", "Name of the program in the config (default: binary name)")
+	cmd.Flags().String("prefix", "", "Prefix added to every tool name (default: \"<name>_\")")
+	cmd.Flags().String("dir", "", "Working directory the program is run in")
+	cmd.Flags().Bool("print", false, "Print the manifest instead of registering it")
+	cmd.Flags().Bool("unregister", false, "Unregister a wrapped program by name")
+	return cmd
+}
+
 // ProxyStartCmd creates the proxy start command.
 func ProxyStartCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "start",
-		Short: "Start a proxy server with registered tools",
+		Short: "Start a proxy server with registered tools, resources and prompts",
 		Long: `Start a proxy server that forwards MCP tool requests to shell scripts.
 
-The server reads tool configurations from $HOME/.mcpt/proxy_config.json.
+The server reads tool configurations from $HOME/.mcpt/proxy_config.json, or from
+the file given with --config. The config file is watched for changes: tools,
+resources and prompts are added, removed or updated live and connected clients
+receive the matching notifications/*/list_changed notification.
 
-Example:
-  mcp proxy start`,
-		Run: func(_ *cobra.Command, _ []string) {
-			// Load tool configurations
-			viper.SetConfigName("proxy_config")
-			viper.SetConfigType("json")
-			viper.AddConfigPath("$HOME/.mcpt")
+With --transport http the proxy is served over streamable HTTP at /mcp, so the
+same tools can be shared by many clients. Each client gets its own session.
+Clients must send one of the tokens from --token-file as
+"Authorization: Bearer <token>".
 
-			if err := viper.ReadInConfig(); err != nil {
-				log.Fatalf("Error reading config: %v", err)
-			}
+Requests are handled concurrently with at most --concurrency scripts running at
+the same time. notifications/cancelled kills the script of the cancelled request.
 
-			var config map[string]map[string]string
-			if err := viper.Unmarshal(&config); err != nil {
-				log.Fatalf("Error unmarshaling config: %v", err)
+Example:
+  mcp proxy start
+  mcp proxy start --config proxy.yaml
+  mcp proxy start --transport http --listen :8080 --token-file tokens.txt`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			configPath, err := proxyConfigPath(cmd)
+			if err != nil {
+				return err
 			}
 
+			noWatch, _ := cmd.Flags().GetBool("no-watch")
+			transport, _ := cmd.Flags().GetString("transport")
+			listen, _ := cmd.Flags().GetString("listen")
+			tokenFile, _ := cmd.Flags().GetString("token-file")
+			concurrency, _ := cmd.Flags().GetInt("concurrency")
+
 			// Run proxy server
 			fmt.Fprintln(os.Stderr, "Starting proxy server...")
-			if err := proxy.RunProxyServer(config); err != nil {
-				log.Fatalf("Error running proxy server: %v", err)
+			if err := proxy.RunProxyServer(proxy.Options{
+				ConfigPath:  configPath,
+				Transport:   transport,
+				Listen:      listen,
+				TokenFile:   tokenFile,
+				Concurrency: concurrency,
+				Watch:       !noWatch,
+			}); err != nil {
+				return fmt.Errorf("error running proxy server: %w", err)
 			}
+			return nil
 		},
 	}
 
+	cmd.Flags().Bool("no-watch", false, "Do not reload the config file when it changes")
+	cmd.Flags().String("transport", "stdio", "Transport to serve: stdio or http (streamable HTTP)")
+	cmd.Flags().String("listen", ":8080", "Address to listen on with --transport http")
+	cmd.Flags().String("token-file", "", "File with accepted bearer tokens, one per line (--transport http)")
+	cmd.Flags().Int("concurrency", proxy.DefaultConcurrency, "Maximum number of scripts executed at the same time")
+
 	return cmd
 }
 
-// LoadProxyConfig loads the proxy configuration from the config file.
-func LoadProxyConfig() (map[string]map[string]string, error) {
-	// Initialize config
-	viper.SetConfigName("proxy_config")
-	viper.SetConfigType("json")
-	viper.AddConfigPath("$HOME/.mcpt")
+// ProxyValidateCmd creates the proxy validate command.
+func ProxyValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Validate a proxy config file",
+		Long: `Validate a proxy config file and report every problem with its line number.
+
+Example:
+  mcp proxy validate --config proxy.yaml`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			configPath, err := proxyConfigPath(cmd)
+			if err != nil {
+				return err
+			}
+
+			problems, err := proxy.ValidateConfig(configPath)
+			if err != nil {
+				return err
+			}
 
-	// Create config directory if it doesn't exist
-	configDir := os.ExpandEnv("$HOME/.mcpt")
-	if err := os.MkdirAll(configDir, 0o750); err != nil {
-		return nil, fmt.Errorf("error creating config directory: %w", err)
+			if len(problems) > 0 {
+				for _, problem := range problems {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s:%s\n", configPath, problem.Error())
+				}
+				return fmt.Errorf("%d problem(s) found in %s", len(problems), configPath)
+			}
+
+			config, err := proxy.LoadConfig(configPath)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "%s: OK (%d tools, %d resources, %d prompts)\n",
+				configPath, len(config.Tools), len(config.Resources), len(config.Prompts))
+			return nil
+		},
 	}
+}
 
-	// Load existing config if it exists
-	var config map[string]map[string]string
-	var configFileNotFound viper.ConfigFileNotFoundError
-	err := viper.ReadInConfig()
+// proxyConfigPath returns the config file given with --config, or the default proxy config path.
+func proxyConfigPath(cmd *cobra.Command) (string, error) {
+	if configPath, err := cmd.Flags().GetString("config"); err == nil && configPath != "" {
+		return configPath, nil
+	}
+	return proxy.DefaultConfigPath()
+}
+
+// LoadProxyConfig loads the proxy configuration from the default config file.
+func LoadProxyConfig() (*proxy.Config, error) {
+	configPath, err := proxy.DefaultConfigPath()
 	if err != nil {
-		if errors.As(err, &configFileNotFound) {
-			// Config file not found, create a new one
-			config = make(map[string]map[string]string)
-		} else {
-			return nil, fmt.Errorf("error reading config: %w", err)
-		}
-	} else {
-		// Config file found, unmarshal it
-		config = make(map[string]map[string]string)
-		unmarshalErr := viper.Unmarshal(&config)
-		if unmarshalErr != nil {
-			return nil, fmt.Errorf("error unmarshaling config: %w", unmarshalErr)
+		return nil, err
+	}
+
+	config, err := proxy.LoadConfig(configPath)
+	if err != nil {
+		var validationErr *proxy.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, fmt.Errorf("invalid config:\n%w", err)
 		}
+		return nil, err
 	}
 
 	return config, nil
 }
 
-// SaveProxyConfig saves the proxy configuration to the config file.
-func SaveProxyConfig(config map[string]map[string]string) error {
-	configPath := os.ExpandEnv("$HOME/.mcpt/proxy_config.json")
-	configJSON, err := json.MarshalIndent(config, "", "  ")
+// SaveProxyConfig saves the proxy configuration to the default config file.
+func SaveProxyConfig(config *proxy.Config) error {
+	configPath, err := proxy.DefaultConfigPath()
 	if err != nil {
-		return fmt.Errorf("error marshaling config: %w", err)
-	}
-
-	writeErr := os.WriteFile(configPath, configJSON, 0o600)
-	if writeErr != nil {
-		return fmt.Errorf("error writing config: %w", writeErr)
+		return err
 	}
 
-	return nil
+	return proxy.SaveConfig(configPath, config)
 }
@@ -4,16 +4,28 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/spf13/cobra"
+	"github.com/yosida95/uritemplate/v3"
 )
 
+// FlagVar sets a variable of a resource template.
+const FlagVar = "--var"
+
 // ReadResourceCmd creates the read-resource command.
 func ReadResourceCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:                "read-resource resource [command args...]",
-		Short:              "Read a resource on the MCP server",
+		Use:   "read-resource resource [command args...]",
+		Short: "Read a resource on the MCP server",
+		Long: `Read a resource on the MCP server.
+
+The resource can be an RFC 6570 URI template, as listed by the resources command,
+whose variables are set with --var name=value.`,
+		Example: `  mcp read-resource test://static/resource/1 npx -y @modelcontextprotocol/server-everything
+  mcp read-resource "file:///{path}" --var path=README.md fs`,
 		DisableFlagParsing: true,
 		SilenceUsage:       true,
 		Run: func(thisCmd *cobra.Command, args []string) {
@@ -34,6 +46,7 @@ func ReadResourceCmd() *cobra.Command {
 			cmdArgs := args
 			parsedArgs := []string{}
 			resourceName := ""
+			vars := map[string]any{}
 
 			i := 0
 			resourceExtracted := false
@@ -43,9 +56,32 @@ func ReadResourceCmd() *cobra.Command {
 				case (cmdArgs[i] == FlagFormat || cmdArgs[i] == FlagFormatShort) && i+1 < len(cmdArgs):
 					FormatOption = cmdArgs[i+1]
 					i += 2
+				case cmdArgs[i] == FlagQuery && i+1 < len(cmdArgs):
+					QueryOption = cmdArgs[i+1]
+					i += 2
+				case cmdArgs[i] == FlagTemplate && i+1 < len(cmdArgs):
+					TemplateOption = cmdArgs[i+1]
+					i += 2
 				case (cmdArgs[i] == FlagParams || cmdArgs[i] == FlagParamsShort) && i+1 < len(cmdArgs):
 					ParamsString = cmdArgs[i+1]
 					i += 2
+				case cmdArgs[i] == FlagOutputDir && i+1 < len(cmdArgs):
+					OutputDir = cmdArgs[i+1]
+					i += 2
+				case cmdArgs[i] == FlagVar && i+1 < len(cmdArgs):
+					name, value, found := strings.Cut(cmdArgs[i+1], "=")
+					if !found || name == "" {
+						fmt.Fprintf(os.Stderr, "Error: invalid %s %q, use name=value\n", FlagVar, cmdArgs[i+1])
+						os.Exit(1)
+					}
+					vars[name] = value
+					i += 2
+				case isClientFlag(cmdArgs[i]) && i+1 < len(cmdArgs):
+					setClientFlag(cmdArgs[i], cmdArgs[i+1])
+					i += 2
+				case cmdArgs[i] == FlagOpen:
+					OpenOutput = true
+					i++
 				case !resourceExtracted:
 					resourceName = cmdArgs[i]
 					resourceExtracted = true
@@ -65,6 +101,12 @@ func ReadResourceCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
+			resourceName, expandErr := expandResourceURI(resourceName, vars)
+			if expandErr != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", expandErr)
+				os.Exit(1)
+			}
+
 			mcpClient, clientErr := CreateClientFunc(parsedArgs)
 			if clientErr != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", clientErr)
@@ -78,6 +120,10 @@ func ReadResourceCmd() *cobra.Command {
 			var responseMap map[string]any
 			if execErr == nil && resp != nil {
 				responseMap = ConvertJSONToMap(resp)
+				if saveErr := saveAndOpenContent(responseMap, resourceName); saveErr != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", saveErr)
+					os.Exit(1)
+				}
 			} else {
 				responseMap = map[string]any{}
 			}
@@ -89,3 +135,27 @@ func ReadResourceCmd() *cobra.Command {
 		},
 	}
 }
+
+// expandResourceURI expands a resource URI template with the variables set with
+// --var. A URI that is not a template is returned as is, and variables the template
+// does not have are an error, as they are most likely misspelled.
+func expandResourceURI(uri string, vars map[string]any) (string, error) {
+	if !strings.Contains(uri, "{") {
+		if len(vars) > 0 {
+			return "", fmt.Errorf("%s is not a resource template, it has no variables to set with %s", uri, FlagVar)
+		}
+		return uri, nil
+	}
+
+	tmpl, err := uritemplate.New(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid resource template %s: %w", uri, err)
+	}
+	names := tmpl.Varnames()
+	for name := range vars {
+		if !slices.Contains(names, name) {
+			return "", fmt.Errorf("resource template %s has no variable %q (variables: %s)", uri, name, strings.Join(names, ", "))
+		}
+	}
+	return expandURITemplate(uri, vars)
+}
@@ -67,3 +67,47 @@ func TestReadResourceCmdRun_Success(t *testing.T) {
 	assertContains(t, output, "text/plain")
 	assertContains(t, output, "bar")
 }
+
+func TestReadResourceCmdRun_Template(t *testing.T) {
+	var uri string
+	cleanup := setupMockClient(func(_ string, params any) (map[string]any, error) {
+		uri, _ = ConvertJSONToMap(params)["uri"].(string)
+		return map[string]any{"contents": []any{}}, nil
+	})
+	defer cleanup()
+
+	cmd := ReadResourceCmd()
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetArgs([]string{"file:///{+path}{?rev}", "--var", "path=docs/read me.md", "--var", "rev=2", "-f", "json", "server"})
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("cmd.Execute() error = %v", err)
+	}
+	assertEquals(t, uri, "file:///docs/read%20me.md?rev=2")
+}
+
+func TestExpandResourceURI(t *testing.T) {
+	tests := []struct {
+		name     string
+		uri      string
+		vars     map[string]any
+		expected string
+		wantErr  bool
+	}{
+		{name: "plain uri", uri: "test://foo", expected: "test://foo"},
+		{name: "template", uri: "users://{id}/profile", vars: map[string]any{"id": "42"}, expected: "users://42/profile"},
+		{name: "unset variable", uri: "search://{?q}", expected: "search://"},
+		{name: "unknown variable", uri: "users://{id}", vars: map[string]any{"name": "x"}, wantErr: true},
+		{name: "vars without template", uri: "test://foo", vars: map[string]any{"id": "1"}, wantErr: true},
+		{name: "invalid template", uri: "users://{id", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uri, err := expandResourceURI(tt.uri, tt.vars)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandResourceURI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assertEquals(t, uri, tt.expected)
+		})
+	}
+}
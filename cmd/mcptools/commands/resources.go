@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/spf13/cobra"
 )
@@ -13,7 +14,7 @@ import (
 func ResourcesCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:                "resources [command args...]",
-		Short:              "List available resources on the MCP server",
+		Short:              "List available resources and resource templates on the MCP server",
 		DisableFlagParsing: true,
 		SilenceUsage:       true,
 		Run: func(thisCmd *cobra.Command, args []string) {
@@ -31,14 +32,16 @@ func ResourcesCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
-			resp, listErr := mcpClient.ListResources(context.Background(), mcp.ListResourcesRequest{})
+			resources, nextCursor, listErr := listResources(context.Background(), mcpClient, mcp.Cursor(CursorOption), !NoPaginate)
 
-			var resources []any
-			if listErr == nil && resp != nil {
-				resources = ConvertJSONToSlice(resp.Resources)
+			resourcesMap := map[string]any{"resources": ConvertJSONToSlice(resources)}
+			addNextCursor(thisCmd, resourcesMap, "resources", nextCursor)
+			// The templates are a list of their own, listed with the first page
+			if listErr == nil && CursorOption == "" {
+				if templates := listResourceTemplates(mcpClient); len(templates) > 0 {
+					resourcesMap["resourceTemplates"] = templates
+				}
 			}
-
-			resourcesMap := map[string]any{"resources": resources}
 			if formatErr := FormatAndPrintResponse(thisCmd, resourcesMap, listErr); formatErr != nil {
 				fmt.Fprintf(os.Stderr, "%v\n", formatErr)
 				os.Exit(1)
@@ -46,3 +49,13 @@ func ResourcesCmd() *cobra.Command {
 		},
 	}
 }
+
+// listResourceTemplates returns the resource templates of the server, or nil when the
+// server has none or does not support them.
+func listResourceTemplates(mcpClient *client.Client) []any {
+	templates, _, err := listTemplates(context.Background(), mcpClient, "", true)
+	if err != nil {
+		return nil
+	}
+	return ConvertJSONToSlice(templates)
+}
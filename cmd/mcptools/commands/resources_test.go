@@ -38,9 +38,13 @@ func TestResourcesCmdRun_Success(t *testing.T) {
 	}
 
 	cleanup := setupMockClient(func(method string, _ any) (map[string]any, error) {
-		if method != "resources/list" {
-			t.Errorf("Expected method 'resources/list', got %q", method)
+		switch method {
+		case "resources/list":
+			return mockResponse, nil
+		case "resources/templates/list":
+			return map[string]any{"resourceTemplates": []any{}}, nil
 		}
+		t.Errorf("Expected method 'resources/list', got %q", method)
 		return mockResponse, nil
 	})
 	defer cleanup()
@@ -64,3 +68,30 @@ func TestResourcesCmdRun_Success(t *testing.T) {
 	assertContains(t, output, "text/plain")
 	assertContains(t, output, "Test resource description")
 }
+
+func TestResourcesCmdRun_Templates(t *testing.T) {
+	cleanup := setupMockClient(func(method string, _ any) (map[string]any, error) {
+		if method == "resources/templates/list" {
+			return map[string]any{"resourceTemplates": []any{
+				map[string]any{"uriTemplate": "file:///{path}", "name": "file", "description": "A file"},
+			}}, nil
+		}
+		return map[string]any{"resources": []any{
+			map[string]any{"uri": "test://resource", "name": "TestResource"},
+		}}, nil
+	})
+	defer cleanup()
+
+	cmd := ResourcesCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"server", "arg"})
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("cmd.Execute() error = %v", err)
+	}
+
+	output := buf.String()
+	assertContains(t, output, "test://resource")
+	assertContains(t, output, "file:///{path}")
+	assertContains(t, output, "A file")
+}
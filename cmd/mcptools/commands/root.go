@@ -19,6 +19,12 @@ const (
 	FlagTransport   = "--transport"
 	FlagAuthUser    = "--auth-user"
 	FlagAuthHeader  = "--auth-header"
+	FlagQuery       = "--query"
+	FlagTemplate    = "--template"
+	FlagOutputDir   = "--output-dir"
+	FlagOpen        = "--open"
+	FlagCursor      = "--cursor"
+	FlagNoPaginate  = "--no-paginate"
 )
 
 // entity types.
@@ -36,8 +42,8 @@ const (
 )
 
 var (
-	// FormatOption is the format option for the command, valid values are "table", "json", and
-	// "pretty".
+	// FormatOption is the format option for the command, valid values are "table", "json",
+	// "pretty", "yaml" and "markdown".
 	// Default is "table".
 	FormatOption = "table"
 	// ParamsString is the params for the command.
@@ -51,6 +57,26 @@ var (
 	AuthUser string
 	// AuthHeader is a custom Authorization header.
 	AuthHeader string
+	// QueryOption is a jq-style expression applied to the response before it is printed.
+	QueryOption string
+	// TemplateOption is a Go template used to print the response instead of FormatOption.
+	TemplateOption string
+	// OutputDir is the directory where call and read-resource save image, audio and blob contents.
+	OutputDir string
+	// OpenOutput opens the files saved to OutputDir with the default application.
+	OpenOutput bool
+	// CursorOption is the cursor of the page the list commands start at.
+	CursorOption string
+	// NoPaginate makes the list commands list a single page instead of all pages.
+	NoPaginate bool
+	// RootsOption are the directories served to the server as its roots.
+	RootsOption []string
+	// SamplingResponder answers the sampling requests of the server. It is a file with
+	// the reply, a command that prints it, or "prompt" to type it in the terminal.
+	SamplingResponder string
+	// ProtocolVersionOption pins the protocol version, instead of negotiating the latest
+	// version both the client and the server support.
+	ProtocolVersionOption string
 )
 
 // RootCmd creates the root command.
@@ -62,11 +88,18 @@ func RootCmd() *cobra.Command {
 It allows you to discover and call tools, list resources, and interact with MCP-compatible services.`,
 	}
 
-	cmd.PersistentFlags().StringVarP(&FormatOption, "format", "f", "table", "Output format (table, json, pretty)")
+	cmd.PersistentFlags().StringVarP(&FormatOption, "format", "f", "table", "Output format (table, json, pretty, yaml, markdown, ndjson)")
 	cmd.PersistentFlags().
 		StringVarP(&ParamsString, "params", "p", "{}", "JSON string of parameters to pass to the tool (for call command)")
+	cmd.PersistentFlags().StringVar(&QueryOption, "query", "", "jq-style expression to select from the output (e.g., '.tools[].name')")
+	cmd.PersistentFlags().StringVar(&TemplateOption, "template", "", "Go template to render the output with (e.g., '{{range .tools}}{{.name}}{{\"\\n\"}}{{end}}')")
 	cmd.PersistentFlags().StringVar(&TransportOption, "transport", "http", "HTTP transport type (http, sse)")
 	cmd.PersistentFlags().StringVar(&AuthUser, "auth-user", "", "Basic authentication in username:password format")
+	cmd.PersistentFlags().StringVar(&CursorOption, "cursor", "", "Cursor of the page to start listing at (for tools, resources and prompts commands)")
+	cmd.PersistentFlags().BoolVar(&NoPaginate, "no-paginate", false, "List a single page instead of following the cursors of all pages")
+	cmd.PersistentFlags().StringArrayVar(&RootsOption, "root", nil, "Directory to serve to the server as a root (repeatable)")
+	cmd.PersistentFlags().StringVar(&SamplingResponder, "sampling-responder", "", "File, command or \"prompt\" answering the sampling requests of the server")
+	cmd.PersistentFlags().StringVar(&ProtocolVersionOption, "protocol-version", "", "MCP protocol version to use instead of negotiating one")
 	cmd.PersistentFlags().StringVar(&AuthHeader, "auth-header", "", "Custom Authorization header (e.g., 'Bearer token' or 'Basic base64credentials')")
 
 	return cmd
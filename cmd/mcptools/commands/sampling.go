@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// samplingModel is the model name of the sampling replies that do not set one.
+const samplingModel = "mcptools"
+
+// errSamplingDeclined is returned to the server when the user declines to reply.
+var errSamplingDeclined = errors.New("the user declined the sampling request")
+
+// samplingResponder answers the sampling/createMessage requests of the server, which
+// ask the client for a message of its LLM. mcptools has no LLM of its own, so the
+// replies are read from a file, printed by a command or typed in the terminal.
+type samplingResponder struct {
+	file    string
+	command []string
+}
+
+// newSamplingResponder returns the responder of a --sampling-responder value: "prompt",
+// a file that is not executable, or a command with its arguments.
+func newSamplingResponder(source string) (*samplingResponder, error) {
+	if source == samplingPrompt {
+		return &samplingResponder{}, nil
+	}
+	if info, err := os.Stat(source); err == nil && !info.IsDir() && info.Mode()&0o111 == 0 {
+		return &samplingResponder{file: source}, nil
+	}
+
+	command := ParseCommandString(source)
+	if len(command) == 0 {
+		return nil, fmt.Errorf("%s is empty", FlagSamplingResponder)
+	}
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return nil, fmt.Errorf("sampling responder %s is neither a file nor a command: %w", source, err)
+	}
+	return &samplingResponder{command: command}, nil
+}
+
+// CreateMessage answers a sampling request of the server.
+func (r *samplingResponder) CreateMessage(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	var reply []byte
+	var err error
+	switch {
+	case r.file != "":
+		reply, err = os.ReadFile(r.file)
+	case r.command != nil:
+		reply, err = r.run(ctx, request.CreateMessageParams)
+	default:
+		return terminal().sample(request.CreateMessageParams)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return samplingResult(reply)
+}
+
+// run runs the command with the params of the request as JSON on its stdin, and
+// returns its output.
+func (r *samplingResponder) run(ctx context.Context, params mcp.CreateMessageParams) ([]byte, error) {
+	input, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, r.command[0], r.command[1:]...) // #nosec G204 - the command is given by the user
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("sampling responder failed: %w: %s", err, message)
+		}
+		return nil, fmt.Errorf("sampling responder failed: %w", err)
+	}
+	return output, nil
+}
+
+// samplingResult returns the result of a reply. A JSON object is a sampling result,
+// whose content can also be a plain string, and anything else is the text of the
+// message.
+func samplingResult(reply []byte) (*mcp.CreateMessageResult, error) {
+	result := &mcp.CreateMessageResult{Model: samplingModel, StopReason: "endTurn"}
+	result.Role = mcp.RoleAssistant
+
+	trimmed := bytes.TrimSpace(reply)
+	if !bytes.HasPrefix(trimmed, []byte("{")) {
+		result.Content = mcp.NewTextContent(strings.TrimRight(string(reply), "\r\n"))
+		return result, nil
+	}
+
+	var fields struct {
+		Content    any      `json:"content"`
+		Role       mcp.Role `json:"role"`
+		Model      string   `json:"model"`
+		StopReason string   `json:"stopReason"`
+	}
+	if err := json.Unmarshal(trimmed, &fields); err != nil {
+		return nil, fmt.Errorf("invalid sampling reply: %w", err)
+	}
+	switch content := fields.Content.(type) {
+	case nil:
+		return nil, fmt.Errorf("invalid sampling reply: no content")
+	case string:
+		result.Content = mcp.NewTextContent(content)
+	default:
+		result.Content = content
+	}
+	if fields.Role != "" {
+		result.Role = fields.Role
+	}
+	if fields.Model != "" {
+		result.Model = fields.Model
+	}
+	if fields.StopReason != "" {
+		result.StopReason = fields.StopReason
+	}
+	return result, nil
+}
+
+// sample shows the messages of a sampling request and asks for the reply.
+func (p *terminalPrompter) sample(params mcp.CreateMessageParams) (*mcp.CreateMessageResult, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	fmt.Fprintf(p.out, "\n[sampling] The server asks for a message of at most %d tokens\n", params.MaxTokens)
+	if params.SystemPrompt != "" {
+		fmt.Fprintf(p.out, "  system: %s\n", params.SystemPrompt)
+	}
+	for _, message := range params.Messages {
+		fmt.Fprintf(p.out, "  %s: %s\n", message.Role, samplingText(message.Content))
+	}
+
+	reply, ok := p.ask("Reply (empty to decline): ")
+	if !ok || strings.TrimSpace(reply) == "" {
+		return nil, errSamplingDeclined
+	}
+	return samplingResult([]byte(reply))
+}
+
+// samplingText returns the text of the content of a sampling message, or its type
+// for images and audio.
+func samplingText(content any) string {
+	fields, _ := content.(map[string]any)
+	if text, ok := fields["text"].(string); ok {
+		return text
+	}
+	if contentType, ok := fields["type"].(string); ok {
+		return "[" + contentType + "]"
+	}
+	return fmt.Sprint(content)
+}
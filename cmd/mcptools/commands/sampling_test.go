@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestSamplingResult(t *testing.T) {
+	tests := []struct {
+		content any
+		name    string
+		reply   string
+		model   string
+		wantErr bool
+	}{
+		{name: "text", reply: "Hello\nthere\n", content: mcp.NewTextContent("Hello\nthere"), model: samplingModel},
+		{name: "text content", reply: `{"content": "Hello", "model": "canned"}`, content: mcp.NewTextContent("Hello"), model: "canned"},
+		{
+			name:    "content object",
+			reply:   `{"content": {"type": "image", "data": "AA==", "mimeType": "image/png"}}`,
+			content: map[string]any{"type": "image", "data": "AA==", "mimeType": "image/png"},
+			model:   samplingModel,
+		},
+		{name: "no content", reply: `{"model": "canned"}`, wantErr: true},
+		{name: "invalid JSON", reply: `{"content": `, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := samplingResult([]byte(tt.reply))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("samplingResult() should fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("samplingResult() error = %v", err)
+			}
+			if !reflect.DeepEqual(result.Content, tt.content) || result.Model != tt.model || result.Role != mcp.RoleAssistant {
+				t.Errorf("samplingResult() = %+v", result)
+			}
+		})
+	}
+}
+
+func TestSamplingResponder(t *testing.T) {
+	request := mcp.CreateMessageRequest{}
+	request.Messages = []mcp.SamplingMessage{{Role: mcp.RoleUser, Content: mcp.NewTextContent("Hi")}}
+	request.SystemPrompt = "Be brief"
+
+	file := filepath.Join(t.TempDir(), "reply.txt")
+	if err := os.WriteFile(file, []byte("From a file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	responder, err := newSamplingResponder(file)
+	if err != nil {
+		t.Fatalf("newSamplingResponder() error = %v", err)
+	}
+	result, err := responder.CreateMessage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("CreateMessage() error = %v", err)
+	}
+	assertEquals(t, result.Content.(mcp.TextContent).Text, "From a file")
+
+	// The command reads the request on its stdin
+	responder, err = newSamplingResponder("grep -o Be.brief")
+	if err != nil {
+		t.Fatalf("newSamplingResponder() error = %v", err)
+	}
+	result, err = responder.CreateMessage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("CreateMessage() error = %v", err)
+	}
+	assertEquals(t, result.Content.(mcp.TextContent).Text, "Be brief")
+
+	responder, err = newSamplingResponder("grep -o nothing")
+	if err != nil {
+		t.Fatalf("newSamplingResponder() error = %v", err)
+	}
+	if _, err := responder.CreateMessage(context.Background(), request); err == nil {
+		t.Errorf("CreateMessage() with a failing command should fail")
+	}
+
+	if _, err := newSamplingResponder("mcptools-no-such-command"); err == nil {
+		t.Errorf("newSamplingResponder() with an unknown command should fail")
+	}
+}
+
+func TestTerminalSample(t *testing.T) {
+	params := mcp.CreateMessageParams{
+		Messages:  []mcp.SamplingMessage{{Role: mcp.RoleUser, Content: map[string]any{"type": "text", "text": "Hi"}}},
+		MaxTokens: 20,
+	}
+
+	out := new(bytes.Buffer)
+	prompter := &terminalPrompter{in: bufio.NewReader(strings.NewReader("Hello\n")), out: out}
+	result, err := prompter.sample(params)
+	if err != nil {
+		t.Fatalf("sample() error = %v", err)
+	}
+	assertEquals(t, result.Content.(mcp.TextContent).Text, "Hello")
+	assertContains(t, out.String(), "at most 20 tokens")
+	assertContains(t, out.String(), "user: Hi")
+
+	prompter = &terminalPrompter{in: bufio.NewReader(strings.NewReader("\n")), out: out}
+	if _, err := prompter.sample(params); !errors.Is(err, errSamplingDeclined) {
+		t.Errorf("sample() with an empty reply error = %v", err)
+	}
+}
@@ -6,30 +6,35 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"os/exec"
 	"strings"
+	"time"
 
-	"github.com/mark3labs/mcp-go/client"
+	"github.com/f/mcptools/pkg/alias"
+	"github.com/f/mcptools/pkg/jsonutils"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/peterh/liner"
 	"github.com/spf13/cobra"
 )
 
+// FlagScript runs the shell commands of a file instead of reading them interactively.
+const FlagScript = "--script"
+
 // ShellCmd creates the shell command.
-func ShellCmd() *cobra.Command { //nolint:gocyclo
+func ShellCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:                "shell [command args...]",
+		Use:                "shell [--script file] [command args...]",
 		Short:              "Start an interactive shell for MCP commands",
 		DisableFlagParsing: true,
 		SilenceUsage:       true,
-		Run: func(thisCmd *cobra.Command, args []string) {
+		RunE: func(thisCmd *cobra.Command, args []string) error {
 			if len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort) {
-				_ = thisCmd.Help()
-				return
+				return thisCmd.Help()
 			}
 
 			cmdArgs := args
 			parsedArgs := []string{}
+			script := ""
 
 			i := 0
 			for i < len(cmdArgs) {
@@ -37,6 +42,18 @@ func ShellCmd() *cobra.Command { //nolint:gocyclo
 				case (cmdArgs[i] == FlagFormat || cmdArgs[i] == FlagFormatShort) && i+1 < len(cmdArgs):
 					FormatOption = cmdArgs[i+1]
 					i += 2
+				case cmdArgs[i] == FlagQuery && i+1 < len(cmdArgs):
+					QueryOption = cmdArgs[i+1]
+					i += 2
+				case cmdArgs[i] == FlagTemplate && i+1 < len(cmdArgs):
+					TemplateOption = cmdArgs[i+1]
+					i += 2
+				case cmdArgs[i] == FlagScript && i+1 < len(cmdArgs) && script == "":
+					script = cmdArgs[i+1]
+					i += 2
+				case isClientFlag(cmdArgs[i]) && i+1 < len(cmdArgs):
+					setClientFlag(cmdArgs[i], cmdArgs[i+1])
+					i += 2
 				case cmdArgs[i] == FlagServerLogs:
 					ShowServerLogs = true
 					i++
@@ -58,25 +75,57 @@ func ShellCmd() *cobra.Command { //nolint:gocyclo
 				os.Exit(1)
 			}
 
-			mcpClient, clientErr := CreateClientFunc(parsedArgs)
-			if clientErr != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", clientErr)
-				os.Exit(1)
+			// The format command changes the format for the rest of the session only
+			defer func(format string) { FormatOption = format }(FormatOption)
+
+			notifier := newShellNotifier(thisCmd.ErrOrStderr())
+			session := newShellSession(thisCmd, notifier)
+			defer session.close()
+			if names := shellServerNames(parsedArgs); names != nil {
+				for _, name := range names {
+					if _, err := session.connect(name, []string{name}); err != nil {
+						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+						os.Exit(1)
+					}
+				}
+			} else {
+				name := ""
+				if _, found := alias.Get(parsedArgs[0]); found && len(parsedArgs) == 1 {
+					name = parsedArgs[0]
+				}
+				if _, err := session.connect(name, parsedArgs); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+
+			if script != "" {
+				reader, closeScript, err := openScript(script, thisCmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+				defer closeScript()
+				return session.runScript(script, reader)
 			}
 
 			fmt.Fprintf(thisCmd.OutOrStdout(), "mcp > MCP Tools Shell (%s)\n", Version)
-			fmt.Fprintf(thisCmd.OutOrStdout(), "mcp > Connected to Server: %s\n", strings.Join(parsedArgs, " "))
+			for _, name := range session.names {
+				fmt.Fprintf(thisCmd.OutOrStdout(), "mcp > Connected to Server: %s\n", session.servers[name].command)
+			}
 			fmt.Fprintf(thisCmd.OutOrStdout(), "\nmcp > Type '/h' for help or '/q' to quit\n")
 
 			line := liner.NewLiner()
 			line.SetCtrlCAborts(true)
 			defer func() { _ = line.Close() }()
 
-			defer setUpHistory(line)()
-			setUpCompleter(line)
+			session.history = &shellHistory{line: line}
+			session.history.load(historyFile(session.current.name))
+			defer session.history.save()
+			setUpCompleter(line, session)
 
+			lastInput := ""
 			for {
-				input, err := line.Prompt("mcp > ")
+				input, err := readInput(line, notifier, session.prompt())
 				if err != nil {
 					if errors.Is(err, liner.ErrPromptAborted) {
 						fmt.Fprintln(thisCmd.OutOrStdout(), "Exiting MCP shell")
@@ -86,114 +135,180 @@ func ShellCmd() *cobra.Command { //nolint:gocyclo
 					break
 				}
 
-				if input == "" {
+				if command, rest, _ := strings.Cut(strings.TrimSpace(input), " "); command == "/e" || command == "/edit" {
+					initial := strings.TrimSpace(rest)
+					if initial == "" {
+						initial = lastInput
+					}
+					if input, err = editInput(initial); err != nil {
+						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+						continue
+					}
+					if input != "" {
+						fmt.Fprintf(thisCmd.OutOrStdout(), "%s%s\n", session.prompt(), input)
+					}
+				}
+
+				if strings.TrimSpace(input) == "" {
 					continue
 				}
 
-				line.AppendHistory(input)
+				// History entries are single lines
+				line.AppendHistory(strings.ReplaceAll(input, "\n", " "))
+				lastInput = input
 
-				if input == "/q" || input == "/quit" || input == "exit" {
+				err = session.execute(input)
+				if errors.Is(err, errShellExit) {
 					fmt.Fprintln(thisCmd.OutOrStdout(), "Exiting MCP shell")
 					break
 				}
-
-				if input == "/h" || input == "/help" || input == "help" {
-					printShellHelp(thisCmd)
-					continue
+				var reported reportedError
+				if err != nil && !errors.As(err, &reported) {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				}
+			}
+			return nil
+		},
+	}
+}
 
-				parts := strings.Fields(input)
-				if len(parts) == 0 {
-					continue
-				}
+// readInput reads a line of input, prompting for more lines while a quote or a
+// JSON value is open or the line ends with a backslash. Aborting a continuation
+// line discards the input.
+func readInput(line *liner.State, notifier *shellNotifier, prompt string) (string, error) {
+	defer notifier.prompting("")
+
+	notifier.prompting(prompt)
+	input, err := line.Prompt(prompt)
+	for err == nil && inputIncomplete(input) {
+		var more string
+		notifier.prompting("... > ")
+		more, err = line.Prompt("... > ")
+		if errors.Is(err, liner.ErrPromptAborted) {
+			return "", nil
+		}
+		input += "\n" + more
+	}
+	return input, err
+}
 
-				command := parts[0]
-				commandArgs := parts[1:]
+// inputIncomplete reports whether a quote, a JSON value or an escape of the input
+// is open.
+func inputIncomplete(input string) bool {
+	_, err := splitWords(input, true)
+	return err != nil
+}
 
-				var resp map[string]any
-				var listErr error
+// editInput opens initial in $VISUAL or $EDITOR, falling back to vi, and returns
+// the edited text without the lines starting with #.
+func editInput(initial string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
 
-				switch command {
-				case "tools":
-					var listToolsResult *mcp.ListToolsResult
-					listToolsResult, listErr = mcpClient.ListTools(context.Background(), mcp.ListToolsRequest{})
+	f, err := os.CreateTemp("", "mcp-*.mcp")
+	if err != nil {
+		return "", fmt.Errorf("error creating temporary file: %w", err)
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
 
-					var tools []any
-					if listErr == nil && listToolsResult != nil {
-						tools = ConvertJSONToSlice(listToolsResult.Tools)
-					}
+	content := "# Edit the command, then save and quit. Lines starting with # are ignored.\n" + initial + "\n"
+	if _, err = f.WriteString(content); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("error writing temporary file: %w", err)
+	}
+	_ = f.Close()
+
+	// The editor can have arguments, such as "code --wait"
+	editorArgs := ParseCommandString(editor)
+	cmd := exec.Command(editorArgs[0], append(editorArgs[1:], f.Name())...) // #nosec G204 - the editor is configured by the user
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err = cmd.Run(); err != nil {
+		return "", fmt.Errorf("error running editor %s: %w", editor, err)
+	}
 
-					resp = map[string]any{"tools": tools}
-					if formatErr := FormatAndPrintResponse(thisCmd, resp, listErr); formatErr != nil {
-						fmt.Fprintf(os.Stderr, "%v\n", formatErr)
-						continue
-					}
-				case "resources":
-					var listResourcesResult *mcp.ListResourcesResult
-					listResourcesResult, listErr = mcpClient.ListResources(context.Background(), mcp.ListResourcesRequest{})
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("error reading edited command: %w", err)
+	}
 
-					var resources []any
-					if listErr == nil && listResourcesResult != nil {
-						resources = ConvertJSONToSlice(listResourcesResult.Resources)
-					}
+	var lines []string
+	for _, editedLine := range strings.Split(string(edited), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(editedLine), "#") {
+			lines = append(lines, editedLine)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
 
-					resp = map[string]any{"resources": resources}
-					if formatErr := FormatAndPrintResponse(thisCmd, resp, listErr); formatErr != nil {
-						fmt.Fprintf(os.Stderr, "%v\n", formatErr)
-						continue
-					}
-				case "prompts":
-					var listPromptsResult *mcp.ListPromptsResult
-					listPromptsResult, listErr = mcpClient.ListPrompts(context.Background(), mcp.ListPromptsRequest{})
+// cutOutputCommand splits a query or template shell command into the command
+// and the rest of the line, which is kept as is so it can contain spaces.
+func cutOutputCommand(input string) (string, string, bool) {
+	command, value, _ := strings.Cut(strings.TrimSpace(input), " ")
+	if command != "query" && command != "template" {
+		return "", "", false
+	}
+	return command, strings.TrimSpace(value), true
+}
 
-					var prompts []any
-					if listErr == nil && listPromptsResult != nil {
-						prompts = ConvertJSONToSlice(listPromptsResult.Prompts)
-					}
+// setOutputOption shows or sets the query or template used for the rest of the
+// session. The value "off" clears it.
+func setOutputOption(thisCmd *cobra.Command, command, value string, sessionQuery, sessionTemplate *string) {
+	option := sessionQuery
+	if command == "template" {
+		option = sessionTemplate
+	}
 
-					resp = map[string]any{"prompts": prompts}
-					if formatErr := FormatAndPrintResponse(thisCmd, resp, listErr); formatErr != nil {
-						fmt.Fprintf(os.Stderr, "%v\n", formatErr)
-						continue
-					}
-				case "format":
-					if len(commandArgs) < 1 {
-						fmt.Fprintf(thisCmd.OutOrStdout(), "Current format: %s\n", FormatOption)
-						continue
-					}
+	switch value {
+	case "":
+		if *option == "" {
+			fmt.Fprintf(thisCmd.OutOrStdout(), "No %s set\n", command)
+		} else {
+			fmt.Fprintf(thisCmd.OutOrStdout(), "Current %s: %s\n", command, *option)
+		}
+	case "off":
+		*option = ""
+		fmt.Fprintf(thisCmd.OutOrStdout(), "Cleared %s\n", command)
+	default:
+		*option = value
+		fmt.Fprintf(thisCmd.OutOrStdout(), "%s set to: %s\n", strings.ToUpper(command[:1])+command[1:], value)
+	}
+}
 
-					oldFormat := FormatOption
-					defer func() { FormatOption = oldFormat }()
-					newFormat := commandArgs[0]
-					if IsValidFormat(newFormat) {
-						FormatOption = newFormat
-						fmt.Fprintf(thisCmd.OutOrStdout(), "Format set to: %s\n", FormatOption)
-					} else {
-						fmt.Fprintln(thisCmd.OutOrStdout(), "Invalid format. Use: table, json, or pretty")
-					}
-				case "call":
-					if len(commandArgs) < 1 {
-						fmt.Fprintln(thisCmd.OutOrStdout(), "Usage: call <entity> [--params '{...}']")
-						continue
-					}
-					err := callCommand(thisCmd, mcpClient, commandArgs)
-					if err != nil {
-						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-						continue
-					}
-				default:
-					if err := callCommand(thisCmd, mcpClient, append([]string{command}, commandArgs...)); err != nil {
-						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-						continue
-					}
-				}
-			}
-		},
+// applyOutputOption sets QueryOption or TemplateOption from a --query or
+// --template option on a shell line and returns the line without it. The
+// option takes the rest of the line, so expressions can contain spaces.
+func applyOutputOption(input string) string {
+	for _, flag := range []string{FlagQuery, FlagTemplate} {
+		index := strings.Index(input, " "+flag+" ")
+		if index < 0 {
+			continue
+		}
+
+		value := strings.TrimSpace(input[index+len(flag)+2:])
+		if flag == FlagQuery {
+			QueryOption, TemplateOption = value, ""
+		} else {
+			TemplateOption = value
+		}
+		return input[:index]
 	}
+	return input
 }
 
-func callCommand(thisCmd *cobra.Command, mcpClient *client.Client, commandArgs []string) error {
-	entityName := commandArgs[0]
+// call calls a tool, reads a resource or gets a prompt on the current server, or on
+// the server the entity is addressed to. The entity name and the params can contain
+// variable references. The result is printed when print is set.
+func (s *shellSession) call(commandArgs []string, print bool) (map[string]any, error) {
+	entityName, err := s.expandReferences(commandArgs[0], true)
+	if err != nil {
+		return nil, err
+	}
+	server, entityName := s.resolveServer(entityName)
 	entityType := EntityTypeTool
 	parts := strings.SplitN(entityName, ":", 2)
 	if len(parts) == 2 {
@@ -209,7 +324,7 @@ func callCommand(thisCmd *cobra.Command, mcpClient *client.Client, commandArgs [
 			continue
 		case FlagFormat, FlagFormatShort:
 			if i+1 >= len(commandArgs) {
-				return fmt.Errorf("no format provided after %s", commandArgs[i])
+				return nil, fmt.Errorf("no format provided after %s", commandArgs[i])
 			}
 			oldFormat := FormatOption
 			defer func() { FormatOption = oldFormat }()
@@ -217,7 +332,7 @@ func callCommand(thisCmd *cobra.Command, mcpClient *client.Client, commandArgs [
 			if IsValidFormat(newFormat) {
 				FormatOption = newFormat
 			} else {
-				fmt.Fprintln(thisCmd.OutOrStdout(), "Invalid format. Use: table, json, or pretty")
+				fmt.Fprintln(s.cmd.OutOrStdout(), "Invalid format. Use: table, json, pretty, yaml, markdown, or ndjson")
 			}
 			i++
 		default:
@@ -226,60 +341,86 @@ func callCommand(thisCmd *cobra.Command, mcpClient *client.Client, commandArgs [
 	}
 
 	if len(remainingArgs) > 0 {
-		if err := parseJSONBestEffort(strings.Join(remainingArgs, " "), &params); err != nil {
-			return fmt.Errorf("invalid JSON for params: %w", err)
+		paramsString, expandErr := s.expandReferences(strings.Join(remainingArgs, " "), false)
+		if expandErr != nil {
+			return nil, expandErr
+		}
+		if err := parseJSONBestEffort(paramsString, &params); err != nil {
+			return nil, fmt.Errorf("invalid JSON for params: %w", err)
 		}
 	}
 
 	var resp map[string]any
 	var execErr error
+	record := CallRecord{Server: server.command, Name: entityName}
+	start := time.Now()
 
 	switch entityType {
 	case EntityTypeTool:
+		record.Method = "tools/call"
 		var toolResponse *mcp.CallToolResult
 		request := mcp.CallToolRequest{}
 		request.Params.Name = entityName
 		request.Params.Arguments = params
-		toolResponse, execErr = mcpClient.CallTool(context.Background(), request)
+		// A progress token asks the server for progress notifications
+		s.progressToken++
+		request.Params.Meta = &mcp.Meta{ProgressToken: s.progressToken}
+		toolResponse, execErr = server.client.CallTool(context.Background(), request)
 		if execErr == nil && toolResponse != nil {
 			resp = ConvertJSONToMap(toolResponse)
 		} else {
 			resp = map[string]any{}
 		}
 	case EntityTypeRes:
+		record.Method = "resources/read"
+		uri := entityName
+		if strings.Contains(uri, "{") {
+			// The params of a resource template are its variables
+			if uri, err = expandURITemplate(entityName, params); err != nil {
+				return nil, err
+			}
+			record.Name = uri
+		}
 		var resourceResponse *mcp.ReadResourceResult
 		request := mcp.ReadResourceRequest{}
-		request.Params.URI = entityName
-		resourceResponse, execErr = mcpClient.ReadResource(context.Background(), request)
+		request.Params.URI = uri
+		resourceResponse, execErr = server.client.ReadResource(context.Background(), request)
 		if execErr == nil && resourceResponse != nil {
 			resp = ConvertJSONToMap(resourceResponse)
 		} else {
 			resp = map[string]any{}
 		}
 	case EntityTypePrompt:
+		record.Method = "prompts/get"
 		var promptResponse *mcp.GetPromptResult
 		request := mcp.GetPromptRequest{}
 		request.Params.Name = entityName
-		promptResponse, execErr = mcpClient.GetPrompt(context.Background(), request)
+		request.Params.Arguments = promptArguments(params)
+		promptResponse, execErr = server.client.GetPrompt(context.Background(), request)
 		if execErr == nil && promptResponse != nil {
 			resp = ConvertJSONToMap(promptResponse)
 		} else {
 			resp = map[string]any{}
 		}
 	default:
-		fmt.Fprintf(os.Stderr, "Error: unsupported entity type: %s\n", entityType)
+		return nil, fmt.Errorf("unsupported entity type: %s", entityType)
 	}
 
-	if execErr != nil {
-		return execErr
+	// In the ndjson format errors are written as records
+	if execErr != nil && (!print || jsonutils.ParseFormat(FormatOption) != jsonutils.FormatNDJSON) {
+		return nil, execErr
 	}
 
-	formatErr := FormatAndPrintResponse(thisCmd, resp, nil)
-	if formatErr != nil {
-		return fmt.Errorf("error formatting output: %w", formatErr)
+	if print {
+		if formatErr := PrintCallResult(s.cmd, record, start, resp, execErr); formatErr != nil {
+			return nil, fmt.Errorf("error formatting output: %w", formatErr)
+		}
+	}
+	if execErr != nil {
+		return nil, reportedError{execErr}
 	}
 
-	return nil
+	return resp, nil
 }
 
 func parseJSONBestEffort(jsonString string, params *map[string]any) error {
@@ -293,43 +434,9 @@ func parseJSONBestEffort(jsonString string, params *map[string]any) error {
 	return nil
 }
 
-func setUpHistory(line *liner.State) func() {
-	historyFile := filepath.Join(getHomeDirectory(), ".mcp_history")
-	if f, err := os.Open(filepath.Clean(historyFile)); err == nil {
-		_, _ = line.ReadHistory(f)
-		_ = f.Close()
-	}
-
-	return func() {
-		if f, err := os.Create(historyFile); err == nil {
-			_, _ = line.WriteHistory(f)
-			_ = f.Close()
-		}
-	}
-}
-
-func setUpCompleter(line *liner.State) {
-	line.SetCompleter(func(line string) (c []string) {
-		commands := []string{
-			"tools",
-			"resources",
-			"prompts",
-			"call",
-			"format",
-			"help",
-			"exit",
-			"/h",
-			"/q",
-			"/help",
-			"/quit",
-		}
-		for _, cmd := range commands {
-			if strings.HasPrefix(cmd, line) {
-				c = append(c, cmd)
-			}
-		}
-		return
-	})
+func setUpCompleter(line *liner.State, session *shellSession) {
+	line.SetTabCompletionStyle(liner.TabPrints)
+	line.SetWordCompleter(session.complete)
 }
 
 func printShellHelp(thisCmd *cobra.Command) {
@@ -338,12 +445,33 @@ func printShellHelp(thisCmd *cobra.Command) {
 	fmt.Fprintln(thisCmd.OutOrStdout(), "  resources                  List available resources")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "  prompts                    List available prompts")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "  call <entity> [--params '{...}']  Call a tool, resource, or prompt")
-	fmt.Fprintln(thisCmd.OutOrStdout(), "  format [json|pretty|table] Get or set output format")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  format [json|pretty|table|yaml|markdown|ndjson] Get or set output format")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  query [<expr>|off]         Get or set a jq-style query applied to every output")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  template [<tmpl>|off]      Get or set a Go template used for every output")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  <command> --query <expr>   Apply a query to one command (also --template <tmpl>)")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  tools --all                List the tools of every server (also resources, prompts)")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  use [<alias>]              Switch to another server, or list the servers")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  <alias>.<tool> {...}       Call a tool of another server (also <alias>:<tool>)")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  loglevel [<level>]         Get or set the level of the server's log messages")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  subscribe [<uri>]          Follow the updates of a resource, or list subscriptions")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  unsubscribe <uri>          Stop following the updates of a resource")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "Variables and Scripting:")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  set <name> = <value>       Set a variable to JSON or a query on the last result")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  set                        List the variables")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  unset <name>               Remove a variable")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  $name, $_.content[0].text  Use a variable or the last result in params")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  \"text ${name}\"             Use a variable inside a JSON string")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  <command> | <command>      Run commands in order, each seeing the last result as $_")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  assert <expr>              Fail unless a query on the last result is true")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "Direct Tool Calling:")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "  <tool_name> {\"param\": \"value\"}  Call a tool directly with JSON parameters")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "  resource:<name>            Read a resource directly")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "  prompt:<name>              Get a prompt directly")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "Special Commands:")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "  /h, /help                  Show this help")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  /e, /edit [<command>]      Edit a command, or the last one, in $EDITOR and run it")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "  /q, /quit, exit            Exit the shell")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "Input:")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  Words can be quoted with '...' or \"...\", and JSON params need no quotes.")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  Input continues on the next line while JSON or a quote is open, or after a \\.")
 }
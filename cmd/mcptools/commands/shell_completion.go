@@ -0,0 +1,499 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/f/mcptools/pkg/alias"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// completionTimeout limits the time the shell waits for the server while completing.
+const completionTimeout = 2 * time.Second
+
+// shellCommands are the commands of the shell that are completed at the start of a line.
+var shellCommands = []string{
+	"tools",
+	"resources",
+	"prompts",
+	"call",
+	"use",
+	"format",
+	"query",
+	"template",
+	"loglevel",
+	"subscribe",
+	"unsubscribe",
+	"set",
+	"unset",
+	"assert",
+	"help",
+	"exit",
+	"/h",
+	"/e",
+	"/q",
+	"/edit",
+	"/help",
+	"/quit",
+}
+
+// shellCatalog caches the tools, prompts, resources and resource templates of a
+// server for completion. A list is fetched the first time it is needed, and again
+// after the server sends a list_changed notification for it.
+type shellCatalog struct {
+	client    *client.Client
+	loaded    map[string]bool
+	tools     []mcp.Tool
+	prompts   []mcp.Prompt
+	resources []mcp.Resource
+	templates []mcp.ResourceTemplate
+	mu        sync.Mutex
+}
+
+// newShellCatalog creates a catalog of the server of a client.
+func newShellCatalog(mcpClient *client.Client) *shellCatalog {
+	catalog := &shellCatalog{client: mcpClient, loaded: map[string]bool{}}
+	mcpClient.OnNotification(func(notification mcp.JSONRPCNotification) {
+		switch notification.Method {
+		case mcp.MethodNotificationToolsListChanged:
+			catalog.invalidate("tools")
+		case mcp.MethodNotificationPromptsListChanged:
+			catalog.invalidate("prompts")
+		case mcp.MethodNotificationResourcesListChanged:
+			catalog.invalidate("resources")
+		}
+	})
+	return catalog
+}
+
+// invalidate marks a list to be fetched again.
+func (c *shellCatalog) invalidate(list string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loaded[list] = false
+}
+
+// load fetches a list unless it is already loaded. Errors leave the list empty,
+// since a server does not have to support every list.
+func (c *shellCatalog) load(list string) {
+	if c.loaded[list] {
+		return
+	}
+	c.loaded[list] = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	switch list {
+	case "tools":
+		c.tools, _, _ = listTools(ctx, c.client, "", true)
+	case "prompts":
+		c.prompts, _, _ = listPrompts(ctx, c.client, "", true)
+	case "resources":
+		c.resources, _, _ = listResources(ctx, c.client, "", true)
+		c.templates, _, _ = listTemplates(ctx, c.client, "", true)
+	}
+}
+
+// entities returns the names of the tools, resources and prompts as they are typed
+// in the shell, such as read_file, resource:file:///a.txt and prompt:summarize.
+func (c *shellCatalog) entities() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load("tools")
+	c.load("prompts")
+	c.load("resources")
+
+	var names []string
+	for _, tool := range c.tools {
+		names = append(names, tool.Name)
+	}
+	for _, resource := range c.resources {
+		names = append(names, EntityTypeRes+":"+resource.URI)
+	}
+	for _, template := range c.templates {
+		if template.URITemplate != nil {
+			names = append(names, EntityTypeRes+":"+template.URITemplate.Raw())
+		}
+	}
+	for _, prompt := range c.prompts {
+		names = append(names, EntityTypePrompt+":"+prompt.Name)
+	}
+	return names
+}
+
+// completionParam is a parameter of a tool, an argument of a prompt or a variable of
+// a resource template.
+type completionParam struct {
+	schema map[string]any
+	name   string
+}
+
+// params returns the parameters of an entity as typed in the shell, and the
+// reference used to ask the server to complete their values, which is nil for tools.
+func (c *shellCatalog) params(entity string) ([]completionParam, any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var params []completionParam
+	switch {
+	case strings.HasPrefix(entity, EntityTypePrompt+":"):
+		c.load("prompts")
+		name := strings.TrimPrefix(entity, EntityTypePrompt+":")
+		for _, prompt := range c.prompts {
+			if prompt.Name != name {
+				continue
+			}
+			for _, argument := range prompt.Arguments {
+				params = append(params, completionParam{name: argument.Name})
+			}
+			return params, mcp.PromptReference{Type: "ref/prompt", Name: name}
+		}
+	case strings.HasPrefix(entity, EntityTypeRes+":"):
+		c.load("resources")
+		uri := strings.TrimPrefix(entity, EntityTypeRes+":")
+		for _, template := range c.templates {
+			if template.URITemplate == nil || template.URITemplate.Raw() != uri {
+				continue
+			}
+			for _, name := range template.URITemplate.Varnames() {
+				params = append(params, completionParam{name: name})
+			}
+			return params, mcp.ResourceReference{Type: "ref/resource", URI: uri}
+		}
+	default:
+		c.load("tools")
+		for _, tool := range c.tools {
+			if tool.Name != entity {
+				continue
+			}
+			names := make([]string, 0, len(tool.InputSchema.Properties))
+			for name := range tool.InputSchema.Properties {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				schema, _ := tool.InputSchema.Properties[name].(map[string]any)
+				params = append(params, completionParam{name: name, schema: schema})
+			}
+		}
+	}
+	return params, nil
+}
+
+// completeValue asks the server for the values of a prompt argument or resource
+// template variable that start with prefix.
+func (c *shellCatalog) completeValue(ref any, name, prefix string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	request := mcp.CompleteRequest{}
+	request.Params.Ref = ref
+	request.Params.Argument.Name = name
+	request.Params.Argument.Value = prefix
+	result, err := c.client.Complete(ctx, request)
+	if err != nil {
+		return nil
+	}
+	return result.Completion.Values
+}
+
+// complete is the word completer of the shell. It completes shell commands and the
+// names of tools, resources and prompts at the start of a command, and the parameter
+// names and values in the JSON params of a call.
+func (s *shellSession) complete(line string, pos int) (string, []string, string) {
+	before, tail := line[:pos], line[pos:]
+
+	// Only the last command of a pipeline is completed
+	segmentStart := 0
+	if pipes := pipeIndexes(before); len(pipes) > 0 {
+		segmentStart = pipes[len(pipes)-1] + 1
+	}
+	segment := before[segmentStart:]
+	offset := segmentStart + len(segment) - len(strings.TrimLeft(segment, " "))
+	segment = strings.TrimLeft(segment, " ")
+
+	word, rest, hasRest := strings.Cut(segment, " ")
+	candidates := []string{}
+	switch {
+	case !hasRest:
+		if segmentStart == 0 {
+			candidates = append(candidates, shellCommands...)
+		}
+		candidates = append(candidates, s.entities(word)...)
+		return before[:offset], filterPrefix(candidates, word), tail
+	case word == "format":
+		return before[:offset+len("format ")], filterPrefix([]string{"table", "json", "pretty", "yaml", "markdown", "ndjson"}, rest), tail
+	case word == "use":
+		for name := range s.servers {
+			if name != "" {
+				candidates = append(candidates, name)
+			}
+		}
+		aliases, _ := alias.Load()
+		for name := range aliases {
+			if _, ok := s.servers[name]; !ok {
+				candidates = append(candidates, name)
+			}
+		}
+		sort.Strings(candidates)
+		return before[:offset+len("use ")], filterPrefix(candidates, rest), tail
+	case word == "loglevel":
+		return before[:offset+len("loglevel ")], filterPrefix(logLevels, rest), tail
+	case word == "subscribe" || word == "unsubscribe":
+		for _, entity := range s.current.catalog.entities() {
+			if uri, ok := strings.CutPrefix(entity, EntityTypeRes+":"); ok && !strings.Contains(uri, "{") {
+				candidates = append(candidates, uri)
+			}
+		}
+		return before[:offset+len(word)+1], filterPrefix(candidates, rest), tail
+	case word == "unset":
+		for name := range s.vars {
+			candidates = append(candidates, name)
+		}
+		sort.Strings(candidates)
+		return before[:offset+len("unset ")], filterPrefix(candidates, rest), tail
+	case word == "call":
+		offset += len("call ")
+		entity, params, hasParams := strings.Cut(strings.TrimLeft(rest, " "), " ")
+		offset += len(rest) - len(strings.TrimLeft(rest, " "))
+		if !hasParams {
+			return before[:offset], filterPrefix(s.entities(entity), entity), tail
+		}
+		return s.completeParams(before, offset+len(entity)+1, entity, params, tail)
+	case isShellKeyword(word):
+		return before, nil, tail
+	default:
+		return s.completeParams(before, offset+len(word)+1, word, rest, tail)
+	}
+}
+
+// entities returns the entities that complete word: the entities of the current
+// server and the names of the other servers. The entities of another server are only
+// listed once word addresses it, as in fs.read, so that other servers are not asked
+// for their lists on every completion.
+func (s *shellSession) entities(word string) []string {
+	names := s.current.catalog.entities()
+	for _, name := range s.names {
+		if name == "" || s.servers[name] == s.current {
+			continue
+		}
+		for _, separator := range []string{".", ":"} {
+			if !strings.HasPrefix(word, name+separator) {
+				continue
+			}
+			for _, entity := range s.servers[name].catalog.entities() {
+				names = append(names, name+separator+entity)
+			}
+		}
+		names = append(names, name+".")
+	}
+	return names
+}
+
+// completeParams completes the JSON params of a call to entity, which start at
+// index start of the line.
+func (s *shellSession) completeParams(before string, start int, entity, params, tail string) (string, []string, string) {
+	// Skip the --params flag and the quote the JSON can be wrapped in
+	for _, prefix := range []string{FlagParams + " ", FlagParamsShort + " ", "'"} {
+		if strings.HasPrefix(params, prefix) {
+			start += len(prefix)
+			params = params[len(prefix):]
+		}
+	}
+
+	state := scanParams(params)
+	if state.done {
+		return before, nil, tail
+	}
+
+	server, entity := s.resolveServer(entity)
+	entityParams, ref := server.catalog.params(entity)
+	head := before[:start+state.start]
+	var candidates []string
+
+	if !state.inValue {
+		for _, param := range entityParams {
+			if state.used[param.name] {
+				continue
+			}
+			key := string(mustJSON(param.name)) + ": "
+			if state.needBrace {
+				key = "{" + key
+			}
+			candidates = append(candidates, key)
+		}
+		prefix := state.partial
+		if prefix != "" && prefix[0] != '"' {
+			prefix = `"` + prefix
+		}
+		return head, filterPrefix(candidates, prefix), tail
+	}
+
+	for _, param := range entityParams {
+		if param.name != state.key {
+			continue
+		}
+		var values []any
+		switch {
+		case param.schema["enum"] != nil:
+			values, _ = param.schema["enum"].([]any)
+		case param.schema["type"] == "boolean":
+			values = []any{true, false}
+		case ref != nil:
+			var prefix string
+			_ = json.Unmarshal([]byte(state.partial+`"`), &prefix)
+			for _, value := range server.catalog.completeValue(ref, param.name, prefix) {
+				values = append(values, value)
+			}
+		}
+		for _, value := range values {
+			candidates = append(candidates, string(mustJSON(value)))
+		}
+	}
+	return head, filterPrefix(candidates, state.partial), tail
+}
+
+// paramsState describes the part of the JSON params that is being typed.
+type paramsState struct {
+	used      map[string]bool
+	key       string
+	partial   string
+	start     int
+	inValue   bool
+	needBrace bool
+	done      bool
+}
+
+// scanParams scans the JSON params typed so far and returns whether a key or the
+// value of key is being typed, the part of it that is typed, and the keys that
+// are already set. It is done when the object is closed or cannot be completed.
+func scanParams(params string) paramsState {
+	state := paramsState{used: map[string]bool{}}
+	i := skipSpaces(params, 0)
+	if i == len(params) {
+		state.start, state.needBrace = i, true
+		return state
+	}
+	if params[i] != '{' {
+		state.done = true
+		return state
+	}
+	i++
+
+	for {
+		// A key
+		i = skipSpaces(params, i)
+		keyStart := i
+		if i < len(params) && params[i] == '}' {
+			state.done = true
+			return state
+		}
+		end, closed := scanJSONToken(params, i)
+		if !closed || end == len(params) {
+			state.start, state.partial = keyStart, params[keyStart:end]
+			return state
+		}
+		_ = json.Unmarshal([]byte(params[keyStart:end]), &state.key)
+
+		// The colon
+		i = skipSpaces(params, end)
+		if i == len(params) || params[i] != ':' {
+			state.done = true
+			return state
+		}
+
+		// The value
+		i = skipSpaces(params, i+1)
+		valueStart := i
+		end, closed = scanJSONToken(params, i)
+		if !closed || end == len(params) {
+			state.start, state.partial, state.inValue = valueStart, params[valueStart:end], true
+			return state
+		}
+		state.used[state.key] = true
+
+		// The separator
+		i = skipSpaces(params, end)
+		if i == len(params) || params[i] != ',' {
+			state.done = true
+			return state
+		}
+		i++
+	}
+}
+
+// scanJSONToken returns the end of the JSON string, number, literal, object or array
+// that starts at index start, and whether it is complete.
+func scanJSONToken(text string, start int) (int, bool) {
+	if start == len(text) {
+		return start, false
+	}
+
+	depth := 0
+	inString := false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case inString && c == '\\':
+			i++
+		case inString && c == '"':
+			inString = false
+			if depth == 0 {
+				return i + 1, true
+			}
+		case inString:
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			if depth == 0 {
+				return i, true
+			}
+			depth--
+			if depth == 0 {
+				return i + 1, true
+			}
+		case depth == 0 && (c == ',' || c == ':' || c == ' '):
+			return i, true
+		}
+	}
+	return len(text), false
+}
+
+// skipSpaces returns the index of the first character at or after i that is not
+// a space.
+func skipSpaces(text string, i int) int {
+	for i < len(text) && (text[i] == ' ' || text[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+// isShellKeyword reports whether a word is a shell command that does not take
+// the params of a call.
+func isShellKeyword(word string) bool {
+	for _, command := range shellCommands {
+		if word == command {
+			return true
+		}
+	}
+	return false
+}
+
+// filterPrefix returns the candidates that start with prefix.
+func filterPrefix(candidates []string, prefix string) []string {
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
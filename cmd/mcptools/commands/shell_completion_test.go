@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestShellComplete(t *testing.T) {
+	var completeParams map[string]any
+	cleanup := setupMockClient(func(method string, params any) (map[string]any, error) {
+		switch method {
+		case "tools/list":
+			return map[string]any{"tools": []any{
+				map[string]any{"name": "read_file", "inputSchema": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"path":     map[string]any{"type": "string"},
+						"encoding": map[string]any{"type": "string", "enum": []any{"utf-8", "base64"}},
+						"recurse":  map[string]any{"type": "boolean"},
+					},
+				}},
+				map[string]any{"name": "write_file", "inputSchema": map[string]any{"type": "object"}},
+			}}, nil
+		case "prompts/list":
+			return map[string]any{"prompts": []any{
+				map[string]any{"name": "review", "arguments": []any{map[string]any{"name": "language"}}},
+			}}, nil
+		case "resources/list":
+			return map[string]any{"resources": []any{map[string]any{"uri": "test://readme", "name": "readme"}}}, nil
+		case "resources/templates/list":
+			return map[string]any{"resourceTemplates": []any{map[string]any{"uriTemplate": "test://users/{id}", "name": "user"}}}, nil
+		case "completion/complete":
+			completeParams = ConvertJSONToMap(params)
+			return map[string]any{"completion": map[string]any{"values": []any{"go", "golang"}}}, nil
+		}
+		return map[string]any{}, nil
+	})
+	defer cleanup()
+
+	mcpClient, err := CreateClientFunc([]string{"server"})
+	if err != nil {
+		t.Fatalf("CreateClientFunc() error = %v", err)
+	}
+	session := newShellSession(&cobra.Command{}, nil)
+	session.addServer("", "server", mcpClient)
+	session.vars["dir"] = "/tmp"
+
+	tests := []struct {
+		name        string
+		line        string
+		head        string
+		completions []string
+	}{
+		{name: "commands and entities", line: "re", head: "", completions: []string{"resources", "read_file", "resource:test://readme", "resource:test://users/{id}"}},
+		{name: "entities after call", line: "call w", head: "call ", completions: []string{"write_file"}},
+		{name: "entities after pipe", line: "tools | pro", head: "tools | ", completions: []string{"prompt:review"}},
+		{name: "format", line: "format ya", head: "format ", completions: []string{"yaml"}},
+		{name: "unset", line: "unset d", head: "unset ", completions: []string{"dir"}},
+		{name: "parameter names", line: "read_file ", head: "read_file ", completions: []string{`{"encoding": `, `{"path": `, `{"recurse": `}},
+		{name: "partial parameter name", line: `read_file {"path": "a", "re`, head: `read_file {"path": "a", `, completions: []string{`"recurse": `}},
+		{name: "bare parameter name", line: `read_file {en`, head: `read_file {`, completions: []string{`"encoding": `}},
+		{name: "enum values", line: `call read_file {"encoding": "b`, head: `call read_file {"encoding": `, completions: []string{`"base64"`}},
+		{name: "boolean values", line: `read_file --params '{"recurse": `, head: `read_file --params '{"recurse": `, completions: []string{"true", "false"}},
+		{name: "prompt argument values", line: `prompt:review {"language": "g`, head: `prompt:review {"language": `, completions: []string{`"go"`, `"golang"`}},
+		{name: "template variables", line: "resource:test://users/{id} ", head: "resource:test://users/{id} ", completions: []string{`{"id": `}},
+		{name: "closed params", line: `read_file {"path": "a"} `, head: `read_file {"path": "a"} `, completions: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			head, completions, tail := session.complete(tt.line+"!", len(tt.line))
+			if head != tt.head || !reflect.DeepEqual(completions, tt.completions) || tail != "!" {
+				t.Errorf("complete(%q) = %q, %q, %q, want %q, %q", tt.line, head, completions, tail, tt.head, tt.completions)
+			}
+		})
+	}
+
+	expectedParams := map[string]any{
+		"ref":      map[string]any{"type": "ref/prompt", "name": "review"},
+		"argument": map[string]any{"name": "language", "value": "g"},
+	}
+	if !reflect.DeepEqual(completeParams, expectedParams) {
+		t.Errorf("Expected completion/complete params %v, got %v", expectedParams, completeParams)
+	}
+}
+
+func TestShellCatalogListChanged(t *testing.T) {
+	tools := []any{map[string]any{"name": "first"}}
+	cleanup := setupMockClient(func(method string, _ any) (map[string]any, error) {
+		if method == "tools/list" {
+			return map[string]any{"tools": tools}, nil
+		}
+		return map[string]any{}, nil
+	})
+	defer cleanup()
+
+	mcpClient, err := CreateClientFunc([]string{"server"})
+	if err != nil {
+		t.Fatalf("CreateClientFunc() error = %v", err)
+	}
+	catalog := newShellCatalog(mcpClient)
+
+	assertEquals(t, catalog.entities()[0], "first")
+	tools = []any{map[string]any{"name": "second"}}
+	assertEquals(t, catalog.entities()[0], "first")
+
+	catalog.invalidate("tools")
+	assertEquals(t, catalog.entities()[0], "second")
+}
+
+func TestShellCallResourceTemplate(t *testing.T) {
+	var uri string
+	cleanup := setupMockClient(func(method string, params any) (map[string]any, error) {
+		if method == "resources/read" {
+			uri, _ = ConvertJSONToMap(params)["uri"].(string)
+		}
+		return map[string]any{"contents": []any{}}, nil
+	})
+	defer cleanup()
+
+	mcpClient, err := CreateClientFunc([]string{"server"})
+	if err != nil {
+		t.Fatalf("CreateClientFunc() error = %v", err)
+	}
+	session := newShellSession(&cobra.Command{}, nil)
+	session.addServer("", "server", mcpClient)
+	if _, err := session.call([]string{"resource:test://users/{id}{?tab}", `{"id":`, `"a b",`, `"tab":`, `2}`}, false); err != nil {
+		t.Fatalf("call() error = %v", err)
+	}
+	assertEquals(t, uri, "test://users/a%20b?tab=2")
+}
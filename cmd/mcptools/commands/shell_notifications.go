@@ -0,0 +1,158 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/term"
+)
+
+// logLevels are the MCP log levels, from the least to the most severe.
+var logLevels = []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// shellNotifier writes the notifications that the server sends on its own while
+// the shell runs. At an interactive prompt, a notification is written above the
+// prompt, which is then written again.
+type shellNotifier struct {
+	out         io.Writer
+	prompt      string
+	mu          sync.Mutex
+	interactive bool
+	useColors   bool
+	servers     bool
+}
+
+// newShellNotifier creates a notifier that writes to out.
+func newShellNotifier(out io.Writer) *shellNotifier {
+	return &shellNotifier{
+		out:         out,
+		interactive: term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd())),
+		useColors:   term.IsTerminal(int(os.Stderr.Fd())),
+	}
+}
+
+// handler returns the notification handler of a server. The notifications are
+// prefixed with the name of the server when the session has several.
+func (n *shellNotifier) handler(server string) func(notification mcp.JSONRPCNotification) {
+	return func(notification mcp.JSONRPCNotification) {
+		n.handle(server, notification)
+	}
+}
+
+// showServers sets whether notifications are prefixed with the name of their server.
+func (n *shellNotifier) showServers(show bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.servers = show
+}
+
+// handle writes a notification of a server.
+func (n *shellNotifier) handle(server string, notification mcp.JSONRPCNotification) {
+	message := formatNotification(notification, n.useColors)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.servers && server != "" {
+		message = colorize(server+": ", jsonutils.ColorBold, n.useColors) + message
+	}
+	if n.prompt != "" {
+		// Clear the prompt line, write the message and write the prompt again. The
+		// typed text is drawn again on the next key press.
+		fmt.Fprintf(n.out, "\r\033[K%s\n%s", message, n.prompt)
+		return
+	}
+	fmt.Fprintln(n.out, message)
+}
+
+// prompting records the prompt that is shown while reading input, or an empty
+// prompt when no input is read.
+func (n *shellNotifier) prompting(prompt string) {
+	if n == nil || !n.interactive {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.prompt = prompt
+}
+
+// formatNotification formats a notification as a single line: log messages with
+// their level, resource updates, list changes, progress, and any other notification
+// with its params as JSON.
+func formatNotification(notification mcp.JSONRPCNotification, useColors bool) string {
+	params := notification.Params.AdditionalFields
+
+	switch notification.Method {
+	case "notifications/message":
+		level, _ := params["level"].(string)
+		message := notificationText(params["data"])
+		if logger, ok := params["logger"].(string); ok && logger != "" {
+			message = logger + ": " + message
+		}
+		return colorize("["+level+"] ", logLevelColor(level), useColors) + message
+	case mcp.MethodNotificationResourceUpdated:
+		uri, _ := params["uri"].(string)
+		return colorize("[resource updated] ", jsonutils.ColorCyan, useColors) + uri
+	case mcp.MethodNotificationToolsListChanged, mcp.MethodNotificationPromptsListChanged, mcp.MethodNotificationResourcesListChanged:
+		list := strings.TrimSuffix(strings.TrimPrefix(notification.Method, "notifications/"), "/list_changed")
+		return colorize("["+list+" list changed]", jsonutils.ColorCyan, useColors)
+	case "notifications/progress":
+		progress, _ := params["progress"].(float64)
+		text := fmt.Sprintf("%g", progress)
+		if total, ok := params["total"].(float64); ok && total > 0 {
+			text = fmt.Sprintf("%g/%g (%.0f%%)", progress, total, progress/total*100)
+		}
+		if message, ok := params["message"].(string); ok && message != "" {
+			text += " " + message
+		}
+		return colorize("[progress] ", jsonutils.ColorBlue, useColors) + text
+	default:
+		text := ""
+		if len(params) > 0 {
+			text = " " + notificationText(params)
+		}
+		return colorize("["+strings.TrimPrefix(notification.Method, "notifications/")+"]", jsonutils.ColorGray, useColors) + text
+	}
+}
+
+// notificationText returns strings as is and other values as compact JSON.
+func notificationText(value any) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// logLevelColor returns the color of a log level: gray for debug, cyan for info and
+// notice, yellow for warnings and red for errors and worse.
+func logLevelColor(level string) string {
+	switch level {
+	case "debug":
+		return jsonutils.ColorGray
+	case "info", "notice":
+		return jsonutils.ColorCyan
+	case "warning":
+		return jsonutils.ColorYellow
+	case "error":
+		return jsonutils.ColorRed
+	default:
+		return jsonutils.ColorBold + jsonutils.ColorRed
+	}
+}
+
+// colorize wraps text in an ANSI color when colors are enabled.
+func colorize(text, color string, useColors bool) string {
+	if !useColors {
+		return text
+	}
+	return color + text + jsonutils.ColorReset
+}
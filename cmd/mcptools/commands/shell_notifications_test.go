@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func newTestNotification(method string, params map[string]any) mcp.JSONRPCNotification {
+	notification := mcp.JSONRPCNotification{JSONRPC: mcp.JSONRPC_VERSION}
+	notification.Method = method
+	notification.Params.AdditionalFields = params
+	return notification
+}
+
+func TestFormatNotification(t *testing.T) {
+	tests := []struct {
+		name         string
+		notification mcp.JSONRPCNotification
+		want         string
+	}{
+		{
+			name:         "log message",
+			notification: newTestNotification("notifications/message", map[string]any{"level": "warning", "logger": "db", "data": "slow query"}),
+			want:         "[warning] db: slow query",
+		},
+		{
+			name:         "structured log message",
+			notification: newTestNotification("notifications/message", map[string]any{"level": "error", "data": map[string]any{"code": 1}}),
+			want:         `[error] {"code":1}`,
+		},
+		{
+			name:         "resource updated",
+			notification: newTestNotification(mcp.MethodNotificationResourceUpdated, map[string]any{"uri": "file:///a.txt"}),
+			want:         "[resource updated] file:///a.txt",
+		},
+		{
+			name:         "list changed",
+			notification: newTestNotification(mcp.MethodNotificationToolsListChanged, nil),
+			want:         "[tools list changed]",
+		},
+		{
+			name:         "progress",
+			notification: newTestNotification("notifications/progress", map[string]any{"progressToken": 1, "progress": float64(3), "total": float64(4), "message": "indexing"}),
+			want:         "[progress] 3/4 (75%) indexing",
+		},
+		{
+			name:         "progress without total",
+			notification: newTestNotification("notifications/progress", map[string]any{"progressToken": 1, "progress": float64(7)}),
+			want:         "[progress] 7",
+		},
+		{
+			name:         "other notification",
+			notification: newTestNotification("notifications/custom", map[string]any{"a": "b"}),
+			want:         `[custom] {"a":"b"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertEquals(t, formatNotification(tt.notification, false), tt.want)
+		})
+	}
+
+	colored := formatNotification(newTestNotification("notifications/message", map[string]any{"level": "error", "data": "failed"}), true)
+	assertEquals(t, colored, "\033[31m[error] \033[0mfailed")
+}
+
+func TestShellNotifierAtPrompt(t *testing.T) {
+	buf := new(bytes.Buffer)
+	notifier := &shellNotifier{out: buf, interactive: true}
+	notification := newTestNotification(mcp.MethodNotificationResourceUpdated, map[string]any{"uri": "test://a"})
+
+	notifier.handle("fs", notification)
+	notifier.prompting("mcp > ")
+	notifier.handle("fs", notification)
+	notifier.prompting("")
+	notifier.showServers(true)
+	notifier.handler("fs")(notification)
+
+	assertEquals(t, buf.String(), "[resource updated] test://a\n\r\033[K[resource updated] test://a\nmcp > fs: [resource updated] test://a\n")
+}
+
+func TestShellLogLevelAndSubscriptions(t *testing.T) {
+	var requests []string
+	cleanupClient := setupMockClient(func(method string, params any) (map[string]any, error) {
+		if method != "tools/list" {
+			requests = append(requests, method+" "+string(mustJSON(params)))
+		}
+		return map[string]any{}, nil
+	})
+	defer cleanupClient()
+
+	script := `loglevel WARNING
+loglevel
+loglevel verbose
+`
+	cmd := ShellCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetIn(strings.NewReader(script))
+	cmd.SetArgs([]string{"--script", "-", "server"})
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), `-:3: invalid log level "verbose"`) {
+		t.Errorf("Expected invalid log level error, got %v", err)
+	}
+	assertContains(t, buf.String(), "Log level set to: warning")
+	assertContains(t, buf.String(), "Current log level: warning")
+
+	script = `set uri = "test://a"
+subscribe $uri
+subscribe test://b
+unsubscribe test://b
+subscribe
+`
+	cmd = ShellCmd()
+	buf = new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetIn(strings.NewReader(script))
+	cmd.SetArgs([]string{"--script", "-", "server"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+
+	expected := []string{
+		`logging/setLevel {"level":"warning"}`,
+		`resources/subscribe {"uri":"test://a"}`,
+		`resources/subscribe {"uri":"test://b"}`,
+		`resources/unsubscribe {"uri":"test://b"}`,
+	}
+	assertEquals(t, strings.Join(requests, "\n"), strings.Join(expected, "\n"))
+	assertContains(t, buf.String(), "Unsubscribed from: test://b\ntest://a\n")
+}
@@ -0,0 +1,192 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/f/mcptools/pkg/alias"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/peterh/liner"
+)
+
+// shellServer is a server the shell is connected to.
+type shellServer struct {
+	subscriptions map[string]bool
+	client        *client.Client
+	catalog       *shellCatalog
+	name          string
+	command       string
+	logLevel      string
+}
+
+// shellServerNames returns the aliases to connect to when the shell is started with
+// several arguments that are all aliases, or nil for a single server command.
+func shellServerNames(args []string) []string {
+	if len(args) < 2 {
+		return nil
+	}
+	for _, arg := range args {
+		if _, found := alias.Get(arg); !found {
+			return nil
+		}
+	}
+	return args
+}
+
+// connect connects to a server and adds it to the session. The name addresses the
+// server in calls, and is empty for a single server that is not an alias.
+func (s *shellSession) connect(name string, args []string) (*shellServer, error) {
+	mcpClient, err := CreateClientFunc(args)
+	if err != nil {
+		if name != "" {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return nil, err
+	}
+	command := strings.Join(args, " ")
+	if server, found := alias.Get(name); found && len(args) == 1 {
+		command = QuoteCommandString(server.Args)
+	}
+	return s.addServer(name, command, mcpClient), nil
+}
+
+// addServer adds a connected server to the session. The first server added is the
+// current one.
+func (s *shellSession) addServer(name, command string, mcpClient *client.Client) *shellServer {
+	server := &shellServer{
+		subscriptions: map[string]bool{},
+		client:        mcpClient,
+		catalog:       newShellCatalog(mcpClient),
+		name:          name,
+		command:       command,
+	}
+	s.servers[name] = server
+	s.names = append(s.names, name)
+	if s.current == nil {
+		s.current = server
+	}
+
+	if s.notifier != nil {
+		mcpClient.OnNotification(s.notifier.handler(name))
+		s.notifier.showServers(len(s.servers) > 1)
+	}
+	return server
+}
+
+// close closes the connections to the servers of the session.
+func (s *shellSession) close() {
+	for _, server := range s.servers {
+		_ = closeClient(server.client)
+	}
+}
+
+// use switches to another server, connecting to it first when it is an alias the
+// session is not connected to yet, or lists the servers when no name is given.
+func (s *shellSession) use(args []string) error {
+	if len(args) == 0 {
+		for _, name := range s.names {
+			marker := " "
+			if s.servers[name] == s.current {
+				marker = "*"
+			}
+			label := name
+			if label == "" {
+				label = "(default)"
+			}
+			fmt.Fprintf(s.cmd.OutOrStdout(), "%s %s  %s\n", marker, label, s.servers[name].command)
+		}
+		return nil
+	}
+
+	name := args[0]
+	server, ok := s.servers[name]
+	if !ok {
+		if _, found := alias.Get(name); !found {
+			return fmt.Errorf("unknown server %q, use the name of an alias", name)
+		}
+		var err error
+		if server, err = s.connect(name, []string{name}); err != nil {
+			return err
+		}
+		fmt.Fprintf(s.cmd.OutOrStdout(), "Connected to server: %s\n", name)
+	}
+
+	s.current = server
+	if s.history != nil {
+		s.history.switchTo(historyFile(name))
+	}
+	fmt.Fprintf(s.cmd.OutOrStdout(), "Using server: %s\n", name)
+	return nil
+}
+
+// resolveServer returns the server an entity is addressed to, such as fs in
+// fs.read_file or gh:search, and the entity without the server. Entities without a
+// server, and the tool:, resource: and prompt: types, are on the current server.
+func (s *shellSession) resolveServer(entity string) (*shellServer, string) {
+	end := strings.IndexAny(entity, ".:")
+	if end > 0 {
+		prefix := entity[:end]
+		if prefix != EntityTypeTool && prefix != EntityTypeRes && prefix != EntityTypePrompt {
+			if server, ok := s.servers[prefix]; ok {
+				return server, entity[end+1:]
+			}
+		}
+	}
+	return s.current, entity
+}
+
+// prompt returns the prompt of the shell, which shows the current server when the
+// session has several.
+func (s *shellSession) prompt() string {
+	if len(s.servers) > 1 && s.current.name != "" {
+		return "mcp " + s.current.name + " > "
+	}
+	return "mcp > "
+}
+
+// shellHistory keeps the input history of the current server in a file.
+type shellHistory struct {
+	line *liner.State
+	file string
+}
+
+// historyFile returns the history file of a server: one file per alias in
+// ~/.mcpt/history, and ~/.mcp_history for a server that is not an alias.
+func historyFile(name string) string {
+	if name == "" {
+		return filepath.Join(getHomeDirectory(), ".mcp_history")
+	}
+	return filepath.Join(getHomeDirectory(), ".mcpt", "history", sanitizeFileName(name))
+}
+
+// load reads the history of a file.
+func (h *shellHistory) load(file string) {
+	h.file = file
+	if f, err := os.Open(filepath.Clean(file)); err == nil {
+		_, _ = h.line.ReadHistory(f)
+		_ = f.Close()
+	}
+}
+
+// save writes the history to its file.
+func (h *shellHistory) save() {
+	if err := os.MkdirAll(filepath.Dir(h.file), 0o750); err != nil {
+		return
+	}
+	if f, err := os.Create(h.file); err == nil {
+		_, _ = h.line.WriteHistory(f)
+		_ = f.Close()
+	}
+}
+
+// switchTo saves the history and loads the history of another file.
+func (h *shellHistory) switchTo(file string) {
+	if file == h.file {
+		return
+	}
+	h.save()
+	h.line.ClearHistory()
+	h.load(file)
+}
@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/f/mcptools/pkg/alias"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/spf13/cobra"
+)
+
+// newMockServerClient returns a client of a mock server that lists one tool and
+// records the tools that are called.
+func newMockServerClient(t *testing.T, tool string, called *[]string) *client.Client {
+	t.Helper()
+	cleanup := setupMockClient(func(method string, params any) (map[string]any, error) {
+		switch method {
+		case "tools/list":
+			return map[string]any{"tools": []any{map[string]any{"name": tool, "inputSchema": map[string]any{"type": "object"}}}}, nil
+		case "tools/call":
+			name, _ := ConvertJSONToMap(params)["name"].(string)
+			*called = append(*called, name)
+			return map[string]any{"content": []any{}}, nil
+		}
+		return map[string]any{}, nil
+	})
+	defer cleanup()
+
+	mcpClient, err := CreateClientFunc(nil)
+	if err != nil {
+		t.Fatalf("CreateClientFunc() error = %v", err)
+	}
+	return mcpClient
+}
+
+func TestShellServers(t *testing.T) {
+	var fsCalls, ghCalls []string
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	session := newShellSession(cmd, nil)
+	session.addServer("fs", "npx fs-server", newMockServerClient(t, "read_file", &fsCalls))
+	session.addServer("gh", "npx gh-server", newMockServerClient(t, "search", &ghCalls))
+	assertEquals(t, session.prompt(), "mcp fs > ")
+
+	for _, entity := range []string{"read_file", "fs.read_file", "gh:search", "gh.search", "tool:read_file"} {
+		if _, err := session.call([]string{entity, "{}"}, false); err != nil {
+			t.Fatalf("call(%q) error = %v", entity, err)
+		}
+	}
+	if !reflect.DeepEqual(fsCalls, []string{"read_file", "read_file", "read_file"}) {
+		t.Errorf("fs calls = %v", fsCalls)
+	}
+	if !reflect.DeepEqual(ghCalls, []string{"search", "search"}) {
+		t.Errorf("gh calls = %v", ghCalls)
+	}
+
+	resp, err := session.list("tools", []string{"--all"}, false)
+	if err != nil {
+		t.Fatalf("list() error = %v", err)
+	}
+	var names []string
+	for _, tool := range resp["tools"].([]any) {
+		names = append(names, tool.(map[string]any)["name"].(string))
+	}
+	if !reflect.DeepEqual(names, []string{"fs.read_file", "gh.search"}) {
+		t.Errorf("tools --all names = %v", names)
+	}
+
+	if err := session.use([]string{"gh"}); err != nil {
+		t.Fatalf("use() error = %v", err)
+	}
+	assertEquals(t, session.prompt(), "mcp gh > ")
+	if _, err := session.call([]string{"search", "{}"}, false); err != nil {
+		t.Fatalf("call() error = %v", err)
+	}
+	if len(ghCalls) != 3 {
+		t.Errorf("gh calls after use = %v", ghCalls)
+	}
+
+	out.Reset()
+	if err := session.use(nil); err != nil {
+		t.Fatalf("use() error = %v", err)
+	}
+	assertContains(t, out.String(), "  fs  npx fs-server")
+	assertContains(t, out.String(), "* gh  npx gh-server")
+
+	t.Setenv("HOME", t.TempDir())
+	if err := session.use([]string{"unknown"}); err == nil {
+		t.Error("use() of an unknown server should fail")
+	}
+}
+
+func TestShellServerNames(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := alias.Save(alias.Aliases{
+		"fs": alias.ServerAlias{Command: "npx fs-server"},
+		"gh": alias.ServerAlias{Command: "npx gh-server"},
+	}); err != nil {
+		t.Fatalf("alias.Save() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		names []string
+	}{
+		{name: "aliases", args: []string{"fs", "gh"}, names: []string{"fs", "gh"}},
+		{name: "single alias", args: []string{"fs"}, names: nil},
+		{name: "command", args: []string{"npx", "fs-server"}, names: nil},
+		{name: "alias and argument", args: []string{"fs", "--verbose"}, names: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if names := shellServerNames(tt.args); !reflect.DeepEqual(names, tt.names) {
+				t.Errorf("shellServerNames(%v) = %v, want %v", tt.args, names, tt.names)
+			}
+		})
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	assertEquals(t, historyFile(""), filepath.Join(home, ".mcp_history"))
+	assertEquals(t, historyFile("fs"), filepath.Join(home, ".mcpt", "history", "fs"))
+}
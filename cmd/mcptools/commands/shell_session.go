@@ -0,0 +1,649 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+// errShellExit is returned by shell commands that end the session.
+var errShellExit = errors.New("exit")
+
+// reportedError is an error that was already written to the output, such as a
+// failed call in the ndjson format, so the shell does not print it again.
+type reportedError struct {
+	error
+}
+
+// shellSession holds the state of a shell: the servers it is connected to, the
+// variables set with set, and the result of the last command, which is the $_
+// variable.
+type shellSession struct {
+	vars          map[string]any
+	servers       map[string]*shellServer
+	cmd           *cobra.Command
+	current       *shellServer
+	notifier      *shellNotifier
+	history       *shellHistory
+	last          any
+	query         string
+	template      string
+	names         []string
+	progressToken int
+}
+
+// newShellSession creates a shell session without servers. Notifications of the
+// servers are written with notifier when it is set.
+func newShellSession(thisCmd *cobra.Command, notifier *shellNotifier) *shellSession {
+	return &shellSession{
+		vars:     map[string]any{},
+		servers:  map[string]*shellServer{},
+		cmd:      thisCmd,
+		notifier: notifier,
+		query:    QueryOption,
+		template: TemplateOption,
+	}
+}
+
+// execute runs a line of the shell. It returns errShellExit when the line ends
+// the session.
+func (s *shellSession) execute(input string) error {
+	// The query and template set with the query and template commands, which
+	// a trailing --query or --template overrides for a single command
+	QueryOption, TemplateOption = s.query, s.template
+
+	input = strings.TrimSpace(input)
+	switch input {
+	case "":
+		return nil
+	case "/q", "/quit", "exit":
+		return errShellExit
+	case "/h", "/help", "help":
+		printShellHelp(s.cmd)
+		return nil
+	}
+
+	if command, value, ok := cutOutputCommand(input); ok {
+		setOutputOption(s.cmd, command, value, &s.query, &s.template)
+		return nil
+	}
+
+	keyword, rest, _ := strings.Cut(input, " ")
+	switch keyword {
+	case "set":
+		return s.set(strings.TrimSpace(rest))
+	case "unset":
+		return s.unset(strings.TrimSpace(rest))
+	case "assert":
+		return s.assert(strings.TrimSpace(rest))
+	}
+
+	// Every command of a pipeline sees the result of the previous one as $_,
+	// and only the output of the last one is printed
+	segments := splitPipeline(applyOutputOption(input))
+	for i, segment := range segments {
+		resp, err := s.runCommand(segment, i == len(segments)-1)
+		if err != nil {
+			return err
+		}
+		if resp != nil {
+			s.last = resp
+		}
+	}
+	return nil
+}
+
+// runScript runs the lines of a script until the end, an exit command or the first
+// failed command. Empty lines and lines starting with # are skipped.
+func (s *shellSession) runScript(name string, reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Like the interactive shell, a command goes on while a quote or JSON value is open
+		start := lineNumber
+		for inputIncomplete(line) && scanner.Scan() {
+			lineNumber++
+			line += "\n" + scanner.Text()
+		}
+
+		err := s.execute(line)
+		if errors.Is(err, errShellExit) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", name, start, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading script: %w", err)
+	}
+	return nil
+}
+
+// runCommand runs a single command and returns its result. The result is printed
+// when print is set.
+func (s *shellSession) runCommand(input string, print bool) (map[string]any, error) {
+	parts, err := splitWords(input, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+
+	command := parts[0]
+	commandArgs := parts[1:]
+
+	switch command {
+	case "tools", "resources", "prompts":
+		return s.list(command, commandArgs, print)
+	case "format":
+		if len(commandArgs) < 1 {
+			fmt.Fprintf(s.cmd.OutOrStdout(), "Current format: %s\n", FormatOption)
+			return nil, nil
+		}
+
+		newFormat := commandArgs[0]
+		if IsValidFormat(newFormat) {
+			FormatOption = newFormat
+			fmt.Fprintf(s.cmd.OutOrStdout(), "Format set to: %s\n", FormatOption)
+		} else {
+			fmt.Fprintln(s.cmd.OutOrStdout(), "Invalid format. Use: table, json, pretty, yaml, markdown, or ndjson")
+		}
+		return nil, nil
+	case "use":
+		return nil, s.use(commandArgs)
+	case "loglevel":
+		return nil, s.setLogLevel(commandArgs)
+	case "subscribe", "unsubscribe":
+		return nil, s.subscribe(command, commandArgs)
+	case "call":
+		if len(commandArgs) < 1 {
+			fmt.Fprintln(s.cmd.OutOrStdout(), "Usage: call <entity> [--params '{...}']")
+			return nil, nil
+		}
+		return s.call(commandArgs, print)
+	default:
+		return s.call(parts, print)
+	}
+}
+
+// list lists the tools, resources or prompts of the current server, or of every
+// server with --all. With --all, the names are prefixed with the server. Resources
+// are listed with the resource templates.
+func (s *shellSession) list(command string, args []string, print bool) (map[string]any, error) {
+	servers := []*shellServer{s.current}
+	record := CallRecord{Server: s.current.command, Method: command + "/list"}
+	if len(args) > 0 && args[0] == "--all" {
+		servers = servers[:0]
+		for _, name := range s.names {
+			servers = append(servers, s.servers[name])
+		}
+		record.Server = strings.Join(s.names, ", ")
+	}
+	start := time.Now()
+
+	items, templates := []any{}, []any{}
+	var listErr error
+	for _, server := range servers {
+		serverItems, err := server.list(command)
+		if err != nil {
+			if len(servers) > 1 {
+				fmt.Fprintf(s.cmd.ErrOrStderr(), "Warning: %s: %v\n", server.name, err)
+				continue
+			}
+			listErr = err
+			break
+		}
+		var serverTemplates []any
+		if command == "resources" {
+			serverTemplates = listResourceTemplates(server.client)
+		}
+		if len(servers) > 1 && server.name != "" {
+			for _, item := range append(serverItems, serverTemplates...) {
+				if entry, ok := item.(map[string]any); ok {
+					entry["name"] = fmt.Sprintf("%s.%v", server.name, entry["name"])
+				}
+			}
+		}
+		items = append(items, serverItems...)
+		templates = append(templates, serverTemplates...)
+	}
+
+	resp := map[string]any{command: items}
+	if len(templates) > 0 {
+		resp["resourceTemplates"] = templates
+	}
+	if print {
+		if formatErr := PrintCallResult(s.cmd, record, start, resp, listErr); formatErr != nil {
+			return nil, formatErr
+		}
+		if listErr != nil {
+			return nil, reportedError{listErr}
+		}
+	}
+	if listErr != nil {
+		return nil, listErr
+	}
+	return resp, nil
+}
+
+// list lists the tools, resources or prompts of a server.
+func (server *shellServer) list(command string) ([]any, error) {
+	switch command {
+	case "tools":
+		tools, _, err := listTools(context.Background(), server.client, "", true)
+		return ConvertJSONToSlice(tools), err
+	case "resources":
+		resources, _, err := listResources(context.Background(), server.client, "", true)
+		return ConvertJSONToSlice(resources), err
+	default:
+		prompts, _, err := listPrompts(context.Background(), server.client, "", true)
+		return ConvertJSONToSlice(prompts), err
+	}
+}
+
+// setLogLevel sets the level of the log messages the server sends, or shows it
+// when no level is given.
+func (s *shellSession) setLogLevel(args []string) error {
+	if len(args) == 0 {
+		if s.current.logLevel == "" {
+			fmt.Fprintln(s.cmd.OutOrStdout(), "Log level not set, the server decides which messages to send")
+		} else {
+			fmt.Fprintf(s.cmd.OutOrStdout(), "Current log level: %s\n", s.current.logLevel)
+		}
+		return nil
+	}
+
+	level := strings.ToLower(args[0])
+	valid := false
+	for _, l := range logLevels {
+		valid = valid || l == level
+	}
+	if !valid {
+		return fmt.Errorf("invalid log level %q, use one of: %s", args[0], strings.Join(logLevels, ", "))
+	}
+
+	request := mcp.SetLevelRequest{}
+	request.Params.Level = mcp.LoggingLevel(level)
+	if err := s.current.client.SetLevel(context.Background(), request); err != nil {
+		return fmt.Errorf("error setting log level: %w", err)
+	}
+	s.current.logLevel = level
+	fmt.Fprintf(s.cmd.OutOrStdout(), "Log level set to: %s\n", level)
+	return nil
+}
+
+// subscribe subscribes to or unsubscribes from the updates of a resource, or lists
+// the subscriptions when no URI is given.
+func (s *shellSession) subscribe(command string, args []string) error {
+	if len(args) == 0 {
+		if command == "unsubscribe" {
+			return fmt.Errorf("usage: unsubscribe <uri>")
+		}
+		if len(s.current.subscriptions) == 0 {
+			fmt.Fprintln(s.cmd.OutOrStdout(), "No subscriptions")
+			return nil
+		}
+		uris := make([]string, 0, len(s.current.subscriptions))
+		for uri := range s.current.subscriptions {
+			uris = append(uris, uri)
+		}
+		sort.Strings(uris)
+		for _, uri := range uris {
+			fmt.Fprintln(s.cmd.OutOrStdout(), uri)
+		}
+		return nil
+	}
+
+	uri, err := s.expandReferences(args[0], true)
+	if err != nil {
+		return err
+	}
+
+	if command == "subscribe" {
+		request := mcp.SubscribeRequest{}
+		request.Params.URI = uri
+		if err := s.current.client.Subscribe(context.Background(), request); err != nil {
+			return fmt.Errorf("error subscribing to %s: %w", uri, err)
+		}
+		s.current.subscriptions[uri] = true
+		fmt.Fprintf(s.cmd.OutOrStdout(), "Subscribed to: %s\n", uri)
+		return nil
+	}
+
+	request := mcp.UnsubscribeRequest{}
+	request.Params.URI = uri
+	if err := s.current.client.Unsubscribe(context.Background(), request); err != nil {
+		return fmt.Errorf("error unsubscribing from %s: %w", uri, err)
+	}
+	delete(s.current.subscriptions, uri)
+	fmt.Fprintf(s.cmd.OutOrStdout(), "Unsubscribed from: %s\n", uri)
+	return nil
+}
+
+// set sets a variable, or lists the variables when there is no assignment. The
+// value is JSON, or a query on the result of the last command.
+func (s *shellSession) set(assignment string) error {
+	if assignment == "" {
+		s.printVariables()
+		return nil
+	}
+
+	name, expr, ok := strings.Cut(assignment, "=")
+	name = strings.TrimSpace(name)
+	expr = strings.TrimSpace(expr)
+	if !ok || expr == "" {
+		return fmt.Errorf("usage: set <name> = <value>")
+	}
+	if !isVariableName(name) || name == "_" {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+
+	value, err := s.evaluate(expr)
+	if err != nil {
+		return err
+	}
+	s.vars[name] = value
+	return nil
+}
+
+// evaluate returns the value of a set expression: JSON, or a query on the result
+// of the last command that yields a single value.
+func (s *shellSession) evaluate(expr string) (any, error) {
+	expanded, err := s.expandReferences(expr, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	if json.Unmarshal([]byte(expanded), &value) == nil {
+		return value, nil
+	}
+
+	results, err := jsonutils.Query(s.last, expanded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %s: %w", expr, err)
+	}
+	if len(results) != 1 {
+		return nil, fmt.Errorf("value %s yields %d results, expected 1", expr, len(results))
+	}
+	return results[0], nil
+}
+
+// unset removes a variable.
+func (s *shellSession) unset(name string) error {
+	if _, ok := s.vars[name]; !ok {
+		return fmt.Errorf("undefined variable $%s", name)
+	}
+	delete(s.vars, name)
+	return nil
+}
+
+// printVariables prints the variables and their values as JSON.
+func (s *shellSession) printVariables() {
+	if len(s.vars) == 0 {
+		fmt.Fprintln(s.cmd.OutOrStdout(), "No variables set")
+		return
+	}
+
+	names := make([]string, 0, len(s.vars))
+	for name := range s.vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(s.cmd.OutOrStdout(), "%s = %s\n", name, mustJSON(s.vars[name]))
+	}
+}
+
+// assert evaluates a query on the result of the last command and fails unless
+// every value it yields is true, that is anything but false and null.
+func (s *shellSession) assert(expr string) error {
+	if expr == "" {
+		return fmt.Errorf("usage: assert <expression>")
+	}
+
+	expanded, err := s.expandReferences(expr, false)
+	if err != nil {
+		return err
+	}
+	results, err := jsonutils.Query(s.last, expanded)
+	if err != nil {
+		return fmt.Errorf("invalid assertion %s: %w", expr, err)
+	}
+
+	passed := len(results) > 0
+	for _, result := range results {
+		if result == nil || result == false {
+			passed = false
+		}
+	}
+	if !passed {
+		return fmt.Errorf("assertion failed: %s", expr)
+	}
+	return nil
+}
+
+// expandReferences replaces the $name and ${name} references of a line with the
+// values of variables, where $_ is the result of the last command. A reference can
+// be followed by a path into the value, such as $_.content[0].text. Outside double
+// quotes a value is written as JSON, or as is for strings when raw is set. Inside
+// double quotes only ${...} references are expanded, escaped for a JSON string.
+func (s *shellSession) expandReferences(text string, raw bool) (string, error) {
+	var buf strings.Builder
+	inString := false
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case inString && c == '\\' && i+1 < len(text):
+			buf.WriteString(text[i : i+2])
+			i++
+			continue
+		case c == '"':
+			inString = !inString
+		case c == '$':
+			ref, end := scanReference(text, i, inString)
+			if ref == "" {
+				break
+			}
+
+			value, err := s.resolve(ref)
+			if err != nil {
+				return "", err
+			}
+			str, isString := value.(string)
+			switch {
+			case inString && !isString:
+				str = string(mustJSON(value))
+				fallthrough
+			case inString:
+				encoded := mustJSON(str)
+				buf.Write(encoded[1 : len(encoded)-1])
+			case raw && isString:
+				buf.WriteString(str)
+			default:
+				buf.Write(mustJSON(value))
+			}
+			i = end - 1
+			continue
+		}
+		buf.WriteByte(c)
+	}
+
+	return buf.String(), nil
+}
+
+// resolve returns the value of a reference without its $: a variable name followed
+// by an optional path.
+func (s *shellSession) resolve(ref string) (any, error) {
+	end := 0
+	for end < len(ref) && isVariableChar(ref[end]) {
+		end++
+	}
+	name, path := ref[:end], strings.TrimSpace(ref[end:])
+
+	value, ok := s.vars[name]
+	if name == "_" {
+		value, ok = s.last, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("undefined variable $%s", name)
+	}
+	if path == "" {
+		return value, nil
+	}
+
+	if !strings.HasPrefix(path, ".") {
+		path = "." + path
+	}
+	results, err := jsonutils.Query(value, path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference $%s: %w", ref, err)
+	}
+	if len(results) != 1 {
+		return nil, fmt.Errorf("reference $%s yields %d values, expected 1", ref, len(results))
+	}
+	return results[0], nil
+}
+
+// scanReference returns the reference that starts with the $ at index start, without
+// the $ and braces, and the index after it. It returns an empty reference when there
+// is none, and in strings when it is not enclosed in braces.
+func scanReference(text string, start int, inString bool) (string, int) {
+	i := start + 1
+	if i < len(text) && text[i] == '{' {
+		end := strings.IndexByte(text[i:], '}')
+		if end < 0 {
+			return "", 0
+		}
+		return strings.TrimSpace(text[i+1 : i+end]), i + end + 1
+	}
+	if inString {
+		return "", 0
+	}
+
+	for i < len(text) && isVariableChar(text[i]) {
+		i++
+	}
+	if i == start+1 {
+		return "", 0
+	}
+
+	// The path: .field, ."field" and [index] parts
+	for i < len(text) {
+		switch {
+		case text[i] == '.' && i+1 < len(text) && isVariableChar(text[i+1]):
+			i++
+			for i < len(text) && isVariableChar(text[i]) {
+				i++
+			}
+		case text[i] == '.' && i+1 < len(text) && text[i+1] == '"':
+			end := strings.IndexByte(text[i+2:], '"')
+			if end < 0 {
+				return text[start+1 : i], i
+			}
+			i += end + 3
+		case text[i] == '[':
+			end := strings.IndexByte(text[i:], ']')
+			if end < 0 {
+				return text[start+1 : i], i
+			}
+			i += end + 1
+		default:
+			return text[start+1 : i], i
+		}
+	}
+	return text[start+1 : i], i
+}
+
+// isVariableName reports whether a name is a valid variable name.
+func isVariableName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isVariableChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isVariableChar reports whether a character can be part of a variable name.
+func isVariableChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// splitPipeline splits a line into the commands of a pipeline at the | characters
+// that are outside of quotes, objects and arrays.
+func splitPipeline(input string) []string {
+	var segments []string
+	start := 0
+	for _, index := range pipeIndexes(input) {
+		segments = append(segments, strings.TrimSpace(input[start:index]))
+		start = index + 1
+	}
+	return append(segments, strings.TrimSpace(input[start:]))
+}
+
+// pipeIndexes returns the indexes of the | characters of a line that are outside
+// of quotes, objects and arrays.
+func pipeIndexes(input string) []int {
+	var indexes []int
+	var quote byte
+	depth := 0
+
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{' || c == '[' || c == '(':
+			depth++
+		case c == '}' || c == ']' || c == ')':
+			depth--
+		case c == '|' && depth == 0:
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// openScript opens a shell script, or stdin when the name is "-".
+func openScript(name string, stdin io.Reader) (io.Reader, func(), error) {
+	if name == "-" {
+		return stdin, func() {}, nil
+	}
+
+	f, err := os.Open(name) // #nosec G304 - the script is given by the user
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening script: %w", err)
+	}
+	return f, func() { _ = f.Close() }, nil
+}
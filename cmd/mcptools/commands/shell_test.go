@@ -104,6 +104,9 @@ func TestShellBasicCommands(t *testing.T) {
 						},
 					},
 				},
+				"resources/templates/list": {
+					"resourceTemplates": []any{},
+				},
 			},
 		},
 		{
@@ -176,6 +179,8 @@ func TestShellCallCommand(t *testing.T) {
 	// 8. call a tool with `call <tool_name> --params <params>`
 	// 9. call a tool with `<tool_name> --params '<params>'`
 	// 10. call a tool with `call <tool_name> --params '<params>'`
+	// Every tool call of the shell asks for progress notifications
+	progressMeta := map[string]any{"progressToken": float64(1)}
 	tests := []struct {
 		name            string
 		mockResponses   map[string]map[string]any
@@ -187,7 +192,7 @@ func TestShellCallCommand(t *testing.T) {
 			name:            "tool_name without params",
 			input:           "test-tool\n/q\n",
 			expectedOutputs: []string{"Tool executed successfully"},
-			expectedParams:  map[string]any{"name": "test-tool", "arguments": map[string]any{}},
+			expectedParams:  map[string]any{"name": "test-tool", "_meta": progressMeta, "arguments": map[string]any{}},
 			mockResponses: map[string]map[string]any{
 				"tools/call": {
 					"content": []any{map[string]any{"type": "text", "text": "Tool executed successfully"}},
@@ -198,7 +203,7 @@ func TestShellCallCommand(t *testing.T) {
 			name:            "call tool without params",
 			input:           "call test-tool\n/q\n",
 			expectedOutputs: []string{"Tool executed successfully"},
-			expectedParams:  map[string]any{"name": "test-tool", "arguments": map[string]any{}},
+			expectedParams:  map[string]any{"name": "test-tool", "_meta": progressMeta, "arguments": map[string]any{}},
 			mockResponses: map[string]map[string]any{
 				"tools/call": {
 					"content": []any{map[string]any{"type": "text", "text": "Tool executed successfully"}},
@@ -209,7 +214,7 @@ func TestShellCallCommand(t *testing.T) {
 			name:            "tool_name with direct params",
 			input:           "test-tool {\"foo\": \"bar\"}\n/q\n",
 			expectedOutputs: []string{"Tool executed successfully"},
-			expectedParams:  map[string]any{"name": "test-tool", "arguments": map[string]any{"foo": "bar"}},
+			expectedParams:  map[string]any{"name": "test-tool", "_meta": progressMeta, "arguments": map[string]any{"foo": "bar"}},
 			mockResponses: map[string]map[string]any{
 				"tools/call": {
 					"content": []any{map[string]any{"type": "text", "text": "Tool executed successfully"}},
@@ -220,7 +225,7 @@ func TestShellCallCommand(t *testing.T) {
 			name:            "call tool with direct params",
 			input:           "call test-tool {\"foo\": \"bar\"}\n/q\n",
 			expectedOutputs: []string{"Tool executed successfully"},
-			expectedParams:  map[string]any{"name": "test-tool", "arguments": map[string]any{"foo": "bar"}},
+			expectedParams:  map[string]any{"name": "test-tool", "_meta": progressMeta, "arguments": map[string]any{"foo": "bar"}},
 			mockResponses: map[string]map[string]any{
 				"tools/call": {
 					"content": []any{map[string]any{"type": "text", "text": "Tool executed successfully"}},
@@ -231,7 +236,7 @@ func TestShellCallCommand(t *testing.T) {
 			name:            "tool_name with quoted direct params",
 			input:           "test-tool '{\"foo\": \"bar\"}'\n/q\n",
 			expectedOutputs: []string{"Tool executed successfully"},
-			expectedParams:  map[string]any{"name": "test-tool", "arguments": map[string]any{"foo": "bar"}},
+			expectedParams:  map[string]any{"name": "test-tool", "_meta": progressMeta, "arguments": map[string]any{"foo": "bar"}},
 			mockResponses: map[string]map[string]any{
 				"tools/call": {
 					"content": []any{map[string]any{"type": "text", "text": "Tool executed successfully"}},
@@ -242,7 +247,7 @@ func TestShellCallCommand(t *testing.T) {
 			name:            "call tool with quoted direct params",
 			input:           "call test-tool '{\"foo\": \"bar\"}'\n/q\n",
 			expectedOutputs: []string{"Tool executed successfully"},
-			expectedParams:  map[string]any{"name": "test-tool", "arguments": map[string]any{"foo": "bar"}},
+			expectedParams:  map[string]any{"name": "test-tool", "_meta": progressMeta, "arguments": map[string]any{"foo": "bar"}},
 			mockResponses: map[string]map[string]any{
 				"tools/call": {
 					"content": []any{map[string]any{"type": "text", "text": "Tool executed successfully"}},
@@ -253,7 +258,7 @@ func TestShellCallCommand(t *testing.T) {
 			name:            "tool_name with params flag",
 			input:           "test-tool --params {\"foo\": \"bar\"}\n/q\n",
 			expectedOutputs: []string{"Tool executed successfully"},
-			expectedParams:  map[string]any{"name": "test-tool", "arguments": map[string]any{"foo": "bar"}},
+			expectedParams:  map[string]any{"name": "test-tool", "_meta": progressMeta, "arguments": map[string]any{"foo": "bar"}},
 			mockResponses: map[string]map[string]any{
 				"tools/call": {
 					"content": []any{map[string]any{"type": "text", "text": "Tool executed successfully"}},
@@ -264,7 +269,7 @@ func TestShellCallCommand(t *testing.T) {
 			name:            "call tool with params flag",
 			input:           "call test-tool --params {\"foo\": \"bar\"}\n/q\n",
 			expectedOutputs: []string{"Tool executed successfully"},
-			expectedParams:  map[string]any{"name": "test-tool", "arguments": map[string]any{"foo": "bar"}},
+			expectedParams:  map[string]any{"name": "test-tool", "_meta": progressMeta, "arguments": map[string]any{"foo": "bar"}},
 			mockResponses: map[string]map[string]any{
 				"tools/call": {
 					"content": []any{map[string]any{"type": "text", "text": "Tool executed successfully"}},
@@ -275,7 +280,7 @@ func TestShellCallCommand(t *testing.T) {
 			name:            "tool_name with quoted params flag",
 			input:           "test-tool --params '{\"foo\": \"bar\"}'\n/q\n",
 			expectedOutputs: []string{"Tool executed successfully"},
-			expectedParams:  map[string]any{"name": "test-tool", "arguments": map[string]any{"foo": "bar"}},
+			expectedParams:  map[string]any{"name": "test-tool", "_meta": progressMeta, "arguments": map[string]any{"foo": "bar"}},
 			mockResponses: map[string]map[string]any{
 				"tools/call": {
 					"content": []any{map[string]any{"type": "text", "text": "Tool executed successfully"}},
@@ -286,7 +291,40 @@ func TestShellCallCommand(t *testing.T) {
 			name:            "call tool with quoted params flag",
 			input:           "call test-tool --params '{\"foo\": \"bar\"}'\n/q\n",
 			expectedOutputs: []string{"Tool executed successfully"},
-			expectedParams:  map[string]any{"name": "test-tool", "arguments": map[string]any{"foo": "bar"}},
+			expectedParams:  map[string]any{"name": "test-tool", "_meta": progressMeta, "arguments": map[string]any{"foo": "bar"}},
+			mockResponses: map[string]map[string]any{
+				"tools/call": {
+					"content": []any{map[string]any{"type": "text", "text": "Tool executed successfully"}},
+				},
+			},
+		},
+		{
+			name:            "multi-line params",
+			input:           "test-tool {\n  \"foo\": \"bar  baz\",\n  \"n\": [1,\n 2]\n}\n/q\n",
+			expectedOutputs: []string{"Tool executed successfully"},
+			expectedParams:  map[string]any{"name": "test-tool", "_meta": progressMeta, "arguments": map[string]any{"foo": "bar  baz", "n": []any{float64(1), float64(2)}}},
+			mockResponses: map[string]map[string]any{
+				"tools/call": {
+					"content": []any{map[string]any{"type": "text", "text": "Tool executed successfully"}},
+				},
+			},
+		},
+		{
+			name:            "prompt with arguments",
+			input:           "call prompt:greet {\"name\": \"Ada\", \"count\": 2}\n/q\n",
+			expectedOutputs: []string{"Hello Ada"},
+			expectedParams:  map[string]any{"name": "greet", "arguments": map[string]any{"name": "Ada", "count": "2"}},
+			mockResponses: map[string]map[string]any{
+				"prompts/get": {
+					"messages": []any{map[string]any{"role": "user", "content": map[string]any{"type": "text", "text": "Hello Ada"}}},
+				},
+			},
+		},
+		{
+			name:            "line continuation",
+			input:           "call test-tool \\\n  {\"foo\": \"bar\"}\n/q\n",
+			expectedOutputs: []string{"Tool executed successfully"},
+			expectedParams:  map[string]any{"name": "test-tool", "_meta": progressMeta, "arguments": map[string]any{"foo": "bar"}},
 			mockResponses: map[string]map[string]any{
 				"tools/call": {
 					"content": []any{map[string]any{"type": "text", "text": "Tool executed successfully"}},
@@ -365,3 +403,131 @@ func TestShellExit(t *testing.T) {
 		})
 	}
 }
+
+func TestShellScript(t *testing.T) {
+	var called []map[string]any
+	cleanupClient := setupMockClient(func(method string, params any) (map[string]any, error) {
+		if method != "tools/call" {
+			return map[string]any{}, nil
+		}
+		jsonParams := ConvertJSONToMap(params)
+		called = append(called, jsonParams)
+		name, _ := jsonParams["name"].(string)
+		return map[string]any{
+			"content": []any{map[string]any{"type": "text", "text": "result of " + name}},
+		}, nil
+	})
+	defer cleanupClient()
+
+	script := `# set up
+set dir = "/tmp"
+list_dir {"path": $dir}
+set listing = $_.content[0].text
+read_file {"path": "${dir}/a.txt"} | write_file {"path": "b.txt", "content": $_.content[0].text}
+assert .content[0].text == "result of write_file"
+assert $listing == "result of list_dir"
+write_file {
+  "path": "c.txt",
+
+  "content": "# not a comment"
+}
+`
+	cmd := ShellCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetIn(strings.NewReader(script))
+	cmd.SetArgs([]string{"--script", "-", "server"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+
+	expectedArguments := []map[string]any{
+		{"path": "/tmp"},
+		{"path": "/tmp/a.txt"},
+		{"path": "b.txt", "content": "result of read_file"},
+		{"path": "c.txt", "content": "# not a comment"},
+	}
+	if len(called) != len(expectedArguments) {
+		t.Fatalf("Expected %d calls, got %v", len(expectedArguments), called)
+	}
+	for i, expected := range expectedArguments {
+		if !reflect.DeepEqual(called[i]["arguments"], expected) {
+			t.Errorf("Call %d: expected arguments %v, got %v", i, expected, called[i]["arguments"])
+		}
+	}
+
+	output := buf.String()
+	if strings.Contains(output, "result of read_file") {
+		t.Errorf("Expected only the last command of a pipeline to be printed, got: %s", output)
+	}
+	assertContains(t, output, "result of write_file")
+}
+
+func TestShellScriptFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		message string
+	}{
+		{name: "failed assertion", script: "test-tool\nassert .isError == true\n", message: "-:2: assertion failed: .isError == true"},
+		{name: "undefined variable", script: "\ntest-tool {\"a\": $missing}\n", message: "-:2: undefined variable $missing"},
+		{name: "invalid value", script: "set x = not json(\n", message: "-:1: invalid value"},
+		{name: "invalid variable name", script: "set _ = 1\n", message: `invalid variable name "_"`},
+		{name: "unterminated JSON value", script: "test-tool\ntest-tool {\"a\":\n  1\n", message: "-:2: unterminated JSON value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleanupClient := setupMockClient(func(_ string, _ any) (map[string]any, error) {
+				return map[string]any{"content": []any{}}, nil
+			})
+			defer cleanupClient()
+
+			cmd := ShellCmd()
+			cmd.SetOut(new(bytes.Buffer))
+			cmd.SetErr(new(bytes.Buffer))
+			cmd.SetIn(strings.NewReader(tt.script))
+			cmd.SetArgs([]string{"--script", "-", "server"})
+
+			err := cmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestSplitPipeline(t *testing.T) {
+	got := splitPipeline(`a {"x": "1|2", "y": [1]} | b '{"z": "|"}' |c`)
+	want := []string{`a {"x": "1|2", "y": [1]}`, `b '{"z": "|"}'`, "c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitPipeline() = %q, want %q", got, want)
+	}
+}
+
+func TestShellEditInput(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", `sh -c 'printf "%s\\n" "# comment" "test-tool {" "\"foo\": \"edited\"}" > "$0"'`)
+
+	cmd, buf, cleanupSetup := setupTestCommand(t, "/e test-tool\n/q\n")
+	defer cleanupSetup()
+
+	var arguments any
+	cleanupClient := setupMockClient(func(method string, params any) (map[string]any, error) {
+		if method == "tools/call" {
+			arguments = ConvertJSONToMap(params)["arguments"]
+		}
+		return map[string]any{"content": []any{}}, nil
+	})
+	defer cleanupClient()
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(arguments, map[string]any{"foo": "edited"}) {
+		t.Errorf("Expected the edited params, got %v", arguments)
+	}
+	assertContains(t, buf.String(), "mcp > test-tool {\n\"foo\": \"edited\"}")
+}
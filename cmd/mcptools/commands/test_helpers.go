@@ -26,7 +26,14 @@ func (m *MockTransport) Start(_ context.Context) error {
 // SendRequest overrides the default implementation of the transport.SendRequest method.
 func (m *MockTransport) SendRequest(_ context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
 	if request.Method == "initialize" {
-		return &transport.JSONRPCResponse{Result: json.RawMessage(`{}`)}, nil
+		// The requested protocol version is accepted, whichever it is
+		params, _ := json.Marshal(request.Params)
+		var initialize struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(params, &initialize)
+		result := fmt.Sprintf(`{"protocolVersion":%q,"serverInfo":{"name":"mock-server","version":"1.0.0"},"capabilities":{"tools":{}}}`, initialize.ProtocolVersion)
+		return &transport.JSONRPCResponse{Result: json.RawMessage(result)}, nil
 	}
 	response, err := m.ExecuteFunc(request.Method, request.Params)
 	if err != nil {
@@ -70,7 +77,9 @@ func setupMockClient(executeFunc func(method string, _ any) (map[string]any, err
 	}
 
 	mockClient := client.NewClient(mockTransport)
-	_, _ = mockClient.Initialize(context.Background(), mcp.InitializeRequest{})
+	if result, err := initializeClient(context.Background(), mockClient); err == nil {
+		initializeResults.Store(mockClient, result)
+	}
 
 	// Override the function that creates clients
 	CreateClientFunc = func(_ []string, _ ...client.ClientOption) (*client.Client, error) {
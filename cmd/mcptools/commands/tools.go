@@ -30,14 +30,10 @@ func ToolsCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
-			resp, listErr := mcpClient.ListTools(context.Background(), mcp.ListToolsRequest{})
+			tools, nextCursor, listErr := listTools(context.Background(), mcpClient, mcp.Cursor(CursorOption), !NoPaginate)
 
-			var tools []any
-			if listErr == nil && resp != nil {
-				tools = ConvertJSONToSlice(resp.Tools)
-			}
-
-			toolsMap := map[string]any{"tools": tools}
+			toolsMap := map[string]any{"tools": ConvertJSONToSlice(tools)}
+			addNextCursor(thisCmd, toolsMap, "tools", nextCursor)
 			if formatErr := FormatAndPrintResponse(thisCmd, toolsMap, listErr); formatErr != nil {
 				fmt.Fprintf(os.Stderr, "%v\n", formatErr)
 				os.Exit(1)
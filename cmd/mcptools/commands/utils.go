@@ -5,8 +5,14 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
+	"os"
+	"os/exec"
+	"sort"
 	"strings"
 	"time"
 
@@ -15,10 +21,14 @@ import (
 	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/yosida95/uritemplate/v3"
 
 	"github.com/spf13/cobra"
 )
 
+// transportFlagSet reports whether the transport was set with --transport.
+var transportFlagSet bool
+
 // sentinel errors.
 var (
 	ErrCommandRequired = fmt.Errorf("command to execute is required when using stdio transport")
@@ -91,64 +101,36 @@ var CreateClientFunc = func(args []string, _ ...client.ClientOption) (*client.Cl
 	if len(args) == 0 {
 		return nil, ErrCommandRequired
 	}
-
-	// Check if the first argument is an alias
-	if len(args) == 1 {
-		server, found := alias.GetServerCommand(args[0])
-		if found {
-			args = ParseCommandString(server)
-		}
+	server := resolveServer(args)
+	if len(server.Args) == 0 {
+		return nil, ErrCommandRequired
 	}
 
 	var c *client.Client
+	var stdErr io.Reader
 	var err error
+	versionHeader := &protocolVersionHeader{}
 
-	if len(args) == 1 && IsHTTP(args[0]) {
-		// Validate transport option for HTTP URLs
-		if TransportOption != TransportHTTP && TransportOption != TransportSSE {
-			return nil, fmt.Errorf("invalid transport option: %s (supported: http, sse)", TransportOption)
-		}
-
-		// Build authentication header
-		authHeader, cleanURL, authErr := buildAuthHeader(args[0])
-		if authErr != nil {
-			return nil, fmt.Errorf("failed to parse authentication: %w", authErr)
-		}
-
-		// Create headers map with required Accept header for MCP protocol
-		headers := make(map[string]string)
-
-		// Add authentication header if provided
-		if authHeader != "" {
-			headers["Authorization"] = authHeader
-		}
-
-		// Add Accept header required by MCP streamable HTTP and SSE transports
-		// Many MCP servers require clients to accept both JSON responses and event streams
-		headers["Accept"] = "application/json, text/event-stream"
-
-		if TransportOption == TransportSSE {
-			// For SSE transport, use transport.ClientOption
-			c, err = client.NewSSEMCPClient(cleanURL, transport.WithHeaders(headers))
-		} else {
-			// For StreamableHTTP transport, use transport.StreamableHTTPCOption
-			c, err = client.NewStreamableHttpClient(cleanURL, transport.WithHTTPHeaders(headers))
+	if isURLServer(server) {
+		// The HTTP transports of mcp-go cannot receive requests from the server
+		if len(RootsOption) > 0 || SamplingResponder != "" {
+			return nil, fmt.Errorf("%s and %s are only supported by servers started with a command", FlagRoot, FlagSamplingResponder)
 		}
 
+		c, err = newHTTPClient(server, versionHeader)
 		if err != nil {
 			return nil, err
 		}
 		err = c.Start(context.Background())
 	} else {
-		c, err = client.NewStdioMCPClient(args[0], nil, args[1:]...)
+		c, stdErr, err = newStdioClient(server)
 	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	stdErr, ok := client.GetStderr(c)
-	if ok && ShowServerLogs {
+	if stdErr != nil && ShowServerLogs {
 		go func() {
 			scanner := bufio.NewScanner(stdErr)
 			for scanner.Scan() {
@@ -157,34 +139,199 @@ var CreateClientFunc = func(args []string, _ ...client.ClientOption) (*client.Cl
 		}()
 	}
 
+	var result *mcp.InitializeResult
 	done := make(chan error, 1)
 
 	go func() {
-		initRequest := mcp.InitializeRequest{}
-		initRequest.Params.ProtocolVersion = "2024-11-05"
-		initRequest.Params.Capabilities = mcp.ClientCapabilities{}
-		initRequest.Params.ClientInfo = mcp.Implementation{
-			Name:    "mcptools",
-			Version: "1.0.0",
+		var err error
+		result, err = initializeClient(context.Background(), c)
+		if err == nil {
+			versionHeader.version.Store(result.ProtocolVersion)
 		}
-		_, err := c.Initialize(context.Background(), initRequest)
 		done <- err
 	}()
 
 	select {
 	case err := <-done:
 		if err != nil {
+			_ = c.Close()
 			return nil, fmt.Errorf("init error: %w", err)
 		}
-	case <-time.After(10 * time.Second):
+		initializeResults.Store(c, result)
+	case <-time.After(initializeTimeout):
+		_ = c.Close()
 		return nil, fmt.Errorf("initialization timed out")
 	}
 
 	return c, nil
 }
 
+// resolveServer returns the server to connect to: the server of an alias when the only
+// argument is the name of an alias, and the server of the arguments otherwise.
+func resolveServer(args []string) alias.ServerAlias {
+	if len(args) == 1 {
+		if server, found := alias.Get(args[0]); found {
+			return server
+		}
+	}
+	return alias.ServerAlias{Args: args}
+}
+
+// isURLServer reports whether the server is at an HTTP URL, rather than started with a
+// command.
+func isURLServer(server alias.ServerAlias) bool {
+	return len(server.Args) == 1 && IsHTTP(server.Args[0])
+}
+
+// serverTransport returns the transport of a server at a URL: the one of --transport
+// when the flag is set, then the one of its alias, and http by default.
+func serverTransport(server alias.ServerAlias) string {
+	if server.Transport != "" && !transportFlagSet {
+		return server.Transport
+	}
+	return TransportOption
+}
+
+// setTransportFlag sets the transport of --transport, which takes precedence over the
+// transport of an alias.
+func setTransportFlag(transport string) {
+	TransportOption = transport
+	transportFlagSet = true
+}
+
+// sortedMapKeys returns the keys of a map in order.
+func sortedMapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// serverCommand returns the command that starts a server, with the environment variables
+// and the working directory of its alias.
+func serverCommand(ctx context.Context, server alias.ServerAlias) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, server.Args[0], server.Args[1:]...) // #nosec G204 - the command is given by the user
+	if len(server.Env) > 0 {
+		cmd.Env = os.Environ()
+		for _, name := range sortedMapKeys(server.Env) {
+			cmd.Env = append(cmd.Env, name+"="+server.Env[name])
+		}
+	}
+	if server.Cwd != "" {
+		cmd.Dir = expandPath(server.Cwd)
+	}
+	return cmd
+}
+
+// credentialHeader returns the Authorization header of a credential reference. A
+// credential without a scheme, such as Basic, is a bearer token.
+func credentialHeader(reference string) (string, error) {
+	kind, name, err := parseCredentialReference(reference)
+	if err != nil {
+		return "", err
+	}
+
+	var credential string
+	switch kind {
+	case "env":
+		credential = os.Getenv(name)
+		if credential == "" {
+			return "", fmt.Errorf("the environment variable %s of credential %s is not set", name, reference)
+		}
+	case "file":
+		data, err := os.ReadFile(expandPath(name)) // #nosec G304 - the file is given by the user
+		if err != nil {
+			return "", fmt.Errorf("failed to read credential %s: %w", reference, err)
+		}
+		credential = strings.TrimSpace(string(data))
+	}
+
+	if !strings.Contains(credential, " ") {
+		credential = "Bearer " + credential
+	}
+	return credential, nil
+}
+
+// parseCredentialReference splits a credential reference, env:NAME or file:PATH, into
+// its kind and its name.
+func parseCredentialReference(reference string) (string, string, error) {
+	kind, name, ok := strings.Cut(reference, ":")
+	if !ok || name == "" || (kind != "env" && kind != "file") {
+		return "", "", fmt.Errorf("invalid credential %s (supported: env:NAME, file:PATH)", reference)
+	}
+	return kind, name, nil
+}
+
+// newHTTPClient creates the client of a server at an HTTP URL, with its transport,
+// headers and credential, and the authentication of the flags. The client is not
+// started.
+func newHTTPClient(server alias.ServerAlias, versionHeader *protocolVersionHeader) (*client.Client, error) {
+	transportName := serverTransport(server)
+	// Validate transport option for HTTP URLs
+	if transportName != TransportHTTP && transportName != TransportSSE {
+		return nil, fmt.Errorf("invalid transport option: %s (supported: http, sse)", transportName)
+	}
+
+	// Build authentication header
+	authHeader, cleanURL, err := buildAuthHeader(server.Args[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse authentication: %w", err)
+	}
+
+	// The credential of an alias is used unless the flags set another one
+	if server.Credential != "" && AuthUser == "" && AuthHeader == "" {
+		if authHeader, err = credentialHeader(server.Credential); err != nil {
+			return nil, err
+		}
+	}
+
+	// Create headers map with required Accept header for MCP protocol
+	headers := make(map[string]string)
+	for name, value := range server.Headers {
+		headers[name] = value
+	}
+
+	// Add authentication header if provided
+	if authHeader != "" {
+		headers["Authorization"] = authHeader
+	}
+
+	// Add Accept header required by MCP streamable HTTP and SSE transports
+	// Many MCP servers require clients to accept both JSON responses and event streams
+	headers["Accept"] = "application/json, text/event-stream"
+
+	if transportName == TransportSSE {
+		// For SSE transport, use transport.ClientOption
+		return client.NewSSEMCPClient(cleanURL, transport.WithHeaders(headers))
+	}
+	// For StreamableHTTP transport, use transport.StreamableHTTPCOption
+	httpClient := &http.Client{Transport: endpointNotFound{http.DefaultTransport}}
+	return client.NewStreamableHttpClient(cleanURL, transport.WithHTTPHeaders(headers), transport.WithHTTPHeaderFunc(versionHeader.headers),
+		transport.WithHTTPBasicClient(httpClient))
+}
+
+// endpointNotFound is the HTTP transport of the streamable HTTP clients. mcp-go takes a
+// 404 answer for the end of the session, and fails when there is no session yet, so
+// the answers without a session are turned into errors.
+type endpointNotFound struct {
+	http.RoundTripper
+}
+
+// RoundTrip sends the request, and returns an error when the server answers 404 outside
+// of a session.
+func (t endpointNotFound) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.RoundTripper.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusNotFound && req.Header.Get("Mcp-Session-Id") == "" {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("request failed with status %d: no MCP endpoint at %s", resp.StatusCode, req.URL.Redacted())
+	}
+	return resp, err
+}
+
 // ProcessFlags processes command line flags, sets the format option, and returns the remaining
-// arguments. Supported format options: json, pretty, and table.
+// arguments. Supported format options: json, pretty, table, yaml and markdown.
 // Supported transport options: http and sse.
 //
 // For example, if the input arguments are ["tools", "--format", "pretty", "npx", "-y",
@@ -199,8 +346,17 @@ func ProcessFlags(args []string) []string {
 		case (args[i] == FlagFormat || args[i] == FlagFormatShort) && i+1 < len(args):
 			FormatOption = args[i+1]
 			i += 2
+		case args[i] == FlagQuery && i+1 < len(args):
+			QueryOption = args[i+1]
+			i += 2
+		case args[i] == FlagTemplate && i+1 < len(args):
+			TemplateOption = args[i+1]
+			i += 2
 		case args[i] == FlagTransport && i+1 < len(args):
-			TransportOption = args[i+1]
+			setTransportFlag(args[i+1])
+			i += 2
+		case isClientFlag(args[i]) && i+1 < len(args):
+			setClientFlag(args[i], args[i+1])
 			i += 2
 		case args[i] == FlagServerLogs:
 			ShowServerLogs = true
@@ -211,6 +367,12 @@ func ProcessFlags(args []string) []string {
 		case args[i] == FlagAuthHeader && i+1 < len(args):
 			AuthHeader = args[i+1]
 			i += 2
+		case args[i] == FlagCursor && i+1 < len(args):
+			CursorOption = args[i+1]
+			i += 2
+		case args[i] == FlagNoPaginate:
+			NoPaginate = true
+			i++
 		default:
 			parsedArgs = append(parsedArgs, args[i])
 			i++
@@ -221,37 +383,238 @@ func ProcessFlags(args []string) []string {
 }
 
 // FormatAndPrintResponse formats and prints an MCP response in the format specified by
-// FormatOption.
+// FormatOption. When TemplateOption is set the response is rendered with the template
+// instead, and when QueryOption is set only the results of the query are printed.
 func FormatAndPrintResponse(cmd *cobra.Command, resp any, err error) error {
 	if err != nil {
 		return fmt.Errorf("error: %w", err)
 	}
 
-	output, err := jsonutils.Format(resp, FormatOption)
+	output, err := FormatOutput(resp)
 	if err != nil {
-		return fmt.Errorf("error formatting output: %w", err)
+		return err
+	}
+
+	// Empty lists have no lines in the ndjson format
+	if output == "" && jsonutils.ParseFormat(FormatOption) == jsonutils.FormatNDJSON {
+		return nil
 	}
 
 	fmt.Fprintln(cmd.OutOrStdout(), output)
 	return nil
 }
 
+// CallRecord is the line written for every call of the shell and batch modes in the
+// ndjson format, so results can be processed with line-oriented tools and loaded into
+// log pipelines.
+type CallRecord struct {
+	Result     any     `json:"result,omitempty"`
+	ID         any     `json:"id,omitempty"`
+	Server     string  `json:"server"`
+	Method     string  `json:"method"`
+	Name       string  `json:"name,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"durationMs"`
+}
+
+// complete sets the duration of the call started at start, and its result or error.
+func (r *CallRecord) complete(start time.Time, resp any, err error) {
+	r.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		r.Error = err.Error()
+	} else {
+		r.Result = resp
+	}
+}
+
+// PrintCallResult prints the result of a call made in the shell or batch mode. In the
+// ndjson format the result is wrapped in a CallRecord with the server, method and
+// duration of the call, and errors are written as records too. Otherwise the result
+// is printed like any other response.
+func PrintCallResult(cmd *cobra.Command, record CallRecord, start time.Time, resp any, err error) error {
+	if jsonutils.ParseFormat(FormatOption) != jsonutils.FormatNDJSON {
+		return FormatAndPrintResponse(cmd, resp, err)
+	}
+
+	record.complete(start, resp, err)
+	return printCallRecord(cmd, record)
+}
+
+// printCallRecord writes a call record as a single line, applying QueryOption or
+// TemplateOption to the record when they are set.
+func printCallRecord(cmd *cobra.Command, record CallRecord) error {
+	output, err := FormatOutput(record)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), output)
+	return nil
+}
+
+// FormatOutput formats data using TemplateOption, QueryOption and FormatOption, in that
+// order of precedence.
+func FormatOutput(data any) (string, error) {
+	if TemplateOption != "" {
+		output, err := jsonutils.ExecuteTemplate(data, TemplateOption)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(output, "\n"), nil
+	}
+
+	if QueryOption != "" {
+		output, err := jsonutils.FormatQuery(data, QueryOption, FormatOption)
+		if err != nil {
+			return "", fmt.Errorf("error applying query: %w", err)
+		}
+		return output, nil
+	}
+
+	output, err := jsonutils.Format(data, FormatOption)
+	if err != nil {
+		return "", fmt.Errorf("error formatting output: %w", err)
+	}
+	return output, nil
+}
+
+// expandURITemplate expands an RFC 6570 URI template of a resource with variables.
+// Strings are used as is and other values as JSON. Variables that are not set
+// expand to nothing, as RFC 6570 defines.
+func expandURITemplate(template string, vars map[string]any) (string, error) {
+	tmpl, err := uritemplate.New(template)
+	if err != nil {
+		return "", fmt.Errorf("invalid resource template %s: %w", template, err)
+	}
+
+	values := uritemplate.Values{}
+	for name, value := range vars {
+		str, ok := value.(string)
+		if !ok {
+			data, _ := json.Marshal(value)
+			str = string(data)
+		}
+		values.Set(name, uritemplate.String(str))
+	}
+	return tmpl.Expand(values)
+}
+
 // IsValidFormat returns true if the format is valid.
 func IsValidFormat(format string) bool {
-	return format == "json" || format == "j" ||
-		format == "pretty" || format == "p" ||
-		format == "table" || format == "t"
+	return jsonutils.IsValidFormat(format)
 }
 
-// ParseCommandString splits a command string into separate arguments,
-// respecting spaces as argument separators.
-// Note: This is a simple implementation that doesn't handle quotes or escapes.
+// ParseCommandString splits a command string into separate arguments like a POSIX
+// shell, so quoted arguments can contain spaces. An unterminated quote runs to the
+// end of the string.
 func ParseCommandString(cmdStr string) []string {
 	if cmdStr == "" {
 		return nil
 	}
 
-	return strings.Fields(cmdStr)
+	args, _ := splitWords(cmdStr, false)
+	return args
+}
+
+// QuoteCommandString joins arguments into a command string that ParseCommandString
+// splits back into the same arguments.
+func QuoteCommandString(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quoteWord(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// quoteWord quotes an argument with single quotes when it is empty or contains
+// whitespace, quotes or backslashes.
+func quoteWord(word string) string {
+	if word != "" && !strings.ContainsAny(word, " \t\n\r'\"\\") {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+// splitWords splits a line into words like a POSIX shell. Words are separated by
+// whitespace, single quotes keep text as is, double quotes allow escaping ", \, $
+// and ` with a backslash, and a backslash outside quotes escapes the next character.
+// With jsonWords set, a word that starts with { or [ is kept as is until its braces
+// balance, so JSON can be typed without quotes. An unterminated quote or JSON value
+// returns the words read so far, including the unterminated one, and an error.
+func splitWords(input string, jsonWords bool) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	var err error
+	inWord := false
+
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case jsonWords && !inWord && (c == '{' || c == '['):
+			end, closed := scanJSONToken(input, i)
+			if !closed {
+				err = errors.New("unterminated JSON value")
+			}
+			word.WriteString(input[i:end])
+			inWord = true
+			i = end - 1
+		case c == '\'':
+			inWord = true
+			end := strings.IndexByte(input[i+1:], '\'')
+			if end < 0 {
+				word.WriteString(input[i+1:])
+				err = errors.New("unterminated single quote")
+				i = len(input)
+				break
+			}
+			word.WriteString(input[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			inWord = true
+			closed := false
+			for i++; i < len(input); i++ {
+				if input[i] == '"' {
+					closed = true
+					break
+				}
+				if input[i] == '\\' && i+1 < len(input) && strings.IndexByte("\"\\$`\n", input[i+1]) >= 0 {
+					i++
+					if input[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(input[i])
+			}
+			if !closed {
+				err = errors.New("unterminated double quote")
+			}
+		case c == '\\':
+			if i+1 == len(input) {
+				err = errors.New("unterminated escape")
+				break
+			}
+			i++
+			// A backslash at the end of a line continues the line
+			if input[i] != '\n' {
+				word.WriteByte(input[i])
+				inWord = true
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, err
 }
 
 // ConvertJSONToSlice converts a JSON serialized object to a slice of any type.
@@ -2,11 +2,19 @@ package commands
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/f/mcptools/pkg/alias"
 	"github.com/spf13/cobra"
 )
 
@@ -190,3 +198,232 @@ nested  {"key":"value"}`[1:] // remove first newline
 		})
 	}
 }
+
+func TestFormatAndPrintResponseWithQueryAndTemplate(t *testing.T) {
+	originalQuery, originalTemplate := QueryOption, TemplateOption
+	defer func() { QueryOption, TemplateOption = originalQuery, originalTemplate }()
+
+	args := ProcessFlags([]string{"cmd", "--query", ".tools[].name", "--template", "{{len .tools}}", "arg1"})
+	if !reflect.DeepEqual(args, []string{"cmd", "arg1"}) {
+		t.Errorf("ProcessFlags() gotArgs = %v", args)
+	}
+	if QueryOption != ".tools[].name" || TemplateOption != "{{len .tools}}" {
+		t.Errorf("ProcessFlags() QueryOption = %q, TemplateOption = %q", QueryOption, TemplateOption)
+	}
+
+	resp := map[string]any{
+		"tools": []any{
+			map[string]any{"name": "read_file"},
+			map[string]any{"name": "write_file"},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+
+	// The template takes precedence over the query
+	if err := FormatAndPrintResponse(cmd, resp, nil); err != nil {
+		t.Fatalf("FormatAndPrintResponse() error = %v", err)
+	}
+	assertEquals(t, buf.String(), "2\n")
+
+	buf.Reset()
+	TemplateOption = ""
+	if err := FormatAndPrintResponse(cmd, resp, nil); err != nil {
+		t.Fatalf("FormatAndPrintResponse() error = %v", err)
+	}
+	assertEquals(t, buf.String(), "read_file\nwrite_file\n")
+
+	QueryOption = ".tools["
+	if err := FormatAndPrintResponse(cmd, resp, nil); err == nil {
+		t.Error("Expected error for invalid query")
+	}
+}
+
+func TestPrintCallResultNDJSON(t *testing.T) {
+	originalFormat := FormatOption
+	defer func() { FormatOption = originalFormat }()
+	FormatOption = "ndjson"
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+
+	record := CallRecord{Server: "npx server", Method: "tools/call", Name: "echo"}
+	if err := PrintCallResult(cmd, record, time.Now(), map[string]any{"content": []any{}}, nil); err != nil {
+		t.Fatalf("PrintCallResult() error = %v", err)
+	}
+	if err := PrintCallResult(cmd, record, time.Now(), map[string]any{}, fmt.Errorf("tool not found")); err != nil {
+		t.Fatalf("PrintCallResult() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected one line per call, got %q", buf.String())
+	}
+
+	var first, second map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("Invalid JSON line %q: %v", lines[0], err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("Invalid JSON line %q: %v", lines[1], err)
+	}
+
+	if first["server"] != "npx server" || first["method"] != "tools/call" || first["name"] != "echo" ||
+		first["result"] == nil || first["error"] != nil {
+		t.Errorf("Unexpected record %v", first)
+	}
+	if _, ok := first["durationMs"].(float64); !ok {
+		t.Errorf("Expected durationMs in record %v", first)
+	}
+	if second["error"] != "tool not found" || second["result"] != nil {
+		t.Errorf("Unexpected error record %v", second)
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      []string
+		jsonWords bool
+		wantErr   bool
+	}{
+		{name: "spaces", input: "  npx -y   server ", want: []string{"npx", "-y", "server"}},
+		{name: "single quotes", input: `echo 'a "b" \c'`, want: []string{"echo", `a "b" \c`}},
+		{name: "double quotes", input: `echo "a \"b\" \c $"`, want: []string{"echo", `a "b" \c $`}},
+		{name: "escapes", input: `a\ b c\"d`, want: []string{"a b", `c"d`}},
+		{name: "adjacent quotes", input: `--name="my server"'s'`, want: []string{"--name=my servers"}},
+		{name: "empty quotes", input: `a "" ''`, want: []string{"a", "", ""}},
+		{name: "line continuation", input: "a \\\nb", want: []string{"a", "b"}},
+		{name: "JSON word", input: `tool {"path": "a b", "list": [1, 2]} x`, jsonWords: true, want: []string{"tool", `{"path": "a b", "list": [1, 2]}`, "x"}},
+		{name: "multi-line JSON", input: "tool {\n  \"a\": \"}\"\n}", jsonWords: true, want: []string{"tool", "{\n  \"a\": \"}\"\n}"}},
+		{name: "JSON without jsonWords", input: `tool {"a": 1}`, want: []string{"tool", "{a:", "1}"}},
+		{name: "unterminated quote", input: `echo "a b`, want: []string{"echo", "a b"}, wantErr: true},
+		{name: "unterminated JSON", input: `tool {"a": [1`, jsonWords: true, want: []string{"tool", `{"a": [1`}, wantErr: true},
+		{name: "trailing backslash", input: `tool \`, want: []string{"tool"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitWords(tt.input, tt.jsonWords)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("splitWords(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitWords(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteCommandString(t *testing.T) {
+	args := []string{"docker", "run", "-e", "NAME=my server", "it's", "", `C:\path`}
+	command := QuoteCommandString(args)
+	assertEquals(t, command, `docker run -e 'NAME=my server' 'it'\''s' '' 'C:\path'`)
+
+	if got := ParseCommandString(command); !reflect.DeepEqual(got, args) {
+		t.Errorf("ParseCommandString(%q) = %q, want %q", command, got, args)
+	}
+}
+
+func TestCredentialHeader(t *testing.T) {
+	t.Setenv("MCP_TEST_TOKEN", "secret")
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("Basic dXNlcjpwYXNz\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tests := []struct {
+		reference string
+		want      string
+		wantErr   bool
+	}{
+		{reference: "env:MCP_TEST_TOKEN", want: "Bearer secret"},
+		{reference: "file:" + tokenFile, want: "Basic dXNlcjpwYXNz"},
+		{reference: "env:MCP_TEST_MISSING", wantErr: true},
+		{reference: "file:" + tokenFile + ".missing", wantErr: true},
+		{reference: "vault:token", wantErr: true},
+		{reference: "env:", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			got, err := credentialHeader(tt.reference)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("credentialHeader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("credentialHeader() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerCommand(t *testing.T) {
+	dir := t.TempDir()
+	cmd := serverCommand(context.Background(), alias.ServerAlias{
+		Env:  map[string]string{"B": "2", "A": "1"},
+		Args: []string{"server", "an argument"},
+		Cwd:  dir,
+	})
+
+	if !reflect.DeepEqual(cmd.Args, []string{"server", "an argument"}) {
+		t.Errorf("Args = %q, want [server \"an argument\"]", cmd.Args)
+	}
+	if cmd.Dir != dir {
+		t.Errorf("Dir = %q, want %q", cmd.Dir, dir)
+	}
+	if got := cmd.Env[len(cmd.Env)-2:]; !reflect.DeepEqual(got, []string{"A=1", "B=2"}) {
+		t.Errorf("Env ends with %q, want [A=1 B=2]", got)
+	}
+	if cmd := serverCommand(context.Background(), alias.ServerAlias{Args: []string{"server"}}); cmd.Env != nil {
+		t.Errorf("Env = %q, want the environment of mcptools", cmd.Env)
+	}
+}
+
+func TestServerTransport(t *testing.T) {
+	defer func(transport string, set bool) { TransportOption, transportFlagSet = transport, set }(TransportOption, transportFlagSet)
+	TransportOption, transportFlagSet = TransportHTTP, false
+
+	server := alias.ServerAlias{Args: []string{"http://localhost/sse"}, Transport: TransportSSE}
+	assertEquals(t, serverTransport(server), TransportSSE)
+	assertEquals(t, serverTransport(alias.ServerAlias{Args: []string{"http://localhost/mcp"}}), TransportHTTP)
+
+	ProcessFlags([]string{FlagTransport, TransportHTTP})
+	assertEquals(t, serverTransport(server), TransportHTTP)
+}
+
+func TestHTTPClientAliasHeaders(t *testing.T) {
+	defer func(user, header string) { AuthUser, AuthHeader = user, header }(AuthUser, AuthHeader)
+	AuthUser, AuthHeader = "", ""
+	t.Setenv("MCP_TEST_TOKEN", "secret")
+
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case headers <- r.Header.Clone():
+		default:
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	c, err := newHTTPClient(alias.ServerAlias{
+		Headers:    map[string]string{"X-Team": "tools"},
+		Args:       []string{server.URL + "/mcp"},
+		Transport:  TransportHTTP,
+		Credential: "env:MCP_TEST_TOKEN",
+	}, &protocolVersionHeader{})
+	if err != nil {
+		t.Fatalf("newHTTPClient() error = %v", err)
+	}
+	defer c.Close() //nolint:errcheck
+	_, _ = initializeClient(context.Background(), c)
+
+	got := <-headers
+	assertEquals(t, got.Get("X-Team"), "tools")
+	assertEquals(t, got.Get("Authorization"), "Bearer secret")
+}
@@ -0,0 +1,448 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// watch flags.
+const (
+	FlagDiff        = "--diff"
+	FlagExec        = "--exec"
+	FlagListChanges = "--list-changes"
+)
+
+// watchLists are the lists whose changes are followed with --list-changes.
+var watchLists = []string{"tools", "prompts", "resources"}
+
+// WatchCmd creates the watch command.
+func WatchCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "watch uri [command args...]",
+		Short: "Follow the updates of a resource or the list changes of an MCP server",
+		Long: `Subscribe to a resource and print it again every time the server sends a
+notifications/resources/updated notification for it. With --diff, only the lines
+that changed since the previous version are printed.
+
+With --list-changes, the tools, prompts and resources lists are followed instead, and
+the added and removed entries are printed every time the server sends a list_changed
+notification.
+
+--exec runs a command on every update. It gets the resource text, or the changes of
+the list as JSON, on stdin, and the MCP_WATCH_EVENT and MCP_WATCH_URI environment
+variables.
+
+The command runs until it is interrupted.`,
+		Example: `  mcp watch file:///project/notes.txt npx -y @modelcontextprotocol/server-filesystem ~
+  mcp watch --diff file:///project/notes.txt fs
+  mcp watch --exec "notify-send 'notes changed'" file:///project/notes.txt fs
+  mcp watch --list-changes -f ndjson fs`,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			if len(args) == 0 || (len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort)) {
+				return thisCmd.Help()
+			}
+
+			options, err := parseWatchArgs(args)
+			if err != nil {
+				return err
+			}
+
+			mcpClient, err := CreateClientFunc(options.parsedArgs)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			w := newWatcher(thisCmd, mcpClient, options)
+			events := newWatchEvents()
+			mcpClient.OnNotification(events.add)
+
+			if options.listChanges {
+				w.loadLists()
+				fmt.Fprintf(thisCmd.ErrOrStderr(), "Watching the %s lists, press Ctrl+C to stop\n", strings.Join(watchLists, ", "))
+			} else {
+				request := mcp.SubscribeRequest{}
+				request.Params.URI = options.uri
+				if err := mcpClient.Subscribe(ctx, request); err != nil {
+					return fmt.Errorf("error subscribing to %s: %w", options.uri, err)
+				}
+				defer w.unsubscribe()
+
+				if err := w.resourceUpdated(false); err != nil {
+					return err
+				}
+				fmt.Fprintf(thisCmd.ErrOrStderr(), "Watching %s, press Ctrl+C to stop\n", options.uri)
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-events.signal:
+				}
+
+				for _, event := range events.take() {
+					if err := w.handle(event); err != nil {
+						fmt.Fprintf(thisCmd.ErrOrStderr(), "Error: %v\n", err)
+					}
+				}
+			}
+		},
+	}
+}
+
+// watchOptions are the arguments of the watch command.
+type watchOptions struct {
+	uri         string
+	hook        string
+	parsedArgs  []string
+	diff        bool
+	listChanges bool
+}
+
+// parseWatchArgs parses the arguments of the watch command.
+func parseWatchArgs(args []string) (watchOptions, error) {
+	var options watchOptions
+	uriExtracted := false
+
+	i := 0
+	for i < len(args) {
+		switch {
+		case (args[i] == FlagFormat || args[i] == FlagFormatShort) && i+1 < len(args):
+			FormatOption = args[i+1]
+			i += 2
+		case args[i] == FlagQuery && i+1 < len(args):
+			QueryOption = args[i+1]
+			i += 2
+		case args[i] == FlagTemplate && i+1 < len(args):
+			TemplateOption = args[i+1]
+			i += 2
+		case args[i] == FlagExec && i+1 < len(args):
+			options.hook = args[i+1]
+			i += 2
+		case isClientFlag(args[i]) && i+1 < len(args):
+			setClientFlag(args[i], args[i+1])
+			i += 2
+		case args[i] == FlagDiff:
+			options.diff = true
+			i++
+		case args[i] == FlagListChanges:
+			options.listChanges = true
+			i++
+		case !uriExtracted && !options.listChanges:
+			options.uri = args[i]
+			uriExtracted = true
+			i++
+		default:
+			options.parsedArgs = append(options.parsedArgs, args[i])
+			i++
+		}
+	}
+
+	switch {
+	case options.listChanges && options.diff:
+		return options, fmt.Errorf("%s can not be used with %s", FlagDiff, FlagListChanges)
+	case options.uri == "" && !options.listChanges:
+		return options, fmt.Errorf("a resource uri is required\nExample: mcp watch file:///project/notes.txt npx -y @modelcontextprotocol/server-filesystem ~")
+	case len(options.parsedArgs) == 0:
+		return options, fmt.Errorf("a server command is required\nExample: mcp watch file:///project/notes.txt npx -y @modelcontextprotocol/server-filesystem ~")
+	}
+	return options, nil
+}
+
+// watchEvents collects the notifications that need a request to the server, which
+// can not be sent from the notification handler. Notifications that arrive before the
+// previous ones are handled are merged, as only the latest state is printed.
+type watchEvents struct {
+	pending map[string]bool
+	signal  chan struct{}
+	order   []string
+	mu      sync.Mutex
+}
+
+// newWatchEvents creates an empty event queue.
+func newWatchEvents() *watchEvents {
+	return &watchEvents{
+		pending: map[string]bool{},
+		signal:  make(chan struct{}, 1),
+	}
+}
+
+// add queues a resource update or a list change. Other notifications are ignored.
+func (e *watchEvents) add(notification mcp.JSONRPCNotification) {
+	var event string
+	switch notification.Method {
+	case mcp.MethodNotificationResourceUpdated:
+		uri, _ := notification.Params.AdditionalFields["uri"].(string)
+		event = EntityTypeRes + ":" + uri
+	case mcp.MethodNotificationToolsListChanged, mcp.MethodNotificationPromptsListChanged, mcp.MethodNotificationResourcesListChanged:
+		event = strings.TrimSuffix(strings.TrimPrefix(notification.Method, "notifications/"), "/list_changed")
+	default:
+		return
+	}
+
+	e.mu.Lock()
+	if !e.pending[event] {
+		e.pending[event] = true
+		e.order = append(e.order, event)
+	}
+	e.mu.Unlock()
+
+	select {
+	case e.signal <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the queued events in the order they arrived and empties the queue.
+func (e *watchEvents) take() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	events := e.order
+	e.pending = map[string]bool{}
+	e.order = nil
+	return events
+}
+
+// watcher prints the updates of a resource or the changes of the server's lists.
+type watcher struct {
+	lists     map[string][]string
+	cmd       *cobra.Command
+	client    *client.Client
+	server    string
+	uri       string
+	hook      string
+	last      string
+	diff      bool
+	listWatch bool
+	useColors bool
+}
+
+// newWatcher creates a watcher for the options of the watch command.
+func newWatcher(thisCmd *cobra.Command, mcpClient *client.Client, options watchOptions) *watcher {
+	return &watcher{
+		lists:     map[string][]string{},
+		cmd:       thisCmd,
+		client:    mcpClient,
+		server:    strings.Join(options.parsedArgs, " "),
+		uri:       options.uri,
+		hook:      options.hook,
+		diff:      options.diff,
+		listWatch: options.listChanges,
+		useColors: term.IsTerminal(int(os.Stdout.Fd())),
+	}
+}
+
+// handle handles a queued event: a resource update of the watched resource or a
+// list change.
+func (w *watcher) handle(event string) error {
+	if uri, ok := strings.CutPrefix(event, EntityTypeRes+":"); ok {
+		if w.listWatch || uri != w.uri {
+			return nil
+		}
+		return w.resourceUpdated(true)
+	}
+	if !w.listWatch {
+		return nil
+	}
+	return w.listChanged(event)
+}
+
+// resourceUpdated reads the resource and prints it, or the diff to the previous
+// version. The hook is run for updates, but not for the first read.
+func (w *watcher) resourceUpdated(update bool) error {
+	request := mcp.ReadResourceRequest{}
+	request.Params.URI = w.uri
+	start := time.Now()
+	result, err := w.client.ReadResource(context.Background(), request)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", w.uri, err)
+	}
+	text := resourceText(result)
+
+	if update {
+		fmt.Fprintf(w.cmd.ErrOrStderr(), "[resource updated] %s at %s\n", w.uri, time.Now().Format(time.TimeOnly))
+	}
+	switch {
+	case w.diff && update:
+		diff := unifiedDiff(w.last, text, 3)
+		if diff == "" {
+			fmt.Fprintln(w.cmd.ErrOrStderr(), "(no changes)")
+		} else {
+			fmt.Fprint(w.cmd.OutOrStdout(), colorizeDiff(diff, w.useColors))
+		}
+	case w.diff:
+		fmt.Fprint(w.cmd.OutOrStdout(), text)
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			fmt.Fprintln(w.cmd.OutOrStdout())
+		}
+	default:
+		record := CallRecord{Server: w.server, Method: "resources/read", Name: w.uri}
+		if err := PrintCallResult(w.cmd, record, start, ConvertJSONToMap(result), nil); err != nil {
+			return err
+		}
+	}
+	w.last = text
+
+	if update {
+		w.runHook("resource_updated", text)
+	}
+	return nil
+}
+
+// resourceText returns the text of the contents of a resource. Binary contents are
+// described by their MIME type and size, so that changes to them show up in diffs.
+func resourceText(result *mcp.ReadResourceResult) string {
+	var parts []string
+	for _, content := range result.Contents {
+		switch c := content.(type) {
+		case mcp.TextResourceContents:
+			parts = append(parts, c.Text)
+		case mcp.BlobResourceContents:
+			parts = append(parts, fmt.Sprintf("<%s blob, %d bytes base64>", c.MIMEType, len(c.Blob)))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// loadLists records the entries of the lists before their changes are followed.
+// A list the server does not support is left empty.
+func (w *watcher) loadLists() {
+	for _, list := range watchLists {
+		w.lists[list], _ = w.listNames(list)
+	}
+}
+
+// listNames returns the names of the tools or prompts, or the URIs of the resources
+// of the server, sorted.
+func (w *watcher) listNames(list string) ([]string, error) {
+	var names []string
+	switch list {
+	case "tools":
+		tools, _, err := listTools(context.Background(), w.client, "", true)
+		if err != nil {
+			return nil, err
+		}
+		for _, tool := range tools {
+			names = append(names, tool.Name)
+		}
+	case "prompts":
+		prompts, _, err := listPrompts(context.Background(), w.client, "", true)
+		if err != nil {
+			return nil, err
+		}
+		for _, prompt := range prompts {
+			names = append(names, prompt.Name)
+		}
+	default:
+		resources, _, err := listResources(context.Background(), w.client, "", true)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range resources {
+			names = append(names, resource.URI)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// listChanged lists the entries of a list again and prints the entries that were
+// added and removed since the list was last seen.
+func (w *watcher) listChanged(list string) error {
+	start := time.Now()
+	names, err := w.listNames(list)
+	if err != nil {
+		return fmt.Errorf("error listing %s: %w", list, err)
+	}
+	added, removed := diffNames(w.lists[list], names)
+	w.lists[list] = names
+	change := map[string]any{"list": list, "added": added, "removed": removed}
+
+	if jsonutils.ParseFormat(FormatOption) == jsonutils.FormatNDJSON {
+		record := CallRecord{Server: w.server, Method: "notifications/" + list + "/list_changed"}
+		if err := PrintCallResult(w.cmd, record, start, change, nil); err != nil {
+			return err
+		}
+	} else {
+		line := colorize("["+list+" list changed]", jsonutils.ColorCyan, w.useColors)
+		for _, name := range added {
+			line += " " + colorize("+"+name, jsonutils.ColorGreen, w.useColors)
+		}
+		for _, name := range removed {
+			line += " " + colorize("-"+name, jsonutils.ColorRed, w.useColors)
+		}
+		fmt.Fprintln(w.cmd.OutOrStdout(), line)
+	}
+
+	w.runHook(list+"_list_changed", string(mustJSON(change)))
+	return nil
+}
+
+// diffNames returns the names that are only in names, and the ones that are only in
+// previous. Both lists are sorted.
+func diffNames(previous, names []string) ([]string, []string) {
+	added, removed := []string{}, []string{}
+	i, j := 0, 0
+	for i < len(previous) || j < len(names) {
+		switch {
+		case j == len(names) || (i < len(previous) && previous[i] < names[j]):
+			removed = append(removed, previous[i])
+			i++
+		case i == len(previous) || names[j] < previous[i]:
+			added = append(added, names[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return added, removed
+}
+
+// runHook runs the --exec command with input on stdin. A failing hook is reported
+// but does not stop watching.
+func (w *watcher) runHook(event, input string) {
+	if w.hook == "" {
+		return
+	}
+	hookArgs := ParseCommandString(w.hook)
+	if len(hookArgs) == 0 {
+		return
+	}
+	cmd := exec.Command(hookArgs[0], hookArgs[1:]...) // #nosec G204 - the hook is given by the user
+	cmd.Env = append(os.Environ(), "MCP_WATCH_EVENT="+event, "MCP_WATCH_URI="+w.uri)
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stdout, cmd.Stderr = w.cmd.OutOrStdout(), w.cmd.ErrOrStderr()
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(w.cmd.ErrOrStderr(), "Warning: hook %s failed: %v\n", w.hook, err)
+	}
+}
+
+// unsubscribe stops the updates of the resource. It is best effort, as the server
+// may already be gone.
+func (w *watcher) unsubscribe() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	request := mcp.UnsubscribeRequest{}
+	request.Params.URI = w.uri
+	_ = w.client.Unsubscribe(ctx, request)
+}
@@ -0,0 +1,158 @@
+package commands
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+func TestParseWatchArgs(t *testing.T) {
+	defer func(format string) { FormatOption = format }(FormatOption)
+
+	options, err := parseWatchArgs([]string{"--diff", "--exec", "notify-send changed", "file:///notes.txt", "npx", "server"})
+	if err != nil {
+		t.Fatalf("parseWatchArgs() error = %v", err)
+	}
+	assertEquals(t, options.uri, "file:///notes.txt")
+	assertEquals(t, options.hook, "notify-send changed")
+	assertEquals(t, strings.Join(options.parsedArgs, " "), "npx server")
+	if !options.diff || options.listChanges {
+		t.Errorf("parseWatchArgs() options = %+v", options)
+	}
+
+	options, err = parseWatchArgs([]string{"--list-changes", "-f", "ndjson", "npx", "server"})
+	if err != nil {
+		t.Fatalf("parseWatchArgs() error = %v", err)
+	}
+	assertEquals(t, options.uri, "")
+	assertEquals(t, strings.Join(options.parsedArgs, " "), "npx server")
+	assertEquals(t, FormatOption, "ndjson")
+
+	for _, args := range [][]string{
+		{"file:///notes.txt"},
+		{"--list-changes"},
+		{"--list-changes", "--diff", "server"},
+	} {
+		if _, err := parseWatchArgs(args); err == nil {
+			t.Errorf("parseWatchArgs(%v) should fail", args)
+		}
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+	}{
+		{name: "equal", old: "a\nb\n", new: "a\nb\n", expected: ""},
+		{name: "changed line", old: "a\nb\nc\n", new: "a\nB\nc\n", expected: "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+		{name: "from empty", old: "", new: "a\nb", expected: "@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{name: "removed at end", old: "a\nb\nc", new: "a\nb", expected: "@@ -1,3 +1,2 @@\n a\n b\n-c\n"},
+		{
+			name:     "separate hunks",
+			old:      "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			new:      "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			expected: "@@ -1,2 +1,2 @@\n-1\n+one\n 2\n@@ -9,2 +9,2 @@\n 9\n-10\n+ten\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := 3
+			if tt.name == "separate hunks" {
+				context = 1
+			}
+			assertEquals(t, unifiedDiff(tt.old, tt.new, context), tt.expected)
+		})
+	}
+}
+
+func TestDiffNames(t *testing.T) {
+	added, removed := diffNames([]string{"a", "b", "d"}, []string{"b", "c", "d", "e"})
+	if !reflect.DeepEqual(added, []string{"c", "e"}) || !reflect.DeepEqual(removed, []string{"a"}) {
+		t.Errorf("diffNames() = %v, %v", added, removed)
+	}
+}
+
+func TestWatchEvents(t *testing.T) {
+	events := newWatchEvents()
+	for _, method := range []string{
+		mcp.MethodNotificationResourceUpdated,
+		mcp.MethodNotificationToolsListChanged,
+		mcp.MethodNotificationResourceUpdated,
+		"notifications/message",
+	} {
+		notification := mcp.JSONRPCNotification{}
+		notification.Method = method
+		notification.Params.AdditionalFields = map[string]any{"uri": "test://a"}
+		events.add(notification)
+	}
+
+	assertEquals(t, strings.Join(events.take(), ","), "resource:test://a,tools")
+	if taken := events.take(); len(taken) != 0 {
+		t.Errorf("take() after take() = %v", taken)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	defer func(format string) { FormatOption = format }(FormatOption)
+	FormatOption = "table"
+
+	text := "first\nsecond\n"
+	tools := []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}}
+	cleanup := setupMockClient(func(method string, _ any) (map[string]any, error) {
+		switch method {
+		case "resources/read":
+			return map[string]any{"contents": []any{map[string]any{"uri": "test://a", "text": text}}}, nil
+		case "tools/list":
+			return map[string]any{"tools": tools}, nil
+		}
+		return map[string]any{}, nil
+	})
+	defer cleanup()
+
+	mcpClient, err := CreateClientFunc([]string{"server"})
+	if err != nil {
+		t.Fatalf("CreateClientFunc() error = %v", err)
+	}
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	cmd.SetErr(new(bytes.Buffer))
+
+	w := newWatcher(cmd, mcpClient, watchOptions{uri: "test://a", diff: true, parsedArgs: []string{"server"}})
+	w.useColors = false
+	if err := w.resourceUpdated(false); err != nil {
+		t.Fatalf("resourceUpdated() error = %v", err)
+	}
+	assertEquals(t, buf.String(), "first\nsecond\n")
+
+	buf.Reset()
+	text = "first\nchanged\n"
+	if err := w.handle("resource:test://a"); err != nil {
+		t.Fatalf("handle() error = %v", err)
+	}
+	assertEquals(t, buf.String(), "@@ -1,2 +1,2 @@\n first\n-second\n+changed\n")
+
+	buf.Reset()
+	if err := w.handle("resource:test://other"); err != nil {
+		t.Fatalf("handle() error = %v", err)
+	}
+	assertEquals(t, buf.String(), "")
+
+	w = newWatcher(cmd, mcpClient, watchOptions{listChanges: true, parsedArgs: []string{"server"}})
+	w.useColors = false
+	w.loadLists()
+	tools = []any{map[string]any{"name": "b"}, map[string]any{"name": "c"}}
+	buf.Reset()
+	if err := w.handle("tools"); err != nil {
+		t.Fatalf("handle() error = %v", err)
+	}
+	assertEquals(t, buf.String(), "[tools list changed] +c -a\n")
+}
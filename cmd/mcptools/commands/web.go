@@ -36,6 +36,9 @@ func WebCmd() *cobra.Command {
 				case (cmdArgs[i] == "--port" || cmdArgs[i] == "-p") && i+1 < len(cmdArgs):
 					port = cmdArgs[i+1]
 					i++
+				case isClientFlag(cmdArgs[i]) && i+1 < len(cmdArgs):
+					setClientFlag(cmdArgs[i], cmdArgs[i+1])
+					i++
 				case cmdArgs[i] == FlagServerLogs:
 					ShowServerLogs = true
 				default:
@@ -49,6 +52,10 @@ func WebCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
+			// The elicitation requests of the server are answered with forms in the browser
+			elicitations := newWebElicitations()
+			elicitInput = elicitations.elicit
+
 			mcpClient, clientErr := CreateClientFunc(parsedArgs)
 			if clientErr != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", clientErr)
@@ -74,6 +81,7 @@ func WebCmd() *cobra.Command {
 			mux.HandleFunc("/api/resources", handleResources(clientCache))
 			mux.HandleFunc("/api/prompts", handlePrompts(clientCache))
 			mux.HandleFunc("/api/call", handleCall(clientCache))
+			mux.HandleFunc("/api/elicitations", elicitations.handle())
 
 			// Start the server
 			//nolint:gosec // Timeouts not implemented for this development/internal tool
@@ -165,61 +173,204 @@ func handleIndex() http.HandlerFunc {
         </div>
     </div>
 
+    <div id="elicitation" class="hidden fixed inset-0 bg-gray-900 bg-opacity-50 flex items-center justify-center">
+        <div class="bg-white rounded-lg shadow-lg p-6 w-full max-w-lg">
+            <h2 class="text-lg font-medium text-gray-700 mb-2">The server asks for input</h2>
+            <p id="elicitation-message" class="text-gray-600 mb-4"></p>
+            <div id="elicitation-fields" class="space-y-3 mb-4"></div>
+            <p id="elicitation-error" class="hidden text-red-600 mb-4"></p>
+            <div class="flex gap-2">
+                <button id="elicitation-accept" class="px-4 py-2 bg-blue-600 text-white font-medium rounded-md hover:bg-blue-700">Accept</button>
+                <button id="elicitation-decline" class="px-4 py-2 bg-gray-200 text-gray-700 font-medium rounded-md hover:bg-gray-300">Decline</button>
+                <button id="elicitation-cancel" class="px-4 py-2 bg-gray-200 text-gray-700 font-medium rounded-md hover:bg-gray-300">Cancel</button>
+            </div>
+        </div>
+    </div>
+
     <script>
-        // Fetch and display tools
-        fetch('/api/tools')
-            .then(response => response.json())
-            .then(data => {
-                const toolsList = document.getElementById('tools-list');
-                if (data.result && data.result.tools) {
-                    data.result.tools.forEach(tool => {
-                        const li = document.createElement('li');
-                        li.className = 'py-2 px-3 cursor-pointer text-blue-600 hover:bg-blue-50 rounded-md transition-colors duration-150';
-                        li.textContent = tool.name;
-                        li.onclick = () => showTool(tool);
-                        toolsList.appendChild(li);
-                    });
-                }
+        // The lists are loaded a page at a time. The first page of every list is
+        // loaded at once, and the next pages when the sidebar is scrolled to its end.
+        const lists = {
+            tools: { url: '/api/tools', cursor: '', loading: false, done: false, render: renderTool },
+            resources: { url: '/api/resources', cursor: '', loading: false, done: false, render: renderResource },
+            prompts: { url: '/api/prompts', cursor: '', loading: false, done: false, render: renderPrompt }
+        };
+
+        function loadPage(name) {
+            const list = lists[name];
+            if (list.loading || list.done) return;
+            list.loading = true;
+
+            const url = list.cursor ? list.url + '?cursor=' + encodeURIComponent(list.cursor) : list.url;
+            fetch(url)
+                .then(response => response.json())
+                .then(data => {
+                    list.loading = false;
+                    if (!data.result) {
+                        list.done = true;
+                        return;
+                    }
+                    (data.result[name] || []).forEach(list.render);
+                    (data.result.resourceTemplates || []).forEach(renderResourceTemplate);
+                    list.cursor = data.result.nextCursor || '';
+                    list.done = !list.cursor;
+                    fillSidebar();
+                })
+                .catch(err => {
+                    list.loading = false;
+                    list.done = true;
+                    console.error('Error fetching ' + name + ':', err);
+                });
+        }
 
-                // Ensure formatted tab is visible by default
-                document.getElementById('formatted-output-container').classList.remove('hidden');
-                document.getElementById('raw-output-container').classList.add('hidden');
-            })
-            .catch(err => console.error('Error fetching tools:', err));
+        // Load the next pages while the end of the sidebar is in view
+        function fillSidebar() {
+            const sidebar = document.getElementById('sidebar');
+            if (sidebar.scrollTop + sidebar.clientHeight >= sidebar.scrollHeight - 200) {
+                Object.keys(lists).forEach(loadPage);
+            }
+        }
 
-        // Fetch and display resources
-        fetch('/api/resources')
-            .then(response => response.json())
-            .then(data => {
-                const resourcesList = document.getElementById('resources-list');
-                if (data.result && data.result.resources) {
-                    data.result.resources.forEach(resource => {
-                        const li = document.createElement('li');
-                        li.className = 'py-2 px-3 cursor-pointer text-green-600 hover:bg-green-50 rounded-md transition-colors duration-150';
-                        li.textContent = resource.uri;
-                        li.onclick = () => callResource(resource.uri);
-                        resourcesList.appendChild(li);
-                    });
+        function renderTool(tool) {
+            const li = document.createElement('li');
+            li.className = 'py-2 px-3 cursor-pointer text-blue-600 hover:bg-blue-50 rounded-md transition-colors duration-150';
+            li.textContent = tool.name;
+            li.onclick = () => showTool(tool);
+            document.getElementById('tools-list').appendChild(li);
+        }
+
+        function renderResource(resource) {
+            const li = document.createElement('li');
+            li.className = 'py-2 px-3 cursor-pointer text-green-600 hover:bg-green-50 rounded-md transition-colors duration-150';
+            li.textContent = resource.uri;
+            li.onclick = () => callResource(resource.uri);
+            document.getElementById('resources-list').appendChild(li);
+        }
+
+        function renderResourceTemplate(template) {
+            const li = document.createElement('li');
+            li.className = 'py-2 px-3 cursor-pointer text-green-600 hover:bg-green-50 rounded-md transition-colors duration-150 italic';
+            li.textContent = template.uriTemplate;
+            li.title = 'Resource template';
+            li.onclick = () => showResourceTemplate(template);
+            document.getElementById('resources-list').appendChild(li);
+        }
+
+        function renderPrompt(prompt) {
+            const li = document.createElement('li');
+            li.className = 'py-2 px-3 cursor-pointer text-orange-600 hover:bg-orange-50 rounded-md transition-colors duration-150';
+            li.textContent = prompt.name;
+            li.onclick = () => callPrompt(prompt.name);
+            document.getElementById('prompts-list').appendChild(li);
+        }
+
+        document.getElementById('sidebar').addEventListener('scroll', fillSidebar);
+        Object.keys(lists).forEach(loadPage);
+
+        // The elicitation requests of the server are shown as forms, one at a time,
+        // while the call that made the server ask for input waits for the answer.
+        let elicitationShown = null;
+
+        function pollElicitations() {
+            if (elicitationShown !== null) return;
+            fetch('/api/elicitations')
+                .then(response => response.json())
+                .then(data => {
+                    if (elicitationShown === null && data.result && data.result.length > 0) {
+                        showElicitation(data.result[0]);
+                    }
+                })
+                .catch(err => console.error('Error fetching elicitations:', err));
+        }
+
+        function showElicitation(elicitation) {
+            elicitationShown = elicitation.id;
+            const schema = elicitation.request.requestedSchema;
+            const required = schema.required || [];
+            document.getElementById('elicitation-message').textContent = elicitation.request.message;
+            document.getElementById('elicitation-error').classList.add('hidden');
+
+            const container = document.getElementById('elicitation-fields');
+            container.innerHTML = '';
+            (schema.fields || []).forEach(field => {
+                const label = document.createElement('label');
+                label.className = 'block text-sm font-medium text-gray-700';
+                label.textContent = (field.title || field.name) + (required.includes(field.name) ? ' *' : '');
+                container.appendChild(label);
+                if (field.description) {
+                    const description = document.createElement('div');
+                    description.className = 'text-xs text-gray-500';
+                    description.textContent = field.description;
+                    container.appendChild(description);
                 }
-            })
-            .catch(err => console.error('Error fetching resources:', err));
 
-        // Fetch and display prompts
-        fetch('/api/prompts')
-            .then(response => response.json())
-            .then(data => {
-                const promptsList = document.getElementById('prompts-list');
-                if (data.result && data.result.prompts) {
-                    data.result.prompts.forEach(prompt => {
-                        const li = document.createElement('li');
-                        li.className = 'py-2 px-3 cursor-pointer text-orange-600 hover:bg-orange-50 rounded-md transition-colors duration-150';
-                        li.textContent = prompt.name;
-                        li.onclick = () => callPrompt(prompt.name);
-                        promptsList.appendChild(li);
+                let input;
+                if (field.enum) {
+                    input = document.createElement('select');
+                    input.appendChild(document.createElement('option'));
+                    field.enum.forEach((value, i) => {
+                        const option = document.createElement('option');
+                        option.value = value;
+                        option.textContent = (field.enumNames && field.enumNames[i]) || value;
+                        input.appendChild(option);
                     });
+                } else if (field.type === 'boolean') {
+                    input = document.createElement('input');
+                    input.type = 'checkbox';
+                    input.checked = field.default === true;
+                } else {
+                    input = document.createElement('input');
+                    input.type = field.type === 'number' || field.type === 'integer' ? 'number' : 'text';
+                    if (field.format === 'email') input.type = 'email';
+                    if (field.format === 'date') input.type = 'date';
+                }
+                if (input.type !== 'checkbox') {
+                    input.className = 'w-full p-2 border border-gray-300 rounded-md';
+                    if (field.default !== undefined) input.value = field.default;
                 }
+                input.dataset.name = field.name;
+                container.appendChild(input);
+            });
+
+            document.getElementById('elicitation').classList.remove('hidden');
+        }
+
+        function answerElicitation(action) {
+            const values = {};
+            document.querySelectorAll('#elicitation-fields [data-name]').forEach(input => {
+                values[input.dataset.name] = input.type === 'checkbox' ? String(input.checked) : input.value;
+            });
+
+            fetch('/api/elicitations', {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json'
+                },
+                body: JSON.stringify({ id: elicitationShown, action: action, values: values })
             })
-            .catch(err => console.error('Error fetching prompts:', err));
+            .then(response => response.json().then(data => ({ status: response.status, data: data })))
+            .then(({ status, data }) => {
+                // Invalid values are shown in the form, a request that is gone is closed
+                if (status === 400) {
+                    const error = document.getElementById('elicitation-error');
+                    error.textContent = data.error;
+                    error.classList.remove('hidden');
+                    return;
+                }
+                document.getElementById('elicitation').classList.add('hidden');
+                elicitationShown = null;
+            })
+            .catch(err => console.error('Error answering elicitation:', err));
+        }
+
+        document.getElementById('elicitation-accept').addEventListener('click', () => answerElicitation('accept'));
+        document.getElementById('elicitation-decline').addEventListener('click', () => answerElicitation('decline'));
+        document.getElementById('elicitation-cancel').addEventListener('click', () => answerElicitation('cancel'));
+        setInterval(pollElicitations, 1000);
+
+        // Ensure formatted tab is visible by default
+        document.getElementById('formatted-output-container').classList.remove('hidden');
+        document.getElementById('raw-output-container').classList.add('hidden');
 
         // Tab switching functionality
         document.getElementById('form-tab').addEventListener('click', () => {
@@ -342,24 +493,51 @@ func handleIndex() http.HandlerFunc {
 
             // Set up execute button
             document.getElementById('execute-btn').onclick = () => {
-                let params = {};
+                const params = readParams(tool);
+                if (params) {
+                    callTool(tool.name, params);
+                }
+            };
+        }
 
-                // Check if we're using the form or JSON editor
-                if (document.getElementById('form-container').classList.contains('hidden')) {
-                    // Using JSON editor
-                    try {
-                        params = JSON.parse(document.getElementById('params-area').value);
-                    } catch (e) {
-                        alert('Error parsing JSON parameters: ' + e.message);
-                        return;
-                    }
-                } else {
-                    // Using form - collect values and update JSON view
-                    params = collectFormValues(tool);
-                    document.getElementById('params-area').value = JSON.stringify(params, null, 2);
+        // Read the parameters from the form or the JSON editor, whichever is shown
+        function readParams(tool) {
+            // Check if we're using the form or JSON editor
+            if (document.getElementById('form-container').classList.contains('hidden')) {
+                // Using JSON editor
+                try {
+                    return JSON.parse(document.getElementById('params-area').value);
+                } catch (e) {
+                    alert('Error parsing JSON parameters: ' + e.message);
+                    return null;
                 }
+            }
 
-                callTool(tool.name, params);
+            // Using form - collect values and update JSON view
+            const params = collectFormValues(tool);
+            document.getElementById('params-area').value = JSON.stringify(params, null, 2);
+            return params;
+        }
+
+        // Show a form for the variables of a resource template
+        function showResourceTemplate(template) {
+            const properties = {};
+            (template.variables || []).forEach(name => {
+                properties[name] = { type: 'string' };
+            });
+            const form = {
+                name: template.uriTemplate,
+                description: template.description,
+                inputSchema: { type: 'object', properties: properties }
+            };
+
+            showTool(form);
+            document.getElementById('main-title').textContent = 'Resource: ' + template.uriTemplate;
+            document.getElementById('execute-btn').onclick = () => {
+                const vars = readParams(form);
+                if (vars) {
+                    callResource(template.uriTemplate, vars);
+                }
             };
         }
 
@@ -926,11 +1104,13 @@ func handleIndex() http.HandlerFunc {
             });
         }
 
-        // Call a resource
-        function callResource(uri) {
-            document.getElementById('main-title').textContent = 'Resource: ' + uri;
-            document.getElementById('tool-description').classList.add('hidden');
-            document.getElementById('tool-panel').classList.add('hidden');
+        // Call a resource, or a resource template with the variables of its form
+        function callResource(uri, vars) {
+            if (!vars) {
+                document.getElementById('main-title').textContent = 'Resource: ' + uri;
+                document.getElementById('tool-description').classList.add('hidden');
+                document.getElementById('tool-panel').classList.add('hidden');
+            }
 
             fetch('/api/call', {
                 method: 'POST',
@@ -939,7 +1119,8 @@ func handleIndex() http.HandlerFunc {
                 },
                 body: JSON.stringify({
                     type: 'resource',
-                    name: uri
+                    name: uri,
+                    params: vars
                 })
             })
             .then(response => response.json())
@@ -1037,7 +1218,7 @@ func handleIndex() http.HandlerFunc {
 
                     // Recursively render properties
                     renderObject(value, objectDiv, level + 1);
-                } else if (key === "content" && Array.isArray(value)) {
+                } else if ((key === "content" || key === "contents") && Array.isArray(value)) {
                     // Special handling for content arrays that might contain parseable JSON
                     const contentDiv = document.createElement('div');
                     contentDiv.className = 'pl-4 border-l-2 border-blue-400 mb-4';
@@ -1064,7 +1245,7 @@ func handleIndex() http.HandlerFunc {
                             itemDiv.appendChild(itemTitle);
 
                             contentDiv.appendChild(itemDiv);
-                            renderObject(item, itemDiv, level + 2);
+                            renderObject(renderMediaPreview(item, itemDiv), itemDiv, level + 2);
                         } else if (typeof item === 'string') {
                             // Try to parse it as JSON
                             try {
@@ -1101,6 +1282,42 @@ func handleIndex() http.HandlerFunc {
             }
         }
 
+        // Render image and audio content inline, and other blobs as download links.
+        // Returns the item with its base64 data shortened for display.
+        function renderMediaPreview(item, container) {
+            const media = item.resource && typeof item.resource === 'object' ? item.resource : item;
+            const field = (item.type === 'image' || item.type === 'audio') ? 'data' : 'blob';
+            const data = media[field];
+            if (typeof data !== 'string') return item;
+
+            const mimeType = media.mimeType || 'application/octet-stream';
+            const src = 'data:' + mimeType + ';base64,' + data;
+            let preview;
+            if (mimeType.startsWith('image/')) {
+                preview = document.createElement('img');
+                preview.src = src;
+                preview.alt = mimeType;
+                preview.className = 'max-w-full max-h-96 border border-gray-200 rounded-md my-2';
+            } else if (mimeType.startsWith('audio/')) {
+                preview = document.createElement('audio');
+                preview.controls = true;
+                preview.src = src;
+                preview.className = 'my-2';
+            } else {
+                preview = document.createElement('a');
+                preview.href = src;
+                preview.download = (media.uri || 'content').split('/').pop() || 'content';
+                preview.textContent = 'Download ' + mimeType;
+                preview.className = 'text-blue-600 underline my-2 inline-block';
+            }
+            container.appendChild(preview);
+
+            const shortened = Object.assign({}, media);
+            shortened[field] = '(' + data.length + ' base64 characters)';
+            if (media === item) return shortened;
+            return Object.assign({}, item, { resource: shortened });
+        }
+
         // Helper function to render primitive values
         function renderPrimitiveValue(container, key, value, level) {
             const propertyDiv = document.createElement('div');
@@ -1165,10 +1382,10 @@ func handleIndex() http.HandlerFunc {
 
 // handleTools handles API requests for listing tools.
 func handleTools(cache *MCPClientCache) http.HandlerFunc {
-	//nolint:revive // Parameter r is required by http.HandlerFunc signature
 	return func(w http.ResponseWriter, r *http.Request) {
+		cursor := mcp.Cursor(r.URL.Query().Get("cursor"))
 		cache.mutex.Lock()
-		resp, err := cache.client.ListTools(context.Background(), mcp.ListToolsRequest{})
+		tools, nextCursor, err := listTools(context.Background(), cache.client, cursor, false)
 		cache.mutex.Unlock()
 
 		w.Header().Set("Content-Type", "application/json")
@@ -1183,17 +1400,25 @@ func handleTools(cache *MCPClientCache) http.HandlerFunc {
 
 		//nolint:errcheck,gosec // No need to handle error from Encode in this context
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"result": resp,
+			"result": map[string]interface{}{
+				"tools":      tools,
+				"nextCursor": nextCursor,
+			},
 		})
 	}
 }
 
 // handleResources handles API requests for listing resources.
 func handleResources(cache *MCPClientCache) http.HandlerFunc {
-	//nolint:revive // Parameter r is required by http.HandlerFunc signature
 	return func(w http.ResponseWriter, r *http.Request) {
+		cursor := mcp.Cursor(r.URL.Query().Get("cursor"))
 		cache.mutex.Lock()
-		resp, err := cache.client.ListResources(context.Background(), mcp.ListResourcesRequest{})
+		resources, nextCursor, err := listResources(context.Background(), cache.client, cursor, false)
+		// The templates are a list of their own, listed with the first page
+		templates := []any{}
+		if err == nil && cursor == "" {
+			templates = listTemplatesWithVariables(cache.client)
+		}
 		cache.mutex.Unlock()
 
 		w.Header().Set("Content-Type", "application/json")
@@ -1208,17 +1433,42 @@ func handleResources(cache *MCPClientCache) http.HandlerFunc {
 
 		//nolint:errcheck,gosec // No need to handle error from Encode in this context
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"result": resp,
+			"result": map[string]interface{}{
+				"resources":         resources,
+				"resourceTemplates": templates,
+				"nextCursor":        nextCursor,
+			},
 		})
 	}
 }
 
+// listTemplatesWithVariables returns the resource templates of the server with the
+// names of their variables, from which the web interface builds a form.
+func listTemplatesWithVariables(mcpClient *client.Client) []any {
+	resourceTemplates, _, err := listTemplates(context.Background(), mcpClient, "", true)
+	if err != nil {
+		return []any{}
+	}
+
+	templates := make([]any, 0, len(resourceTemplates))
+	for _, template := range resourceTemplates {
+		entry := ConvertJSONToMap(template)
+		variables := []string{}
+		if template.URITemplate != nil {
+			variables = template.URITemplate.Varnames()
+		}
+		entry["variables"] = variables
+		templates = append(templates, entry)
+	}
+	return templates
+}
+
 // handlePrompts handles API requests for listing prompts.
 func handlePrompts(cache *MCPClientCache) http.HandlerFunc {
-	//nolint:revive // Parameter r is required by http.HandlerFunc signature
 	return func(w http.ResponseWriter, r *http.Request) {
+		cursor := mcp.Cursor(r.URL.Query().Get("cursor"))
 		cache.mutex.Lock()
-		resp, err := cache.client.ListPrompts(context.Background(), mcp.ListPromptsRequest{})
+		prompts, nextCursor, err := listPrompts(context.Background(), cache.client, cursor, false)
 		cache.mutex.Unlock()
 
 		w.Header().Set("Content-Type", "application/json")
@@ -1233,7 +1483,10 @@ func handlePrompts(cache *MCPClientCache) http.HandlerFunc {
 
 		//nolint:errcheck,gosec // No need to handle error from Encode in this context
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"result": resp,
+			"result": map[string]interface{}{
+				"prompts":    prompts,
+				"nextCursor": nextCursor,
+			},
 		})
 	}
 }
@@ -1280,8 +1533,14 @@ func handleCall(cache *MCPClientCache) http.HandlerFunc {
 			var resourceResponse *mcp.ReadResourceResult
 			request := mcp.ReadResourceRequest{}
 			request.Params.URI = requestData.Name
-			resourceResponse, callErr = cache.client.ReadResource(context.Background(), request)
-			resp = ConvertJSONToMap(resourceResponse)
+			// A resource template is read with its variables expanded
+			if strings.Contains(requestData.Name, "{") {
+				request.Params.URI, callErr = expandURITemplate(requestData.Name, requestData.Params)
+			}
+			if callErr == nil {
+				resourceResponse, callErr = cache.client.ReadResource(context.Background(), request)
+				resp = ConvertJSONToMap(resourceResponse)
+			}
 		case EntityTypePrompt:
 			var promptResponse *mcp.GetPromptResult
 			request := mcp.GetPromptRequest{}
@@ -30,10 +30,14 @@ func main() {
 		commands.ToolsCmd(),
 		commands.ResourcesCmd(),
 		commands.PromptsCmd(),
+		commands.InfoCmd(),
+		commands.DoctorCmd(),
 		commands.CallCmd(),
 		commands.GetPromptCmd(),
 		commands.ReadResourceCmd(),
 		commands.ShellCmd(),
+		commands.BatchCmd(),
+		commands.WatchCmd(),
 		commands.WebCmd(),
 		commands.MockCmd(),
 		commands.ProxyCmd(),
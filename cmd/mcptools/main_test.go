@@ -388,7 +388,7 @@ func TestProxyToolRegistration(t *testing.T) {
 			}
 
 			toolName := tc.args[0]
-			if _, exists := config[toolName]; !exists {
+			if _, exists := config.Tools[toolName]; !exists {
 				t.Errorf("Tool %s was not registered in config", toolName)
 			}
 		})
@@ -430,7 +430,7 @@ func TestProxyToolUnregistration(t *testing.T) {
 		t.Fatalf("Error loading config: %v", err)
 	}
 
-	if _, exists := config["test_tool"]; exists {
+	if _, exists := config.Tools["test_tool"]; exists {
 		t.Error("Tool was not unregistered from config")
 	}
 }
@@ -8,13 +8,37 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
-// ServerAlias represents a single server command alias.
+// ServerAlias represents a single server alias: a command with its arguments, started
+// with extra environment variables in a working directory, or the URL of an HTTP server
+// with its transport, headers and a reference to its credential.
 type ServerAlias struct {
-	Command string `json:"command"`
+	Env     map[string]string `json:"env,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Args are the command and its arguments, or the URL of the server.
+	Args      []string `json:"args"`
+	Cwd       string   `json:"cwd,omitempty"`
+	Transport string   `json:"transport,omitempty"`
+	// Credential references the credential of the server, without storing it: env:NAME
+	// reads it from an environment variable, and file:PATH from a file.
+	Credential string `json:"credential,omitempty"`
+	// Command is the command string of the aliases saved by earlier versions, which
+	// Load and Save migrate to Args.
+	Command string `json:"command,omitempty"`
 }
 
+// SplitCommand splits the command strings of the aliases saved by earlier versions into
+// arguments when they are migrated. The commands package sets it to its parser, which
+// handles quoted arguments.
+var SplitCommand = strings.Fields
+
+// JoinCommand joins the arguments of an alias into the command string returned by
+// GetServerCommand. The commands package sets it to its quoting, which its parser
+// splits back into the same arguments.
+var JoinCommand = func(args []string) string { return strings.Join(args, " ") }
+
 // Aliases stores command aliases for MCP servers.
 type Aliases map[string]ServerAlias
 
@@ -34,43 +58,100 @@ func GetConfigPath() (string, error) {
 	return filepath.Join(configDir, "aliases.json"), nil
 }
 
-// Load loads server aliases from the configuration file.
+// Load loads server aliases from the configuration file. The aliases saved by earlier
+// versions are migrated in memory, and the file is only rewritten by Save or Migrate.
 func Load() (Aliases, error) {
 	configPath, err := GetConfigPath()
 	if err != nil {
 		return nil, err
 	}
 
+	aliases, _, err := read(configPath)
+	if err != nil {
+		return nil, err
+	}
+	migrate(aliases)
+	return aliases, nil
+}
+
+// read reads the aliases of the configuration file as they are saved, and returns its
+// content. A missing or empty file has no aliases.
+func read(configPath string) (Aliases, []byte, error) {
 	aliases := make(Aliases)
 
 	var statErr error
 	if _, statErr = os.Stat(configPath); os.IsNotExist(statErr) {
-		return aliases, nil
+		return aliases, nil, nil
 	}
 
 	configFile, err := os.ReadFile(configPath) // #nosec G304 - configPath is generated internally by GetConfigPath
 	if err != nil {
-		return nil, fmt.Errorf("failed to read alias config file: %w", err)
+		return nil, nil, fmt.Errorf("failed to read alias config file: %w", err)
 	}
 
 	if len(configFile) == 0 {
-		return aliases, nil
+		return aliases, configFile, nil
 	}
 
 	if unmarshalErr := json.Unmarshal(configFile, &aliases); unmarshalErr != nil {
-		return nil, fmt.Errorf("failed to parse alias config file: %w", unmarshalErr)
+		return nil, nil, fmt.Errorf("failed to parse alias config file: %w", unmarshalErr)
 	}
 
-	return aliases, nil
+	return aliases, configFile, nil
+}
+
+// Migrate rewrites the configuration file when it holds aliases saved by earlier
+// versions, and reports whether it did.
+func Migrate() (bool, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return false, err
+	}
+
+	aliases, _, err := read(configPath)
+	if err != nil {
+		return false, err
+	}
+	if !migrate(aliases) {
+		return false, nil
+	}
+	return true, Save(aliases)
+}
+
+// migrate moves the command strings of the aliases saved by earlier versions to their
+// arguments, and reports whether there were any.
+func migrate(aliases Aliases) bool {
+	migrated := false
+	for name, alias := range aliases {
+		if alias.Command == "" {
+			continue
+		}
+		if len(alias.Args) == 0 {
+			alias.Args = SplitCommand(alias.Command)
+		}
+		alias.Command = ""
+		aliases[name] = alias
+		migrated = true
+	}
+	return migrated
 }
 
-// Save saves server aliases to the configuration file.
+// Save saves server aliases to the configuration file. A file with aliases saved by
+// earlier versions is backed up first.
 func Save(aliases Aliases) error {
 	configPath, err := GetConfigPath()
 	if err != nil {
 		return err
 	}
 
+	// The file of the earlier version is kept next to the migrated one
+	saved, configFile, err := read(configPath)
+	if err == nil && migrate(saved) {
+		if backupErr := os.WriteFile(configPath+".bak", configFile, 0o600); backupErr != nil {
+			return fmt.Errorf("failed to back up alias config file: %w", backupErr)
+		}
+	}
+
 	configJSON, err := json.MarshalIndent(aliases, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal alias config: %w", err)
@@ -84,17 +165,25 @@ func Save(aliases Aliases) error {
 	return nil
 }
 
-// GetServerCommand retrieves the server command for a given alias.
-func GetServerCommand(aliasName string) (string, bool) {
+// Get retrieves the server of a given alias.
+func Get(aliasName string) (ServerAlias, bool) {
 	aliases, err := Load()
 	if err != nil {
-		return "", false
+		return ServerAlias{}, false
 	}
 
 	alias, exists := aliases[aliasName]
+	return alias, exists
+}
+
+// GetServerCommand retrieves the server command for a given alias.
+//
+// Deprecated: Use Get, which keeps the arguments of the command apart.
+func GetServerCommand(aliasName string) (string, bool) {
+	alias, exists := Get(aliasName)
 	if !exists {
 		return "", false
 	}
 
-	return alias.Command, true
+	return JoinCommand(alias.Args), true
 }
@@ -0,0 +1,309 @@
+package jsonutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// contentIndent indents the details shown below a content block.
+const contentIndent = "  "
+
+// formatCallResult formats a tool call result for the table view: an error
+// banner when isError is set, every content block, and the structured content
+// as a key/value tree.
+func formatCallResult(result map[string]any) (string, error) {
+	useColors := isTerminal()
+	width := getTermWidth()
+	isError, _ := result["isError"].(bool)
+
+	var blocks []string
+	if isError {
+		blocks = append(blocks, colorize("ERROR: the tool call failed", ColorBold+ColorRed, useColors))
+	}
+
+	if content, hasContent := result["content"]; hasContent && content != nil {
+		contentSlice, ok := content.([]any)
+		if !ok {
+			return "", fmt.Errorf("content is not a slice")
+		}
+		for _, c := range contentSlice {
+			item, ok1 := c.(map[string]any)
+			if !ok1 {
+				continue
+			}
+			blocks = append(blocks, formatContentItem(item, width, isError, useColors))
+		}
+	}
+
+	if structured, ok := result["structuredContent"]; ok && structured != nil {
+		var tree strings.Builder
+		tree.WriteString(colorize("Structured content:", ColorBold+ColorCyan, useColors) + "\n")
+		writeTree(&tree, structured, contentIndent, width, useColors)
+		blocks = append(blocks, strings.TrimSuffix(tree.String(), "\n"))
+	}
+
+	return strings.Join(blocks, "\n"), nil
+}
+
+// formatContentItem formats a single content block of a tool call result or
+// prompt message.
+func formatContentItem(item map[string]any, width int, isError, useColors bool) string {
+	var buf strings.Builder
+	contentType, _ := item["type"].(string)
+	mimeType, _ := item["mimeType"].(string)
+
+	switch contentType {
+	case "text":
+		text, _ := item["text"].(string)
+		textColor := ColorGray
+		if isError {
+			textColor = ColorRed
+		}
+		buf.WriteString(colorize(strings.TrimSuffix(wrapLines(text, width), "\n"), textColor, useColors))
+	case "image", "audio":
+		label := "[" + strings.ToUpper(contentType) + " " + mimeType + "]"
+		buf.WriteString(colorize(label, ColorYellow, useColors) + contentSize(item["data"]) + savedFileSuffix(item))
+	case "resource":
+		resource, _ := item["resource"].(map[string]any)
+		uri, _ := resource["uri"].(string)
+		resourceMIME, _ := resource["mimeType"].(string)
+		label := strings.TrimSpace("[RESOURCE " + uri + " " + resourceMIME + "]")
+		buf.WriteString(colorize(label, ColorYellow, useColors))
+		if text, hasText := resource["text"].(string); hasText {
+			buf.WriteString("\n" + indentLines(wrapLines(strings.TrimSuffix(text, "\n"), width-len(contentIndent)), contentIndent))
+		} else {
+			buf.WriteString(contentSize(resource["blob"]) + savedFileSuffix(resource))
+		}
+	case "resource_link":
+		name, _ := item["name"].(string)
+		uri, _ := item["uri"].(string)
+		label := colorize("[RESOURCE LINK]", ColorYellow, useColors) + " " + name
+		if uri != "" {
+			label += " " + colorize("<"+uri+">", ColorCyan, useColors)
+		}
+		if mimeType != "" {
+			label += " " + mimeType
+		}
+		buf.WriteString(label)
+		if desc, _ := item["description"].(string); desc != "" {
+			for _, line := range wrapText(desc, width-len(contentIndent)) {
+				buf.WriteString("\n" + contentIndent + colorize(line, ColorGray, useColors))
+			}
+		}
+	default:
+		buf.WriteString(colorize(fmt.Sprintf("[%s CONTENT]", strings.ToUpper(contentType)), ColorYellow, useColors))
+	}
+
+	if annotations := formatAnnotations(item); annotations != "" {
+		buf.WriteString("\n" + contentIndent + colorize(annotations, ColorGray, useColors))
+	}
+
+	return buf.String()
+}
+
+// formatAnnotations describes the audience, priority and last modification
+// time of a content block, or of its embedded resource.
+func formatAnnotations(item map[string]any) string {
+	annotations, ok := item["annotations"].(map[string]any)
+	if !ok {
+		resource, _ := item["resource"].(map[string]any)
+		if annotations, ok = resource["annotations"].(map[string]any); !ok {
+			return ""
+		}
+	}
+
+	var parts []string
+	if audience, hasAudience := annotations["audience"].([]any); hasAudience && len(audience) > 0 {
+		roles := make([]string, 0, len(audience))
+		for _, role := range audience {
+			roles = append(roles, fmt.Sprint(role))
+		}
+		parts = append(parts, "audience: "+strings.Join(roles, ", "))
+	}
+	if priority, hasPriority := annotations["priority"].(float64); hasPriority {
+		parts = append(parts, fmt.Sprintf("priority: %g", priority))
+	}
+	if modified, hasModified := annotations["lastModified"].(string); hasModified && modified != "" {
+		parts = append(parts, "modified: "+modified)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// formatResourceContents formats the contents of a read resource. Text is
+// shown as is, and binary contents by their MIME type and saved file.
+func formatResourceContents(contents []any) (string, error) {
+	var buf strings.Builder
+	useColors := isTerminal()
+
+	for _, c := range contents {
+		item, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		if text, hasText := item["text"].(string); hasText {
+			buf.WriteString(strings.TrimSuffix(text, "\n") + "\n")
+			continue
+		}
+
+		uri, _ := item["uri"].(string)
+		mimeType, _ := item["mimeType"].(string)
+		label := strings.TrimSpace("[BLOB " + uri + " " + mimeType + "]")
+		buf.WriteString(colorize(label, ColorYellow, useColors) + contentSize(item["blob"]) + savedFileSuffix(item) + "\n")
+	}
+
+	return buf.String(), nil
+}
+
+// savedFileSuffix returns " saved to <path>" for content that was saved to a
+// file, or an empty string.
+func savedFileSuffix(item map[string]any) string {
+	if file, ok := item["file"].(string); ok {
+		return " saved to " + file
+	}
+	return ""
+}
+
+// contentSize returns the decoded size of base64 data, such as " (12.3 KB)",
+// or an empty string when there is no data.
+func contentSize(data any) string {
+	encoded, ok := data.(string)
+	if !ok || encoded == "" {
+		return ""
+	}
+
+	size := float64(len(encoded)) * 3 / 4
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf(" (%.1f MB)", size/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf(" (%.1f KB)", size/1024)
+	default:
+		return fmt.Sprintf(" (%d bytes)", int(size))
+	}
+}
+
+// writeTree writes a value as an indented key/value tree. Objects show one key
+// per line, arrays one "-" item per line, and long strings wrap to the width.
+func writeTree(buf *strings.Builder, value any, indent string, width int, useColors bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			buf.WriteString(indent + "{}\n")
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			writeTreeEntry(buf, colorize(k, ColorCyan, useColors)+":", len(k)+1, v[k], indent, width, useColors)
+		}
+	case []any:
+		if len(v) == 0 {
+			buf.WriteString(indent + "[]\n")
+			return
+		}
+		for _, item := range v {
+			writeTreeEntry(buf, "-", 1, item, indent, width, useColors)
+		}
+	default:
+		writeTreeEntry(buf, "", 0, value, indent, width, useColors)
+	}
+}
+
+// writeTreeEntry writes a key or array marker followed by a value, on the same
+// line for scalars and on the following lines for non-empty objects and arrays.
+func writeTreeEntry(buf *strings.Builder, label string, labelWidth int, value any, indent string, width int, useColors bool) {
+	switch v := value.(type) {
+	case map[string]any, []any:
+		if isEmptyContainer(v) {
+			empty := "{}"
+			if _, isArray := v.([]any); isArray {
+				empty = "[]"
+			}
+			buf.WriteString(indent + label + " " + empty + "\n")
+			return
+		}
+		buf.WriteString(indent + label + "\n")
+		writeTree(buf, v, indent+contentIndent, width, useColors)
+	default:
+		text := treeScalar(v)
+		prefix := indent
+		if label != "" {
+			prefix += label + " "
+			labelWidth++
+		}
+		lines := wrapText(text, max(width-len(indent)-labelWidth, 20))
+		if len(lines) <= 1 || strings.Contains(text, "\n") {
+			buf.WriteString(prefix + text + "\n")
+			return
+		}
+		continuation := strings.Repeat(" ", len(indent)+labelWidth)
+		buf.WriteString(prefix + lines[0] + "\n")
+		for _, line := range lines[1:] {
+			buf.WriteString(continuation + line + "\n")
+		}
+	}
+}
+
+// isEmptyContainer reports whether an object or array has no elements.
+func isEmptyContainer(value any) bool {
+	switch v := value.(type) {
+	case map[string]any:
+		return len(v) == 0
+	case []any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// treeScalar returns the display text of a scalar value.
+func treeScalar(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return "null"
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// wrapLines wraps the lines of text that are longer than width at word
+// boundaries, keeping line breaks and the indentation of every line.
+func wrapLines(text string, width int) string {
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if len(line) <= width {
+			out = append(out, line)
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		for _, wrapped := range wrapText(line, max(width-len(indent), 20)) {
+			out = append(out, indent+wrapped)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// indentLines prefixes every line of text with indent.
+func indentLines(text, indent string) string {
+	return indent + strings.ReplaceAll(text, "\n", "\n"+indent)
+}
+
+// colorize wraps text in an ANSI color when colors are enabled.
+func colorize(text, color string, useColors bool) string {
+	if !useColors || text == "" {
+		return text
+	}
+	return color + text + ColorReset
+}
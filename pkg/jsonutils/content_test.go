@@ -0,0 +1,71 @@
+package jsonutils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatCallResult(t *testing.T) {
+	result := map[string]any{
+		"isError": true,
+		"content": []any{
+			map[string]any{"type": "text", "text": "File not found"},
+			map[string]any{
+				"type": "resource",
+				"resource": map[string]any{
+					"uri":      "file:///notes.txt",
+					"mimeType": "text/plain",
+					"text":     "first line\nsecond line\n",
+				},
+				"annotations": map[string]any{"audience": []any{"user", "assistant"}, "priority": 0.8},
+			},
+			map[string]any{
+				"type":        "resource_link",
+				"name":        "report",
+				"uri":         "file:///report.pdf",
+				"mimeType":    "application/pdf",
+				"description": "The quarterly report",
+			},
+			map[string]any{"type": "image", "mimeType": "image/png", "data": strings.Repeat("A", 4096)},
+		},
+		"structuredContent": map[string]any{
+			"count": 2,
+			"files": []any{"a.txt", map[string]any{"name": "b.txt", "size": 10}},
+			"empty": map[string]any{},
+		},
+	}
+
+	output, err := Format(result, "table")
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	expected := `ERROR: the tool call failed
+File not found
+[RESOURCE file:///notes.txt text/plain]
+  first line
+  second line
+  audience: user, assistant  priority: 0.8
+[RESOURCE LINK] report <file:///report.pdf> application/pdf
+  The quarterly report
+[IMAGE image/png] (3.0 KB)
+Structured content:
+  count: 2
+  empty: {}
+  files:
+    - a.txt
+    -
+      name: b.txt
+      size: 10`
+	if output != expected {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", output, expected)
+	}
+}
+
+func TestWrapLines(t *testing.T) {
+	text := "short\n    an indented line that is too long to fit\nlast"
+	expected := "short\n    an indented line that\n    is too long to fit\nlast"
+	if output := wrapLines(text, 25); output != expected {
+		t.Errorf("wrapLines() = %q, want %q", output, expected)
+	}
+}
@@ -0,0 +1,60 @@
+package jsonutils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// statusColors are the colors of the statuses of the doctor checks.
+var statusColors = map[string]string{
+	"ok":   ColorGreen,
+	"warn": ColorYellow,
+	"fail": ColorRed,
+}
+
+// formatChecks formats the checks of a doctor report, one per line with its status and
+// name, followed by the other lines of its detail and its hint.
+func formatChecks(checks []any) (string, error) {
+	var buf bytes.Buffer
+	useColors := isTerminal()
+
+	nameWidth := 0
+	for _, check := range checks {
+		checkMap, _ := check.(map[string]any)
+		name, _ := checkMap["name"].(string)
+		nameWidth = max(nameWidth, len(name))
+	}
+	indent := strings.Repeat(" ", 6+nameWidth+2)
+
+	for _, check := range checks {
+		checkMap, _ := check.(map[string]any)
+		name, _ := checkMap["name"].(string)
+		status, _ := checkMap["status"].(string)
+		detail, _ := checkMap["detail"].(string)
+		hint, _ := checkMap["hint"].(string)
+
+		statusText := fmt.Sprintf("%-4s", status)
+		if color, ok := statusColors[status]; ok && useColors {
+			statusText = color + statusText + ColorReset
+		}
+		lines := strings.Split(detail, "\n")
+		fmt.Fprintf(&buf, "%s  %-*s  %s\n", statusText, nameWidth, name, lines[0])
+		for _, line := range lines[1:] {
+			fmt.Fprintf(&buf, "%s%s\n", indent, line)
+		}
+		if hint != "" {
+			hintLines := strings.Split(hint, "\n")
+			if useColors {
+				fmt.Fprintf(&buf, "%s%shint: %s%s\n", indent, ColorCyan, hintLines[0], ColorReset)
+			} else {
+				fmt.Fprintf(&buf, "%shint: %s\n", indent, hintLines[0])
+			}
+			for _, line := range hintLines[1:] {
+				fmt.Fprintf(&buf, "%s  %s\n", indent, line)
+			}
+		}
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
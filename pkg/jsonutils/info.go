@@ -0,0 +1,75 @@
+package jsonutils
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// formatServerInfo formats the initialize result of a server: its name and version,
+// the negotiated protocol version, its capabilities and its instructions.
+func formatServerInfo(info map[string]any) (string, error) {
+	var buf bytes.Buffer
+	useColors := isTerminal()
+	heading := func(text string) {
+		if useColors {
+			fmt.Fprintf(&buf, "%s%s%s\n", ColorBold+ColorCyan, text, ColorReset)
+		} else {
+			fmt.Fprintln(&buf, text)
+		}
+	}
+
+	serverInfo, _ := info["serverInfo"].(map[string]any)
+	name, _ := serverInfo["name"].(string)
+	version, _ := serverInfo["version"].(string)
+	protocolVersion, _ := info["protocolVersion"].(string)
+	heading("Server")
+	fmt.Fprintf(&buf, "  %s %s\n", name, version)
+	fmt.Fprintf(&buf, "  protocol version %s\n", protocolVersion)
+
+	fmt.Fprintln(&buf)
+	heading("Capabilities")
+	capabilities, _ := info["capabilities"].(map[string]any)
+	if len(capabilities) == 0 {
+		fmt.Fprintln(&buf, "  none")
+	}
+	for _, capability := range sortedKeys(capabilities) {
+		// The options of a capability, such as listChanged, are listed when enabled
+		var options []string
+		if optionsMap, ok := capabilities[capability].(map[string]any); ok {
+			for _, option := range sortedKeys(optionsMap) {
+				if enabled, isBool := optionsMap[option].(bool); !isBool || enabled {
+					options = append(options, option)
+				}
+			}
+		}
+		if len(options) > 0 {
+			fmt.Fprintf(&buf, "  %s (%s)\n", capability, strings.Join(options, ", "))
+		} else {
+			fmt.Fprintf(&buf, "  %s\n", capability)
+		}
+	}
+
+	if instructions, _ := info["instructions"].(string); instructions != "" {
+		fmt.Fprintln(&buf)
+		heading("Instructions")
+		for _, paragraph := range strings.Split(strings.TrimSpace(instructions), "\n") {
+			for _, line := range wrapText(paragraph, getTermWidth()-2) {
+				fmt.Fprintf(&buf, "  %s\n", line)
+			}
+		}
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// sortedKeys returns the keys of a map in order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -14,6 +14,7 @@ import (
 	"text/tabwriter"
 
 	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
 )
 
 // ANSI color codes for terminal output.
@@ -47,9 +48,12 @@ type OutputFormat string
 
 // constants.
 const (
-	FormatJSON   OutputFormat = "json"
-	FormatPretty OutputFormat = "pretty"
-	FormatTable  OutputFormat = "table"
+	FormatJSON     OutputFormat = "json"
+	FormatPretty   OutputFormat = "pretty"
+	FormatTable    OutputFormat = "table"
+	FormatYAML     OutputFormat = "yaml"
+	FormatMarkdown OutputFormat = "markdown"
+	FormatNDJSON   OutputFormat = "ndjson"
 )
 
 // ParseFormat converts a string to an OutputFormat.
@@ -61,6 +65,12 @@ func ParseFormat(format string) OutputFormat {
 		return FormatPretty
 	case "table", "t":
 		return FormatTable
+	case "yaml", "yml", "y":
+		return FormatYAML
+	case "markdown", "md", "m":
+		return FormatMarkdown
+	case "ndjson", "jsonl":
+		return FormatNDJSON
 	default:
 		return FormatTable
 	}
@@ -77,11 +87,100 @@ func Format(data any, format string) (string, error) {
 		return formatJSON(data, true)
 	case FormatTable:
 		return formatTable(data)
+	case FormatYAML:
+		return formatYAML(data)
+	case FormatMarkdown:
+		return formatMarkdown(data)
+	case FormatNDJSON:
+		return formatNDJSON(data)
 	default:
 		return formatTable(data)
 	}
 }
 
+// IsValidFormat returns true if format is the name or short name of an output format.
+func IsValidFormat(format string) bool {
+	switch strings.ToLower(format) {
+	case "json", "j", "pretty", "p", "table", "t", "yaml", "yml", "y", "markdown", "md", "m", "ndjson", "jsonl":
+		return true
+	default:
+		return false
+	}
+}
+
+// formatYAML converts data to YAML. The data is converted to JSON first, so
+// field names follow the JSON tags of the MCP types.
+func formatYAML(data any) (string, error) {
+	generic, err := toGeneric(data)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(generic); err != nil {
+		return "", fmt.Errorf("error formatting YAML: %w", err)
+	}
+	_ = encoder.Close()
+
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// ndjsonListKeys are the keys of list results whose entities are written one
+// per line in the ndjson format.
+var ndjsonListKeys = []string{"tools", "resources", "resourceTemplates", "prompts"}
+
+// formatNDJSON formats the data as newline delimited JSON. Arrays and list
+// results such as {"tools": [...]} are written one element per line, so they
+// can be processed with line-oriented tools, and anything else as a single
+// line of compact JSON.
+func formatNDJSON(data any) (string, error) {
+	generic, err := toGeneric(data)
+	if err != nil {
+		return "", err
+	}
+
+	items, isArray := generic.([]any)
+	if mapVal, ok := generic.(map[string]any); ok {
+		// The resources list also has the resource templates
+		for _, key := range ndjsonListKeys {
+			if list, isList := mapVal[key].([]any); isList {
+				items = append(items, list...)
+				isArray = true
+			}
+		}
+	}
+	if !isArray {
+		return formatJSON(generic, false)
+	}
+
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		line, err := formatJSON(item, false)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// toGeneric converts data to its JSON representation made of maps, slices and
+// basic values.
+func toGeneric(data any) (any, error) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("error formatting JSON: %w", err)
+	}
+
+	var generic any
+	if err := json.Unmarshal(jsonData, &generic); err != nil {
+		return nil, fmt.Errorf("error formatting JSON: %w", err)
+	}
+	return generic, nil
+}
+
 // formatJSON converts data to JSON with optional pretty printing.
 func formatJSON(data any, pretty bool) (string, error) {
 	var output []byte
@@ -118,20 +217,33 @@ func formatTable(data any) (string, error) {
 		return formatJSON(data, true)
 	}
 
+	if _, ok := mapVal["serverInfo"]; ok {
+		return formatServerInfo(mapVal)
+	}
+
+	if checks, ok := mapVal["checks"].([]any); ok {
+		return formatChecks(checks)
+	}
+
 	if tools, ok1 := mapVal["tools"]; ok1 {
 		return formatToolsList(tools)
 	}
 
 	if resources, ok2 := mapVal["resources"]; ok2 {
-		return formatResourcesList(resources)
+		return formatResourcesList(withResourceTemplates(resources, mapVal["resourceTemplates"]))
 	}
 
 	if prompts, ok3 := mapVal["prompts"]; ok3 {
 		return formatPromptsList(prompts)
 	}
 
-	if content, ok4 := mapVal["content"]; ok4 {
-		return formatContent(content)
+	_, hasContent := mapVal["content"]
+	if _, hasStructured := mapVal["structuredContent"]; hasContent || hasStructured {
+		return formatCallResult(mapVal)
+	}
+
+	if contents, ok5 := mapVal["contents"].([]any); ok5 {
+		return formatResourceContents(contents)
 	}
 
 	return formatGenericMap(mapVal)
@@ -607,6 +719,31 @@ func wrapText(text string, width int) []string {
 	return lines
 }
 
+// withResourceTemplates returns the resources followed by the resource templates,
+// which are shown with their URI template as URI.
+func withResourceTemplates(resources, templates any) any {
+	resourcesSlice, ok := resources.([]any)
+	templatesSlice, _ := templates.([]any)
+	if !ok || len(templatesSlice) == 0 {
+		return resources
+	}
+
+	merged := append([]any{}, resourcesSlice...)
+	for _, t := range templatesSlice {
+		template, ok1 := t.(map[string]any)
+		if !ok1 {
+			continue
+		}
+		row := make(map[string]any, len(template)+1)
+		for key, value := range template {
+			row[key] = value
+		}
+		row["uri"] = template["uriTemplate"]
+		merged = append(merged, row)
+	}
+	return merged
+}
+
 // formatResourcesList formats a list of resources as a table.
 func formatResourcesList(resources any) (string, error) {
 	resourcesSlice, ok := resources.([]any)
@@ -724,50 +861,6 @@ func formatPromptsList(prompts any) (string, error) {
 	return buf.String(), nil
 }
 
-func formatContent(content any) (string, error) {
-	contentSlice, ok := content.([]any)
-	if !ok {
-		return "", fmt.Errorf("content is not a slice")
-	}
-
-	var buf strings.Builder
-	useColors := isTerminal()
-
-	for _, c := range contentSlice {
-		contentItem, ok1 := c.(map[string]any)
-		if !ok1 {
-			continue
-		}
-
-		contentType, _ := contentItem["type"].(string)
-
-		switch contentType {
-		case "text":
-			text, _ := contentItem["text"].(string)
-			if useColors {
-				buf.WriteString(ColorGray + text + ColorReset)
-			} else {
-				buf.WriteString(text)
-			}
-		case "image":
-			if useColors {
-				buf.WriteString(ColorYellow + "[IMAGE CONTENT]" + ColorReset + "\n")
-			} else {
-				buf.WriteString("[IMAGE CONTENT]\n")
-			}
-		default:
-			if useColors {
-				buf.WriteString(fmt.Sprintf("%s[%s CONTENT]%s\n",
-					ColorYellow, strings.ToUpper(contentType), ColorReset))
-			} else {
-				buf.WriteString(fmt.Sprintf("[%s CONTENT]\n", strings.ToUpper(contentType)))
-			}
-		}
-	}
-
-	return buf.String(), nil
-}
-
 func formatGenericMap(data map[string]any) (string, error) {
 	if len(data) == 0 {
 		return "No data available", nil
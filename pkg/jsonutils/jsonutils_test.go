@@ -180,6 +180,12 @@ func TestParseFormat(t *testing.T) {
 		{FormatPretty, "P"},
 		{FormatTable, "table"},
 		{FormatTable, "T"},
+		{FormatYAML, "yaml"},
+		{FormatYAML, "yml"},
+		{FormatMarkdown, "markdown"},
+		{FormatMarkdown, "MD"},
+		{FormatNDJSON, "ndjson"},
+		{FormatNDJSON, "jsonl"},
 		{FormatTable, "unknown"},
 	}
 
@@ -193,6 +199,180 @@ func TestParseFormat(t *testing.T) {
 	}
 }
 
+func TestFormatYAML(t *testing.T) {
+	type resource struct {
+		URI      string `json:"uri"`
+		MimeType string `json:"mimeType,omitempty"`
+	}
+	data := map[string]any{
+		"resources": []resource{{URI: "file:///a.txt", MimeType: "text/plain"}},
+	}
+
+	output, err := Format(data, "yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "resources:\n  - mimeType: text/plain\n    uri: file:///a.txt"
+	if output != expected {
+		t.Errorf("expected YAML using JSON field names:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestFormatNDJSON(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     any
+		expected string
+	}{
+		{
+			name: "tools list",
+			data: map[string]any{"tools": []any{
+				map[string]any{"name": "a"},
+				map[string]any{"name": "b", "description": "B"},
+			}},
+			expected: `{"name":"a"}` + "\n" + `{"description":"B","name":"b"}`,
+		},
+		{
+			name:     "empty list",
+			data:     map[string]any{"resources": []any{}},
+			expected: "",
+		},
+		{
+			name: "resources and templates",
+			data: map[string]any{
+				"resources":         []any{map[string]any{"uri": "file:///a.txt"}},
+				"resourceTemplates": []any{map[string]any{"uriTemplate": "file:///{path}"}},
+			},
+			expected: `{"uri":"file:///a.txt"}` + "\n" + `{"uriTemplate":"file:///{path}"}`,
+		},
+		{
+			name:     "array",
+			data:     []any{1, "two"},
+			expected: "1\n\"two\"",
+		},
+		{
+			name:     "call result",
+			data:     map[string]any{"content": []any{map[string]any{"type": "text", "text": "a\nb"}}},
+			expected: `{"content":[{"text":"a\nb","type":"text"}]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := Format(tc.data, "ndjson")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tc.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.expected, output)
+			}
+		})
+	}
+}
+
+func TestFormatMarkdown(t *testing.T) {
+	testCases := []struct {
+		data     any
+		name     string
+		expected string
+	}{
+		{
+			name: "tools",
+			data: map[string]any{
+				"tools": []any{
+					map[string]any{
+						"name":        "read_file",
+						"description": "Read a file | fast",
+						"inputSchema": map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"path": map[string]any{"type": "string"},
+							},
+							"required": []any{"path"},
+						},
+					},
+				},
+			},
+			expected: "| Tool | Parameters | Description |\n|---|---|---|\n| `read_file` | `path:str` | Read a file \\| fast |",
+		},
+		{
+			name: "resources",
+			data: map[string]any{
+				"resources": []any{
+					map[string]any{"name": "readme", "uri": "docs://readme", "mimeType": "text/markdown"},
+				},
+			},
+			expected: "| Name | URI | MIME Type | Description |\n|---|---|---|---|\n| readme | `docs://readme` | text/markdown |  |",
+		},
+		{
+			name: "resources and templates",
+			data: map[string]any{
+				"resources": []any{
+					map[string]any{"name": "readme", "uri": "docs://readme", "mimeType": "text/markdown"},
+				},
+				"resourceTemplates": []any{
+					map[string]any{"name": "doc", "uriTemplate": "docs://{name}", "description": "A document"},
+				},
+			},
+			expected: "| Name | URI | MIME Type | Description |\n|---|---|---|---|\n| readme | `docs://readme` | text/markdown |  |\n| doc | `docs://{name}` |  | A document |",
+		},
+		{
+			name: "prompts",
+			data: map[string]any{
+				"prompts": []any{
+					map[string]any{
+						"name":        "review",
+						"description": "Review code",
+						"arguments": []any{
+							map[string]any{"name": "file", "required": true},
+							map[string]any{"name": "focus"},
+						},
+					},
+				},
+			},
+			expected: "| Prompt | Arguments | Description |\n|---|---|---|\n| `review` | `file, [focus]` | Review code |",
+		},
+		{
+			name: "call result",
+			data: map[string]any{
+				"content": []any{
+					map[string]any{"type": "text", "text": "Hello\n"},
+					map[string]any{"type": "text", "text": `{"a":1}`},
+					map[string]any{"type": "image", "mimeType": "image/png", "data": "AAAA"},
+				},
+			},
+			expected: "Hello\n\n```json\n{\n  \"a\": 1\n}\n```\n\n_[image: image/png]_",
+		},
+		{
+			name: "resource contents",
+			data: map[string]any{
+				"contents": []any{
+					map[string]any{"uri": "file:///main.go", "mimeType": "text/x-go", "text": "package main\n"},
+				},
+			},
+			expected: "```go\npackage main\n```",
+		},
+		{
+			name:     "generic map",
+			data:     map[string]any{"name": "server", "version": 2},
+			expected: "| Key | Value |\n|---|---|\n| name | server |\n| version | `2` |",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := Format(tc.data, "markdown")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tc.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.expected, output)
+			}
+		})
+	}
+}
+
 // TestToolsListFormatting tests the man-like formatting for tools list.
 func TestToolsListFormatting(t *testing.T) {
 	tools := []any{
@@ -504,3 +684,57 @@ func TestNormalizeParameterType(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatServerInfo(t *testing.T) {
+	info := map[string]any{
+		"protocolVersion": "2025-06-18",
+		"serverInfo":      map[string]any{"name": "everything", "version": "1.2.0"},
+		"capabilities": map[string]any{
+			"tools":     map[string]any{"listChanged": true},
+			"resources": map[string]any{"subscribe": true, "listChanged": false},
+			"logging":   map[string]any{},
+		},
+		"instructions": "Use the tools.",
+	}
+
+	output, err := Format(info, "table")
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	expected := `Server
+  everything 1.2.0
+  protocol version 2025-06-18
+
+Capabilities
+  logging
+  resources (subscribe)
+  tools (listChanged)
+
+Instructions
+  Use the tools.`
+	if output != expected {
+		t.Errorf("Format() =\n%s\nwant\n%s", output, expected)
+	}
+}
+
+func TestFormatChecks(t *testing.T) {
+	report := map[string]any{
+		"transport": "stdio",
+		"checks": []any{
+			map[string]any{"name": "startup", "status": "ok", "detail": "process started in 1.0ms"},
+			map[string]any{"name": "stdout", "status": "fail", "detail": "1 line besides the JSON-RPC messages:\nStarting", "hint": "use stderr"},
+		},
+	}
+
+	output, err := Format(report, "table")
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	expected := `ok    startup  process started in 1.0ms
+fail  stdout   1 line besides the JSON-RPC messages:
+               Starting
+               hint: use stderr`
+	if output != expected {
+		t.Errorf("Format() =\n%s\nwant\n%s", output, expected)
+	}
+}
@@ -0,0 +1,311 @@
+package jsonutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// formatMarkdown formats the data as markdown, for pasting into documents and
+// pull requests. Tool, resource and prompt lists become tables, and call
+// results become text and fenced code blocks.
+func formatMarkdown(data any) (string, error) {
+	generic, err := toGeneric(data)
+	if err != nil {
+		return "", err
+	}
+
+	mapVal, ok := generic.(map[string]any)
+	if !ok {
+		return fencedJSON(generic)
+	}
+
+	if tools, ok1 := mapVal["tools"].([]any); ok1 {
+		return markdownToolsList(tools), nil
+	}
+
+	if resources, ok2 := withResourceTemplates(mapVal["resources"], mapVal["resourceTemplates"]).([]any); ok2 {
+		return markdownResourcesList(resources), nil
+	}
+
+	if prompts, ok3 := mapVal["prompts"].([]any); ok3 {
+		return markdownPromptsList(prompts), nil
+	}
+
+	if content, ok4 := mapVal["content"].([]any); ok4 {
+		return markdownContent(content), nil
+	}
+
+	if contents, ok5 := mapVal["contents"].([]any); ok5 {
+		return markdownContent(contents), nil
+	}
+
+	if messages, ok6 := mapVal["messages"].([]any); ok6 {
+		return markdownMessages(messages), nil
+	}
+
+	return markdownGenericMap(mapVal), nil
+}
+
+// MarkdownTable renders a markdown table with the given header and rows.
+// Pipes and line breaks in cells are escaped.
+func MarkdownTable(header []string, rows [][]string) string {
+	var buf strings.Builder
+
+	writeRow := func(cells []string) {
+		buf.WriteString("|")
+		for _, cell := range cells {
+			buf.WriteString(" " + escapeMarkdownCell(cell) + " |")
+		}
+		buf.WriteString("\n")
+	}
+
+	writeRow(header)
+	separators := make([]string, len(header))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	buf.WriteString("|" + strings.Join(separators, "|") + "|\n")
+	for _, row := range rows {
+		writeRow(row)
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// escapeMarkdownCell makes a value safe to use inside a markdown table cell.
+func escapeMarkdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	value = strings.ReplaceAll(value, "\r\n", "<br>")
+	return strings.ReplaceAll(value, "\n", "<br>")
+}
+
+// markdownToolsList formats a list of tools as a markdown table.
+func markdownToolsList(tools []any) string {
+	if len(tools) == 0 {
+		return "No tools available"
+	}
+
+	rows := make([][]string, 0, len(tools))
+	for _, t := range tools {
+		tool, ok := t.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		name, _ := tool["name"].(string)
+		desc, _ := tool["description"].(string)
+
+		params := ""
+		if inputSchema, hasSchema := tool["inputSchema"]; hasSchema && inputSchema != nil {
+			params = formatParameters(inputSchema)
+		}
+		if params == "" {
+			if legacy, hasParams := tool["parameters"]; hasParams && legacy != nil {
+				params = formatParameters(legacy)
+			}
+		}
+		if params != "" {
+			params = "`" + params + "`"
+		}
+
+		rows = append(rows, []string{"`" + name + "`", params, desc})
+	}
+
+	return MarkdownTable([]string{"Tool", "Parameters", "Description"}, rows)
+}
+
+// markdownResourcesList formats a list of resources as a markdown table.
+func markdownResourcesList(resources []any) string {
+	if len(resources) == 0 {
+		return "No resources available"
+	}
+
+	rows := make([][]string, 0, len(resources))
+	for _, r := range resources {
+		resource, ok := r.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		name, _ := resource["name"].(string)
+		uri, _ := resource["uri"].(string)
+		mimeType, _ := resource["mimeType"].(string)
+		desc, _ := resource["description"].(string)
+
+		rows = append(rows, []string{name, "`" + uri + "`", mimeType, desc})
+	}
+
+	return MarkdownTable([]string{"Name", "URI", "MIME Type", "Description"}, rows)
+}
+
+// markdownPromptsList formats a list of prompts as a markdown table.
+func markdownPromptsList(prompts []any) string {
+	if len(prompts) == 0 {
+		return "No prompts available"
+	}
+
+	rows := make([][]string, 0, len(prompts))
+	for _, p := range prompts {
+		prompt, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		name, _ := prompt["name"].(string)
+		desc, _ := prompt["description"].(string)
+
+		var arguments []string
+		args, _ := prompt["arguments"].([]any)
+		for _, a := range args {
+			arg, ok1 := a.(map[string]any)
+			if !ok1 {
+				continue
+			}
+			argName, _ := arg["name"].(string)
+			if required, _ := arg["required"].(bool); !required {
+				argName = "[" + argName + "]"
+			}
+			arguments = append(arguments, argName)
+		}
+
+		argStr := ""
+		if len(arguments) > 0 {
+			argStr = "`" + strings.Join(arguments, ", ") + "`"
+		}
+
+		rows = append(rows, []string{"`" + name + "`", argStr, desc})
+	}
+
+	return MarkdownTable([]string{"Prompt", "Arguments", "Description"}, rows)
+}
+
+// markdownContent formats tool call or resource contents. JSON text is shown
+// as a fenced code block, other text as is.
+func markdownContent(content []any) string {
+	blocks := make([]string, 0, len(content))
+	for _, c := range content {
+		item, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		contentType, _ := item["type"].(string)
+		mimeType, _ := item["mimeType"].(string)
+		if resource, isResource := item["resource"].(map[string]any); isResource {
+			// Embedded resources are shown like resource contents
+			item = resource
+			mimeType, _ = item["mimeType"].(string)
+		}
+
+		text, hasText := item["text"].(string)
+		switch {
+		case hasText:
+			blocks = append(blocks, markdownText(text, mimeType))
+		case item["file"] != nil:
+			// Content saved to a file with --output-dir
+			file, _ := item["file"].(string)
+			if contentType == "image" {
+				blocks = append(blocks, fmt.Sprintf("![%s](%s)", mimeType, file))
+			} else {
+				blocks = append(blocks, fmt.Sprintf("[%s](%s)", file, file))
+			}
+		case contentType == "image" || contentType == "audio":
+			blocks = append(blocks, fmt.Sprintf("_[%s: %s]_", contentType, mimeType))
+		case item["blob"] != nil:
+			uri, _ := item["uri"].(string)
+			blocks = append(blocks, fmt.Sprintf("_[blob: %s %s]_", uri, mimeType))
+		default:
+			if contentType == "" {
+				contentType = "unknown"
+			}
+			blocks = append(blocks, fmt.Sprintf("_[%s content]_", contentType))
+		}
+	}
+
+	return strings.Join(blocks, "\n\n")
+}
+
+// markdownText returns text as is, or as a fenced code block when it is JSON
+// or has a MIME type that is not markdown or plain text.
+func markdownText(text, mimeType string) string {
+	trimmed := strings.TrimSpace(text)
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+		var value any
+		_ = json.Unmarshal([]byte(trimmed), &value)
+		pretty, _ := json.MarshalIndent(value, "", "  ")
+		return "```json\n" + string(pretty) + "\n```"
+	}
+
+	switch mimeType {
+	case "", "text/plain", "text/markdown":
+		return strings.TrimRight(text, "\n")
+	}
+
+	language := mimeType[strings.LastIndexAny(mimeType, "/+")+1:]
+	return "```" + strings.TrimPrefix(language, "x-") + "\n" + strings.TrimRight(text, "\n") + "\n```"
+}
+
+// markdownMessages formats the messages of a prompt with their roles as headings.
+func markdownMessages(messages []any) string {
+	blocks := make([]string, 0, len(messages))
+	for _, m := range messages {
+		message, ok := m.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		role, _ := message["role"].(string)
+		var content []any
+		switch c := message["content"].(type) {
+		case []any:
+			content = c
+		case map[string]any:
+			content = []any{c}
+		}
+
+		blocks = append(blocks, "**"+role+"**\n\n"+markdownContent(content))
+	}
+
+	return strings.Join(blocks, "\n\n")
+}
+
+// markdownGenericMap formats any other object as a key/value table.
+func markdownGenericMap(data map[string]any) string {
+	if len(data) == 0 {
+		return "No data available"
+	}
+
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	rows := make([][]string, 0, len(keys))
+	for _, k := range keys {
+		var value string
+		switch v := data[k].(type) {
+		case string:
+			value = v
+		case nil:
+			value = ""
+		default:
+			jsonBytes, _ := json.Marshal(v)
+			value = "`" + string(jsonBytes) + "`"
+		}
+		rows = append(rows, []string{k, value})
+	}
+
+	return MarkdownTable([]string{"Key", "Value"}, rows)
+}
+
+// fencedJSON returns data as a pretty printed JSON code block.
+func fencedJSON(data any) (string, error) {
+	output, err := formatJSON(data, true)
+	if err != nil {
+		return "", err
+	}
+	return "```json\n" + output + "\n```", nil
+}
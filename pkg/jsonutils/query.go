@@ -0,0 +1,2052 @@
+package jsonutils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Query runs a jq-style expression against data and returns every value it
+// produces. The data is converted to its JSON form first, so fields are
+// accessed by their JSON names.
+//
+// The supported subset of jq covers paths (.a.b, .[0], .[], .[1:3], ..),
+// pipes and commas, array and object construction, string interpolation,
+// arithmetic and comparison operators, and/or/not, the alternative operator
+// //, if-then-else, the optional operator ?, the formats @csv, @tsv, @json,
+// @base64, @sh and @text, and these functions: length, keys, values, has,
+// map, map_values, select, empty, not, type, tostring, tonumber, tojson,
+// fromjson, ascii_downcase, ascii_upcase, startswith, endswith, ltrimstr,
+// rtrimstr, contains, test, split, join, first, last, limit, range, add, any,
+// all, min, max, sort, sort_by, group_by, unique, unique_by, reverse, flatten,
+// to_entries, from_entries and with_entries. Variables, reduce, foreach,
+// try-catch, function definitions and assignments are reported as errors.
+func Query(data any, expr string) ([]any, error) {
+	filter, err := parseQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	generic, err := toGeneric(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter(generic)
+}
+
+// FormatQuery runs a query against data and formats every result on its own
+// line. Strings are printed as is, like jq -r, and other values in the given
+// output format.
+func FormatQuery(data any, expr, format string) (string, error) {
+	results, err := Query(data, expr)
+	if err != nil {
+		return "", err
+	}
+
+	lines := make([]string, 0, len(results))
+	for _, result := range results {
+		switch value := result.(type) {
+		case string:
+			lines = append(lines, value)
+		case map[string]any, []any:
+			output, formatErr := Format(value, format)
+			if formatErr != nil {
+				return "", formatErr
+			}
+			lines = append(lines, output)
+		default:
+			output, formatErr := formatJSON(value, false)
+			if formatErr != nil {
+				return "", formatErr
+			}
+			lines = append(lines, output)
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// filter is a compiled query. It produces zero or more outputs for an input.
+type filter func(input any) ([]any, error)
+
+// tokenKind identifies the kind of a query token.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenDot
+	tokenRecurse
+	tokenField
+	tokenIdent
+	tokenFormat
+	tokenNumber
+	tokenString
+	tokenOp
+)
+
+// token is a single lexical element of a query.
+type token struct {
+	parts  []stringPart // Parts of an interpolated string
+	text   string
+	number float64
+	kind   tokenKind
+}
+
+// stringPart is a literal text or an interpolated \(...) expression of a string.
+type stringPart struct {
+	text   string
+	expr   string
+	isExpr bool
+}
+
+// operators lists the operator tokens, longest first. Assignment operators are
+// only recognized to report that they are not supported.
+var operators = []string{
+	"//=", "|=", "+=", "-=", "*=", "/=", "%=", "==", "!=", "<=", ">=", "//", "|", ",", "(", ")",
+	"[", "]", "{", "}", ":", ";", "?", "<", ">", "+", "-", "*", "/", "%", "=",
+}
+
+// assignmentOperators lists the jq assignment operators, which are not supported.
+var assignmentOperators = map[string]bool{
+	"=": true, "|=": true, "+=": true, "-=": true, "*=": true, "/=": true, "%=": true, "//=": true,
+}
+
+// unsupportedKeywords maps the jq keywords that are not supported to the error
+// reported for them.
+var unsupportedKeywords = map[string]string{
+	"as":      "variable bindings (. as $name) are not supported",
+	"reduce":  "reduce is not supported",
+	"foreach": "foreach is not supported",
+	"def":     "function definitions (def) are not supported",
+	"try":     "try-catch is not supported, use the optional operator ? instead",
+	"catch":   "try-catch is not supported, use the optional operator ? instead",
+	"label":   "label is not supported",
+	"import":  "modules are not supported",
+	"include": "modules are not supported",
+}
+
+// tokenizeQuery splits a query into tokens.
+func tokenizeQuery(expr string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '#':
+			// Comment until the end of the line
+			for i < len(expr) && expr[i] != '\n' {
+				i++
+			}
+		case c == '.':
+			switch {
+			case i+1 < len(expr) && expr[i+1] == '.':
+				tokens = append(tokens, token{kind: tokenRecurse, text: ".."})
+				i += 2
+			case i+1 < len(expr) && isIdentStart(expr[i+1]):
+				j := i + 1
+				for j < len(expr) && isIdentChar(expr[j]) {
+					j++
+				}
+				tokens = append(tokens, token{kind: tokenField, text: expr[i+1 : j]})
+				i = j
+			default:
+				tokens = append(tokens, token{kind: tokenDot, text: "."})
+				i++
+			}
+		case c == '"':
+			parts, end, err := lexString(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, parts: parts})
+			i = end
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(expr) && (expr[j] >= '0' && expr[j] <= '9' || expr[j] == '.' || expr[j] == 'e' || expr[j] == 'E' ||
+				((expr[j] == '+' || expr[j] == '-') && (expr[j-1] == 'e' || expr[j-1] == 'E'))) {
+				j++
+			}
+			number, err := strconv.ParseFloat(expr[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", expr[i:j])
+			}
+			tokens = append(tokens, token{kind: tokenNumber, number: number, text: expr[i:j]})
+			i = j
+		case c == '@' || c == '$' || isIdentStart(c):
+			j := i + 1
+			for j < len(expr) && isIdentChar(expr[j]) {
+				j++
+			}
+			kind := tokenIdent
+			if c == '@' {
+				kind = tokenFormat
+			}
+			tokens = append(tokens, token{kind: kind, text: expr[i:j]})
+			i = j
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(expr[i:], op) {
+					tokens = append(tokens, token{kind: tokenOp, text: op})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+// lexString reads a string literal starting at the opening quote and returns
+// its parts and the position after the closing quote.
+func lexString(expr string, start int) ([]stringPart, int, error) {
+	var parts []stringPart
+	var text strings.Builder
+	i := start + 1
+	for i < len(expr) {
+		c := expr[i]
+		switch {
+		case c == '"':
+			if text.Len() > 0 || len(parts) == 0 {
+				parts = append(parts, stringPart{text: text.String()})
+			}
+			return parts, i + 1, nil
+		case c == '\\' && i+1 < len(expr) && expr[i+1] == '(':
+			// String interpolation, find the matching parenthesis
+			depth := 1
+			j := i + 2
+			for j < len(expr) && depth > 0 {
+				switch expr[j] {
+				case '(':
+					depth++
+				case ')':
+					depth--
+				case '"':
+					_, end, err := lexString(expr, j)
+					if err != nil {
+						return nil, 0, err
+					}
+					j = end - 1
+				}
+				j++
+			}
+			if depth > 0 {
+				return nil, 0, fmt.Errorf("unterminated string interpolation")
+			}
+			if text.Len() > 0 {
+				parts = append(parts, stringPart{text: text.String()})
+				text.Reset()
+			}
+			parts = append(parts, stringPart{expr: expr[i+2 : j-1], isExpr: true})
+			i = j
+		case c == '\\' && i+1 < len(expr):
+			end := i + 2
+			if expr[i+1] == 'u' {
+				end = i + 6
+			}
+			if end > len(expr) {
+				return nil, 0, fmt.Errorf("invalid escape in string")
+			}
+			unquoted, err := strconv.Unquote(`"` + expr[i:end] + `"`)
+			if err != nil {
+				return nil, 0, fmt.Errorf("invalid escape %s in string", expr[i:end])
+			}
+			text.WriteString(unquoted)
+			i = end
+		default:
+			text.WriteByte(c)
+			i++
+		}
+	}
+	return nil, 0, fmt.Errorf("unterminated string")
+}
+
+// isIdentStart reports whether c can start an identifier.
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isIdentChar reports whether c can appear in an identifier.
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+// queryParser is a recursive descent parser for queries.
+type queryParser struct {
+	tokens []token
+	pos    int
+}
+
+// parseQuery compiles a query expression.
+func parseQuery(expr string) (filter, error) {
+	tokens, err := tokenizeQuery(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+
+	p := &queryParser{tokens: tokens}
+	f, err := p.parsePipe()
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("invalid query: unexpected %q", p.peek().text)
+	}
+	return f, nil
+}
+
+func (p *queryParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// isOp reports whether the next token is the given operator or keyword.
+func (p *queryParser) isOp(text string) bool {
+	t := p.peek()
+	return (t.kind == tokenOp || t.kind == tokenIdent) && t.text == text
+}
+
+func (p *queryParser) expect(text string) error {
+	if !p.isOp(text) {
+		t := p.peek()
+		if t.kind == tokenEOF {
+			return fmt.Errorf("expected %q but the query ended", text)
+		}
+		return fmt.Errorf("expected %q, got %q", text, t.text)
+	}
+	p.next()
+	return nil
+}
+
+// parsePipe parses "a | b".
+func (p *queryParser) parsePipe() (filter, error) {
+	left, err := p.parseComma()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("|") {
+		p.next()
+		right, err := p.parseComma()
+		if err != nil {
+			return nil, err
+		}
+		left = pipeFilter(left, right)
+	}
+	return left, nil
+}
+
+// parseComma parses "a, b".
+func (p *queryParser) parseComma() (filter, error) {
+	left, err := p.parseAlternative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp(",") {
+		p.next()
+		right, err := p.parseAlternative()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(input any) ([]any, error) {
+			leftOut, err := l(input)
+			if err != nil {
+				return nil, err
+			}
+			rightOut, err := r(input)
+			if err != nil {
+				return nil, err
+			}
+			return append(leftOut, rightOut...), nil
+		}
+	}
+	return left, nil
+}
+
+// parseAlternative parses "a // b".
+func (p *queryParser) parseAlternative() (filter, error) {
+	left, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("//") {
+		p.next()
+		right, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(input any) ([]any, error) {
+			leftOut, _ := l(input)
+			var truthy []any
+			for _, v := range leftOut {
+				if isTruthy(v) {
+					truthy = append(truthy, v)
+				}
+			}
+			if len(truthy) > 0 {
+				return truthy, nil
+			}
+			return r(input)
+		}
+	}
+
+	// Assignments and bindings would follow a complete expression
+	if t := p.peek(); t.kind == tokenOp && assignmentOperators[t.text] {
+		return nil, fmt.Errorf("assignment operators such as %s are not supported", t.text)
+	}
+	if p.isOp("as") {
+		return nil, errors.New(unsupportedKeywords["as"])
+	}
+	return left, nil
+}
+
+// parseOr parses "a or b".
+func (p *queryParser) parseOr() (filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryFilter(left, right, func(a, b any) (any, error) {
+			return isTruthy(a) || isTruthy(b), nil
+		})
+	}
+	return left, nil
+}
+
+// parseAnd parses "a and b".
+func (p *queryParser) parseAnd() (filter, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("and") {
+		p.next()
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryFilter(left, right, func(a, b any) (any, error) {
+			return isTruthy(a) && isTruthy(b), nil
+		})
+	}
+	return left, nil
+}
+
+// parseComparison parses "a == b", "a < b" and the other comparisons.
+func (p *queryParser) parseComparison() (filter, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if !p.isOp(op) {
+			continue
+		}
+		p.next()
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		operator := op
+		return binaryFilter(left, right, func(a, b any) (any, error) {
+			cmp := compareValues(a, b)
+			switch operator {
+			case "==":
+				return cmp == 0, nil
+			case "!=":
+				return cmp != 0, nil
+			case "<=":
+				return cmp <= 0, nil
+			case ">=":
+				return cmp >= 0, nil
+			case "<":
+				return cmp < 0, nil
+			default:
+				return cmp > 0, nil
+			}
+		}), nil
+	}
+	return left, nil
+}
+
+// parseAdditive parses "a + b" and "a - b".
+func (p *queryParser) parseAdditive() (filter, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+") || p.isOp("-") {
+		op := p.next().text
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		operator := op
+		left = binaryFilter(left, right, func(a, b any) (any, error) {
+			return arithmetic(operator, a, b)
+		})
+	}
+	return left, nil
+}
+
+// parseMultiplicative parses "a * b", "a / b" and "a % b".
+func (p *queryParser) parseMultiplicative() (filter, error) {
+	left, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*") || p.isOp("/") || p.isOp("%") {
+		op := p.next().text
+		right, err := p.parsePostfix()
+		if err != nil {
+			return nil, err
+		}
+		operator := op
+		left = binaryFilter(left, right, func(a, b any) (any, error) {
+			return arithmetic(operator, a, b)
+		})
+	}
+	return left, nil
+}
+
+// parsePostfix parses a term followed by field accesses, indexes, iterations,
+// slices and optional operators.
+func (p *queryParser) parsePostfix() (filter, error) {
+	term, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch {
+		case p.peek().kind == tokenField:
+			name := p.next().text
+			term = pipeFilter(term, indexFilter(name))
+		case p.peek().kind == tokenDot && p.tokens[p.pos+1].kind == tokenString:
+			p.next()
+			name, err := p.parseTerm()
+			if err != nil {
+				return nil, err
+			}
+			term = pipeFilter(term, dynamicIndexFilter(name))
+		case p.isOp("[") || (p.peek().kind == tokenDot && p.tokens[p.pos+1].kind == tokenOp && p.tokens[p.pos+1].text == "["):
+			if p.peek().kind == tokenDot {
+				p.next()
+			}
+			bracket, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			term = pipeFilter(term, bracket)
+		case p.isOp("?"):
+			p.next()
+			inner := term
+			term = func(input any) ([]any, error) {
+				out, err := inner(input)
+				if err != nil {
+					// Errors are suppressed, the term produces no output
+					return nil, nil
+				}
+				return out, nil
+			}
+		default:
+			return term, nil
+		}
+	}
+}
+
+// parseBracket parses "[]", "[index]" and "[from:to]" after a term.
+func (p *queryParser) parseBracket() (filter, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+
+	if p.isOp("]") {
+		p.next()
+		return iterateFilter, nil
+	}
+
+	var from, to filter
+	var err error
+	if !p.isOp(":") {
+		if from, err = p.parsePipe(); err != nil {
+			return nil, err
+		}
+	}
+	if p.isOp(":") {
+		p.next()
+		if !p.isOp("]") {
+			if to, err = p.parsePipe(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return sliceFilter(from, to), nil
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return dynamicIndexFilter(from), nil
+}
+
+// parseTerm parses a single term.
+func (p *queryParser) parseTerm() (filter, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenDot:
+		p.next()
+		// ."field" and .[...] are handled as postfix operations on the identity
+		if next := p.peek(); next.kind == tokenString {
+			name, err := p.parseTerm()
+			if err != nil {
+				return nil, err
+			}
+			return dynamicIndexFilter(name), nil
+		}
+		if p.isOp("[") {
+			return p.parseBracket()
+		}
+		return identityFilter, nil
+	case t.kind == tokenRecurse:
+		p.next()
+		return recurseFilter, nil
+	case t.kind == tokenField:
+		p.next()
+		return indexFilter(t.text), nil
+	case t.kind == tokenNumber:
+		p.next()
+		return constFilter(t.number), nil
+	case t.kind == tokenString:
+		p.next()
+		return p.stringFilter(t.parts)
+	case t.kind == tokenFormat:
+		p.next()
+		return formatFilter(t.text)
+	case t.kind == tokenOp && t.text == "(":
+		p.next()
+		inner, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	case t.kind == tokenOp && t.text == "[":
+		p.next()
+		if p.isOp("]") {
+			p.next()
+			return constFilter([]any{}), nil
+		}
+		inner, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return func(input any) ([]any, error) {
+			out, err := inner(input)
+			if err != nil {
+				return nil, err
+			}
+			if out == nil {
+				out = []any{}
+			}
+			return []any{out}, nil
+		}, nil
+	case t.kind == tokenOp && t.text == "{":
+		return p.parseObject()
+	case t.kind == tokenOp && t.text == "-":
+		p.next()
+		inner, err := p.parsePostfix()
+		if err != nil {
+			return nil, err
+		}
+		return pipeFilter(inner, func(input any) ([]any, error) {
+			n, ok := input.(float64)
+			if !ok {
+				return nil, fmt.Errorf("cannot negate %s", typeName(input))
+			}
+			return []any{-n}, nil
+		}), nil
+	case t.kind == tokenIdent:
+		return p.parseIdent()
+	case t.kind == tokenEOF:
+		return nil, fmt.Errorf("unexpected end of query")
+	default:
+		return nil, fmt.Errorf("unexpected %q", t.text)
+	}
+}
+
+// parseIdent parses keywords, literals and function calls.
+func (p *queryParser) parseIdent() (filter, error) {
+	name := p.next().text
+	switch name {
+	case "true":
+		return constFilter(true), nil
+	case "false":
+		return constFilter(false), nil
+	case "null":
+		return constFilter(nil), nil
+	case "if":
+		return p.parseIf()
+	}
+	if message, unsupported := unsupportedKeywords[name]; unsupported {
+		return nil, errors.New(message)
+	}
+	if strings.HasPrefix(name, "$") {
+		return nil, fmt.Errorf("variables such as %s are not supported", name)
+	}
+
+	var args []filter
+	if p.isOp("(") {
+		p.next()
+		for {
+			arg, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.isOp(";") {
+				p.next()
+				continue
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	return builtinFilter(name, args)
+}
+
+// parseIf parses "if cond then a [elif cond then b] [else c] end".
+func (p *queryParser) parseIf() (filter, error) {
+	cond, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("then"); err != nil {
+		return nil, err
+	}
+	then, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+
+	var otherwise filter = identityFilter
+	switch {
+	case p.isOp("elif"):
+		p.next()
+		if otherwise, err = p.parseIf(); err != nil {
+			return nil, err
+		}
+		return ifFilter(cond, then, otherwise), nil
+	case p.isOp("else"):
+		p.next()
+		if otherwise, err = p.parsePipe(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect("end"); err != nil {
+		return nil, err
+	}
+	return ifFilter(cond, then, otherwise), nil
+}
+
+// parseObject parses an object construction such as {name, desc: .description}.
+func (p *queryParser) parseObject() (filter, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	type entry struct {
+		key   filter
+		value filter
+	}
+	var entries []entry
+
+	for !p.isOp("}") {
+		var key filter
+		var value filter
+		keyToken := p.peek()
+		switch {
+		case keyToken.kind == tokenIdent:
+			p.next()
+			key = constFilter(keyToken.text)
+			value = indexFilter(keyToken.text)
+		case keyToken.kind == tokenString:
+			p.next()
+			var err error
+			if key, err = p.stringFilter(keyToken.parts); err != nil {
+				return nil, err
+			}
+			value = dynamicIndexFilter(key)
+		case p.isOp("("):
+			p.next()
+			var err error
+			if key, err = p.parsePipe(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("invalid object key %q", keyToken.text)
+		}
+
+		if p.isOp(":") {
+			p.next()
+			var err error
+			if value, err = p.parseAlternative(); err != nil {
+				return nil, err
+			}
+		} else if value == nil {
+			return nil, fmt.Errorf("expected \":\" after object key")
+		}
+		entries = append(entries, entry{key: key, value: value})
+
+		if !p.isOp(",") {
+			break
+		}
+		p.next()
+	}
+	if err := p.expect("}"); err != nil {
+		return nil, err
+	}
+
+	return func(input any) ([]any, error) {
+		objects := []map[string]any{{}}
+		for _, e := range entries {
+			keys, err := e.key(input)
+			if err != nil {
+				return nil, err
+			}
+			values, err := e.value(input)
+			if err != nil {
+				return nil, err
+			}
+
+			var expanded []map[string]any
+			for _, object := range objects {
+				for _, k := range keys {
+					keyStr, ok := k.(string)
+					if !ok {
+						return nil, fmt.Errorf("object keys must be strings, got %s", typeName(k))
+					}
+					for _, v := range values {
+						copied := make(map[string]any, len(object)+1)
+						for ck, cv := range object {
+							copied[ck] = cv
+						}
+						copied[keyStr] = v
+						expanded = append(expanded, copied)
+					}
+				}
+			}
+			objects = expanded
+		}
+
+		out := make([]any, len(objects))
+		for i, object := range objects {
+			out[i] = object
+		}
+		return out, nil
+	}, nil
+}
+
+// stringFilter creates a filter for a string literal, evaluating interpolations.
+func (p *queryParser) stringFilter(parts []stringPart) (filter, error) {
+	if len(parts) == 1 && !parts[0].isExpr {
+		return constFilter(parts[0].text), nil
+	}
+
+	filters := make([]filter, len(parts))
+	for i, part := range parts {
+		if !part.isExpr {
+			filters[i] = constFilter(part.text)
+			continue
+		}
+		f, err := parseQuery(part.expr)
+		if err != nil {
+			return nil, err
+		}
+		filters[i] = pipeFilter(f, func(input any) ([]any, error) {
+			if s, ok := input.(string); ok {
+				return []any{s}, nil
+			}
+			return []any{toJSONString(input)}, nil
+		})
+	}
+
+	return func(input any) ([]any, error) {
+		results := []string{""}
+		for _, f := range filters {
+			out, err := f(input)
+			if err != nil {
+				return nil, err
+			}
+			var next []string
+			for _, prefix := range results {
+				for _, v := range out {
+					next = append(next, prefix+v.(string))
+				}
+			}
+			results = next
+		}
+		out := make([]any, len(results))
+		for i, r := range results {
+			out[i] = r
+		}
+		return out, nil
+	}, nil
+}
+
+// identityFilter returns its input.
+func identityFilter(input any) ([]any, error) {
+	return []any{input}, nil
+}
+
+// constFilter returns a filter that always produces value.
+func constFilter(value any) filter {
+	return func(any) ([]any, error) {
+		return []any{value}, nil
+	}
+}
+
+// pipeFilter feeds every output of left into right.
+func pipeFilter(left, right filter) filter {
+	return func(input any) ([]any, error) {
+		leftOut, err := left(input)
+		if err != nil {
+			return nil, err
+		}
+		var out []any
+		for _, v := range leftOut {
+			rightOut, err := right(v)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, rightOut...)
+		}
+		return out, nil
+	}
+}
+
+// binaryFilter applies op to every combination of the outputs of left and right.
+func binaryFilter(left, right filter, op func(a, b any) (any, error)) filter {
+	return func(input any) ([]any, error) {
+		leftOut, err := left(input)
+		if err != nil {
+			return nil, err
+		}
+		rightOut, err := right(input)
+		if err != nil {
+			return nil, err
+		}
+		var out []any
+		for _, r := range rightOut {
+			for _, l := range leftOut {
+				v, err := op(l, r)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, v)
+			}
+		}
+		return out, nil
+	}
+}
+
+// ifFilter creates an if-then-else filter.
+func ifFilter(cond, then, otherwise filter) filter {
+	return func(input any) ([]any, error) {
+		conds, err := cond(input)
+		if err != nil {
+			return nil, err
+		}
+		var out []any
+		for _, c := range conds {
+			branch := otherwise
+			if isTruthy(c) {
+				branch = then
+			}
+			branchOut, err := branch(input)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, branchOut...)
+		}
+		return out, nil
+	}
+}
+
+// indexFilter returns the field name of an object.
+func indexFilter(name string) filter {
+	return func(input any) ([]any, error) {
+		v, err := indexValue(input, name)
+		if err != nil {
+			return nil, err
+		}
+		return []any{v}, nil
+	}
+}
+
+// dynamicIndexFilter indexes the input with every output of index.
+func dynamicIndexFilter(index filter) filter {
+	return func(input any) ([]any, error) {
+		keys, err := index(input)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]any, 0, len(keys))
+		for _, key := range keys {
+			v, err := indexValue(input, key)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	}
+}
+
+// indexValue returns the element of an array or the field of an object.
+func indexValue(input, key any) (any, error) {
+	switch container := input.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		name, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot index object with %s", typeName(key))
+		}
+		return container[name], nil
+	case []any:
+		n, ok := key.(float64)
+		if !ok {
+			return nil, fmt.Errorf("cannot index array with %s", typeName(key))
+		}
+		i := int(n)
+		if i < 0 {
+			i += len(container)
+		}
+		if i < 0 || i >= len(container) {
+			return nil, nil
+		}
+		return container[i], nil
+	default:
+		return nil, fmt.Errorf("cannot index %s with %s", typeName(input), toJSONString(key))
+	}
+}
+
+// iterateFilter produces every element of an array or value of an object.
+func iterateFilter(input any) ([]any, error) {
+	switch container := input.(type) {
+	case []any:
+		return container, nil
+	case map[string]any:
+		keys := sortedMapKeys(container)
+		out := make([]any, len(keys))
+		for i, k := range keys {
+			out[i] = container[k]
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("cannot iterate over %s", typeName(input))
+	}
+}
+
+// recurseFilter produces the input and every value nested in it.
+func recurseFilter(input any) ([]any, error) {
+	out := []any{input}
+	if _, ok := input.([]any); !ok {
+		if _, isMap := input.(map[string]any); !isMap {
+			return out, nil
+		}
+	}
+	children, _ := iterateFilter(input)
+	for _, child := range children {
+		nested, _ := recurseFilter(child)
+		out = append(out, nested...)
+	}
+	return out, nil
+}
+
+// sliceFilter returns a slice of an array or string.
+func sliceFilter(from, to filter) filter {
+	return func(input any) ([]any, error) {
+		var length int
+		switch v := input.(type) {
+		case nil:
+			return []any{nil}, nil
+		case []any:
+			length = len(v)
+		case string:
+			length = len([]rune(v))
+		default:
+			return nil, fmt.Errorf("cannot slice %s", typeName(input))
+		}
+
+		bound := func(f filter, def int) (int, error) {
+			if f == nil {
+				return def, nil
+			}
+			out, err := f(input)
+			if err != nil || len(out) == 0 {
+				return def, err
+			}
+			n, ok := out[0].(float64)
+			if !ok {
+				return 0, fmt.Errorf("slice indexes must be numbers")
+			}
+			i := int(math.Floor(n))
+			if i < 0 {
+				i += length
+			}
+			return max(0, min(i, length)), nil
+		}
+
+		start, err := bound(from, 0)
+		if err != nil {
+			return nil, err
+		}
+		end, err := bound(to, length)
+		if err != nil {
+			return nil, err
+		}
+		if end < start {
+			end = start
+		}
+
+		if s, ok := input.(string); ok {
+			return []any{string([]rune(s)[start:end])}, nil
+		}
+		return []any{append([]any{}, input.([]any)[start:end]...)}, nil
+	}
+}
+
+// formatFilter creates a filter for @csv, @tsv, @json, @base64, @sh and @text.
+func formatFilter(name string) (filter, error) {
+	switch name {
+	case "@text":
+		return func(input any) ([]any, error) {
+			if s, ok := input.(string); ok {
+				return []any{s}, nil
+			}
+			return []any{toJSONString(input)}, nil
+		}, nil
+	case "@json":
+		return func(input any) ([]any, error) {
+			return []any{toJSONString(input)}, nil
+		}, nil
+	case "@base64":
+		return func(input any) ([]any, error) {
+			s, ok := input.(string)
+			if !ok {
+				s = toJSONString(input)
+			}
+			return []any{base64.StdEncoding.EncodeToString([]byte(s))}, nil
+		}, nil
+	case "@csv", "@tsv", "@sh":
+		return func(input any) ([]any, error) {
+			values, ok := input.([]any)
+			if !ok {
+				if name != "@sh" {
+					return nil, fmt.Errorf("%s requires an array, got %s", name, typeName(input))
+				}
+				values = []any{input}
+			}
+			fields := make([]string, len(values))
+			for i, v := range values {
+				s, isString := v.(string)
+				if !isString {
+					s = toJSONString(v)
+				}
+				switch name {
+				case "@csv":
+					if isString {
+						s = `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+					}
+				case "@tsv":
+					s = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r").Replace(s)
+				case "@sh":
+					if isString {
+						s = "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+					}
+				}
+				fields[i] = s
+			}
+			separator := map[string]string{"@csv": ",", "@tsv": "\t", "@sh": " "}[name]
+			return []any{strings.Join(fields, separator)}, nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %s", name)
+	}
+}
+
+// builtinFilter creates a filter for a builtin function.
+func builtinFilter(name string, args []filter) (filter, error) {
+	arity := map[string]int{
+		"has": 1, "map": 1, "map_values": 1, "select": 1, "startswith": 1, "endswith": 1, "ltrimstr": 1,
+		"rtrimstr": 1, "contains": 1, "test": 1, "split": 1, "join": 1, "sort_by": 1, "group_by": 1,
+		"unique_by": 1, "with_entries": 1, "limit": 2,
+	}
+	if expected, ok := arity[name]; ok && len(args) != expected {
+		return nil, fmt.Errorf("%s requires %d argument(s)", name, expected)
+	}
+
+	// Functions of the input value
+	simple := map[string]func(any) (any, error){
+		"length":         lengthOf,
+		"keys":           keysOf,
+		"not":            func(v any) (any, error) { return !isTruthy(v), nil },
+		"type":           func(v any) (any, error) { return typeName(v), nil },
+		"tostring":       func(v any) (any, error) { return toStringValue(v), nil },
+		"tojson":         func(v any) (any, error) { return toJSONString(v), nil },
+		"fromjson":       fromJSON,
+		"tonumber":       toNumber,
+		"ascii_downcase": stringFunc(strings.ToLower),
+		"ascii_upcase":   stringFunc(strings.ToUpper),
+		"add":            addValues,
+		"any":            func(v any) (any, error) { return anyAll(v, true) },
+		"all":            func(v any) (any, error) { return anyAll(v, false) },
+		"min":            func(v any) (any, error) { return minMax(v, -1) },
+		"max":            func(v any) (any, error) { return minMax(v, 1) },
+		"sort":           sortValues,
+		"unique":         uniqueValues,
+		"reverse":        reverseValues,
+		"flatten":        flattenValues,
+		"to_entries":     toEntries,
+		"from_entries":   fromEntries,
+	}
+	if fn, ok := simple[name]; ok && len(args) == 0 {
+		return valueFilter(fn), nil
+	}
+
+	switch name {
+	case "empty":
+		return func(any) ([]any, error) { return nil, nil }, nil
+	case "values":
+		return func(input any) ([]any, error) {
+			if input == nil {
+				return nil, nil
+			}
+			return []any{input}, nil
+		}, nil
+	case "first", "last":
+		if len(args) == 1 {
+			return func(input any) ([]any, error) {
+				out, err := args[0](input)
+				if err != nil || len(out) == 0 {
+					return nil, err
+				}
+				if name == "first" {
+					return out[:1], nil
+				}
+				return out[len(out)-1:], nil
+			}, nil
+		}
+		index := 0.0
+		if name == "last" {
+			index = -1
+		}
+		return elementFilter(index), nil
+	case "range":
+		if len(args) < 1 || len(args) > 2 {
+			return nil, fmt.Errorf("range requires 1 or 2 arguments")
+		}
+		return func(input any) ([]any, error) {
+			bounds := make([]float64, 0, 2)
+			for _, arg := range args {
+				out, err := arg(input)
+				if err != nil || len(out) == 0 {
+					return nil, err
+				}
+				n, ok := out[0].(float64)
+				if !ok {
+					return nil, fmt.Errorf("range bounds must be numbers")
+				}
+				bounds = append(bounds, n)
+			}
+			from, to := 0.0, bounds[0]
+			if len(bounds) == 2 {
+				from, to = bounds[0], bounds[1]
+			}
+			var out []any
+			for i := from; i < to; i++ {
+				out = append(out, i)
+			}
+			return out, nil
+		}, nil
+	case "select":
+		return func(input any) ([]any, error) {
+			conds, err := args[0](input)
+			if err != nil {
+				return nil, err
+			}
+			var out []any
+			for _, c := range conds {
+				if isTruthy(c) {
+					out = append(out, input)
+				}
+			}
+			return out, nil
+		}, nil
+	case "map":
+		return pipeFilter(iterateFilter, args[0]).collect(), nil
+	case "map_values":
+		return func(input any) ([]any, error) {
+			object, ok := input.(map[string]any)
+			if !ok {
+				return pipeFilter(iterateFilter, args[0]).collect()(input)
+			}
+			out := make(map[string]any, len(object))
+			for k, v := range object {
+				values, err := args[0](v)
+				if err != nil {
+					return nil, err
+				}
+				if len(values) > 0 {
+					out[k] = values[0]
+				}
+			}
+			return []any{out}, nil
+		}, nil
+	case "with_entries":
+		mapEntries := pipeFilter(iterateFilter, args[0]).collect()
+		return pipeFilter(valueFilter(toEntries), pipeFilter(mapEntries, valueFilter(fromEntries))), nil
+	case "limit":
+		return func(input any) ([]any, error) {
+			counts, err := args[0](input)
+			if err != nil || len(counts) == 0 {
+				return nil, err
+			}
+			n, _ := counts[0].(float64)
+			out, err := args[1](input)
+			if err != nil {
+				return nil, err
+			}
+			if int(n) < len(out) {
+				out = out[:max(0, int(n))]
+			}
+			return out, nil
+		}, nil
+	case "sort_by", "group_by", "unique_by":
+		return func(input any) ([]any, error) {
+			return byFunc(name, input, args[0])
+		}, nil
+	}
+
+	// Functions with one argument evaluated against the input
+	withArg := map[string]func(input, arg any) (any, error){
+		"has":        hasKey,
+		"startswith": stringPredicate(strings.HasPrefix),
+		"endswith":   stringPredicate(strings.HasSuffix),
+		"ltrimstr":   trimFunc(strings.TrimPrefix),
+		"rtrimstr":   trimFunc(strings.TrimSuffix),
+		"contains":   func(input, arg any) (any, error) { return containsValue(input, arg), nil },
+		"test":       testRegexp,
+		"split":      splitString,
+		"join":       joinValues,
+	}
+	if fn, ok := withArg[name]; ok {
+		return func(input any) ([]any, error) {
+			argValues, err := args[0](input)
+			if err != nil {
+				return nil, err
+			}
+			out := make([]any, 0, len(argValues))
+			for _, arg := range argValues {
+				v, err := fn(input, arg)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, v)
+			}
+			return out, nil
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown function %s/%d", name, len(args))
+}
+
+// valueFilter creates a filter producing the single value fn returns for its input.
+func valueFilter(fn func(any) (any, error)) filter {
+	return func(input any) ([]any, error) {
+		v, err := fn(input)
+		if err != nil {
+			return nil, err
+		}
+		return []any{v}, nil
+	}
+}
+
+// collect wraps all outputs of f into a single array.
+func (f filter) collect() filter {
+	return func(input any) ([]any, error) {
+		out, err := f(input)
+		if err != nil {
+			return nil, err
+		}
+		if out == nil {
+			out = []any{}
+		}
+		return []any{out}, nil
+	}
+}
+
+// elementFilter returns the element at a fixed array index.
+func elementFilter(index float64) filter {
+	return func(input any) ([]any, error) {
+		v, err := indexValue(input, index)
+		if err != nil {
+			return nil, err
+		}
+		return []any{v}, nil
+	}
+}
+
+// isTruthy reports whether a value counts as true. Only false and null are false.
+func isTruthy(v any) bool {
+	if v == nil {
+		return false
+	}
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	return true
+}
+
+// typeName returns the jq type name of a value.
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return typeArray
+	case map[string]any:
+		return typeObject
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// typeOrder ranks types for sorting: null < false < true < numbers < strings < arrays < objects.
+func typeOrder(v any) int {
+	switch value := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		if value {
+			return 2
+		}
+		return 1
+	case float64:
+		return 3
+	case string:
+		return 4
+	case []any:
+		return 5
+	default:
+		return 6
+	}
+}
+
+// compareValues compares two values in jq order.
+func compareValues(a, b any) int {
+	if ta, tb := typeOrder(a), typeOrder(b); ta != tb {
+		return ta - tb
+	}
+
+	switch av := a.(type) {
+	case float64:
+		bv := b.(float64)
+		switch {
+		case av < bv:
+			return -1
+		case av > bv:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(av, b.(string))
+	case []any:
+		bv := b.([]any)
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			if c := compareValues(av[i], bv[i]); c != 0 {
+				return c
+			}
+		}
+		return len(av) - len(bv)
+	case map[string]any:
+		bv := b.(map[string]any)
+		if c := compareValues(toAnySlice(sortedMapKeys(av)), toAnySlice(sortedMapKeys(bv))); c != 0 {
+			return c
+		}
+		for _, k := range sortedMapKeys(av) {
+			if c := compareValues(av[k], bv[k]); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// arithmetic applies +, -, *, / or % to two values.
+func arithmetic(op string, a, b any) (any, error) {
+	an, aIsNum := a.(float64)
+	bn, bIsNum := b.(float64)
+	if aIsNum && bIsNum {
+		switch op {
+		case "+":
+			return an + bn, nil
+		case "-":
+			return an - bn, nil
+		case "*":
+			return an * bn, nil
+		case "/":
+			if bn == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return an / bn, nil
+		case "%":
+			if int(bn) == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return float64(int(an) % int(bn)), nil
+		}
+	}
+
+	switch op {
+	case "+":
+		if a == nil {
+			return b, nil
+		}
+		if b == nil {
+			return a, nil
+		}
+		switch av := a.(type) {
+		case string:
+			if bv, ok := b.(string); ok {
+				return av + bv, nil
+			}
+		case []any:
+			if bv, ok := b.([]any); ok {
+				return append(append([]any{}, av...), bv...), nil
+			}
+		case map[string]any:
+			if bv, ok := b.(map[string]any); ok {
+				merged := make(map[string]any, len(av)+len(bv))
+				for k, v := range av {
+					merged[k] = v
+				}
+				for k, v := range bv {
+					merged[k] = v
+				}
+				return merged, nil
+			}
+		}
+	case "-":
+		av, aOK := a.([]any)
+		bv, bOK := b.([]any)
+		if aOK && bOK {
+			out := []any{}
+			for _, v := range av {
+				if !containsEqual(bv, v) {
+					out = append(out, v)
+				}
+			}
+			return out, nil
+		}
+	case "/":
+		as, aOK := a.(string)
+		bs, bOK := b.(string)
+		if aOK && bOK {
+			return splitString(as, bs)
+		}
+	}
+
+	return nil, fmt.Errorf("cannot apply %s to %s and %s", op, typeName(a), typeName(b))
+}
+
+// containsEqual reports whether list contains a value equal to v.
+func containsEqual(list []any, v any) bool {
+	for _, item := range list {
+		if compareValues(item, v) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func lengthOf(v any) (any, error) {
+	switch value := v.(type) {
+	case nil:
+		return 0.0, nil
+	case bool:
+		return nil, fmt.Errorf("boolean has no length")
+	case float64:
+		return math.Abs(value), nil
+	case string:
+		return float64(len([]rune(value))), nil
+	case []any:
+		return float64(len(value)), nil
+	case map[string]any:
+		return float64(len(value)), nil
+	}
+	return nil, fmt.Errorf("%s has no length", typeName(v))
+}
+
+func keysOf(v any) (any, error) {
+	switch value := v.(type) {
+	case map[string]any:
+		return toAnySlice(sortedMapKeys(value)), nil
+	case []any:
+		out := make([]any, len(value))
+		for i := range value {
+			out[i] = float64(i)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%s has no keys", typeName(v))
+}
+
+func hasKey(input, key any) (any, error) {
+	switch value := input.(type) {
+	case map[string]any:
+		name, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot check whether object has a key of type %s", typeName(key))
+		}
+		_, exists := value[name]
+		return exists, nil
+	case []any:
+		n, ok := key.(float64)
+		if !ok {
+			return nil, fmt.Errorf("cannot check whether array has a key of type %s", typeName(key))
+		}
+		return n >= 0 && int(n) < len(value), nil
+	}
+	return nil, fmt.Errorf("cannot check whether %s has a key", typeName(input))
+}
+
+func toStringValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return toJSONString(v)
+}
+
+func toJSONString(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+func fromJSON(v any) (any, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot be parsed as JSON", typeName(v))
+	}
+	var out any
+	if err := json.Unmarshal([]byte(s), &out); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return out, nil
+}
+
+func toNumber(v any) (any, error) {
+	switch value := v.(type) {
+	case float64:
+		return value, nil
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %q as a number", value)
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("%s cannot be parsed as a number", typeName(v))
+}
+
+func stringFunc(fn func(string) string) func(any) (any, error) {
+	return func(v any) (any, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a string", typeName(v))
+		}
+		return fn(s), nil
+	}
+}
+
+func stringPredicate(fn func(s, arg string) bool) func(input, arg any) (any, error) {
+	return func(input, arg any) (any, error) {
+		s, ok1 := input.(string)
+		a, ok2 := arg.(string)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("startswith and endswith require strings")
+		}
+		return fn(s, a), nil
+	}
+}
+
+func trimFunc(fn func(s, arg string) string) func(input, arg any) (any, error) {
+	return func(input, arg any) (any, error) {
+		s, ok1 := input.(string)
+		a, ok2 := arg.(string)
+		if !ok1 || !ok2 {
+			return input, nil
+		}
+		return fn(s, a), nil
+	}
+}
+
+func containsValue(input, arg any) bool {
+	switch value := input.(type) {
+	case string:
+		s, ok := arg.(string)
+		return ok && strings.Contains(value, s)
+	case []any:
+		want, ok := arg.([]any)
+		if !ok {
+			return false
+		}
+		for _, w := range want {
+			found := false
+			for _, v := range value {
+				if containsValue(v, w) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		want, ok := arg.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, w := range want {
+			v, exists := value[k]
+			if !exists || !containsValue(v, w) {
+				return false
+			}
+		}
+		return true
+	default:
+		return compareValues(input, arg) == 0
+	}
+}
+
+func testRegexp(input, arg any) (any, error) {
+	pattern, ok := arg.(string)
+	if !ok {
+		return nil, fmt.Errorf("test pattern must be a string, got %s", typeName(arg))
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	s, ok := input.(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot test %s against a pattern", typeName(input))
+	}
+	return re.MatchString(s), nil
+}
+
+func splitString(input, arg any) (any, error) {
+	s, ok1 := input.(string)
+	separator, ok2 := arg.(string)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("split requires strings")
+	}
+	if s == "" {
+		return []any{}, nil
+	}
+	return toAnySlice(strings.Split(s, separator)), nil
+}
+
+func joinValues(input, arg any) (any, error) {
+	values, ok := input.([]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot join %s", typeName(input))
+	}
+	separator, ok := arg.(string)
+	if !ok {
+		return nil, fmt.Errorf("join separator must be a string")
+	}
+	parts := make([]string, len(values))
+	for i, v := range values {
+		switch value := v.(type) {
+		case nil:
+			parts[i] = ""
+		case string:
+			parts[i] = value
+		case float64, bool:
+			parts[i] = toJSONString(value)
+		default:
+			return nil, fmt.Errorf("cannot join %s", typeName(v))
+		}
+	}
+	return strings.Join(parts, separator), nil
+}
+
+func addValues(v any) (any, error) {
+	values, err := iterateFilter(v)
+	if err != nil {
+		return nil, err
+	}
+	var sum any
+	for _, value := range values {
+		if sum, err = arithmetic("+", sum, value); err != nil {
+			return nil, err
+		}
+	}
+	return sum, nil
+}
+
+func anyAll(v any, isAny bool) (any, error) {
+	values, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot check %s", typeName(v))
+	}
+	for _, value := range values {
+		if isTruthy(value) == isAny {
+			return isAny, nil
+		}
+	}
+	return !isAny, nil
+}
+
+func minMax(v any, sign int) (any, error) {
+	values, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot find the minimum or maximum of %s", typeName(v))
+	}
+	var result any
+	for i, value := range values {
+		if i == 0 || compareValues(value, result)*sign > 0 {
+			result = value
+		}
+	}
+	return result, nil
+}
+
+func sortValues(v any) (any, error) {
+	values, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot sort %s", typeName(v))
+	}
+	sorted := append([]any{}, values...)
+	sort.SliceStable(sorted, func(i, j int) bool { return compareValues(sorted[i], sorted[j]) < 0 })
+	return sorted, nil
+}
+
+func uniqueValues(v any) (any, error) {
+	sorted, err := sortValues(v)
+	if err != nil {
+		return nil, err
+	}
+	out := []any{}
+	for _, value := range sorted.([]any) {
+		if len(out) == 0 || compareValues(out[len(out)-1], value) != 0 {
+			out = append(out, value)
+		}
+	}
+	return out, nil
+}
+
+func reverseValues(v any) (any, error) {
+	switch value := v.(type) {
+	case nil:
+		return []any{}, nil
+	case string:
+		runes := []rune(value)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes), nil
+	case []any:
+		out := make([]any, len(value))
+		for i, item := range value {
+			out[len(value)-1-i] = item
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("cannot reverse %s", typeName(v))
+}
+
+func flattenValues(v any) (any, error) {
+	values, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot flatten %s", typeName(v))
+	}
+	out := []any{}
+	for _, value := range values {
+		if nested, isArray := value.([]any); isArray {
+			flat, _ := flattenValues(nested)
+			out = append(out, flat.([]any)...)
+		} else {
+			out = append(out, value)
+		}
+	}
+	return out, nil
+}
+
+func toEntries(v any) (any, error) {
+	object, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot convert %s to entries", typeName(v))
+	}
+	out := make([]any, 0, len(object))
+	for _, k := range sortedMapKeys(object) {
+		out = append(out, map[string]any{"key": k, "value": object[k]})
+	}
+	return out, nil
+}
+
+func fromEntries(v any) (any, error) {
+	entries, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot convert %s from entries", typeName(v))
+	}
+	out := make(map[string]any, len(entries))
+	for _, e := range entries {
+		entry, isMap := e.(map[string]any)
+		if !isMap {
+			return nil, fmt.Errorf("entries must be objects")
+		}
+		var key any
+		for _, name := range []string{"key", "k", "name", "Name", "Key", "K"} {
+			if k, exists := entry[name]; exists && k != nil {
+				key = k
+				break
+			}
+		}
+		value, exists := entry["value"]
+		if !exists {
+			value = entry["v"]
+		}
+		switch k := key.(type) {
+		case string:
+			out[k] = value
+		case float64, bool:
+			out[toJSONString(k)] = value
+		default:
+			return nil, fmt.Errorf("entry keys must be strings")
+		}
+	}
+	return out, nil
+}
+
+// byFunc implements sort_by, group_by and unique_by.
+func byFunc(name string, input any, key filter) ([]any, error) {
+	values, ok := input.([]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot %s %s", name, typeName(input))
+	}
+
+	type keyed struct {
+		value any
+		key   any
+	}
+	items := make([]keyed, len(values))
+	for i, v := range values {
+		keys, err := key(v)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = keyed{value: v, key: toAnySlice(keys)}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return compareValues(items[i].key, items[j].key) < 0 })
+
+	switch name {
+	case "sort_by":
+		out := make([]any, len(items))
+		for i, item := range items {
+			out[i] = item.value
+		}
+		return []any{out}, nil
+	case "group_by":
+		out := []any{}
+		for i, item := range items {
+			if i == 0 || compareValues(items[i-1].key, item.key) != 0 {
+				out = append(out, []any{})
+			}
+			last := len(out) - 1
+			out[last] = append(out[last].([]any), item.value)
+		}
+		return []any{out}, nil
+	default:
+		out := []any{}
+		for i, item := range items {
+			if i == 0 || compareValues(items[i-1].key, item.key) != 0 {
+				out = append(out, item.value)
+			}
+		}
+		return []any{out}, nil
+	}
+}
+
+// sortedMapKeys returns the keys of an object in sorted order.
+func sortedMapKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toAnySlice converts a slice to a slice of any.
+func toAnySlice[T any](values []T) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
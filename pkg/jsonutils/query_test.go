@@ -0,0 +1,484 @@
+package jsonutils
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	data := map[string]any{
+		"tools": []any{
+			map[string]any{"name": "read_file", "description": "Read a file", "inputSchema": map[string]any{"required": []any{"path"}}},
+			map[string]any{"name": "write_file", "description": "Write a file", "inputSchema": map[string]any{"required": []any{"path", "content"}}},
+			map[string]any{"name": "list_dir"},
+		},
+		"content": []any{
+			map[string]any{"type": "text", "text": `{"count": 3}`},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []any
+	}{
+		{name: "identity", query: ".content[0].type", want: []any{"text"}},
+		{name: "iterate", query: ".tools[].name", want: []any{"read_file", "write_file", "list_dir"}},
+		{name: "index from end", query: ".tools[-1].name", want: []any{"list_dir"}},
+		{name: "slice", query: "[.tools[1:][].name]", want: []any{[]any{"write_file", "list_dir"}}},
+		{name: "quoted field", query: `.tools[0]."name"`, want: []any{"read_file"}},
+		{name: "missing field", query: ".tools[2].description", want: []any{nil}},
+		{name: "length", query: ".tools | length", want: []any{float64(3)}},
+		{name: "select", query: `.tools[] | select(.name | startswith("write")) | .name`, want: []any{"write_file"}},
+		{name: "map", query: `.tools | map(.name | ascii_upcase) | join(",")`, want: []any{"READ_FILE,WRITE_FILE,LIST_DIR"}},
+		{name: "alternative", query: `.tools[] | .description // "none"`, want: []any{"Read a file", "Write a file", "none"}},
+		{name: "object construction", query: `.tools[0] | {name, params: (.inputSchema.required | length)}`, want: []any{map[string]any{"name": "read_file", "params": float64(1)}}},
+		{name: "comma", query: ".tools[0].name, .tools[1].name", want: []any{"read_file", "write_file"}},
+		{name: "arithmetic", query: "(1 + 2) * 3 - 4 / 2", want: []any{float64(7)}},
+		{name: "comparison", query: ".tools | length > 2 and true", want: []any{true}},
+		{name: "if", query: `.tools[] | if has("description") then .name else empty end`, want: []any{"read_file", "write_file"}},
+		{name: "interpolation", query: `.tools[0] | "\(.name): \(.description)"`, want: []any{"read_file: Read a file"}},
+		{name: "fromjson", query: ".content[0].text | fromjson | .count", want: []any{float64(3)}},
+		{name: "sort_by", query: "[.tools | sort_by(.name) | .[].name]", want: []any{[]any{"list_dir", "read_file", "write_file"}}},
+		{name: "keys", query: ".tools[0] | keys", want: []any{[]any{"description", "inputSchema", "name"}}},
+		{name: "optional", query: `.tools[].name[0]?`, want: nil},
+		{name: "to_entries", query: `{a: 1} | to_entries | .[0].key`, want: []any{"a"}},
+		{name: "csv", query: `[.tools[0].name, 1] | @csv`, want: []any{`"read_file",1`}},
+		{name: "add", query: `[.tools[].inputSchema.required // [] | length] | add`, want: []any{float64(3)}},
+		{name: "test", query: `[.tools[].name | select(test("^w"))]`, want: []any{[]any{"write_file"}}},
+		{name: "recurse", query: `[.. | .name? // empty]`, want: []any{[]any{"read_file", "write_file", "list_dir"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Query(data, tt.query)
+			if err != nil {
+				t.Fatalf("Query(%q) error = %v", tt.query, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Query(%q) = %#v, want %#v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	data := map[string]any{"tools": []any{"a"}}
+
+	for _, query := range []string{
+		".tools[",
+		".tools | unknown_function",
+		`"unterminated`,
+		".tools | map()",
+		".tools[0].name",
+		"1 / 0",
+	} {
+		if _, err := Query(data, query); err == nil {
+			t.Errorf("Expected error for query %q", query)
+		}
+	}
+}
+
+// queryTest runs a query against a JSON input and compares all its outputs,
+// written as a JSON array, with want.
+type queryTest struct {
+	input string
+	query string
+	want  string
+}
+
+// runQueryTests runs each query test as a subtest named after its query.
+func runQueryTests(t *testing.T, tests []queryTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var input any
+			if tt.input != "" {
+				if err := json.Unmarshal([]byte(tt.input), &input); err != nil {
+					t.Fatalf("invalid input %q: %v", tt.input, err)
+				}
+			}
+
+			got, err := Query(input, tt.query)
+			if err != nil {
+				t.Fatalf("Query(%q) error = %v", tt.query, err)
+			}
+			if got == nil {
+				got = []any{}
+			}
+			gotJSON, _ := json.Marshal(got)
+
+			var want any
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatalf("invalid expected outputs %q: %v", tt.want, err)
+			}
+			wantJSON, _ := json.Marshal(want)
+
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("Query(%s, %q) = %s, want %s", tt.input, tt.query, gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestQueryPaths(t *testing.T) {
+	runQueryTests(t, []queryTest{
+		{input: `{"a": {"b": 1}}`, query: ".", want: `[{"a": {"b": 1}}]`},
+		{input: `{"a": {"b": 1}}`, query: ".a.b", want: `[1]`},
+		{input: `{"a": {"b": 1}}`, query: ".a.c.d", want: `[null]`},
+		{input: `null`, query: ".a[0]", want: `[null]`},
+		{input: `{"a-b": 1}`, query: `."a-b"`, want: `[1]`},
+		{input: `{"a": {"b c": 2}}`, query: `.a."b c"`, want: `[2]`},
+		{input: `{"a": 1}`, query: `.["a"]`, want: `[1]`},
+		{input: `{"k": "a", "a": 3}`, query: `.[.k]`, want: `[3]`},
+		{input: `[10, 20, 30]`, query: ".[0]", want: `[10]`},
+		{input: `[10, 20, 30]`, query: ".[-1]", want: `[30]`},
+		{input: `[10, 20, 30]`, query: ".[5]", want: `[null]`},
+		{input: `[10, 20, 30]`, query: ".[0, 2]", want: `[10, 30]`},
+		{input: `[10, 20, 30]`, query: ".[]", want: `[10, 20, 30]`},
+		{input: `{"b": 2, "a": 1}`, query: ".[]", want: `[1, 2]`},
+		{input: `[[1, 2], [3]]`, query: ".[][]", want: `[1, 2, 3]`},
+		{input: `[{"a": 1}, {"a": 2}]`, query: ".[].a", want: `[1, 2]`},
+		{input: `{"a": [1, 2]}`, query: ".a.[]", want: `[1, 2]`},
+		{input: `[1, 2, 3, 4]`, query: ".[1:3]", want: `[[2, 3]]`},
+		{input: `[1, 2, 3, 4]`, query: ".[:2]", want: `[[1, 2]]`},
+		{input: `[1, 2, 3, 4]`, query: ".[2:]", want: `[[3, 4]]`},
+		{input: `[1, 2, 3, 4]`, query: ".[-2:]", want: `[[3, 4]]`},
+		{input: `[1, 2, 3, 4]`, query: ".[:-1]", want: `[[1, 2, 3]]`},
+		{input: `[1, 2, 3, 4]`, query: ".[3:1]", want: `[[]]`},
+		{input: `[1, 2, 3, 4]`, query: ".[1:10]", want: `[[2, 3, 4]]`},
+		{input: `"héllo"`, query: ".[1:3]", want: `["él"]`},
+		{input: `null`, query: ".[1:3]", want: `[null]`},
+		{input: `{"a": [{"b": 1}, 2]}`, query: "[..]", want: `[[{"a": [{"b": 1}, 2]}, [{"b": 1}, 2], {"b": 1}, 1, 2]]`},
+		{input: `{"a": 1}`, query: ".a?", want: `[1]`},
+		{input: `1`, query: ".a?", want: `[]`},
+		{input: `1`, query: ".[]?", want: `[]`},
+		{input: `[1, [2], {"a": 3}]`, query: ".[] | .[0]?", want: `[2]`},
+		{input: `[{"a": "x"}, "y"]`, query: "[.[] | .a?]", want: `[["x"]]`},
+	})
+}
+
+func TestQueryOperators(t *testing.T) {
+	runQueryTests(t, []queryTest{
+		{query: "1 + 2 * 3", want: `[7]`},
+		{query: "(1 + 2) * 3", want: `[9]`},
+		{query: "10 - 2 - 3", want: `[5]`},
+		{query: "8 / 2 / 2", want: `[2]`},
+		{query: "7 % 3", want: `[1]`},
+		{query: "-1 + 2", want: `[1]`},
+		{query: "1 - -1", want: `[2]`},
+		{input: `{"a": 3}`, query: "-.a", want: `[-3]`},
+		{query: "1.5e1 + 0.5", want: `[15.5]`},
+		{query: `"a" + "b"`, want: `["ab"]`},
+		{query: `[1, 2] + [3]`, want: `[[1, 2, 3]]`},
+		{query: `[1, 2, 3, 2] - [2]`, want: `[[1, 3]]`},
+		{query: `{"a": 1, "b": 1} + {"b": 2}`, want: `[{"a": 1, "b": 2}]`},
+		{query: "null + 1", want: `[1]`},
+		{query: "1 + null", want: `[1]`},
+		{query: `"a,b" / ","`, want: `[["a", "b"]]`},
+		{query: "(1, 2) + (10, 20)", want: `[11, 12, 21, 22]`},
+		{query: "1, 2 | . * 10", want: `[10, 20]`},
+		{query: `"a" + "b" | length`, want: `[2]`},
+		{query: "1 == 1.0", want: `[true]`},
+		{query: `1 != "1"`, want: `[true]`},
+		{query: `[1, 2] == [1, 2]`, want: `[true]`},
+		{query: `{"a": [1]} == {"a": [1]}`, want: `[true]`},
+		{query: "1 < 2, 2 <= 2, 3 > 2, 2 >= 3", want: `[true, true, true, false]`},
+		{query: `null < false, false < true, true < 0, 0 < "a", "a" < [], [] < {}`, want: `[true, true, true, true, true, true]`},
+		{query: `"abc" < "abd"`, want: `[true]`},
+		{query: "1 + 1 == 2 and 3 > 2", want: `[true]`},
+		{query: "true or false and false", want: `[true]`},
+		{query: "(true or false) and false", want: `[false]`},
+		{query: "null and true, 0 and true", want: `[false, true]`},
+		{query: "(true, false) and true", want: `[true, false]`},
+		{query: "true | not", want: `[false]`},
+		{query: "null // 1", want: `[1]`},
+		{query: "false // null // 3", want: `[3]`},
+		{query: "0 // 1", want: `[0]`},
+		{query: "empty // 1", want: `[1]`},
+		{query: "(null, 2, false, 3) // 4", want: `[2, 3]`},
+		{query: "null // 1, 2", want: `[1, 2]`},
+		{input: `{"b": "x"}`, query: ".a // .b | length", want: `[1]`},
+		{input: `1`, query: ".a // 2", want: `[2]`},
+		{query: "if true then 1 else 2 end", want: `[1]`},
+		{query: "if null then 1 else 2 end", want: `[2]`},
+		{query: "if 0 then 1 else 2 end", want: `[1]`},
+		{input: `2`, query: `if . == 1 then "a" elif . == 2 then "b" else "c" end`, want: `["b"]`},
+		{input: `3`, query: `if . == 1 then "a" elif . == 2 then "b" end`, want: `[3]`},
+		{input: `5`, query: "if (true, false) then . else -. end", want: `[5, -5]`},
+		{query: "1 # a comment\n+ 1", want: `[2]`},
+	})
+}
+
+func TestQueryConstruction(t *testing.T) {
+	runQueryTests(t, []queryTest{
+		{query: "[]", want: `[[]]`},
+		{query: "[1, 2]", want: `[[1, 2]]`},
+		{query: "[empty]", want: `[[]]`},
+		{input: `[1, 2]`, query: "[.[] | . * 2]", want: `[[2, 4]]`},
+		{query: "{}", want: `[{}]`},
+		{input: `{"a": 1, "b": 2}`, query: "{a}", want: `[{"a": 1}]`},
+		{input: `{"a": 1}`, query: `{"a", b: 2}`, want: `[{"a": 1, "b": 2}]`},
+		{input: `{"k": "x"}`, query: "{(.k): 1}", want: `[{"x": 1}]`},
+		{input: `{"k": "x"}`, query: `{"\(.k)y": 1}`, want: `[{"xy": 1}]`},
+		{query: "{a: (1, 2)}", want: `[{"a": 1}, {"a": 2}]`},
+		{query: "{a: 1 + 1, b: null // 3}", want: `[{"a": 2, "b": 3}]`},
+		{query: "{if: 1, then: 2}", want: `[{"if": 1, "then": 2}]`},
+		{query: "{a: {b: [1]}}", want: `[{"a": {"b": [1]}}]`},
+		{query: `"plain"`, want: `["plain"]`},
+		{query: `"tab\tquote\"u\u00e9"`, want: `["tab\tquote\"u\u00e9"]`},
+		{input: `{"n": 1}`, query: `"n=\(.n)"`, want: `["n=1"]`},
+		{input: `{"a": [1], "s": "x"}`, query: `"\(.a) \(.s) \(null)"`, want: `["[1] x null"]`},
+		{query: `"\(1, 2)!"`, want: `["1!", "2!"]`},
+		{query: `"\("a" + "(b)")"`, want: `["a(b)"]`},
+		{query: `"\("\("nested")")"`, want: `["nested"]`},
+	})
+}
+
+func TestQueryBuiltins(t *testing.T) {
+	runQueryTests(t, []queryTest{
+		{input: `[1, 2]`, query: "length", want: `[2]`},
+		{input: `{"a": 1}`, query: "length", want: `[1]`},
+		{input: `"héllo"`, query: "length", want: `[5]`},
+		{input: `-3`, query: "length", want: `[3]`},
+		{input: `null`, query: "length", want: `[0]`},
+		{input: `{"b": 1, "a": 2}`, query: "keys", want: `[["a", "b"]]`},
+		{input: `["x", "y"]`, query: "keys", want: `[[0, 1]]`},
+		{input: `{"a": 1}`, query: `has("a"), has("b")`, want: `[true, false]`},
+		{input: `[1]`, query: "has(0), has(1)", want: `[true, false]`},
+		{input: `[1, null, 2]`, query: "[.[] | values]", want: `[[1, 2]]`},
+		{input: `[1, 2, 3]`, query: "map(. + 1)", want: `[[2, 3, 4]]`},
+		{input: `{"a": 1}`, query: "map(. + 1)", want: `[[2]]`},
+		{input: `{"a": 1, "b": 2}`, query: "map_values(. * 10)", want: `[{"a": 10, "b": 20}]`},
+		{input: `{"a": 1, "b": null}`, query: "map_values(values)", want: `[{"a": 1}]`},
+		{input: `[1, 2, 3]`, query: "map(select(. > 1))", want: `[[2, 3]]`},
+		{input: `[1, 2]`, query: ".[] | select(. == 1, . == 1)", want: `[1, 1]`},
+		{input: `[1, "a", null, true, [], {}]`, query: "map(type)", want: `[["number", "string", "null", "boolean", "array", "object"]]`},
+		{input: `[1, "a", [1]]`, query: "map(tostring)", want: `[["1", "a", "[1]"]]`},
+		{input: `["12", 3, " 4.5 "]`, query: "map(tonumber)", want: `[[12, 3, 4.5]]`},
+		{input: `{"a": [1, "x"]}`, query: "tojson", want: `["{\"a\":[1,\"x\"]}"]`},
+		{input: `"{\"a\": 1}"`, query: "fromjson.a", want: `[1]`},
+		{input: `"MiXed"`, query: "ascii_downcase, ascii_upcase", want: `["mixed", "MIXED"]`},
+		{input: `"foobar"`, query: `startswith("foo"), endswith("foo")`, want: `[true, false]`},
+		{input: `"foobar"`, query: `ltrimstr("foo"), rtrimstr("bar"), ltrimstr("x")`, want: `["bar", "foo", "foobar"]`},
+		{input: `1`, query: `ltrimstr("x")`, want: `[1]`},
+		{input: `"foobar"`, query: `contains("oba")`, want: `[true]`},
+		{input: `[1, [2, 3], "abc"]`, query: `contains([[2], "b"])`, want: `[true]`},
+		{input: `{"a": {"b": 1, "c": 2}}`, query: `contains({"a": {"b": 1}}), contains({"d": 1})`, want: `[true, false]`},
+		{input: `"abc"`, query: `test("^a.c$"), test("d")`, want: `[true, false]`},
+		{input: `"a,b,,c"`, query: `split(",")`, want: `[["a", "b", "", "c"]]`},
+		{input: `""`, query: `split(",")`, want: `[[]]`},
+		{input: `["a", 1, null, true]`, query: `join("-")`, want: `["a-1--true"]`},
+		{input: `[]`, query: `join("-")`, want: `[""]`},
+		{input: `[3, 1, 2]`, query: "first, last", want: `[3, 2]`},
+		{input: `[]`, query: "first, last", want: `[null, null]`},
+		{query: "first(empty)", want: `[]`},
+		{query: "first(1, 2), last(1, 2)", want: `[1, 2]`},
+		{query: "[limit(2; 1, 2, 3)], [limit(0; 1, 2)]", want: `[[1, 2], []]`},
+		{query: "[range(3)], [range(2; 5)], [range(0)]", want: `[[0, 1, 2], [2, 3, 4], []]`},
+		{input: `[1, 2, 3]`, query: "add", want: `[6]`},
+		{input: `["a", "b"]`, query: "add", want: `["ab"]`},
+		{input: `[[1], [2]]`, query: "add", want: `[[1, 2]]`},
+		{input: `{"a": 1, "b": 2}`, query: "add", want: `[3]`},
+		{input: `[]`, query: "add", want: `[null]`},
+		{input: `[false, 1]`, query: "any, all", want: `[true, false]`},
+		{input: `[]`, query: "any, all", want: `[false, true]`},
+		{input: `[3, 1, 2]`, query: "min, max", want: `[1, 3]`},
+		{input: `[]`, query: "min", want: `[null]`},
+		{input: `[3, "a", null, true, false, [1], {}, 1]`, query: "sort", want: `[[null, false, true, 1, 3, "a", [1], {}]]`},
+		{input: `[{"n": "b", "v": 1}, {"n": "a", "v": 2}, {"n": "b", "v": 0}]`, query: "sort_by(.n) | map(.v)", want: `[[2, 1, 0]]`},
+		{input: `[{"n": "b", "v": 1}, {"n": "a", "v": 2}, {"n": "b", "v": 0}]`, query: "group_by(.n) | map(map(.v))", want: `[[[2], [1, 0]]]`},
+		{input: `[{"n": "b", "v": 1}, {"n": "a", "v": 2}, {"n": "b", "v": 0}]`, query: "unique_by(.n) | map(.v)", want: `[[2, 1]]`},
+		{input: `[2, 1, 2, 1]`, query: "unique", want: `[[1, 2]]`},
+		{input: `[1, 2, 3]`, query: "reverse", want: `[[3, 2, 1]]`},
+		{input: `"abc"`, query: "reverse", want: `["cba"]`},
+		{input: `null`, query: "reverse", want: `[[]]`},
+		{input: `[1, [2, [3, []]]]`, query: "flatten", want: `[[1, 2, 3]]`},
+		{input: `{"b": 2, "a": 1}`, query: "to_entries", want: `[[{"key": "a", "value": 1}, {"key": "b", "value": 2}]]`},
+		{input: `[{"key": "a", "value": 1}, {"k": "b", "v": 2}, {"name": 3, "value": 4}]`, query: "from_entries", want: `[{"a": 1, "b": 2, "3": 4}]`},
+		{input: `{"a": 1, "b": 2}`, query: "with_entries(select(.value > 1))", want: `[{"b": 2}]`},
+		{input: `{"a": 1}`, query: "with_entries({key: (.key | ascii_upcase), value})", want: `[{"A": 1}]`},
+		{query: "empty", want: `[]`},
+		{query: "[1, empty, 2]", want: `[[1, 2]]`},
+	})
+}
+
+func TestQueryFormats(t *testing.T) {
+	runQueryTests(t, []queryTest{
+		{input: `["a", "b\"c", 1, null, true]`, query: "@csv", want: `["\"a\",\"b\"\"c\",1,null,true"]`},
+		{input: `["a\tb", "c\nd", "e\\f", 1]`, query: "@tsv", want: `["a\\tb\tc\\nd\te\\\\f\t1"]`},
+		{input: `["it's", 1]`, query: "@sh", want: `["'it'\\''s' 1"]`},
+		{input: `"a b"`, query: "@sh", want: `["'a b'"]`},
+		{input: `{"a": 1}`, query: "@json", want: `["{\"a\":1}"]`},
+		{input: `"hi"`, query: "@json", want: `["\"hi\""]`},
+		{input: `"hi"`, query: "@text", want: `["hi"]`},
+		{input: `[1]`, query: "@text", want: `["[1]"]`},
+		{input: `"hello"`, query: "@base64", want: `["aGVsbG8="]`},
+		{input: `[1]`, query: "@base64", want: `["WzFd"]`},
+	})
+}
+
+func TestQueryErrorMessages(t *testing.T) {
+	tests := []struct {
+		input string
+		query string
+		want  string
+	}{
+		// Syntax errors
+		{query: ".a[", want: "invalid query: unexpected end of query"},
+		{query: "(1", want: `invalid query: expected ")" but the query ended`},
+		{query: "[1, 2", want: `invalid query: expected "]" but the query ended`},
+		{query: "if . then 1", want: `invalid query: expected "end" but the query ended`},
+		{query: "if . 1 end", want: `invalid query: expected "then", got "1"`},
+		{query: "{a: }", want: `invalid query: unexpected "}"`},
+		{query: "{1: 2}", want: `invalid query: invalid object key "1"`},
+		{query: "{(1)}", want: `invalid query: expected ":" after object key`},
+		{query: "1 2", want: `invalid query: unexpected "2"`},
+		{query: "1 < 2 < 3", want: `invalid query: unexpected "<"`},
+		{query: ".a &", want: "invalid query: unexpected character '&' at position 3"},
+		{query: `"unterminated`, want: "invalid query: unterminated string"},
+		{query: `"\(1`, want: "invalid query: unterminated string interpolation"},
+		{query: `"\q"`, want: `invalid query: invalid escape \q in string`},
+		{query: "1e", want: `invalid query: invalid number "1e"`},
+		{query: "@base32", want: "invalid query: unknown format @base32"},
+		{query: "unknown", want: "invalid query: unknown function unknown/0"},
+		{query: "map()", want: "invalid query: unexpected \")\""},
+		{query: "map(1; 2)", want: "invalid query: map requires 1 argument(s)"},
+		{query: "limit(1)", want: "invalid query: limit requires 2 argument(s)"},
+		{query: "range(1; 2; 3)", want: "invalid query: range requires 1 or 2 arguments"},
+		{query: "flatten(1)", want: "invalid query: unknown function flatten/1"},
+
+		// Unsupported constructs
+		{query: ". as $x | $x", want: "invalid query: variable bindings (. as $name) are not supported"},
+		{query: "[.[] as $x | $x]", want: "invalid query: variable bindings (. as $name) are not supported"},
+		{query: "$ENV", want: "invalid query: variables such as $ENV are not supported"},
+		{query: "reduce .[] as $x (0; . + $x)", want: "invalid query: reduce is not supported"},
+		{query: "foreach .[] as $x (0; 1)", want: "invalid query: foreach is not supported"},
+		{query: "def f: .; f", want: "invalid query: function definitions (def) are not supported"},
+		{query: "try error", want: "invalid query: try-catch is not supported, use the optional operator ? instead"},
+		{query: "label $out | 1", want: "invalid query: label is not supported"},
+		{query: `import "a" as a; .`, want: "invalid query: modules are not supported"},
+		{query: ".a = 1", want: "invalid query: assignment operators such as = are not supported"},
+		{query: ".a |= 1", want: "invalid query: assignment operators such as |= are not supported"},
+		{query: "(.a += 1)", want: "invalid query: assignment operators such as += are not supported"},
+		{query: ".a //= 1", want: "invalid query: assignment operators such as //= are not supported"},
+
+		// Runtime errors
+		{input: `1`, query: ".a", want: `cannot index number with "a"`},
+		{input: `[1]`, query: `.["a"]`, want: "cannot index array with string"},
+		{input: `{}`, query: ".[0]", want: "cannot index object with number"},
+		{input: `1`, query: ".[]", want: "cannot iterate over number"},
+		{input: `1`, query: ".[1:2]", want: "cannot slice number"},
+		{input: `[1]`, query: `.[:"a"]`, want: "slice indexes must be numbers"},
+		{query: `"a" - 1`, want: "cannot apply - to string and number"},
+		{query: `{} * 2`, want: "cannot apply * to object and number"},
+		{query: "1 / 0", want: "division by zero"},
+		{query: "5 % 0", want: "division by zero"},
+		{query: `-"a"`, want: "cannot negate string"},
+		{query: "true | length", want: "boolean has no length"},
+		{query: "1 | keys", want: "number has no keys"},
+		{query: `"a" | has("a")`, want: "cannot check whether string has a key"},
+		{query: `{} | has(0)`, want: "cannot check whether object has a key of type number"},
+		{query: `"x" | tonumber`, want: `cannot parse "x" as a number`},
+		{query: "[] | tonumber", want: "array cannot be parsed as a number"},
+		{query: `"{" | fromjson`, want: "invalid JSON"},
+		{query: "1 | fromjson", want: "number cannot be parsed as JSON"},
+		{query: "1 | ascii_upcase", want: "number is not a string"},
+		{query: `1 | startswith("a")`, want: "startswith and endswith require strings"},
+		{query: `"abc" | test("[")`, want: "invalid pattern: error parsing regexp: missing closing ]: `[`"},
+		{query: `{} | test("[")`, want: "invalid pattern: error parsing regexp: missing closing ]: `[`"},
+		{query: `{} | test("a")`, want: "cannot test object against a pattern"},
+		{query: `"a" | test(1)`, want: "test pattern must be a string, got number"},
+		{query: "1 | split(1)", want: "split requires strings"},
+		{query: `{} | join(",")`, want: "cannot join object"},
+		{query: `[[1]] | join(",")`, want: "cannot join array"},
+		{query: `[1] | join(1)`, want: "join separator must be a string"},
+		{query: `range("a")`, want: "range bounds must be numbers"},
+		{query: `{(1): 2}`, want: "object keys must be strings, got number"},
+		{query: "{} | @csv", want: "@csv requires an array, got object"},
+		{query: "1 | sort", want: "cannot sort number"},
+		{query: "1 | sort_by(.)", want: "cannot sort_by number"},
+		{query: "[1] | to_entries", want: "cannot convert array to entries"},
+		{query: "[1] | from_entries", want: "entries must be objects"},
+		{query: `[{"key": null}] | from_entries`, want: "entry keys must be strings"},
+		{query: "1 | any", want: "cannot check number"},
+		{query: "1 | min", want: "cannot find the minimum or maximum of number"},
+		{query: "1 | flatten", want: "cannot flatten number"},
+		{query: "1 | reverse", want: "cannot reverse number"},
+		{query: `1 | select(error)`, want: "unknown function error/0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var input any
+			if tt.input != "" {
+				if err := json.Unmarshal([]byte(tt.input), &input); err != nil {
+					t.Fatalf("invalid input %q: %v", tt.input, err)
+				}
+			}
+			_, err := Query(input, tt.query)
+			if err == nil {
+				t.Fatalf("Query(%q) succeeded, want error %q", tt.query, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Query(%q) error = %q, want %q", tt.query, err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatQuery(t *testing.T) {
+	data := map[string]any{
+		"tools": []any{
+			map[string]any{"name": "read_file", "description": "Read a file"},
+			map[string]any{"name": "write_file", "description": "Write a file"},
+		},
+	}
+
+	output, err := FormatQuery(data, ".tools[].name", "table")
+	if err != nil {
+		t.Fatalf("FormatQuery() error = %v", err)
+	}
+	if output != "read_file\nwrite_file" {
+		t.Errorf("Expected raw strings one per line, got %q", output)
+	}
+
+	output, err = FormatQuery(data, "{count: (.tools | length)}", "json")
+	if err != nil {
+		t.Fatalf("FormatQuery() error = %v", err)
+	}
+	if output != `{"count":2}` {
+		t.Errorf("Expected compact JSON object, got %q", output)
+	}
+}
+
+func TestExecuteTemplate(t *testing.T) {
+	data := map[string]any{
+		"tools": []any{
+			map[string]any{"name": "read_file", "description": "Read a file"},
+			map[string]any{"name": "list_dir"},
+		},
+	}
+
+	output, err := ExecuteTemplate(data, `{{range .tools}}{{.name}}: {{default "-" .description}}{{"\n"}}{{end}}`)
+	if err != nil {
+		t.Fatalf("ExecuteTemplate() error = %v", err)
+	}
+	if output != "read_file: Read a file\nlist_dir: -\n" {
+		t.Errorf("Unexpected template output %q", output)
+	}
+
+	output, err = ExecuteTemplate(data, `{{query ".tools | length" . }} {{json (index .tools 1)}}`)
+	if err != nil {
+		t.Fatalf("ExecuteTemplate() error = %v", err)
+	}
+	if output != `2 {"name":"list_dir"}` {
+		t.Errorf("Unexpected template output %q", output)
+	}
+
+	if _, err := ExecuteTemplate(data, "{{.tools"); err == nil {
+		t.Error("Expected error for invalid template")
+	}
+}
@@ -0,0 +1,66 @@
+package jsonutils
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the helper functions available in output templates.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		return formatJSON(v, false)
+	},
+	"pretty": func(v any) (string, error) {
+		return formatJSON(v, true)
+	},
+	"yaml": func(v any) (string, error) {
+		return formatYAML(v)
+	},
+	"join": func(separator string, values []any) string {
+		parts := make([]string, len(values))
+		for i, v := range values {
+			parts[i] = toStringValue(v)
+		}
+		return strings.Join(parts, separator)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"default": func(fallback, v any) any {
+		if v == nil || v == "" {
+			return fallback
+		}
+		return v
+	},
+	"query": func(expr string, data any) (any, error) {
+		results, err := Query(data, expr)
+		if err != nil || len(results) == 0 {
+			return nil, err
+		}
+		return results[0], nil
+	},
+}
+
+// ExecuteTemplate renders data with a Go text/template. The data is converted
+// to its JSON form first, so fields are accessed by their JSON names, as in
+// {{range .tools}}{{.name}}{{"\n"}}{{end}}. Besides the builtin functions,
+// templates can use json, pretty, yaml, join, upper, lower, trim, default and
+// query, which returns the first result of a jq-style expression.
+func ExecuteTemplate(data any, text string) (string, error) {
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
+	}
+
+	generic, err := toGeneric(data)
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, generic); err != nil {
+		return "", fmt.Errorf("error executing template: %w", err)
+	}
+	return buf.String(), nil
+}
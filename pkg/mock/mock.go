@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/f/mcptools/pkg/protocol"
 )
 
 // Tool represents a mock tool in the MCP protocol.
@@ -186,6 +188,8 @@ func (s *Server) Start() error {
 		switch request.Method {
 		case "initialize":
 			response = s.handleInitialize(request.Params)
+		case "ping":
+			response = map[string]any{}
 		case "tools/list":
 			response = s.handleToolsList()
 		case "tools/call":
@@ -223,11 +227,10 @@ func (s *Server) handleInitialize(params map[string]any) map[string]any {
 		fmt.Fprintf(os.Stderr, "Client initialized: %s v%s\n", clientName, clientVersion)
 	}
 
-	// Extract protocol version from params, defaulting to latest if not provided
-	protocolVersion := "2024-11-05"
-	if version, ok := params["protocolVersion"].(string); ok {
-		protocolVersion = version
-	}
+	// The requested protocol version is used when it is supported, and the latest
+	// version otherwise, for the client to decide whether it supports it
+	requestedVersion, _ := params["protocolVersion"].(string)
+	protocolVersion := protocol.Negotiate(requestedVersion)
 
 	// Return server information and capabilities in the format expected by clients
 	capabilities := map[string]any{
@@ -0,0 +1,30 @@
+// Package protocol holds the versions of the Model Context Protocol that mcptools
+// supports, and their negotiation.
+package protocol
+
+import "slices"
+
+// Latest is the latest protocol version supported by mcptools.
+const Latest = "2025-06-18"
+
+// VersionHeader is the HTTP header with the negotiated protocol version, which
+// streamable HTTP clients send from version 2025-06-18 on.
+const VersionHeader = "MCP-Protocol-Version"
+
+// Versions are the protocol versions supported by mcptools, the latest first.
+var Versions = []string{Latest, "2025-03-26", "2024-11-05"}
+
+// Supported reports whether mcptools supports the protocol version.
+func Supported(version string) bool {
+	return slices.Contains(Versions, version)
+}
+
+// Negotiate returns the version a server answers to the version requested by a
+// client: the same version when it is supported, and the latest one otherwise. The
+// client then decides whether it supports the answer.
+func Negotiate(requested string) string {
+	if Supported(requested) {
+		return requested
+	}
+	return Latest
+}
@@ -0,0 +1,18 @@
+package protocol
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	tests := map[string]string{
+		"2024-11-05": "2024-11-05",
+		"2025-03-26": "2025-03-26",
+		Latest:       Latest,
+		"2099-01-01": Latest,
+		"":           Latest,
+	}
+	for requested, expected := range tests {
+		if negotiated := Negotiate(requested); negotiated != expected {
+			t.Errorf("Negotiate(%q) = %q, want %q", requested, negotiated, expected)
+		}
+	}
+}
@@ -0,0 +1,955 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/yosida95/uritemplate/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigVersion is the current version of the proxy configuration schema.
+const ConfigVersion = 1
+
+// Config is the typed, versioned proxy configuration file.
+//
+// A configuration file looks like this (JSON files use the same structure):
+//
+//	version: 1
+//	tools:
+//	  add_operation:
+//	    description: Adds a and b
+//	    parameters: "a:int,b:int"
+//	    script: ./add.sh
+//	resources:
+//	  readme:
+//	    uri: docs://readme
+//	    file: ./README.md
+//	prompts:
+//	  review:
+//	    file: ./prompts/review.md
+//	openapi:
+//	  petstore:
+//	    spec: ./petstore.yaml
+//	    baseUrl: https://petstore.example.com/v1
+//	wrap:
+//	  git:
+//	    binary: git
+//	    subcommands:
+//	      status:
+//	        flags:
+//	          - flag: --short
+//	            type: bool
+type Config struct {
+	Tools     map[string]ToolConfig     `json:"tools,omitempty" yaml:"tools,omitempty"`
+	Resources map[string]ResourceConfig `json:"resources,omitempty" yaml:"resources,omitempty"`
+	Prompts   map[string]PromptConfig   `json:"prompts,omitempty" yaml:"prompts,omitempty"`
+	OpenAPI   map[string]OpenAPIConfig  `json:"openapi,omitempty" yaml:"openapi,omitempty"`
+	Wrap      map[string]WrapConfig     `json:"wrap,omitempty" yaml:"wrap,omitempty"`
+	// baseDir is the directory relative paths in the configuration are resolved against.
+	baseDir string
+	Version int `json:"version" yaml:"version"`
+}
+
+// ToolConfig describes a single tool in the proxy configuration.
+type ToolConfig struct {
+	Description string        `json:"description,omitempty" yaml:"description,omitempty"`
+	Script      string        `json:"script,omitempty" yaml:"script,omitempty"`
+	Command     string        `json:"command,omitempty" yaml:"command,omitempty"`
+	Parameters  ParameterList `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// ResourceConfig describes a resource backed by a file, a glob of files or the
+// output of a command. A URI containing {variables} makes it a resource template.
+type ResourceConfig struct {
+	URI         string `json:"uri,omitempty" yaml:"uri,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty" yaml:"mimeType,omitempty"`
+	File        string `json:"file,omitempty" yaml:"file,omitempty"`
+	Glob        string `json:"glob,omitempty" yaml:"glob,omitempty"`
+	Command     string `json:"command,omitempty" yaml:"command,omitempty"`
+}
+
+// PromptConfig describes a prompt template stored in a markdown file with front matter.
+type PromptConfig struct {
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	File        string `json:"file" yaml:"file"`
+}
+
+// ParameterList is a list of tool parameters. In configuration files it can be
+// written either as a "name:type,[name:type]" string or as a list of objects.
+type ParameterList []Parameter
+
+// UnmarshalYAML decodes a parameter list from either its string or list form.
+func (p *ParameterList) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		params, err := ParseParameters(node.Value)
+		if err != nil {
+			return err
+		}
+		*p = params
+		return nil
+	case yaml.SequenceNode:
+		params := make(ParameterList, 0, len(node.Content))
+		for _, item := range node.Content {
+			var raw struct {
+				Required    *bool  `yaml:"required"`
+				Name        string `yaml:"name"`
+				Type        string `yaml:"type"`
+				Description string `yaml:"description"`
+			}
+			if err := item.Decode(&raw); err != nil {
+				return err
+			}
+			params = append(params, Parameter{
+				Name:        raw.Name,
+				Type:        jsonutils.NormalizeParameterType(raw.Type),
+				Description: raw.Description,
+				Required:    raw.Required == nil || *raw.Required,
+			})
+		}
+		*p = params
+		return nil
+	default:
+		return fmt.Errorf("parameters must be a string or a list")
+	}
+}
+
+// ConfigError is a single problem found in a configuration file.
+type ConfigError struct {
+	Message string
+	Line    int
+	Column  int
+}
+
+// Error implements the error interface.
+func (e ConfigError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// ValidationError holds all the problems found in a configuration file.
+type ValidationError struct {
+	Path     string
+	Problems []ConfigError
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		lines = append(lines, fmt.Sprintf("%s:%s", e.Path, problem.Error()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DefaultConfigPath returns the default proxy configuration path ($HOME/.mcpt/proxy_config.json).
+func DefaultConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mcpt", "proxy_config.json"), nil
+}
+
+// NewConfig returns an empty configuration at the current schema version.
+func NewConfig() *Config {
+	return &Config{
+		Version:   ConfigVersion,
+		Tools:     make(map[string]ToolConfig),
+		Resources: make(map[string]ResourceConfig),
+		Prompts:   make(map[string]PromptConfig),
+		OpenAPI:   make(map[string]OpenAPIConfig),
+		Wrap:      make(map[string]WrapConfig),
+	}
+}
+
+// LoadConfig reads and validates the configuration file at path. A missing file
+// results in an empty configuration. Relative paths are resolved against the
+// directory of the configuration file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path is provided by the user on purpose
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			config := NewConfig()
+			config.baseDir = filepath.Dir(path)
+			return config, nil
+		}
+		return nil, fmt.Errorf("error reading config: %w", err)
+	}
+
+	config, problems := parseConfig(data, filepath.Dir(path), false)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Path: path, Problems: problems}
+	}
+
+	return config, nil
+}
+
+// ValidateConfig reads the configuration file at path and returns every problem
+// found in it. Unlike LoadConfig, it also checks that scripts exist and are
+// executable and that referenced files can be read.
+func ValidateConfig(path string) ([]ConfigError, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path is provided by the user on purpose
+	if err != nil {
+		return nil, fmt.Errorf("error reading config: %w", err)
+	}
+
+	_, problems := parseConfig(data, filepath.Dir(path), true)
+	return problems, nil
+}
+
+// ParseConfig parses a YAML or JSON configuration document. Both the versioned
+// format and the legacy "name -> {description, parameters, script, command}" map
+// are accepted. baseDir is used to resolve relative paths.
+func ParseConfig(data []byte, baseDir string) (*Config, []ConfigError) {
+	return parseConfig(data, baseDir, false)
+}
+
+// parseConfig implements ParseConfig, optionally checking that referenced files exist.
+func parseConfig(data []byte, baseDir string, checkFiles bool) (*Config, []ConfigError) {
+	config := NewConfig()
+	config.baseDir = baseDir
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, []ConfigError{yamlError(err)}
+	}
+
+	// Empty document
+	if len(root.Content) == 0 {
+		return config, nil
+	}
+
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return nil, []ConfigError{nodeError(doc, "config must be a mapping")}
+	}
+
+	versionNode := mappingValue(doc, "version")
+	if versionNode == nil {
+		if sectionNode := versionedSection(doc); sectionNode != nil {
+			return nil, []ConfigError{nodeError(sectionNode,
+				fmt.Sprintf("missing version: the %s section needs \"version: %d\" at the top of the file", sectionNode.Value, ConfigVersion))}
+		}
+
+		// Legacy format: the document is a map of tool definitions
+		problems := parseSection(doc, "tool", func(nameNode, defNode *yaml.Node) []ConfigError {
+			tool, toolProblems := parseToolConfig(nameNode, defNode, config, checkFiles)
+			config.Tools[nameNode.Value] = tool
+			return toolProblems
+		})
+		if len(problems) > 0 {
+			return nil, problems
+		}
+		return config, nil
+	}
+
+	var version int
+	if err := versionNode.Decode(&version); err != nil {
+		return nil, []ConfigError{nodeError(versionNode, "version must be an integer")}
+	}
+	if version != ConfigVersion {
+		return nil, []ConfigError{nodeError(versionNode,
+			fmt.Sprintf("unsupported config version %d (supported: %d)", version, ConfigVersion))}
+	}
+
+	problems := checkFields(doc, "", append([]string{"version"}, configSections...)...)
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	if toolsNode := mappingValue(doc, "tools"); toolsNode != nil {
+		problems = append(problems, parseSection(toolsNode, "tool", func(nameNode, defNode *yaml.Node) []ConfigError {
+			tool, toolProblems := parseToolConfig(nameNode, defNode, config, checkFiles)
+			config.Tools[nameNode.Value] = tool
+			return toolProblems
+		})...)
+	}
+
+	if resourcesNode := mappingValue(doc, "resources"); resourcesNode != nil {
+		problems = append(problems, parseSection(resourcesNode, "resource", func(nameNode, defNode *yaml.Node) []ConfigError {
+			resource, resourceProblems := parseResourceConfig(nameNode, defNode, config, checkFiles)
+			config.Resources[nameNode.Value] = resource
+			return resourceProblems
+		})...)
+	}
+
+	if promptsNode := mappingValue(doc, "prompts"); promptsNode != nil {
+		problems = append(problems, parseSection(promptsNode, "prompt", func(nameNode, defNode *yaml.Node) []ConfigError {
+			prompt, promptProblems := parsePromptConfig(nameNode, defNode, config, checkFiles)
+			config.Prompts[nameNode.Value] = prompt
+			return promptProblems
+		})...)
+	}
+
+	if openAPINode := mappingValue(doc, "openapi"); openAPINode != nil {
+		problems = append(problems, parseSection(openAPINode, "openapi", func(nameNode, defNode *yaml.Node) []ConfigError {
+			api, apiProblems := parseOpenAPIConfig(nameNode, defNode, config, checkFiles)
+			config.OpenAPI[nameNode.Value] = api
+			return apiProblems
+		})...)
+	}
+
+	if wrapNode := mappingValue(doc, "wrap"); wrapNode != nil {
+		problems = append(problems, parseSection(wrapNode, "wrap", func(nameNode, defNode *yaml.Node) []ConfigError {
+			wrap, wrapProblems := parseWrapConfig(nameNode, defNode, config, checkFiles)
+			config.Wrap[nameNode.Value] = wrap
+			return wrapProblems
+		})...)
+	}
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	return config, nil
+}
+
+// configSections lists the top-level sections of the versioned format.
+var configSections = []string{"tools", "resources", "prompts", "openapi", "wrap"}
+
+// versionedSection returns the key node of the first section of the versioned
+// format in a document without a version, or nil if it is a legacy document. A
+// section maps names to definitions, whereas a legacy tool that happens to be
+// named like a section maps fields to values.
+func versionedSection(doc *yaml.Node) *yaml.Node {
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		keyNode, valueNode := doc.Content[i], doc.Content[i+1]
+		if !slices.Contains(configSections, keyNode.Value) || valueNode.Kind != yaml.MappingNode || len(valueNode.Content) == 0 {
+			continue
+		}
+		definitions := true
+		for j := 1; j < len(valueNode.Content); j += 2 {
+			if valueNode.Content[j].Kind != yaml.MappingNode {
+				definitions = false
+				break
+			}
+		}
+		if definitions {
+			return keyNode
+		}
+	}
+	return nil
+}
+
+// parseSection validates a "name: definition" mapping and parses each entry with parse.
+func parseSection(node *yaml.Node, kind string, parse func(nameNode, defNode *yaml.Node) []ConfigError) []ConfigError {
+	if node.Kind != yaml.MappingNode {
+		return []ConfigError{nodeError(node, fmt.Sprintf("%ss must be a mapping of %s names to %s definitions", kind, kind, kind))}
+	}
+
+	var problems []ConfigError
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		nameNode := node.Content[i]
+		defNode := node.Content[i+1]
+
+		if nameNode.Value == "" {
+			problems = append(problems, nodeError(nameNode, kind+" name cannot be empty"))
+			continue
+		}
+		if defNode.Kind != yaml.MappingNode {
+			problems = append(problems, nodeError(defNode, fmt.Sprintf("%s %s: definition must be a mapping", kind, nameNode.Value)))
+			continue
+		}
+
+		problems = append(problems, parse(nameNode, defNode)...)
+	}
+
+	return problems
+}
+
+// checkFields reports every key of a mapping node that is not in allowed.
+func checkFields(node *yaml.Node, prefix string, allowed ...string) []ConfigError {
+	var problems []ConfigError
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		known := false
+		for _, field := range allowed {
+			if key == field {
+				known = true
+				break
+			}
+		}
+		if !known {
+			problems = append(problems, nodeError(node.Content[i], fmt.Sprintf("%sunknown field %q", prefix, key)))
+		}
+	}
+	return problems
+}
+
+// parseToolConfig decodes and validates a single tool definition.
+func parseToolConfig(nameNode, toolNode *yaml.Node, config *Config, checkFiles bool) (ToolConfig, []ConfigError) {
+	var tool ToolConfig
+	name := nameNode.Value
+	prefix := fmt.Sprintf("tool %s: ", name)
+
+	if problems := checkFields(toolNode, prefix, "description", "parameters", "script", "command"); len(problems) > 0 {
+		return tool, problems
+	}
+
+	if err := toolNode.Decode(&tool); err != nil {
+		return tool, []ConfigError{decodeError(toolNode, prefix, err)}
+	}
+
+	var problems []ConfigError
+	if tool.Script == "" && tool.Command == "" {
+		problems = append(problems, nodeError(toolNode, prefix+"either script or command must be set"))
+	}
+
+	if paramsNode := mappingValue(toolNode, "parameters"); paramsNode != nil {
+		seen := make(map[string]bool)
+		for _, param := range tool.Parameters {
+			switch {
+			case param.Name == "":
+				problems = append(problems, nodeError(paramsNode, prefix+"parameter name cannot be empty"))
+			case seen[param.Name]:
+				problems = append(problems, nodeError(paramsNode, prefix+"duplicate parameter "+param.Name))
+			case !validParameterTypes[param.Type]:
+				problems = append(problems, nodeError(paramsNode,
+					fmt.Sprintf("%sinvalid parameter type %q for %s, supported types: string, int, float, bool",
+						prefix, param.Type, param.Name)))
+			}
+			seen[param.Name] = true
+		}
+	}
+
+	if checkFiles && tool.Script != "" && tool.Command == "" {
+		if err := checkScript(config.resolvePath(tool.Script)); err != nil {
+			problems = append(problems, nodeError(mappingValue(toolNode, "script"), prefix+err.Error()))
+		}
+	}
+
+	return tool, problems
+}
+
+// parseResourceConfig decodes and validates a single resource definition.
+func parseResourceConfig(nameNode, resourceNode *yaml.Node, config *Config, checkFiles bool) (ResourceConfig, []ConfigError) {
+	var resource ResourceConfig
+	prefix := fmt.Sprintf("resource %s: ", nameNode.Value)
+
+	if problems := checkFields(resourceNode, prefix, "uri", "description", "mimeType", "file", "glob", "command"); len(problems) > 0 {
+		return resource, problems
+	}
+
+	if err := resourceNode.Decode(&resource); err != nil {
+		return resource, []ConfigError{decodeError(resourceNode, prefix, err)}
+	}
+
+	var problems []ConfigError
+	sources := 0
+	for _, source := range []string{resource.File, resource.Glob, resource.Command} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		problems = append(problems, nodeError(resourceNode, prefix+"exactly one of file, glob or command must be set"))
+	}
+
+	if resource.URI == "" && resource.Glob == "" {
+		problems = append(problems, nodeError(resourceNode, prefix+"uri must be set"))
+	}
+
+	if resource.URI != "" {
+		template, err := uritemplate.New(resource.URI)
+		switch {
+		case err != nil:
+			problems = append(problems, nodeError(mappingValue(resourceNode, "uri"), prefix+"invalid uri template: "+err.Error()))
+		case resource.Glob != "" && !hasVarname(template, "path"):
+			problems = append(problems, nodeError(mappingValue(resourceNode, "uri"), prefix+"uri of a glob resource must contain {path} or {+path}"))
+		}
+	}
+
+	if resource.Glob != "" {
+		if _, err := filepath.Match(resource.Glob, ""); err != nil {
+			problems = append(problems, nodeError(mappingValue(resourceNode, "glob"), prefix+"invalid glob: "+err.Error()))
+		}
+	}
+
+	if checkFiles && resource.File != "" && !strings.Contains(resource.File, "{") {
+		if _, err := os.Stat(config.resolvePath(resource.File)); err != nil {
+			problems = append(problems, nodeError(mappingValue(resourceNode, "file"), prefix+err.Error()))
+		}
+	}
+
+	return resource, problems
+}
+
+// parsePromptConfig decodes and validates a single prompt definition.
+func parsePromptConfig(nameNode, promptNode *yaml.Node, config *Config, checkFiles bool) (PromptConfig, []ConfigError) {
+	var prompt PromptConfig
+	prefix := fmt.Sprintf("prompt %s: ", nameNode.Value)
+
+	if problems := checkFields(promptNode, prefix, "description", "file"); len(problems) > 0 {
+		return prompt, problems
+	}
+
+	if err := promptNode.Decode(&prompt); err != nil {
+		return prompt, []ConfigError{decodeError(promptNode, prefix, err)}
+	}
+
+	if prompt.File == "" {
+		return prompt, []ConfigError{nodeError(promptNode, prefix+"file must be set")}
+	}
+
+	if checkFiles {
+		if _, err := loadPromptFile(config.resolvePath(prompt.File)); err != nil {
+			return prompt, []ConfigError{nodeError(mappingValue(promptNode, "file"), prefix+err.Error())}
+		}
+	}
+
+	return prompt, nil
+}
+
+// parseOpenAPIConfig decodes and validates a single OpenAPI definition.
+func parseOpenAPIConfig(nameNode, apiNode *yaml.Node, config *Config, checkFiles bool) (OpenAPIConfig, []ConfigError) {
+	var api OpenAPIConfig
+	prefix := fmt.Sprintf("openapi %s: ", nameNode.Value)
+
+	problems := checkFields(apiNode, prefix, "spec", "baseUrl", "prefix", "operations", "headers", "auth")
+	if authNode := mappingValue(apiNode, "auth"); authNode != nil && authNode.Kind == yaml.MappingNode {
+		problems = append(problems, checkFields(authNode, prefix+"auth: ",
+			"type", "header", "token", "tokenEnv", "username", "password", "passwordEnv")...)
+	}
+	if len(problems) > 0 {
+		return api, problems
+	}
+
+	if err := apiNode.Decode(&api); err != nil {
+		return api, []ConfigError{decodeError(apiNode, prefix, err)}
+	}
+
+	if api.Spec == "" {
+		problems = append(problems, nodeError(apiNode, prefix+"spec must be set"))
+	}
+	if api.BaseURL != "" && !strings.HasPrefix(api.BaseURL, "http://") && !strings.HasPrefix(api.BaseURL, "https://") {
+		problems = append(problems, nodeError(mappingValue(apiNode, "baseUrl"), prefix+"baseUrl must be an http or https URL"))
+	}
+	if api.Auth != nil && !validAuthTypes[api.Auth.Type] {
+		problems = append(problems, nodeError(mappingValue(apiNode, "auth"),
+			fmt.Sprintf("%sinvalid auth type %q, supported types: bearer, basic, apiKey", prefix, api.Auth.Type)))
+	}
+
+	if checkFiles && len(problems) == 0 {
+		if _, err := LoadOpenAPITools(api, config.baseDir); err != nil {
+			problems = append(problems, nodeError(mappingValue(apiNode, "spec"), prefix+err.Error()))
+		}
+	}
+
+	return api, problems
+}
+
+// parseWrapConfig decodes and validates a wrapped program manifest.
+func parseWrapConfig(nameNode, wrapNode *yaml.Node, config *Config, checkFiles bool) (WrapConfig, []ConfigError) {
+	var wrap WrapConfig
+	prefix := fmt.Sprintf("wrap %s: ", nameNode.Value)
+
+	problems := checkFields(wrapNode, prefix, "binary", "prefix", "dir", "subcommands")
+	subcommandsNode := mappingValue(wrapNode, "subcommands")
+	if subcommandsNode != nil && subcommandsNode.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(subcommandsNode.Content); i += 2 {
+			subNode := subcommandsNode.Content[i+1]
+			if subNode.Kind != yaml.MappingNode {
+				continue
+			}
+			subPrefix := fmt.Sprintf("%ssubcommand %s: ", prefix, subcommandsNode.Content[i].Value)
+			problems = append(problems, checkFields(subNode, subPrefix, "description", "args", "flags", "positionals")...)
+			for _, key := range []string{"flags", "positionals"} {
+				listNode := mappingValue(subNode, key)
+				if listNode == nil || listNode.Kind != yaml.SequenceNode {
+					continue
+				}
+				for _, itemNode := range listNode.Content {
+					if itemNode.Kind == yaml.MappingNode {
+						problems = append(problems, checkFields(itemNode, subPrefix,
+							"name", "flag", "type", "description", "enum", "required")...)
+					}
+				}
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return wrap, problems
+	}
+
+	if err := wrapNode.Decode(&wrap); err != nil {
+		return wrap, []ConfigError{decodeError(wrapNode, prefix, err)}
+	}
+
+	if wrap.Binary == "" {
+		problems = append(problems, nodeError(wrapNode, prefix+"binary must be set"))
+	}
+	if len(wrap.Subcommands) == 0 {
+		problems = append(problems, nodeError(wrapNode, prefix+"at least one subcommand must be set"))
+	}
+	if subcommandsNode != nil {
+		for i := 0; i+1 < len(subcommandsNode.Content); i += 2 {
+			name := subcommandsNode.Content[i].Value
+			if err := wrap.Subcommands[name].validate(); err != nil {
+				problems = append(problems, nodeError(subcommandsNode.Content[i+1],
+					fmt.Sprintf("%ssubcommand %s: %v", prefix, name, err)))
+			}
+		}
+	}
+
+	if checkFiles && len(problems) == 0 {
+		if _, err := resolveBinary(wrap.Binary, config.baseDir); err != nil {
+			problems = append(problems, nodeError(mappingValue(wrapNode, "binary"), prefix+err.Error()))
+		}
+	}
+
+	return wrap, problems
+}
+
+// resolvePath resolves a path from the configuration against its base directory.
+func (c *Config) resolvePath(path string) string {
+	if path == "" || filepath.IsAbs(path) || c.baseDir == "" {
+		return path
+	}
+	return filepath.Join(c.baseDir, path)
+}
+
+// SaveConfig writes the configuration to path. Files ending in .yaml or .yml are
+// written as YAML, anything else as JSON.
+//
+// An existing file is edited in place: entries that did not change keep their
+// comments and formatting, and the file keeps its schema version. As the legacy
+// format only holds tools, saving other entries to a legacy file is an error.
+func SaveConfig(path string, config *Config) error {
+	yamlFile := isYAMLPath(path)
+	root, err := readDocument(path)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if root == nil {
+		data, err = marshalConfig(config, yamlFile)
+	} else {
+		if err := updateDocument(root.Content[0], config, yamlFile); err != nil {
+			return fmt.Errorf("error saving config to %s: %w", path, err)
+		}
+		data, err = marshalDocument(root, yamlFile)
+	}
+	if err != nil {
+		return fmt.Errorf("error marshaling config: %w", err)
+	}
+
+	if mkdirErr := os.MkdirAll(filepath.Dir(path), 0o750); mkdirErr != nil {
+		return fmt.Errorf("error creating config directory: %w", mkdirErr)
+	}
+
+	if writeErr := os.WriteFile(path, data, 0o600); writeErr != nil {
+		return fmt.Errorf("error writing config: %w", writeErr)
+	}
+
+	return nil
+}
+
+// isYAMLPath reports whether a configuration file is written as YAML.
+func isYAMLPath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// marshalConfig marshals a configuration that is written to a new file.
+func marshalConfig(config *Config, yamlFile bool) ([]byte, error) {
+	if config.Version == 0 {
+		config.Version = ConfigVersion
+	}
+	if yamlFile {
+		return yaml.Marshal(config)
+	}
+	return json.MarshalIndent(config, "", "  ")
+}
+
+// readDocument parses the configuration file at path, or returns nil if it does
+// not exist or is empty.
+func readDocument(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path is provided by the user on purpose
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading config: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("error parsing config: %w", err)
+	}
+	if len(root.Content) == 0 || len(root.Content[0].Content) == 0 {
+		return nil, nil
+	}
+	if root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("error parsing config: config must be a mapping")
+	}
+	return &root, nil
+}
+
+// updateDocument updates the sections of a parsed configuration document to match
+// config.
+func updateDocument(doc *yaml.Node, config *Config, yamlFile bool) error {
+	if mappingValue(doc, "version") == nil {
+		if len(config.Resources) > 0 || len(config.Prompts) > 0 || len(config.OpenAPI) > 0 || len(config.Wrap) > 0 {
+			return fmt.Errorf("the file uses the legacy format, which only holds tools, "+
+				"add \"version: %d\" at its top and move its tools under \"tools:\" to save other entries", ConfigVersion)
+		}
+		return updateEntries(doc, config.Tools, yamlFile)
+	}
+
+	if err := updateSection(doc, "tools", config.Tools, yamlFile); err != nil {
+		return err
+	}
+	if err := updateSection(doc, "resources", config.Resources, yamlFile); err != nil {
+		return err
+	}
+	if err := updateSection(doc, "prompts", config.Prompts, yamlFile); err != nil {
+		return err
+	}
+	if err := updateSection(doc, "openapi", config.OpenAPI, yamlFile); err != nil {
+		return err
+	}
+	return updateSection(doc, "wrap", config.Wrap, yamlFile)
+}
+
+// updateSection updates a section of a versioned document, adding it if needed.
+func updateSection[T any](doc *yaml.Node, key string, entries map[string]T, yamlFile bool) error {
+	section := mappingValue(doc, key)
+	if section == nil {
+		if len(entries) == 0 {
+			return nil
+		}
+		section = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, section)
+	}
+	return updateEntries(section, entries, yamlFile)
+}
+
+// updateEntries updates a "name: definition" mapping to hold entries: removed
+// entries are deleted, changed entries are replaced and new entries are appended,
+// while unchanged entries are left as they are.
+func updateEntries[T any](section *yaml.Node, entries map[string]T, yamlFile bool) error {
+	if section.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping", section.Line)
+	}
+	if len(section.Content) == 0 {
+		// An empty mapping is written {}, the entries added to it are written as a block
+		section.Style &^= yaml.FlowStyle
+	}
+
+	content := make([]*yaml.Node, 0, len(section.Content))
+	seen := make(map[string]bool, len(entries))
+	for i := 0; i+1 < len(section.Content); i += 2 {
+		keyNode, valueNode := section.Content[i], section.Content[i+1]
+		entry, exists := entries[keyNode.Value]
+		if !exists {
+			continue
+		}
+		seen[keyNode.Value] = true
+
+		var current T
+		if err := valueNode.Decode(&current); err != nil || !reflect.DeepEqual(current, entry) {
+			encoded, err := encodeNode(entry, yamlFile)
+			if err != nil {
+				return err
+			}
+			valueNode = encoded
+		}
+		content = append(content, keyNode, valueNode)
+	}
+
+	for _, name := range sortedKeys(entries) {
+		if seen[name] {
+			continue
+		}
+		encoded, err := encodeNode(entries[name], yamlFile)
+		if err != nil {
+			return err
+		}
+		content = append(content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, encoded)
+	}
+
+	section.Content = content
+	return nil
+}
+
+// encodeNode encodes a definition as a node. Definitions saved to JSON files are
+// encoded with their JSON field names.
+func encodeNode(value any, yamlFile bool) (*yaml.Node, error) {
+	if yamlFile {
+		var node yaml.Node
+		if err := node.Encode(value); err != nil {
+			return nil, err
+		}
+		return &node, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	return root.Content[0], nil
+}
+
+// marshalDocument marshals an edited document, as YAML with the indentation of the
+// file, or as indented JSON keeping the order of its keys.
+func marshalDocument(root *yaml.Node, yamlFile bool) ([]byte, error) {
+	if yamlFile {
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(yamlIndent(root.Content[0]))
+		if err := encoder.Encode(root); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	compact, err := appendJSON(nil, root)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, compact, "", "  "); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlIndent returns the indentation of the nested mappings of a document, or the
+// default indentation of the YAML encoder.
+func yamlIndent(doc *yaml.Node) int {
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		child := doc.Content[i+1]
+		if child.Kind != yaml.MappingNode || child.Style&yaml.FlowStyle != 0 || len(child.Content) == 0 {
+			continue
+		}
+		if indent := child.Content[0].Column - doc.Content[i].Column; indent > 0 {
+			return indent
+		}
+	}
+	return 4
+}
+
+// appendJSON appends the JSON encoding of a node to buf, keeping the order of the
+// keys of mappings.
+func appendJSON(buf []byte, node *yaml.Node) ([]byte, error) {
+	var err error
+	switch node.Kind {
+	case yaml.DocumentNode:
+		return appendJSON(buf, node.Content[0])
+	case yaml.AliasNode:
+		return appendJSON(buf, node.Alias)
+	case yaml.MappingNode:
+		buf = append(buf, '{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			key, keyErr := json.Marshal(node.Content[i].Value)
+			if keyErr != nil {
+				return nil, keyErr
+			}
+			buf = append(append(buf, key...), ':')
+			if buf, err = appendJSON(buf, node.Content[i+1]); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, '}'), nil
+	case yaml.SequenceNode:
+		buf = append(buf, '[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			if buf, err = appendJSON(buf, item); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, ']'), nil
+	default:
+		var value any
+		if err := node.Decode(&value); err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		return append(buf, data...), nil
+	}
+}
+
+// ToolNames returns the configured tool names in sorted order.
+func (c *Config) ToolNames() []string {
+	return sortedKeys(c.Tools)
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// nodeError creates a ConfigError located at the given node.
+func nodeError(node *yaml.Node, message string) ConfigError {
+	return ConfigError{Line: node.Line, Column: node.Column, Message: message}
+}
+
+// decodeError converts a decoding error for a definition into a ConfigError.
+func decodeError(node *yaml.Node, prefix string, err error) ConfigError {
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		// yaml.v3 already reports "line N: ..." for type errors
+		return nodeError(node, prefix+strings.Join(typeErr.Errors, "; "))
+	}
+	return nodeError(node, prefix+err.Error())
+}
+
+// yamlError converts a syntax error from the YAML parser into a ConfigError.
+func yamlError(err error) ConfigError {
+	message := strings.TrimPrefix(err.Error(), "yaml: ")
+	var line int
+	if n, _ := fmt.Sscanf(message, "line %d:", &line); n == 1 {
+		message = strings.TrimSpace(message[strings.Index(message, ":")+1:])
+		return ConfigError{Line: line, Column: 1, Message: message}
+	}
+	return ConfigError{Message: message}
+}
@@ -0,0 +1,508 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/f/mcptools/pkg/protocol"
+)
+
+const (
+	// sessionHeader carries the session ID of streamable HTTP clients.
+	sessionHeader = "Mcp-Session-Id"
+	// sessionIdleTimeout is how long an unused session is kept before it expires.
+	sessionIdleTimeout = 30 * time.Minute
+	// keepAliveInterval is how often idle event streams receive a comment to stay open.
+	keepAliveInterval = 30 * time.Second
+	// maxRequestSize limits the size of a request body.
+	maxRequestSize = 10 << 20
+)
+
+// HTTPOptions configures the streamable HTTP transport.
+type HTTPOptions struct {
+	// Listen is the address to listen on, e.g. ":8080".
+	Listen string
+	// Tokens are the accepted bearer tokens. No tokens disables authentication.
+	Tokens []string
+	// Concurrency limits the number of scripts and commands executed at the same time.
+	Concurrency int
+}
+
+// httpSession is a client session of the streamable HTTP transport.
+type httpSession struct {
+	lastSeen    time.Time
+	events      chan []byte
+	done        chan struct{}
+	id          string
+	initialized bool
+	streaming   bool
+}
+
+// httpTransport serves the proxy over the MCP streamable HTTP transport.
+type httpTransport struct {
+	server   *Server
+	sessions map[string]*httpSession
+	tokens   [][]byte
+	mu       sync.Mutex
+}
+
+// newHTTPTransport creates a streamable HTTP transport for server.
+func newHTTPTransport(server *Server, options HTTPOptions) *httpTransport {
+	tokens := make([][]byte, 0, len(options.Tokens))
+	for _, token := range options.Tokens {
+		tokens = append(tokens, []byte(token))
+	}
+
+	return &httpTransport{
+		server:   server,
+		sessions: make(map[string]*httpSession),
+		tokens:   tokens,
+	}
+}
+
+// LoadTokens reads bearer tokens from a file, one per line. Empty lines and
+// lines starting with # are ignored.
+func LoadTokens(path string) ([]string, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path is provided by the user on purpose
+	if err != nil {
+		return nil, fmt.Errorf("error reading token file: %w", err)
+	}
+
+	var tokens []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, line)
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("no tokens found in %s", path)
+	}
+
+	return tokens, nil
+}
+
+// StartHTTP serves the proxy over streamable HTTP on options.Listen at the /mcp endpoint.
+func (s *Server) StartHTTP(options HTTPOptions) error {
+	transport := newHTTPTransport(s, options)
+	if options.Concurrency != 0 {
+		s.SetConcurrency(options.Concurrency)
+	}
+	s.mu.Lock()
+	s.http = transport
+	s.mu.Unlock()
+
+	// Check error from Close() when deferring
+	defer func() {
+		if err := s.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error closing log file: %v\n", err)
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", transport)
+
+	httpServer := &http.Server{
+		Addr:              options.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	s.log(fmt.Sprintf("Proxy server listening on %s", options.Listen))
+	fmt.Fprintf(os.Stderr, "Proxy server listening on http://%s/mcp (concurrency: %d)\n",
+		displayAddr(options.Listen), cap(s.workers))
+
+	return httpServer.ListenAndServe()
+}
+
+// displayAddr returns a listen address suitable for building a URL.
+func displayAddr(listen string) string {
+	if strings.HasPrefix(listen, ":") {
+		return "localhost" + listen
+	}
+	return listen
+}
+
+// ServeHTTP implements http.Handler.
+func (t *httpTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !t.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Clients send the negotiated version after initialize, and an unsupported one
+	// is rejected as the specification requires
+	if version := r.Header.Get(protocol.VersionHeader); version != "" && !protocol.Supported(version) {
+		http.Error(w, "unsupported protocol version "+version, http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		t.handlePost(w, r)
+	case http.MethodGet:
+		t.handleStream(w, r)
+	case http.MethodDelete:
+		t.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// authorized checks the bearer token of a request in constant time.
+func (t *httpTransport) authorized(r *http.Request) bool {
+	if len(t.tokens) == 0 {
+		return true
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+
+	authorized := false
+	for _, expected := range t.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), expected) == 1 {
+			authorized = true
+		}
+	}
+	return authorized
+}
+
+// handlePost handles a JSON-RPC message sent by the client.
+func (t *httpTransport) handlePost(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Method  string                 `json:"method"`
+		Params  map[string]interface{} `json:"params,omitempty"`
+		JSONRPC string                 `json:"jsonrpc"`
+		ID      json.RawMessage        `json:"id,omitempty"`
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxRequestSize)
+	if err := json.NewDecoder(body).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      nil,
+			"error": map[string]interface{}{
+				"code":    -32700,
+				"message": fmt.Sprintf("parse error: %v", err),
+			},
+		})
+		return
+	}
+
+	t.server.logJSON("Received request", request)
+
+	var session *httpSession
+	if request.Method == "initialize" {
+		session = t.createSession()
+		w.Header().Set(sessionHeader, session.id)
+	} else {
+		var status int
+		session, status = t.session(r)
+		if session == nil {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+	}
+
+	// Notifications and responses are acknowledged without a body
+	if len(request.ID) == 0 || request.Method == "" {
+		if request.Method == "notifications/initialized" {
+			t.mu.Lock()
+			session.initialized = true
+			t.mu.Unlock()
+		} else {
+			t.server.handleNotification(session.id, request.Method, request.Params)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	// Closing the connection or notifications/cancelled cancels the request
+	key := requestKey(session.id, request.ID)
+	ctx, cancel := t.server.track(r.Context(), key)
+	defer t.server.untrack(key, cancel)
+
+	// Notifications for this request, such as progress, are streamed in the
+	// response when the client accepts server-sent events
+	stream := newEventStream(w, r)
+	ctx = withNotifier(ctx, func(method string, params any) {
+		notification := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+		t.server.logJSON("Sending notification", notification)
+		if stream != nil {
+			stream.send(notification)
+			return
+		}
+		t.sendToSession(session, notification)
+	})
+
+	result, err := t.server.handleRequest(ctx, request.Method, request.Params)
+	if ctx.Err() != nil {
+		t.server.log(fmt.Sprintf("Request %s cancelled", request.ID))
+		if stream == nil || !stream.started {
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+
+	var response map[string]interface{}
+	if err != nil {
+		t.server.log(fmt.Sprintf("Error handling request: %v", err))
+		response = errorResponse(request.ID, err)
+	} else {
+		response = map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      request.ID,
+			"result":  result,
+		}
+	}
+
+	t.server.logJSON("Sending response", response)
+	if stream != nil && stream.started {
+		stream.send(response)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// eventStream writes a POST response as server-sent events. The stream is only
+// started once the first message is sent, so requests without notifications
+// still get a plain JSON response.
+type eventStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	started bool
+}
+
+// newEventStream returns an event stream for the response, or nil when the
+// client does not accept server-sent events.
+func newEventStream(w http.ResponseWriter, r *http.Request) *eventStream {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return nil
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil
+	}
+	return &eventStream{w: w, flusher: flusher}
+}
+
+// send writes a JSON-RPC message as an event.
+func (e *eventStream) send(message any) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+
+	if !e.started {
+		e.w.Header().Set("Content-Type", "text/event-stream")
+		e.w.Header().Set("Cache-Control", "no-cache")
+		e.w.WriteHeader(http.StatusOK)
+		e.started = true
+	}
+
+	fmt.Fprintf(e.w, "event: message\ndata: %s\n\n", data)
+	e.flusher.Flush()
+}
+
+// sendToSession queues a message on the event stream of a session.
+func (t *httpTransport) sendToSession(session *httpSession, message any) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		t.server.log(fmt.Sprintf("Error encoding notification: %v", err))
+		return
+	}
+
+	// Never block on slow clients
+	select {
+	case session.events <- data:
+	default:
+	}
+}
+
+// handleStream opens a server-sent events stream for server notifications.
+func (t *httpTransport) handleStream(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "Accept must include text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	session, status := t.session(r)
+	if session == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	t.mu.Lock()
+	if session.streaming {
+		t.mu.Unlock()
+		http.Error(w, "session already has an event stream", http.StatusConflict)
+		return
+	}
+	session.streaming = true
+	t.mu.Unlock()
+
+	defer func() {
+		t.mu.Lock()
+		session.streaming = false
+		session.lastSeen = time.Now()
+		t.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-session.done:
+			return
+		case event := <-session.events:
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", event)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// handleDelete terminates a session.
+func (t *httpTransport) handleDelete(w http.ResponseWriter, r *http.Request) {
+	session, status := t.session(r)
+	if session == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	t.mu.Lock()
+	closed := t.closeSession(session)
+	t.mu.Unlock()
+	// Another DELETE or the idle expiry closed the session since it was looked up
+	if !closed {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	t.server.log(fmt.Sprintf("Session %s terminated by client", session.id))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// createSession starts a new session and expires idle ones.
+func (t *httpTransport) createSession() *httpSession {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		// crypto/rand never fails on supported platforms
+		panic(fmt.Sprintf("error generating session id: %v", err))
+	}
+
+	session := &httpSession{
+		id:       hex.EncodeToString(idBytes),
+		events:   make(chan []byte, 16),
+		done:     make(chan struct{}),
+		lastSeen: time.Now(),
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, existing := range t.sessions {
+		if !existing.streaming && time.Since(existing.lastSeen) > sessionIdleTimeout {
+			t.server.log(fmt.Sprintf("Session %s expired", existing.id))
+			t.closeSession(existing)
+		}
+	}
+	t.sessions[session.id] = session
+
+	t.server.log(fmt.Sprintf("Session %s created", session.id))
+	return session
+}
+
+// closeSession removes a session and ends its event stream, and reports whether it
+// was still open. t.mu must be held.
+func (t *httpTransport) closeSession(session *httpSession) bool {
+	if t.sessions[session.id] != session {
+		return false
+	}
+	delete(t.sessions, session.id)
+	close(session.done)
+	return true
+}
+
+// session returns the session of a request, or the HTTP status to reply with.
+func (t *httpTransport) session(r *http.Request) (*httpSession, int) {
+	id := r.Header.Get(sessionHeader)
+	if id == "" {
+		return nil, http.StatusBadRequest
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	session, exists := t.sessions[id]
+	if !exists {
+		return nil, http.StatusNotFound
+	}
+	session.lastSeen = time.Now()
+	return session, 0
+}
+
+// broadcast queues a notification on the event stream of every initialized session.
+func (t *httpTransport) broadcast(method string, params any) {
+	notification := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+	}
+	if params != nil {
+		notification["params"] = params
+	}
+
+	t.server.logJSON("Sending notification", notification)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, session := range t.sessions {
+		if session.initialized {
+			t.sendToSession(session, notification)
+		}
+	}
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
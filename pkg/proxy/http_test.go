@@ -0,0 +1,190 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/f/mcptools/pkg/protocol"
+)
+
+func newTestHTTPServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	config, problems := ParseConfig([]byte(`version: 1
+tools:
+  greet:
+    parameters: "name:string"
+    command: echo "hello $name"
+`), "")
+	if len(problems) > 0 {
+		t.Fatalf("Unexpected problems: %v", problems)
+	}
+
+	server := &Server{}
+	if err := server.ApplyConfig(config); err != nil {
+		t.Fatalf("ApplyConfig() error = %v", err)
+	}
+
+	server.SetConcurrency(2)
+	transport := newHTTPTransport(server, HTTPOptions{Tokens: []string{"secret"}})
+	server.http = transport
+
+	httpServer := httptest.NewServer(transport)
+	t.Cleanup(httpServer.Close)
+	return httpServer
+}
+
+func postMCP(t *testing.T, url, token, session, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if session != "" {
+		req.Header.Set(sessionHeader, session)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestHTTPTransport(t *testing.T) {
+	httpServer := newTestHTTPServer(t)
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`
+
+	if resp := postMCP(t, httpServer.URL, "", "", initialize); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", resp.StatusCode)
+	}
+	if resp := postMCP(t, httpServer.URL, "wrong", "", initialize); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with wrong token, got %d", resp.StatusCode)
+	}
+
+	resp := postMCP(t, httpServer.URL, "secret", "", initialize)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 for initialize, got %d", resp.StatusCode)
+	}
+	session := resp.Header.Get(sessionHeader)
+	if session == "" {
+		t.Fatal("Expected a session ID in the initialize response")
+	}
+
+	resp = postMCP(t, httpServer.URL, "secret", session, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected 202 for notification, got %d", resp.StatusCode)
+	}
+
+	resp = postMCP(t, httpServer.URL, "secret", session,
+		`{"jsonrpc":"2.0","id":"call-1","method":"tools/call","params":{"name":"greet","arguments":{"name":"world"}}}`)
+	var result struct {
+		ID     string `json:"id"`
+		Result struct {
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.ID != "call-1" || len(result.Result.Content) != 1 || result.Result.Content[0].Text != "hello world\n" {
+		t.Errorf("Unexpected tool call response: %+v", result)
+	}
+
+	if resp := postMCP(t, httpServer.URL, "secret", "", `{"jsonrpc":"2.0","id":2,"method":"ping"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 without session, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, httpServer.URL, strings.NewReader(`{"jsonrpc":"2.0","id":4,"method":"ping"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set(sessionHeader, session)
+	req.Header.Set(protocol.VersionHeader, "1999-01-01")
+	versionResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	_ = versionResp.Body.Close()
+	if versionResp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unsupported protocol version, got %d", versionResp.StatusCode)
+	}
+
+	req, _ = http.NewRequest(http.MethodDelete, httpServer.URL, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set(sessionHeader, session)
+	deleteResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE failed: %v", err)
+	}
+	_ = deleteResp.Body.Close()
+	if deleteResp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected 204 for DELETE, got %d", deleteResp.StatusCode)
+	}
+
+	if resp := postMCP(t, httpServer.URL, "secret", session, `{"jsonrpc":"2.0","id":3,"method":"ping"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for terminated session, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPConcurrentDelete(t *testing.T) {
+	httpServer := newTestHTTPServer(t)
+	resp := postMCP(t, httpServer.URL, "secret", "",
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`)
+	session := resp.Header.Get(sessionHeader)
+	if session == "" {
+		t.Fatal("Expected a session ID in the initialize response")
+	}
+
+	// The session is closed once, whatever the number of DELETEs racing for it
+	const deletes = 8
+	statuses := make(chan int, deletes)
+	var wg sync.WaitGroup
+	for i := 0; i < deletes; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodDelete, httpServer.URL, nil)
+			req.Header.Set("Authorization", "Bearer secret")
+			req.Header.Set(sessionHeader, session)
+			deleteResp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Errorf("DELETE failed: %v", err)
+				return
+			}
+			_ = deleteResp.Body.Close()
+			statuses <- deleteResp.StatusCode
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	counts := map[int]int{}
+	for status := range statuses {
+		counts[status]++
+	}
+	if counts[http.StatusNoContent] != 1 || counts[http.StatusNotFound] != deletes-1 {
+		t.Errorf("Expected one 204 and %d 404 for concurrent DELETEs, got %v", deletes-1, counts)
+	}
+
+	// The idle expiry can also close a session after a DELETE looked it up
+	transport := newHTTPTransport(&Server{}, HTTPOptions{})
+	expired := &httpSession{id: "expired", done: make(chan struct{})}
+	transport.sessions[expired.id] = expired
+	if !transport.closeSession(expired) {
+		t.Error("Expected the first close of a session to close it")
+	}
+	if transport.closeSession(expired) {
+		t.Error("Expected the second close of a session to do nothing")
+	}
+}
@@ -0,0 +1,590 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIConfig describes an OpenAPI 3 spec whose operations are exposed as tools.
+type OpenAPIConfig struct {
+	Headers    map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Auth       *OpenAPIAuth      `json:"auth,omitempty" yaml:"auth,omitempty"`
+	Spec       string            `json:"spec" yaml:"spec"`
+	BaseURL    string            `json:"baseUrl,omitempty" yaml:"baseUrl,omitempty"`
+	Prefix     string            `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	Operations []string          `json:"operations,omitempty" yaml:"operations,omitempty"`
+}
+
+// OpenAPIAuth configures how requests to an API are authenticated. Secrets can
+// be read from environment variables so they do not have to be stored in the
+// config file.
+type OpenAPIAuth struct {
+	Type        string `json:"type" yaml:"type"`                                   // bearer, basic or apiKey
+	Header      string `json:"header,omitempty" yaml:"header,omitempty"`           // apiKey header, default X-API-Key
+	Token       string `json:"token,omitempty" yaml:"token,omitempty"`             // bearer token or API key
+	TokenEnv    string `json:"tokenEnv,omitempty" yaml:"tokenEnv,omitempty"`       // variable holding the token
+	Username    string `json:"username,omitempty" yaml:"username,omitempty"`       // basic auth user
+	Password    string `json:"password,omitempty" yaml:"password,omitempty"`       // basic auth password
+	PasswordEnv string `json:"passwordEnv,omitempty" yaml:"passwordEnv,omitempty"` // variable holding the password
+}
+
+// validAuthTypes lists the supported OpenAPI authentication types.
+var validAuthTypes = map[string]bool{"bearer": true, "basic": true, "apiKey": true}
+
+// Operation is an OpenAPI operation executed as an HTTP request.
+type Operation struct {
+	// Fields ordered for optimal memory alignment (8-byte aligned fields first)
+	Headers         map[string]string
+	Auth            *OpenAPIAuth
+	Method          string
+	Path            string
+	BaseURL         string
+	BodyContentType string // Empty when the operation has no request body
+	Parameters      []OperationParameter
+}
+
+// OperationParameter is a path, query, header or cookie parameter of an operation.
+type OperationParameter struct {
+	Name string
+	In   string
+}
+
+// operationTimeout limits the duration of a single API request.
+const operationTimeout = 60 * time.Second
+
+// maxResponseSize limits the size of an API response returned to the client.
+const maxResponseSize = 10 << 20
+
+// httpMethods lists the OpenAPI operation keys in the order tools are generated.
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// toolNamePattern matches characters that are not allowed in tool names.
+var toolNamePattern = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// LoadOpenAPITools reads an OpenAPI spec and creates a tool for each of its
+// operations. A relative spec path is resolved against baseDir.
+func LoadOpenAPITools(config OpenAPIConfig, baseDir string) ([]Tool, error) {
+	path := absPath(config.Spec, baseDir)
+	data, err := os.ReadFile(path) // #nosec G304 - path comes from the config
+	if err != nil {
+		return nil, fmt.Errorf("error reading OpenAPI spec: %w", err)
+	}
+
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing OpenAPI spec %s: %w", path, err)
+	}
+	doc, _ = normalizeYAML(doc).(map[string]interface{})
+
+	version, _ := doc["openapi"].(string)
+	if !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("%s: only OpenAPI 3 specs are supported", path)
+	}
+
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = specServerURL(doc)
+	}
+	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		return nil, fmt.Errorf("%s: no absolute server URL in the spec, set a base URL", path)
+	}
+
+	include := make(map[string]bool, len(config.Operations))
+	for _, name := range config.Operations {
+		include[name] = true
+	}
+
+	paths, _ := doc["paths"].(map[string]interface{})
+	seen := make(map[string]bool)
+	var tools []Tool
+	for _, urlPath := range sortedKeys(paths) {
+		pathItem, _ := resolveRef(doc, paths[urlPath]).(map[string]interface{})
+		for _, method := range httpMethods {
+			op, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			operationID, _ := op["operationId"].(string)
+			if operationID == "" {
+				operationID = toolName(method + urlPath)
+			}
+			if len(include) > 0 && !include[operationID] {
+				continue
+			}
+
+			name := toolName(config.Prefix + operationID)
+			if seen[name] {
+				return nil, fmt.Errorf("%s: duplicate tool name %s", path, name)
+			}
+			seen[name] = true
+
+			tool, err := openAPITool(doc, name, method, urlPath, pathItem, op)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s %s: %w", path, strings.ToUpper(method), urlPath, err)
+			}
+			tool.Operation.BaseURL = strings.TrimRight(baseURL, "/")
+			tool.Operation.Headers = config.Headers
+			tool.Operation.Auth = config.Auth
+			tools = append(tools, tool)
+		}
+	}
+
+	for _, name := range config.Operations {
+		if !seen[toolName(config.Prefix+name)] {
+			return nil, fmt.Errorf("%s: operation %s not found", path, name)
+		}
+	}
+
+	return tools, nil
+}
+
+// openAPITool creates the tool for a single operation.
+func openAPITool(doc map[string]interface{}, name, method, urlPath string, pathItem, op map[string]interface{}) (Tool, error) {
+	operation := &Operation{Method: strings.ToUpper(method), Path: urlPath}
+	properties := make(map[string]interface{})
+	var required []string
+
+	// Operation parameters override path item parameters with the same name and location
+	params := make(map[string]map[string]interface{})
+	var order []string
+	for _, list := range []interface{}{pathItem["parameters"], op["parameters"]} {
+		items, _ := list.([]interface{})
+		for _, item := range items {
+			param, _ := resolveRef(doc, item).(map[string]interface{})
+			paramName, _ := param["name"].(string)
+			in, _ := param["in"].(string)
+			if paramName == "" || in == "" {
+				return Tool{}, fmt.Errorf("parameter without name or location")
+			}
+			key := in + ":" + paramName
+			if _, exists := params[key]; !exists {
+				order = append(order, key)
+			}
+			params[key] = param
+		}
+	}
+
+	for _, key := range order {
+		param := params[key]
+		paramName := param["name"].(string)
+		in := param["in"].(string)
+		if _, exists := properties[paramName]; exists {
+			return Tool{}, fmt.Errorf("parameter %s is defined in more than one location", paramName)
+		}
+
+		schema, _ := inlineSchema(doc, param["schema"], nil).(map[string]interface{})
+		if schema == nil {
+			schema = map[string]interface{}{"type": "string"}
+		}
+		if description, ok := param["description"].(string); ok && description != "" {
+			schema["description"] = description
+		}
+		properties[paramName] = schema
+
+		if isRequired, _ := param["required"].(bool); isRequired || in == "path" {
+			required = append(required, paramName)
+		}
+		operation.Parameters = append(operation.Parameters, OperationParameter{Name: paramName, In: in})
+	}
+
+	if body, ok := resolveRef(doc, op["requestBody"]).(map[string]interface{}); ok {
+		content, _ := body["content"].(map[string]interface{})
+		contentType := ""
+		for _, candidate := range sortedKeys(content) {
+			if contentType == "" || isJSONContentType(candidate) && !isJSONContentType(contentType) {
+				contentType = candidate
+			}
+		}
+		if contentType != "" {
+			if _, exists := properties["body"]; exists {
+				return Tool{}, fmt.Errorf("parameter body conflicts with the request body")
+			}
+			media, _ := content[contentType].(map[string]interface{})
+			schema, _ := inlineSchema(doc, media["schema"], nil).(map[string]interface{})
+			if schema == nil || !isJSONContentType(contentType) {
+				schema = map[string]interface{}{"type": "string"}
+			}
+			schema["description"] = "Request body (" + contentType + ")"
+			if description, ok := body["description"].(string); ok && description != "" {
+				schema["description"] = description
+			}
+			properties["body"] = schema
+			operation.BodyContentType = contentType
+
+			if isRequired, _ := body["required"].(bool); isRequired {
+				required = append(required, "body")
+			}
+		}
+	}
+
+	inputSchema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		inputSchema["required"] = required
+	}
+
+	description, _ := op["summary"].(string)
+	if description == "" {
+		description, _ = op["description"].(string)
+	}
+	description = strings.TrimSpace(strings.TrimSpace(description) + " (" + operation.Method + " " + urlPath + ")")
+
+	return Tool{
+		Name:        name,
+		Description: description,
+		InputSchema: inputSchema,
+		Operation:   operation,
+	}, nil
+}
+
+// toolName turns an operation ID into a valid tool name.
+func toolName(operationID string) string {
+	name := strings.Trim(toolNamePattern.ReplaceAllString(operationID, "_"), "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// specServerURL returns the first server URL of a spec with its variables set to their defaults.
+func specServerURL(doc map[string]interface{}) string {
+	servers, _ := doc["servers"].([]interface{})
+	if len(servers) == 0 {
+		return ""
+	}
+	server, _ := servers[0].(map[string]interface{})
+	serverURL, _ := server["url"].(string)
+
+	variables, _ := server["variables"].(map[string]interface{})
+	for name, value := range variables {
+		variable, _ := value.(map[string]interface{})
+		if defaultValue, ok := variable["default"].(string); ok {
+			serverURL = strings.ReplaceAll(serverURL, "{"+name+"}", defaultValue)
+		}
+	}
+	return serverURL
+}
+
+// isJSONContentType reports whether a media type carries JSON.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// normalizeYAML converts maps with non-string keys, as produced for keys like
+// response codes, into maps with string keys so they can be encoded as JSON.
+func normalizeYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeYAML(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = normalizeYAML(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeYAML(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// resolveRef follows a local "$ref" (e.g. "#/components/parameters/id") of a node.
+func resolveRef(doc map[string]interface{}, node interface{}) interface{} {
+	for range 32 {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return node
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return node
+		}
+		node = lookupPointer(doc, ref)
+	}
+	return nil
+}
+
+// lookupPointer returns the node a local JSON pointer reference points to.
+func lookupPointer(doc map[string]interface{}, ref string) interface{} {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+
+	var node interface{} = doc
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		node = m[part]
+	}
+	return node
+}
+
+// inlineSchema returns a copy of schema with all local references replaced by
+// their targets, so the result is a self-contained JSON schema. Recursive
+// references are replaced by an empty schema.
+func inlineSchema(doc map[string]interface{}, schema interface{}, seen []string) interface{} {
+	switch v := schema.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			for _, visited := range seen {
+				if visited == ref {
+					return map[string]interface{}{}
+				}
+			}
+			return inlineSchema(doc, lookupPointer(doc, ref), append(seen, ref))
+		}
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = inlineSchema(doc, item, seen)
+		}
+		return m
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = inlineSchema(doc, item, seen)
+		}
+		return items
+	default:
+		return v
+	}
+}
+
+// callOperation executes an operation as an HTTP request and returns the
+// response body as tool content.
+func (s *Server) callOperation(ctx context.Context, op *Operation, arguments map[string]interface{}) (map[string]interface{}, error) {
+	req, err := op.newRequest(ctx, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(fmt.Sprintf("Calling %s %s", req.Method, req.URL.Redacted()))
+
+	client := &http.Client{Timeout: operationTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling API: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("error reading API response: %w", err)
+	}
+
+	s.log(fmt.Sprintf("API response: %s (%d bytes)", resp.Status, len(body)))
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	var content map[string]interface{}
+	switch {
+	case resp.StatusCode >= http.StatusBadRequest:
+		content = map[string]interface{}{
+			"type": "text",
+			"text": strings.TrimSpace(fmt.Sprintf("HTTP %s\n%s", resp.Status, body)),
+		}
+	case strings.HasPrefix(mediaType, "image/") || strings.HasPrefix(mediaType, "audio/"):
+		content = map[string]interface{}{
+			"type":     strings.SplitN(mediaType, "/", 2)[0],
+			"data":     base64.StdEncoding.EncodeToString(body),
+			"mimeType": mediaType,
+		}
+	case !utf8.Valid(body):
+		content = map[string]interface{}{
+			"type": "resource",
+			"resource": map[string]interface{}{
+				"uri":      op.BaseURL + op.Path,
+				"mimeType": mediaType,
+				"blob":     base64.StdEncoding.EncodeToString(body),
+			},
+		}
+	default:
+		content = map[string]interface{}{
+			"type": "text",
+			"text": string(body),
+		}
+	}
+
+	result := map[string]interface{}{
+		"content": []map[string]interface{}{content},
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		result["isError"] = true
+	}
+	return result, nil
+}
+
+// newRequest builds the HTTP request for a call with the given arguments.
+func (op *Operation) newRequest(ctx context.Context, arguments map[string]interface{}) (*http.Request, error) {
+	path := op.Path
+	query := url.Values{}
+	headers := http.Header{}
+	var cookies []*http.Cookie
+
+	for _, param := range op.Parameters {
+		value, exists := arguments[param.Name]
+		if !exists || value == nil {
+			continue
+		}
+
+		switch param.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+param.Name+"}", url.PathEscape(paramString(value)))
+		case "query":
+			if items, ok := value.([]interface{}); ok {
+				for _, item := range items {
+					query.Add(param.Name, paramString(item))
+				}
+			} else {
+				query.Set(param.Name, paramString(value))
+			}
+		case "header":
+			headers.Set(param.Name, paramString(value))
+		case "cookie":
+			cookies = append(cookies, &http.Cookie{Name: param.Name, Value: paramString(value)})
+		}
+	}
+
+	if strings.Contains(path, "{") {
+		return nil, fmt.Errorf("missing path parameters for %s", op.Path)
+	}
+
+	requestURL := op.BaseURL + path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if value, exists := arguments["body"]; exists && op.BodyContentType != "" {
+		if str, ok := value.(string); ok && !isJSONContentType(op.BodyContentType) {
+			body = strings.NewReader(str)
+		} else {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("error encoding request body: %w", err)
+			}
+			body = bytes.NewReader(data)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, op.Method, requestURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", op.BodyContentType)
+	}
+	for name, value := range op.Headers {
+		req.Header.Set(name, value)
+	}
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	if err := op.Auth.apply(req); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// apply adds the configured credentials to a request.
+func (a *OpenAPIAuth) apply(req *http.Request) error {
+	if a == nil {
+		return nil
+	}
+
+	token := a.Token
+	if a.TokenEnv != "" {
+		token = os.Getenv(a.TokenEnv)
+	}
+
+	switch a.Type {
+	case "bearer":
+		if token == "" {
+			return fmt.Errorf("no bearer token configured (set %s)", envName(a.TokenEnv, "token"))
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case "apiKey":
+		if token == "" {
+			return fmt.Errorf("no API key configured (set %s)", envName(a.TokenEnv, "token"))
+		}
+		header := a.Header
+		if header == "" {
+			header = "X-API-Key"
+		}
+		req.Header.Set(header, token)
+	case "basic":
+		password := a.Password
+		if a.PasswordEnv != "" {
+			password = os.Getenv(a.PasswordEnv)
+		}
+		req.SetBasicAuth(a.Username, password)
+	default:
+		return fmt.Errorf("unsupported auth type: %s", a.Type)
+	}
+
+	return nil
+}
+
+// envName describes where a missing secret should come from.
+func envName(env, field string) string {
+	if env != "" {
+		return "$" + env
+	}
+	return field
+}
+
+// paramString formats an argument value for use in a URL or header.
+func paramString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		// JSON numbers are decoded as float64, print integers without a fraction
+		if v == float64(int64(v)) {
+			return fmt.Sprintf("%d", int64(v))
+		}
+		return fmt.Sprintf("%v", v)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, paramString(item))
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
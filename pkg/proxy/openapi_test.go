@@ -0,0 +1,201 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const petstoreSpec = `openapi: 3.0.3
+info:
+  title: Petstore
+  version: 1.0.0
+servers:
+  - url: https://petstore.example.com/v1
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List pets
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+        - $ref: '#/components/parameters/TraceID'
+    post:
+      operationId: createPet
+      summary: Create a pet
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        description: ID of the pet
+        schema:
+          type: string
+    get:
+      summary: Get a pet
+      responses:
+        200:
+          description: A pet
+components:
+  parameters:
+    TraceID:
+      name: X-Trace-Id
+      in: header
+      schema:
+        type: string
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+        parent:
+          $ref: '#/components/schemas/Pet'
+`
+
+func TestLoadOpenAPITools(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "petstore.yaml"), petstoreSpec)
+
+	tools, err := LoadOpenAPITools(OpenAPIConfig{Spec: "petstore.yaml", Prefix: "pet_"}, dir)
+	if err != nil {
+		t.Fatalf("LoadOpenAPITools() error = %v", err)
+	}
+
+	names := make([]string, 0, len(tools))
+	byName := make(map[string]Tool)
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+		byName[tool.Name] = tool
+	}
+	expected := []string{"pet_listPets", "pet_createPet", "pet_get_pets_petId"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected tools %v, got %v", expected, names)
+	}
+
+	list := byName["pet_listPets"]
+	if list.Operation.BaseURL != "https://petstore.example.com/v1" {
+		t.Errorf("Expected base URL from the spec, got %s", list.Operation.BaseURL)
+	}
+	properties := list.InputSchema["properties"].(map[string]interface{})
+	if _, ok := properties["limit"]; !ok {
+		t.Error("Expected query parameter limit in the input schema")
+	}
+	if _, ok := properties["X-Trace-Id"]; !ok {
+		t.Error("Expected referenced header parameter in the input schema")
+	}
+
+	get := byName["pet_get_pets_petId"]
+	if !reflect.DeepEqual(get.InputSchema["required"], []string{"petId"}) {
+		t.Errorf("Expected path parameter to be required, got %v", get.InputSchema["required"])
+	}
+
+	create := byName["pet_createPet"]
+	body := create.InputSchema["properties"].(map[string]interface{})["body"].(map[string]interface{})
+	if body["type"] != "object" || !reflect.DeepEqual(create.InputSchema["required"], []string{"body"}) {
+		t.Errorf("Expected required object body, got %v", create.InputSchema)
+	}
+	parent := body["properties"].(map[string]interface{})["parent"]
+	if !reflect.DeepEqual(parent, map[string]interface{}{}) {
+		t.Errorf("Expected recursive reference to be cut, got %v", parent)
+	}
+
+	if _, err := LoadOpenAPITools(OpenAPIConfig{Spec: "petstore.yaml", Operations: []string{"missing"}}, dir); err == nil {
+		t.Error("Expected error for unknown operation")
+	}
+}
+
+func TestCallOperation(t *testing.T) {
+	var gotRequest *http.Request
+	var gotBody []byte
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRequest = r
+		gotBody, _ = io.ReadAll(r.Body)
+		if r.URL.Path == "/v1/pets/404" {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer api.Close()
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "petstore.yaml"), petstoreSpec)
+	t.Setenv("PETSTORE_TOKEN", "secret")
+
+	config := NewConfig()
+	config.OpenAPI["petstore"] = OpenAPIConfig{
+		Spec:    filepath.Join(dir, "petstore.yaml"),
+		BaseURL: api.URL + "/v1",
+		Headers: map[string]string{"X-Client": "mcptools"},
+		Auth:    &OpenAPIAuth{Type: "bearer", TokenEnv: "PETSTORE_TOKEN"},
+	}
+
+	server := &Server{}
+	if err := server.ApplyConfig(config); err != nil {
+		t.Fatalf("ApplyConfig() error = %v", err)
+	}
+
+	call := func(name string, arguments map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		result, err := server.handleRequest(context.Background(), "tools/call", map[string]interface{}{
+			"name":      name,
+			"arguments": arguments,
+		})
+		if err != nil {
+			t.Fatalf("tools/call %s error = %v", name, err)
+		}
+		return result.(map[string]interface{})
+	}
+
+	result := call("listPets", map[string]interface{}{"limit": float64(10), "X-Trace-Id": "abc"})
+	if gotRequest.Method != http.MethodGet || gotRequest.URL.Path != "/v1/pets" || gotRequest.URL.Query().Get("limit") != "10" {
+		t.Errorf("Unexpected request: %s %s", gotRequest.Method, gotRequest.URL)
+	}
+	if gotRequest.Header.Get("Authorization") != "Bearer secret" {
+		t.Errorf("Expected bearer auth, got %q", gotRequest.Header.Get("Authorization"))
+	}
+	if gotRequest.Header.Get("X-Trace-Id") != "abc" || gotRequest.Header.Get("X-Client") != "mcptools" {
+		t.Errorf("Expected header parameter and configured header, got %v", gotRequest.Header)
+	}
+	content := result["content"].([]map[string]interface{})
+	if content[0]["text"] != `{"ok":true}` {
+		t.Errorf("Expected response body as content, got %v", content[0])
+	}
+
+	call("createPet", map[string]interface{}{"body": map[string]interface{}{"name": "Rex"}})
+	var body map[string]interface{}
+	if err := json.Unmarshal(gotBody, &body); err != nil || body["name"] != "Rex" {
+		t.Errorf("Expected JSON body, got %s", gotBody)
+	}
+	if gotRequest.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected JSON content type, got %s", gotRequest.Header.Get("Content-Type"))
+	}
+
+	result = call("get_pets_petId", map[string]interface{}{"petId": "404"})
+	if result["isError"] != true {
+		t.Errorf("Expected isError for 404 response, got %v", result)
+	}
+
+	if _, err := server.handleRequest(context.Background(), "tools/call", map[string]interface{}{
+		"name":      "get_pets_petId",
+		"arguments": map[string]interface{}{},
+	}); err == nil {
+		t.Error("Expected error for missing path parameter")
+	}
+}
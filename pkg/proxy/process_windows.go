@@ -0,0 +1,8 @@
+//go:build windows
+
+package proxy
+
+import "os/exec"
+
+// killProcessGroup is a no-op on Windows, where cancellation kills the shell process.
+func killProcessGroup(_ *exec.Cmd) {}
@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// notifyFunc sends a notification to the client that made a request.
+type notifyFunc func(method string, params any)
+
+// notifierKey is the context key of the request notifier.
+type notifierKey struct{}
+
+// withNotifier returns a context carrying the notifier for the current request.
+func withNotifier(ctx context.Context, notify notifyFunc) context.Context {
+	return context.WithValue(ctx, notifierKey{}, notify)
+}
+
+// notifierFrom returns the notifier of the current request, or nil.
+func notifierFrom(ctx context.Context) notifyFunc {
+	notify, _ := ctx.Value(notifierKey{}).(notifyFunc)
+	return notify
+}
+
+// progressPattern matches the "PROGRESS n/total [message]" output convention.
+var progressPattern = regexp.MustCompile(`^PROGRESS\s+(\d+(?:\.\d+)?)(?:\s*/\s*(\d+(?:\.\d+)?))?\s*(.*)$`)
+
+// progressReporter turns script output lines into notifications/progress.
+//
+// Every line is reported as a message with the number of lines printed so far
+// as progress. Once a script prints a line like "PROGRESS 3/10 compiling", it
+// reports progress itself: only PROGRESS lines are sent from then on. As the
+// progress of a token must increase with every notification, a PROGRESS line
+// that does not increase on the last progress sent, counted lines included, is
+// dropped.
+type progressReporter struct {
+	token    any
+	notify   notifyFunc
+	last     float64
+	sent     bool
+	explicit bool
+}
+
+// newProgressReporter returns a reporter for a request that carries a
+// progressToken in params._meta, or nil when no progress was requested.
+func newProgressReporter(ctx context.Context, params map[string]interface{}) *progressReporter {
+	meta, _ := params["_meta"].(map[string]interface{})
+	token, exists := meta["progressToken"]
+	if !exists || token == nil {
+		return nil
+	}
+
+	notify := notifierFrom(ctx)
+	if notify == nil {
+		return nil
+	}
+
+	return &progressReporter{token: token, notify: notify}
+}
+
+// line reports a single line of script output.
+func (p *progressReporter) line(line string) {
+	params := map[string]interface{}{
+		"progressToken": p.token,
+	}
+
+	if match := progressPattern.FindStringSubmatch(line); match != nil {
+		progress, _ := strconv.ParseFloat(match[1], 64)
+		p.explicit = true
+		if p.sent && progress <= p.last {
+			return
+		}
+		p.last = progress
+
+		params["progress"] = progress
+		if match[2] != "" {
+			total, _ := strconv.ParseFloat(match[2], 64)
+			params["total"] = total
+		}
+		if message := strings.TrimSpace(match[3]); message != "" {
+			params["message"] = message
+		}
+	} else {
+		if p.explicit || strings.TrimSpace(line) == "" {
+			return
+		}
+		p.last++
+		params["progress"] = p.last
+		params["message"] = line
+	}
+
+	p.sent = true
+	p.notify("notifications/progress", params)
+}
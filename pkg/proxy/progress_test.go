@@ -0,0 +1,109 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+)
+
+func TestProgressReporter(t *testing.T) {
+	var notifications []map[string]interface{}
+	ctx := withNotifier(context.Background(), func(_ string, params any) {
+		notifications = append(notifications, params.(map[string]interface{}))
+	})
+
+	params := map[string]interface{}{
+		"_meta": map[string]interface{}{"progressToken": "tok"},
+	}
+	reporter := newProgressReporter(ctx, params)
+	if reporter == nil {
+		t.Fatal("Expected a progress reporter for a request with a progressToken")
+	}
+
+	for _, line := range []string{"starting", "", "PROGRESS 3/10 compiling", "ignored", "PROGRESS 2/10", "PROGRESS 10/10"} {
+		reporter.line(line)
+	}
+
+	expected := []map[string]interface{}{
+		{"progressToken": "tok", "progress": 1.0, "message": "starting"},
+		{"progressToken": "tok", "progress": 3.0, "total": 10.0, "message": "compiling"},
+		{"progressToken": "tok", "progress": 10.0, "total": 10.0},
+	}
+	if len(notifications) != len(expected) {
+		t.Fatalf("Expected %d notifications, got %d: %v", len(expected), len(notifications), notifications)
+	}
+	for i, want := range expected {
+		for key, value := range want {
+			if notifications[i][key] != value {
+				t.Errorf("Notification %d: expected %s=%v, got %v", i, key, value, notifications[i][key])
+			}
+		}
+		if _, hasMessage := want["message"]; !hasMessage && notifications[i]["message"] != nil {
+			t.Errorf("Notification %d: unexpected message %v", i, notifications[i]["message"])
+		}
+	}
+
+	if newProgressReporter(ctx, map[string]interface{}{}) != nil {
+		t.Error("Expected no reporter without a progressToken")
+	}
+	if newProgressReporter(context.Background(), params) != nil {
+		t.Error("Expected no reporter without a notifier")
+	}
+}
+
+func TestProgressReporterAfterPlainLines(t *testing.T) {
+	var progress []interface{}
+	ctx := withNotifier(context.Background(), func(_ string, params any) {
+		progress = append(progress, params.(map[string]interface{})["progress"])
+	})
+	reporter := newProgressReporter(ctx, map[string]interface{}{
+		"_meta": map[string]interface{}{"progressToken": "tok"},
+	})
+
+	lines := []string{"Starting", "Loading", "Resolving", "PROGRESS 1/10", "PROGRESS 2/10", "step", "PROGRESS 2/10", "PROGRESS 5/10"}
+	for _, line := range lines {
+		reporter.line(line)
+	}
+
+	// The PROGRESS lines that do not go past the lines already counted are dropped
+	expected := []interface{}{1.0, 2.0, 3.0, 5.0}
+	if len(progress) != len(expected) {
+		t.Fatalf("Expected progress %v, got %v", expected, progress)
+	}
+	for i := range expected {
+		if progress[i] != expected[i] {
+			t.Errorf("Expected progress %v, got %v", expected, progress)
+			break
+		}
+	}
+}
+
+func TestToolCallProgress(t *testing.T) {
+	server := newTestServer(t, map[string]ToolConfig{
+		"build": {Command: "echo one; echo PROGRESS 1/2; echo PROGRESS 2/2 done"},
+	})
+
+	var progress []interface{}
+	ctx := withNotifier(context.Background(), func(method string, params any) {
+		if method == "notifications/progress" {
+			progress = append(progress, params.(map[string]interface{})["progress"])
+		}
+	})
+
+	result, err := server.handleRequest(ctx, "tools/call", map[string]interface{}{
+		"name":      "build",
+		"arguments": map[string]interface{}{},
+		"_meta":     map[string]interface{}{"progressToken": 1},
+	})
+	if err != nil {
+		t.Fatalf("tools/call error = %v", err)
+	}
+
+	if len(progress) != 2 || progress[0] != 1.0 || progress[1] != 2.0 {
+		t.Errorf("Unexpected progress notifications: %v", progress)
+	}
+
+	content := result.(map[string]interface{})["content"].([]map[string]interface{})
+	if content[0]["text"] != "one\nPROGRESS 1/2\nPROGRESS 2/2 done\n" {
+		t.Errorf("Expected the full output in the result, got %q", content[0]["text"])
+	}
+}
@@ -0,0 +1,229 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PromptArgument describes an argument of a prompt template.
+type PromptArgument struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool   `json:"required" yaml:"required"`
+}
+
+// Prompt represents a prompt template loaded from a markdown file.
+type Prompt struct {
+	// Fields ordered for optimal memory alignment (8-byte aligned fields first)
+	Name        string
+	Description string
+	File        string
+	Body        string
+	Arguments   []PromptArgument
+}
+
+// promptFile is the parsed content of a prompt markdown file.
+type promptFile struct {
+	Description string           `yaml:"description"`
+	Arguments   []PromptArgument `yaml:"arguments"`
+	Body        string           `yaml:"-"`
+}
+
+// placeholderPattern matches {{name}} placeholders in prompt bodies.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// loadPromptFile reads a markdown prompt file. An optional YAML front matter
+// block delimited by "---" lines declares the description and arguments:
+//
+//	---
+//	description: Review a file
+//	arguments:
+//	  - name: file
+//	    description: File to review
+//	    required: true
+//	---
+//	Please review {{file}}.
+//
+// Without declared arguments, every {{placeholder}} becomes a required argument.
+func loadPromptFile(path string) (promptFile, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path comes from the config
+	if err != nil {
+		return promptFile{}, fmt.Errorf("prompt file not found: %w", err)
+	}
+
+	prompt, err := parsePromptFile(data)
+	if err != nil {
+		return promptFile{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return prompt, nil
+}
+
+// parsePromptFile parses the front matter and body of a prompt file.
+func parsePromptFile(data []byte) (promptFile, error) {
+	var prompt promptFile
+
+	content := string(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")))
+	if strings.HasPrefix(content, "---\n") {
+		rest := content[len("---\n"):]
+		end := strings.Index(rest, "\n---")
+		if end < 0 {
+			return prompt, fmt.Errorf("front matter is not terminated by ---")
+		}
+
+		if err := yaml.Unmarshal([]byte(rest[:end]), &prompt); err != nil {
+			return prompt, fmt.Errorf("invalid front matter: %w", err)
+		}
+
+		content = rest[end+len("\n---"):]
+		content = strings.TrimPrefix(content, "\n")
+	}
+	prompt.Body = strings.TrimSpace(content)
+
+	seen := make(map[string]bool)
+	for _, arg := range prompt.Arguments {
+		if arg.Name == "" {
+			return prompt, fmt.Errorf("argument name cannot be empty")
+		}
+		if seen[arg.Name] {
+			return prompt, fmt.Errorf("duplicate argument %s", arg.Name)
+		}
+		seen[arg.Name] = true
+	}
+
+	if len(prompt.Arguments) == 0 {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(prompt.Body, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				prompt.Arguments = append(prompt.Arguments, PromptArgument{Name: match[1], Required: true})
+			}
+		}
+	}
+
+	return prompt, nil
+}
+
+// NewPrompt creates a prompt from its configuration by loading the prompt file.
+// A relative file path is resolved against baseDir.
+func NewPrompt(name string, config PromptConfig, baseDir string) (Prompt, error) {
+	path := absPath(config.File, baseDir)
+
+	file, err := loadPromptFile(path)
+	if err != nil {
+		return Prompt{}, err
+	}
+
+	description := config.Description
+	if description == "" {
+		description = file.Description
+	}
+
+	return Prompt{
+		Name:        name,
+		Description: description,
+		File:        path,
+		Body:        file.Body,
+		Arguments:   file.Arguments,
+	}, nil
+}
+
+// Render substitutes the arguments into the prompt body.
+func (p Prompt) Render(arguments map[string]interface{}) (string, error) {
+	for _, arg := range p.Arguments {
+		if _, exists := arguments[arg.Name]; arg.Required && !exists {
+			return "", fmt.Errorf("missing required argument: %s", arg.Name)
+		}
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(p.Body, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		if value, exists := arguments[name]; exists {
+			return fmt.Sprintf("%v", value)
+		}
+		return ""
+	}), nil
+}
+
+// sortedPrompts returns the registered prompts ordered by name.
+func (s *Server) sortedPrompts() []Prompt {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prompts := make([]Prompt, 0, len(s.prompts))
+	for _, prompt := range s.prompts {
+		prompts = append(prompts, prompt)
+	}
+	sort.Slice(prompts, func(i, j int) bool { return prompts[i].Name < prompts[j].Name })
+	return prompts
+}
+
+// handlePromptsList returns the list of available prompts.
+func (s *Server) handlePromptsList() map[string]interface{} {
+	sorted := s.sortedPrompts()
+	prompts := make([]map[string]interface{}, 0, len(sorted))
+
+	for _, prompt := range sorted {
+		item := map[string]interface{}{
+			"name":      prompt.Name,
+			"arguments": prompt.Arguments,
+		}
+		if prompt.Description != "" {
+			item["description"] = prompt.Description
+		}
+		prompts = append(prompts, item)
+	}
+
+	return map[string]interface{}{
+		"prompts": prompts,
+	}
+}
+
+// handlePromptGet handles a prompts/get request.
+func (s *Server) handlePromptGet(params map[string]interface{}) (map[string]interface{}, error) {
+	name, ok := params["name"].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("missing 'name' parameter")
+	}
+
+	s.mu.RLock()
+	prompt, exists := s.prompts[name]
+	s.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("prompt not found: %s", name)
+	}
+
+	// Re-read the file so edits to the prompt take effect without a reload
+	if file, err := loadPromptFile(prompt.File); err == nil {
+		prompt.Body = file.Body
+		prompt.Arguments = file.Arguments
+	}
+
+	arguments, _ := params["arguments"].(map[string]interface{})
+	text, err := prompt.Render(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"messages": []map[string]interface{}{
+			{
+				"role": "user",
+				"content": map[string]interface{}{
+					"type": "text",
+					"text": text,
+				},
+			},
+		},
+	}
+	if prompt.Description != "" {
+		result["description"] = prompt.Description
+	}
+
+	return result, nil
+}
@@ -0,0 +1,86 @@
+package proxy
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePromptFile(t *testing.T) {
+	prompt, err := parsePromptFile([]byte(`---
+description: Review a file
+arguments:
+  - name: file
+    description: File to review
+    required: true
+  - name: focus
+---
+Please review {{file}}.
+{{ focus }}
+`))
+	if err != nil {
+		t.Fatalf("parsePromptFile() error = %v", err)
+	}
+
+	if prompt.Description != "Review a file" {
+		t.Errorf("Expected description from front matter, got %q", prompt.Description)
+	}
+	if len(prompt.Arguments) != 2 || !prompt.Arguments[0].Required || prompt.Arguments[1].Required {
+		t.Errorf("Unexpected arguments: %+v", prompt.Arguments)
+	}
+	if prompt.Body != "Please review {{file}}.\n{{ focus }}" {
+		t.Errorf("Unexpected body: %q", prompt.Body)
+	}
+}
+
+func TestParsePromptFilePlaceholders(t *testing.T) {
+	prompt, err := parsePromptFile([]byte("Translate {{text}} to {{language}}, keep {{text}} short."))
+	if err != nil {
+		t.Fatalf("parsePromptFile() error = %v", err)
+	}
+
+	if len(prompt.Arguments) != 2 || prompt.Arguments[0].Name != "text" || prompt.Arguments[1].Name != "language" {
+		t.Errorf("Expected arguments from placeholders, got %+v", prompt.Arguments)
+	}
+
+	if _, err := parsePromptFile([]byte("---\ndescription: unterminated\n")); err == nil {
+		t.Error("Expected error for unterminated front matter")
+	}
+}
+
+func TestPromptGet(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "greet.md"), "---\ndescription: Greets\n---\nHello {{name}}!")
+
+	config, problems := ParseConfig([]byte("version: 1\nprompts:\n  greet:\n    file: ./greet.md\n"), dir)
+	if len(problems) > 0 {
+		t.Fatalf("Unexpected problems: %v", problems)
+	}
+
+	server := &Server{}
+	if err := server.ApplyConfig(config); err != nil {
+		t.Fatalf("ApplyConfig() error = %v", err)
+	}
+
+	prompts := server.handlePromptsList()["prompts"].([]map[string]interface{})
+	if len(prompts) != 1 || prompts[0]["description"] != "Greets" {
+		t.Fatalf("Unexpected prompts: %v", prompts)
+	}
+
+	result, err := server.handlePromptGet(map[string]interface{}{
+		"name":      "greet",
+		"arguments": map[string]interface{}{"name": "World"},
+	})
+	if err != nil {
+		t.Fatalf("handlePromptGet() error = %v", err)
+	}
+
+	messages := result["messages"].([]map[string]interface{})
+	text := messages[0]["content"].(map[string]interface{})["text"]
+	if text != "Hello World!" {
+		t.Errorf("Expected rendered prompt, got %q", text)
+	}
+
+	if _, err := server.handlePromptGet(map[string]interface{}{"name": "greet"}); err == nil {
+		t.Error("Expected error for missing required argument")
+	}
+}
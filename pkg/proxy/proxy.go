@@ -1,29 +1,48 @@
-// Package proxy provides functionality for proxying MCP tool requests to shell scripts.
+// Package proxy provides functionality for proxying MCP tool requests to shell
+// scripts and serving files, command output and prompt templates over MCP.
 package proxy
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/f/mcptools/pkg/protocol"
 )
 
+// DefaultConcurrency is the default number of scripts and commands the proxy executes at the same time.
+const DefaultConcurrency = 8
+
 // Parameter represents a tool parameter with a name and type.
 type Parameter struct {
-	Name     string
-	Type     string
-	Required bool
+	Name        string `json:"name" yaml:"name"`
+	Type        string `json:"type" yaml:"type"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool   `json:"required" yaml:"required"`
 }
 
-// Tool represents a proxy tool that executes a shell script or command.
+// validParameterTypes lists the normalized parameter types supported by the proxy.
+var validParameterTypes = map[string]bool{"string": true, "int": true, "float": true, "bool": true}
+
+// Tool represents a proxy tool that executes a shell script, a command, a
+// wrapped program or an OpenAPI operation.
 type Tool struct {
 	// Fields ordered for optimal memory alignment (8-byte aligned fields first)
+	InputSchema map[string]interface{} // Schema of tools not defined by Parameters
+	Operation   *Operation             // OpenAPI operation to call instead of a script
+	Wrapped     *WrappedCommand        // Program to run with generated arguments instead of a script
 	Name        string
 	Description string
 	ScriptPath  string
@@ -34,9 +53,16 @@ type Tool struct {
 // Server handles proxying requests to shell scripts.
 type Server struct {
 	// Fields ordered for optimal memory alignment (8-byte aligned fields first)
-	tools   map[string]Tool
-	logFile *os.File
-	id      int
+	tools       map[string]Tool
+	resources   map[string]Resource
+	prompts     map[string]Prompt
+	inFlight    map[string]context.CancelFunc // Running requests by request key
+	logFile     *os.File
+	workers     chan struct{}  // Limits concurrent script executions, nil for no limit
+	http        *httpTransport // Set while serving over streamable HTTP
+	mu          sync.RWMutex
+	writeMu     sync.Mutex
+	initialized bool
 }
 
 // NewProxyServer creates a new proxy server.
@@ -74,9 +100,12 @@ func NewProxyServer() (*Server, error) {
 	fmt.Fprintf(os.Stderr, "Logging to %s\n", logPath)
 
 	return &Server{
-		tools:   make(map[string]Tool),
-		id:      0,
-		logFile: logFile,
+		tools:     make(map[string]Tool),
+		resources: make(map[string]Resource),
+		prompts:   make(map[string]Prompt),
+		inFlight:  make(map[string]context.CancelFunc),
+		workers:   make(chan struct{}, DefaultConcurrency),
+		logFile:   logFile,
 	}, nil
 }
 
@@ -107,59 +136,206 @@ func (s *Server) Close() error {
 // AddTool adds a new tool to the proxy server.
 func (s *Server) AddTool(name, description, paramStr, scriptPath string, command string) error {
 	// Parse parameters
-	params, err := parseParameters(paramStr)
+	params, err := ParseParameters(paramStr)
 	if err != nil {
 		return fmt.Errorf("invalid parameters: %w", err)
 	}
 
+	tool, err := newTool(name, ToolConfig{
+		Description: description,
+		Parameters:  params,
+		Script:      scriptPath,
+		Command:     command,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.tools[name] = tool
+	s.mu.Unlock()
+
+	return nil
+}
+
+// newTool creates a tool from its configuration, validating the script if one is used.
+func newTool(name string, config ToolConfig) (Tool, error) {
 	// If a command is provided, use it directly
-	if command != "" {
-		s.tools[name] = Tool{
+	if config.Command != "" {
+		return Tool{
 			Name:        name,
-			Description: description,
-			Parameters:  params,
-			Command:     command,
-		}
-		return nil
+			Description: config.Description,
+			Parameters:  config.Parameters,
+			Command:     config.Command,
+		}, nil
 	}
 
 	// Otherwise, validate and use the script path
-	absPath, err := filepath.Abs(scriptPath)
+	absPath, err := filepath.Abs(config.Script)
 	if err != nil {
-		return fmt.Errorf("invalid script path: %w", err)
+		return Tool{}, fmt.Errorf("invalid script path: %w", err)
 	}
 
 	// Clean the path to avoid any path traversal
 	absPath = filepath.Clean(absPath)
 
+	if err := checkScript(absPath); err != nil {
+		return Tool{}, err
+	}
+
+	return Tool{
+		Name:        name,
+		Description: config.Description,
+		Parameters:  config.Parameters,
+		ScriptPath:  absPath,
+	}, nil
+}
+
+// checkScript verifies that path points to an executable file.
+func checkScript(path string) error {
 	// Check if script exists and is executable
-	info, err := os.Stat(absPath)
+	info, err := os.Stat(path)
 	if err != nil {
 		return fmt.Errorf("script not found: %w", err)
 	}
 
 	if info.IsDir() {
-		return fmt.Errorf("not a script: %s is a directory", absPath)
+		return fmt.Errorf("not a script: %s is a directory", path)
 	}
 
 	// Additional security check: verify the file is executable
 	if info.Mode()&0o111 == 0 {
-		return fmt.Errorf("script is not executable: %s", absPath)
+		return fmt.Errorf("script is not executable: %s", path)
 	}
 
-	s.tools[name] = Tool{
-		Name:        name,
-		Description: description,
-		Parameters:  params,
-		ScriptPath:  absPath,
+	return nil
+}
+
+// ApplyConfig replaces the registered tools, resources and prompts with the ones
+// in config. When a list changes after the client has initialized, the matching
+// notifications/*/list_changed notification is sent.
+func (s *Server) ApplyConfig(config *Config) error {
+	tools := make(map[string]Tool, len(config.Tools))
+	for _, name := range config.ToolNames() {
+		toolConfig := config.Tools[name]
+		toolConfig.Script = config.resolvePath(toolConfig.Script)
+		tool, err := newTool(name, toolConfig)
+		if err != nil {
+			return fmt.Errorf("error adding tool %s: %w", name, err)
+		}
+		tools[name] = tool
+	}
+
+	for _, name := range sortedKeys(config.OpenAPI) {
+		apiTools, err := LoadOpenAPITools(config.OpenAPI[name], config.baseDir)
+		if err != nil {
+			return fmt.Errorf("error adding OpenAPI tools from %s: %w", name, err)
+		}
+		for _, tool := range apiTools {
+			if _, exists := tools[tool.Name]; exists {
+				return fmt.Errorf("error adding OpenAPI tools from %s: duplicate tool name %s", name, tool.Name)
+			}
+			tools[tool.Name] = tool
+		}
+	}
+
+	for _, name := range sortedKeys(config.Wrap) {
+		wrapTools, err := LoadWrapTools(name, config.Wrap[name], config.baseDir)
+		if err != nil {
+			return fmt.Errorf("error adding wrapped program %s: %w", name, err)
+		}
+		for _, tool := range wrapTools {
+			if _, exists := tools[tool.Name]; exists {
+				return fmt.Errorf("error adding wrapped program %s: duplicate tool name %s", name, tool.Name)
+			}
+			tools[tool.Name] = tool
+		}
+	}
+
+	resources := make(map[string]Resource, len(config.Resources))
+	for _, name := range sortedKeys(config.Resources) {
+		resource, err := NewResource(name, config.Resources[name], config.baseDir)
+		if err != nil {
+			return fmt.Errorf("error adding resource %s: %w", name, err)
+		}
+		resources[name] = resource
+	}
+
+	prompts := make(map[string]Prompt, len(config.Prompts))
+	for _, name := range sortedKeys(config.Prompts) {
+		prompt, err := NewPrompt(name, config.Prompts[name], config.baseDir)
+		if err != nil {
+			return fmt.Errorf("error adding prompt %s: %w", name, err)
+		}
+		prompts[name] = prompt
+	}
+
+	s.mu.Lock()
+	toolsChanged := !reflect.DeepEqual(s.tools, tools)
+	resourcesChanged := !reflect.DeepEqual(s.resources, resources)
+	promptsChanged := !reflect.DeepEqual(s.prompts, prompts)
+	s.tools = tools
+	s.resources = resources
+	s.prompts = prompts
+	s.mu.Unlock()
+
+	if toolsChanged || resourcesChanged || promptsChanged {
+		s.log(fmt.Sprintf("Loaded %d tools, %d resources and %d prompts from config",
+			len(tools), len(resources), len(prompts)))
+	}
+	if toolsChanged {
+		s.broadcast("notifications/tools/list_changed", nil)
+	}
+	if resourcesChanged {
+		s.broadcast("notifications/resources/list_changed", nil)
+	}
+	if promptsChanged {
+		s.broadcast("notifications/prompts/list_changed", nil)
 	}
 
 	return nil
 }
 
-// parseParameters parses a comma-separated parameter string in the format "name:type,name:type".
+// broadcast sends a notification to every client that has completed initialization.
+func (s *Server) broadcast(method string, params any) {
+	s.mu.RLock()
+	transport := s.http
+	initialized := s.initialized
+	s.mu.RUnlock()
+
+	if transport != nil {
+		transport.broadcast(method, params)
+		return
+	}
+	if initialized {
+		s.writeNotification(method, params)
+	}
+}
+
+// getTool returns the tool with the given name.
+func (s *Server) getTool(name string) (Tool, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tool, exists := s.tools[name]
+	return tool, exists
+}
+
+// sortedTools returns the registered tools ordered by name.
+func (s *Server) sortedTools() []Tool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tools := make([]Tool, 0, len(s.tools))
+	for _, tool := range s.tools {
+		tools = append(tools, tool)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools
+}
+
+// ParseParameters parses a comma-separated parameter string in the format "name:type,name:type".
 // If a parameter is wrapped in square brackets like [name:type], it's considered optional.
-func parseParameters(paramStr string) ([]Parameter, error) {
+func ParseParameters(paramStr string) ([]Parameter, error) {
 	if paramStr == "" {
 		return []Parameter{}, nil
 	}
@@ -196,8 +372,7 @@ func parseParameters(paramStr string) ([]Parameter, error) {
 		normalizedType := jsonutils.NormalizeParameterType(paramType)
 
 		// Validate parameter type
-		validTypes := map[string]bool{"string": true, "int": true, "float": true, "bool": true}
-		if !validTypes[normalizedType] {
+		if !validParameterTypes[normalizedType] {
 			return nil, fmt.Errorf("invalid parameter type: %s, supported types: string, int, float, bool", paramType)
 		}
 
@@ -212,12 +387,45 @@ func parseParameters(paramStr string) ([]Parameter, error) {
 }
 
 // ExecuteScript executes a shell script or command with the given parameters.
-func (s *Server) ExecuteScript(toolName string, args map[string]interface{}) (string, error) {
-	tool, exists := s.tools[toolName]
+// Canceling ctx kills the script.
+func (s *Server) ExecuteScript(ctx context.Context, toolName string, args map[string]interface{}) (string, error) {
+	return s.executeScript(ctx, toolName, args, nil)
+}
+
+// executeScript implements ExecuteScript, calling onLine for every line the
+// script prints while it runs when onLine is not nil.
+func (s *Server) executeScript(ctx context.Context, toolName string, args map[string]interface{}, onLine func(string)) (string, error) {
+	tool, exists := s.getTool(toolName)
 	if !exists {
 		return "", fmt.Errorf("tool not found: %s", toolName)
 	}
 
+	var command string
+	if tool.Command != "" {
+		// Use the inline command
+		command = tool.Command
+	} else {
+		// Use the script file
+		scriptPath := filepath.Clean(tool.ScriptPath)
+		if err := checkScript(scriptPath); err != nil {
+			return "", err
+		}
+		command = scriptPath
+	}
+
+	output, err := runShell(ctx, command, args, onLine)
+	if err != nil {
+		return "", fmt.Errorf("error executing command: %w", err)
+	}
+
+	return string(output), nil
+}
+
+// runShell runs command with the system shell, passing args as environment
+// variables, and returns its standard output. When onLine is not nil, it is
+// called with every output line as soon as it is printed. Canceling ctx kills
+// the command and every process it started.
+func runShell(ctx context.Context, command string, args map[string]interface{}, onLine func(string)) ([]byte, error) {
 	// Set up environment variables for the script/command
 	env := os.Environ()
 	for name, value := range args {
@@ -233,47 +441,67 @@ func (s *Server) ExecuteScript(toolName string, args map[string]interface{}) (st
 		shell = "/bin/bash"
 	}
 
-	var cmd *exec.Cmd
-	if tool.Command != "" {
-		// Use the inline command
-		// #nosec G204 - Command is validated and comes from a trusted source (config)
-		cmd = exec.Command(shell, "-c", tool.Command)
-	} else {
-		// Use the script file
-		scriptPath := filepath.Clean(tool.ScriptPath)
-		info, err := os.Stat(scriptPath)
-		if err != nil {
-			return "", fmt.Errorf("script not found or not accessible: %w", err)
-		}
-		if info.IsDir() {
-			return "", fmt.Errorf("not a script: %s is a directory", scriptPath)
-		}
-		if info.Mode()&0o111 == 0 {
-			return "", fmt.Errorf("script is not executable: %s", scriptPath)
-		}
-		// #nosec G204 - scriptPath is validated and comes from a trusted source (config)
-		cmd = exec.Command(shell, "-c", scriptPath)
-	}
-
+	// #nosec G204 - command is validated and comes from a trusted source (config)
+	cmd := exec.CommandContext(ctx, shell, "-c", command)
 	cmd.Env = env
 	cmd.Stderr = os.Stderr
+	return runCommand(cmd, onLine)
+}
+
+// runCommand runs cmd and returns its standard output, calling onLine with every
+// output line as soon as it is printed when onLine is not nil. The command and
+// every process it started are killed when the context of cmd is canceled.
+func runCommand(cmd *exec.Cmd, onLine func(string)) ([]byte, error) {
+	killProcessGroup(cmd)
 
-	// Execute and capture output
-	output, err := cmd.Output()
+	if onLine == nil {
+		// Execute and capture output
+		return cmd.Output()
+	}
+
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return "", fmt.Errorf("error executing command: %w", err)
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
 	}
 
-	return string(output), nil
+	// Capture the full output while streaming it line by line
+	var output bytes.Buffer
+	reader := bufio.NewReader(io.TeeReader(stdout, &output))
+	for {
+		line, readErr := reader.ReadString('\n')
+		if line != "" {
+			onLine(strings.TrimRight(line, "\r\n"))
+		}
+		if readErr != nil {
+			break
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
 }
 
 // GetToolSchema generates a JSON schema for the tool's parameters.
 func (s *Server) GetToolSchema(toolName string) (map[string]interface{}, error) {
-	tool, exists := s.tools[toolName]
+	tool, exists := s.getTool(toolName)
 	if !exists {
 		return nil, fmt.Errorf("tool not found: %s", toolName)
 	}
 
+	return toolSchema(tool), nil
+}
+
+// toolSchema generates a JSON schema directly from the tool parameters.
+func toolSchema(tool Tool) map[string]interface{} {
+	if tool.InputSchema != nil {
+		return tool.InputSchema
+	}
+
 	properties := make(map[string]interface{})
 	required := make([]string, 0, len(tool.Parameters))
 
@@ -299,6 +527,10 @@ func (s *Server) GetToolSchema(toolName string) (map[string]interface{}, error)
 			}
 		}
 
+		if param.Description != "" && paramSchema != nil {
+			paramSchema["description"] = param.Description
+		}
+
 		properties[param.Name] = paramSchema
 
 		// Only add the parameter to required list if it's marked as required
@@ -316,12 +548,18 @@ func (s *Server) GetToolSchema(toolName string) (map[string]interface{}, error)
 		schema["required"] = required
 	}
 
-	return schema, nil
+	return schema
 }
 
 // Start begins listening for JSON-RPC requests on stdin and responding on stdout.
+// Requests are handled concurrently and responses are written as they finish.
 func (s *Server) Start() error {
-	decoder := json.NewDecoder(os.Stdin)
+	return s.serve(os.Stdin)
+}
+
+// serve handles the JSON-RPC requests read from r until it is closed.
+func (s *Server) serve(r io.Reader) error {
+	decoder := json.NewDecoder(r)
 
 	s.log("Proxy server started, waiting for requests...")
 	fmt.Fprintf(os.Stderr, "Proxy server started, waiting for requests...\n")
@@ -333,16 +571,19 @@ func (s *Server) Start() error {
 		}
 	}()
 
+	// Let running requests finish before returning
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
 	for {
 		// Request struct with fields ordered for optimal memory alignment
 		var request struct {
 			Method  string                 `json:"method"`           // string (16 bytes: pointer + len)
 			Params  map[string]interface{} `json:"params,omitempty"` // map (8 bytes)
 			JSONRPC string                 `json:"jsonrpc"`          // string (16 bytes: pointer + len)
-			ID      int                    `json:"id"`               // int (8 bytes)
+			ID      json.RawMessage        `json:"id,omitempty"`     // slice (24 bytes)
 		}
 
-		fmt.Fprintf(os.Stderr, "Waiting for request...\n")
 		if err := decoder.Decode(&request); err != nil {
 			if err == io.EOF {
 				s.log("Client disconnected (EOF)")
@@ -355,39 +596,179 @@ func (s *Server) Start() error {
 
 		// Log the incoming request
 		s.logJSON("Received request", request)
-		fmt.Fprintf(os.Stderr, "Received request: %s (ID: %d)\n", request.Method, request.ID)
-		s.id = request.ID
+		fmt.Fprintf(os.Stderr, "Received request: %s (ID: %s)\n", request.Method, request.ID)
 
 		// Handle notifications (methods without an ID)
-		if request.Method == "notifications/initialized" {
-			fmt.Fprintf(os.Stderr, "Received initialization notification\n")
-			s.log("Received initialization notification")
+		if len(request.ID) == 0 {
+			s.handleNotification("", request.Method, request.Params)
+			continue
+		}
+
+		// Responses to server requests are not expected
+		if request.Method == "" {
 			continue
 		}
 
-		var response any
-		var err error
+		// Track the request before reading on, so a cancellation that follows it
+		// right away finds it
+		key := requestKey("", request.ID)
+		ctx, cancel := s.track(context.Background(), key)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer s.untrack(key, cancel)
+
+			ctx = withNotifier(ctx, s.writeNotification)
+
+			response, err := s.handleRequest(ctx, request.Method, request.Params)
+			if ctx.Err() != nil {
+				// Cancelled requests receive no response
+				s.log(fmt.Sprintf("Request %s cancelled", request.ID))
+				return
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error handling request: %v\n", err)
+				s.log(fmt.Sprintf("Error handling request: %v", err))
+				s.writeError(request.ID, err)
+				return
+			}
+
+			s.writeResponse(request.ID, response)
+		}()
+	}
+}
+
+// SetConcurrency limits the number of scripts and commands executed at the same
+// time. A limit of zero or less removes the limit.
+func (s *Server) SetConcurrency(limit int) {
+	if limit <= 0 {
+		s.workers = nil
+		return
+	}
+	s.workers = make(chan struct{}, limit)
+}
 
-		switch request.Method {
-		case "initialize":
-			response = s.handleInitialize(request.Params)
-		case "tools/list":
-			response = s.handleToolsList()
-		case "tools/call":
-			response, err = s.handleToolCall(request.Params)
-		default:
-			err = fmt.Errorf("method not found")
+// acquireWorker waits for a free worker slot or for ctx to be canceled.
+func (s *Server) acquireWorker(ctx context.Context) error {
+	if s.workers == nil {
+		return nil
+	}
+	select {
+	case s.workers <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// releaseWorker frees a worker slot taken by acquireWorker.
+func (s *Server) releaseWorker() {
+	if s.workers != nil {
+		<-s.workers
+	}
+}
+
+// requestKey identifies a request of a client session for cancellation.
+func requestKey(session string, id any) string {
+	var raw []byte
+	switch v := id.(type) {
+	case json.RawMessage:
+		// Normalize the raw ID so 1 and 1.0 or differently spaced values match
+		var value any
+		if err := json.Unmarshal(v, &value); err == nil {
+			raw, _ = json.Marshal(value)
+		} else {
+			raw = v
 		}
+	default:
+		raw, _ = json.Marshal(v)
+	}
+	return session + "/" + string(raw)
+}
 
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error handling request: %v\n", err)
-			s.log(fmt.Sprintf("Error handling request: %v", err))
-			s.writeError(err)
-			continue
+// track registers a running request so it can be canceled by the client.
+func (s *Server) track(parent context.Context, key string) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+
+	s.mu.Lock()
+	if s.inFlight == nil {
+		s.inFlight = make(map[string]context.CancelFunc)
+	}
+	s.inFlight[key] = cancel
+	s.mu.Unlock()
+
+	return ctx, cancel
+}
+
+// untrack removes a finished request registered with track.
+func (s *Server) untrack(key string, cancel context.CancelFunc) {
+	s.mu.Lock()
+	delete(s.inFlight, key)
+	s.mu.Unlock()
+	cancel()
+}
+
+// handleNotification handles a notification sent by the client of a session.
+func (s *Server) handleNotification(session, method string, params map[string]interface{}) {
+	switch method {
+	case "notifications/initialized":
+		fmt.Fprintf(os.Stderr, "Received initialization notification\n")
+		s.log("Received initialization notification")
+		s.mu.Lock()
+		s.initialized = true
+		s.mu.Unlock()
+	case "notifications/cancelled":
+		requestID, exists := params["requestId"]
+		if !exists {
+			return
 		}
+		key := requestKey(session, requestID)
 
-		fmt.Fprintf(os.Stderr, "Sending response\n")
-		s.writeResponse(response)
+		s.mu.RLock()
+		cancel, running := s.inFlight[key]
+		s.mu.RUnlock()
+
+		if running {
+			reason, _ := params["reason"].(string)
+			s.log(fmt.Sprintf("Cancelling request %v: %s", requestID, reason))
+			cancel()
+		}
+	}
+}
+
+// handleRequest dispatches a JSON-RPC request to the handler for its method.
+// Methods that run scripts or commands wait for a free worker first.
+func (s *Server) handleRequest(ctx context.Context, method string, params map[string]interface{}) (any, error) {
+	switch method {
+	case "initialize":
+		return s.handleInitialize(params), nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		return s.handleToolsList(), nil
+	case "tools/call":
+		if err := s.acquireWorker(ctx); err != nil {
+			return nil, err
+		}
+		defer s.releaseWorker()
+		return s.handleToolCall(ctx, params)
+	case "resources/list":
+		return s.handleResourcesList(), nil
+	case "resources/templates/list":
+		return s.handleResourceTemplatesList(), nil
+	case "resources/read":
+		if err := s.acquireWorker(ctx); err != nil {
+			return nil, err
+		}
+		defer s.releaseWorker()
+		return s.handleResourceRead(ctx, params)
+	case "prompts/list":
+		return s.handlePromptsList(), nil
+	case "prompts/get":
+		return s.handlePromptGet(params)
+	default:
+		return nil, fmt.Errorf("method not found")
 	}
 }
 
@@ -400,15 +781,22 @@ func (s *Server) handleInitialize(params map[string]interface{}) map[string]inte
 		fmt.Fprintf(os.Stderr, "Client initialized: %s v%s\n", clientName, clientVersion)
 	}
 
-	// Extract protocol version from params, defaulting to latest if not provided
-	protocolVersion := "2024-11-05"
-	if version, ok := params["protocolVersion"].(string); ok {
-		protocolVersion = version
-	}
+	// The requested protocol version is used when it is supported, and the latest
+	// version otherwise, for the client to decide whether it supports it
+	requestedVersion, _ := params["protocolVersion"].(string)
+	protocolVersion := protocol.Negotiate(requestedVersion)
 
 	// Return server information and capabilities in the format expected by clients
 	capabilities := map[string]interface{}{
-		"tools": map[string]interface{}{},
+		"tools": map[string]interface{}{
+			"listChanged": true,
+		},
+		"resources": map[string]interface{}{
+			"listChanged": true,
+		},
+		"prompts": map[string]interface{}{
+			"listChanged": true,
+		},
 	}
 
 	return map[string]interface{}{
@@ -423,56 +811,14 @@ func (s *Server) handleInitialize(params map[string]interface{}) map[string]inte
 
 // handleToolsList returns the list of available tools.
 func (s *Server) handleToolsList() map[string]interface{} {
-	tools := make([]map[string]interface{}, 0, len(s.tools))
-
-	for _, tool := range s.tools {
-		// Generate schema directly from the tool parameters
-		properties := make(map[string]interface{})
-		required := make([]string, 0, len(tool.Parameters))
-
-		for _, param := range tool.Parameters {
-			var paramSchema map[string]interface{}
-
-			switch param.Type {
-			case "string":
-				paramSchema = map[string]interface{}{
-					"type": "string",
-				}
-			case "int":
-				paramSchema = map[string]interface{}{
-					"type": "integer",
-				}
-			case "float":
-				paramSchema = map[string]interface{}{
-					"type": "number",
-				}
-			case "bool":
-				paramSchema = map[string]interface{}{
-					"type": "boolean",
-				}
-			}
-
-			properties[param.Name] = paramSchema
-
-			// Only add to required list if the parameter is required
-			if param.Required {
-				required = append(required, param.Name)
-			}
-		}
-
-		schema := map[string]interface{}{
-			"type":       "object",
-			"properties": properties,
-		}
-
-		if len(required) > 0 {
-			schema["required"] = required
-		}
+	sorted := s.sortedTools()
+	tools := make([]map[string]interface{}, 0, len(sorted))
 
+	for _, tool := range sorted {
 		tools = append(tools, map[string]interface{}{
 			"name":        tool.Name,
 			"description": tool.Description,
-			"inputSchema": schema,
+			"inputSchema": toolSchema(tool),
 		})
 	}
 
@@ -482,7 +828,7 @@ func (s *Server) handleToolsList() map[string]interface{} {
 }
 
 // handleToolCall handles a tool call request.
-func (s *Server) handleToolCall(params map[string]interface{}) (map[string]interface{}, error) {
+func (s *Server) handleToolCall(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
 	nameValue, ok := params["name"]
 	if !ok {
 		return nil, fmt.Errorf("missing 'name' parameter")
@@ -493,7 +839,7 @@ func (s *Server) handleToolCall(params map[string]interface{}) (map[string]inter
 		return nil, fmt.Errorf("'name' parameter must be a string")
 	}
 
-	tool, exists := s.tools[name]
+	tool, exists := s.getTool(name)
 	if !exists {
 		return nil, fmt.Errorf("tool not found: %s", name)
 	}
@@ -521,8 +867,28 @@ func (s *Server) handleToolCall(params map[string]interface{}) (map[string]inter
 	// Log the input parameters
 	s.logJSON("Tool input", arguments)
 
+	if tool.Operation != nil {
+		required, _ := tool.InputSchema["required"].([]string)
+		for _, name := range required {
+			if _, exists := arguments[name]; !exists {
+				return nil, fmt.Errorf("missing required parameter: %s", name)
+			}
+		}
+		return s.callOperation(ctx, tool.Operation, arguments)
+	}
+
+	// Stream output lines as progress notifications when the client asked for progress
+	var onLine func(string)
+	if reporter := newProgressReporter(ctx, params); reporter != nil {
+		onLine = reporter.line
+	}
+
+	if tool.Wrapped != nil {
+		return s.callWrapped(ctx, tool.Wrapped, arguments, onLine)
+	}
+
 	// Execute the shell script
-	output, err := s.ExecuteScript(name, arguments)
+	output, err := s.executeScript(ctx, name, arguments, onLine)
 	if err != nil {
 		s.log(fmt.Sprintf("Error executing script: %v", err))
 		return nil, fmt.Errorf("error executing script: %w", err)
@@ -561,16 +927,18 @@ func (s *Server) handleToolCall(params map[string]interface{}) (map[string]inter
 }
 
 // writeResponse writes a successful JSON-RPC response to stdout.
-func (s *Server) writeResponse(result any) {
+func (s *Server) writeResponse(id any, result any) {
 	response := map[string]interface{}{
 		"jsonrpc": "2.0",
-		"id":      s.id,
+		"id":      id,
 		"result":  result,
 	}
 
 	// Log the outgoing response
 	s.logJSON("Sending response", response)
 
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 	err := json.NewEncoder(os.Stdout).Encode(response)
 	if err != nil {
 		s.log(fmt.Sprintf("Error encoding response: %v", err))
@@ -579,56 +947,150 @@ func (s *Server) writeResponse(result any) {
 }
 
 // writeError writes a JSON-RPC error response to stdout.
-func (s *Server) writeError(err error) {
+func (s *Server) writeError(id any, err error) {
+	response := errorResponse(id, err)
+
+	// Log the outgoing error response
+	s.logJSON("Sending error response", response)
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	encodeErr := json.NewEncoder(os.Stdout).Encode(response)
+	if encodeErr != nil {
+		s.log(fmt.Sprintf("Error encoding error response: %v", encodeErr))
+		fmt.Fprintf(os.Stderr, "Error encoding error response: %v\n", encodeErr)
+	}
+}
+
+// errorResponse builds a JSON-RPC error response for the request with the given id.
+func errorResponse(id any, err error) map[string]interface{} {
 	// Use method not found error code for unsupported methods
 	code := -32000 // Default server error
 	if err.Error() == "method not found" {
 		code = -32601 // Method not found error code
 	}
 
-	response := map[string]interface{}{
+	return map[string]interface{}{
 		"jsonrpc": "2.0",
-		"id":      s.id,
+		"id":      id,
 		"error": map[string]interface{}{
 			"code":    code,
 			"message": err.Error(),
 		},
 	}
+}
 
-	// Log the outgoing error response
-	s.logJSON("Sending error response", response)
+// writeNotification writes a JSON-RPC notification to stdout.
+func (s *Server) writeNotification(method string, params any) {
+	notification := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+	}
+	if params != nil {
+		notification["params"] = params
+	}
 
-	encodeErr := json.NewEncoder(os.Stdout).Encode(response)
-	if encodeErr != nil {
-		s.log(fmt.Sprintf("Error encoding error response: %v", encodeErr))
-		fmt.Fprintf(os.Stderr, "Error encoding error response: %v\n", encodeErr)
+	// Log the outgoing notification
+	s.logJSON("Sending notification", notification)
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := json.NewEncoder(os.Stdout).Encode(notification); err != nil {
+		s.log(fmt.Sprintf("Error encoding notification: %v", err))
+		fmt.Fprintf(os.Stderr, "Error encoding notification: %v\n", err)
 	}
 }
 
-// RunProxyServer creates and runs a proxy server with the specified tool configs.
-func RunProxyServer(toolConfigs map[string]map[string]string) error {
+// Options configures RunProxyServer.
+type Options struct {
+	// ConfigPath is the path of the proxy configuration file.
+	ConfigPath string
+	// Transport is "stdio" (default) or "http" for streamable HTTP.
+	Transport string
+	// Listen is the address the HTTP transport listens on.
+	Listen string
+	// TokenFile holds the bearer tokens accepted by the HTTP transport.
+	TokenFile string
+	// Concurrency limits the number of scripts and commands executed at the same time.
+	Concurrency int
+	// Watch reloads the configuration whenever the file changes.
+	Watch bool
+}
+
+// RunProxyServer creates and runs a proxy server with the tools from the configuration file.
+func RunProxyServer(options Options) error {
+	config, err := LoadConfig(options.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	var httpOptions HTTPOptions
+	switch options.Transport {
+	case "", "stdio":
+	case "http":
+		httpOptions = HTTPOptions{Listen: options.Listen, Concurrency: options.Concurrency}
+		if httpOptions.Listen == "" {
+			httpOptions.Listen = ":8080"
+		}
+		if options.TokenFile != "" {
+			if httpOptions.Tokens, err = LoadTokens(options.TokenFile); err != nil {
+				return err
+			}
+		} else {
+			fmt.Fprintln(os.Stderr, "Warning: no --token-file given, the proxy accepts unauthenticated requests")
+		}
+	default:
+		return fmt.Errorf("unsupported transport: %s (supported: stdio, http)", options.Transport)
+	}
+
 	server, err := NewProxyServer()
 	if err != nil {
 		return fmt.Errorf("error creating server: %w", err)
 	}
+	if options.Concurrency != 0 {
+		server.SetConcurrency(options.Concurrency)
+	}
+
+	// Add tools from config
+	if applyErr := server.ApplyConfig(config); applyErr != nil {
+		return applyErr
+	}
 
-	// Add tools from configs
-	for name, config := range toolConfigs {
-		description := config["description"]
-		parameters := config["parameters"]
-		scriptPath := config["script"]
-		command := config["command"]
+	// Print registered tools, resources and prompts
+	fmt.Fprintln(os.Stderr, "Registered proxy tools:")
+	server.printTools()
+	server.printResourcesAndPrompts()
 
-		addErr := server.AddTool(name, description, parameters, scriptPath, command)
-		if addErr != nil {
-			return fmt.Errorf("error adding tool %s: %w", name, addErr)
+	if options.Watch {
+		stop, watchErr := server.WatchConfig(options.ConfigPath)
+		if watchErr != nil {
+			return fmt.Errorf("error watching config: %w", watchErr)
 		}
+		defer stop()
+		fmt.Fprintf(os.Stderr, "Watching %s for changes\n", options.ConfigPath)
 	}
 
-	// Print registered tools
-	fmt.Fprintln(os.Stderr, "Registered proxy tools:")
-	for name, tool := range server.tools {
-		fmt.Fprintf(os.Stderr, "- %s: %s (%s: %s)\n", name, tool.Description,
+	server.log(fmt.Sprintf("Starting proxy server with %d tools, %d resources and %d prompts",
+		len(config.Tools), len(config.Resources), len(config.Prompts)))
+	if options.Transport == "http" {
+		return server.StartHTTP(httpOptions)
+	}
+	return server.Start()
+}
+
+// printTools prints the registered tools and their parameters to stderr.
+func (s *Server) printTools() {
+	for _, tool := range s.sortedTools() {
+		if tool.Operation != nil {
+			fmt.Fprintf(os.Stderr, "- %s: %s (api: %s)\n", tool.Name, tool.Description, tool.Operation.BaseURL)
+			continue
+		}
+		if tool.Wrapped != nil {
+			fmt.Fprintf(os.Stderr, "- %s: %s (exec: %s %s)\n", tool.Name, tool.Description,
+				tool.Wrapped.Binary, strings.Join(tool.Wrapped.Args, " "))
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "- %s: %s (%s: %s)\n", tool.Name, tool.Description,
 			map[bool]string{true: "script", false: "command"}[tool.ScriptPath != ""],
 			map[bool]string{true: tool.ScriptPath, false: tool.Command}[tool.ScriptPath != ""])
 		paramStr := ""
@@ -646,7 +1108,29 @@ func RunProxyServer(toolConfigs map[string]map[string]string) error {
 			fmt.Fprintf(os.Stderr, "  Parameters: %s\n", paramStr)
 		}
 	}
+}
 
-	server.log(fmt.Sprintf("Starting proxy server with %d tools", len(toolConfigs)))
-	return server.Start()
+// printResourcesAndPrompts prints the registered resources and prompts to stderr.
+func (s *Server) printResourcesAndPrompts() {
+	if resources := s.sortedResources(); len(resources) > 0 {
+		fmt.Fprintln(os.Stderr, "Registered proxy resources:")
+		for _, resource := range resources {
+			source := map[bool]string{true: "glob: " + resource.Glob, false: "file: " + resource.File}[resource.Glob != ""]
+			if resource.Command != "" {
+				source = "command: " + resource.Command
+			}
+			uri := resource.URI
+			if uri == "" {
+				uri = "file://..."
+			}
+			fmt.Fprintf(os.Stderr, "- %s: %s (%s)\n", resource.Name, uri, source)
+		}
+	}
+
+	if prompts := s.sortedPrompts(); len(prompts) > 0 {
+		fmt.Fprintln(os.Stderr, "Registered proxy prompts:")
+		for _, prompt := range prompts {
+			fmt.Fprintf(os.Stderr, "- %s: %s (file: %s)\n", prompt.Name, prompt.Description, prompt.File)
+		}
+	}
 }
@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/f/mcptools/pkg/protocol"
+)
+
+func newTestServer(t *testing.T, tools map[string]ToolConfig) *Server {
+	t.Helper()
+
+	config := NewConfig()
+	config.Tools = tools
+
+	server := &Server{}
+	if err := server.ApplyConfig(config); err != nil {
+		t.Fatalf("ApplyConfig() error = %v", err)
+	}
+	return server
+}
+
+func TestCancelRequest(t *testing.T) {
+	server := newTestServer(t, map[string]ToolConfig{
+		"slow": {Command: "sleep 30 & wait"},
+	})
+
+	key := requestKey("", json.RawMessage(`7`))
+	ctx, cancel := server.track(context.Background(), key)
+	defer server.untrack(key, cancel)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := server.handleRequest(ctx, "tools/call", map[string]interface{}{
+			"name":      "slow",
+			"arguments": map[string]interface{}{},
+		})
+		done <- err
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	server.handleNotification("", "notifications/cancelled", map[string]interface{}{
+		"requestId": float64(7),
+		"reason":    "test",
+	})
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Expected cancelled tool call to fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Cancelled tool call did not stop")
+	}
+}
+
+func TestCancelRequestRightAfterIt(t *testing.T) {
+	server := newTestServer(t, map[string]ToolConfig{
+		"slow": {Command: "sleep 30 & wait"},
+	})
+
+	input := `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"slow","arguments":{}}}
+{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7}}
+`
+	done := make(chan error, 1)
+	go func() {
+		done <- server.serve(strings.NewReader(input))
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serve() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Cancellation sent right after the request was lost")
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	server := newTestServer(t, map[string]ToolConfig{
+		"wait": {Command: "sleep 0.3"},
+	})
+	server.SetConcurrency(2)
+
+	start := time.Now()
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		go func() {
+			_, _ = server.handleRequest(context.Background(), "tools/call", map[string]interface{}{
+				"name":      "wait",
+				"arguments": map[string]interface{}{},
+			})
+			done <- struct{}{}
+		}()
+	}
+
+	// Requests that do not run scripts are never blocked by the worker pool
+	if _, err := server.handleRequest(context.Background(), "ping", nil); err != nil {
+		t.Errorf("ping error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("ping waited for running scripts: %v", elapsed)
+	}
+
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+
+	// Four calls with two workers need two rounds
+	if elapsed := time.Since(start); elapsed < 600*time.Millisecond {
+		t.Errorf("Expected calls to be limited to 2 at a time, finished in %v", elapsed)
+	}
+}
+
+func TestInitializeNegotiatesVersion(t *testing.T) {
+	server := newTestServer(t, nil)
+	for requested, expected := range map[string]string{
+		"2024-11-05": "2024-11-05",
+		"2099-01-01": protocol.Latest,
+	} {
+		result, err := server.handleRequest(context.Background(), "initialize", map[string]interface{}{"protocolVersion": requested})
+		if err != nil {
+			t.Fatalf("initialize error = %v", err)
+		}
+		if version := result.(map[string]interface{})["protocolVersion"]; version != expected {
+			t.Errorf("initialize with %s negotiated %v, want %s", requested, version, expected)
+		}
+	}
+}
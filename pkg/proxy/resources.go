@@ -0,0 +1,363 @@
+package proxy
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yosida95/uritemplate/v3"
+)
+
+// Resource represents a proxy resource backed by a file, a glob of files or
+// the output of a command. Resources whose URI contains {variables} are
+// exposed as resource templates.
+type Resource struct {
+	// Fields ordered for optimal memory alignment (8-byte aligned fields first)
+	template    *uritemplate.Template
+	Name        string
+	URI         string
+	Description string
+	MimeType    string
+	File        string // Absolute file path, may contain {variables} for templates
+	Glob        string // Absolute glob pattern
+	Command     string // Inline command whose output is the resource content
+}
+
+// IsTemplate reports whether the resource is exposed as a resource template.
+func (r Resource) IsTemplate() bool {
+	return r.Glob == "" && r.template != nil && len(r.template.Varnames()) > 0
+}
+
+// NewResource creates a resource from its configuration, resolving relative paths against baseDir.
+func NewResource(name string, config ResourceConfig, baseDir string) (Resource, error) {
+	resource := Resource{
+		Name:        name,
+		URI:         config.URI,
+		Description: config.Description,
+		MimeType:    config.MimeType,
+		Command:     config.Command,
+	}
+
+	if config.URI != "" {
+		template, err := uritemplate.New(config.URI)
+		if err != nil {
+			return Resource{}, fmt.Errorf("invalid uri template: %w", err)
+		}
+		resource.template = template
+	}
+
+	switch {
+	case config.File != "":
+		resource.File = absPath(config.File, baseDir)
+	case config.Glob != "":
+		resource.Glob = absPath(config.Glob, baseDir)
+		if resource.template != nil && !hasVarname(resource.template, "path") {
+			return Resource{}, fmt.Errorf("uri of a glob resource must contain {path} or {+path}")
+		}
+	case config.Command == "":
+		return Resource{}, fmt.Errorf("exactly one of file, glob or command must be set")
+	}
+
+	return resource, nil
+}
+
+// absPath resolves path against baseDir and cleans it.
+func absPath(path, baseDir string) string {
+	if !filepath.IsAbs(path) && baseDir != "" {
+		path = filepath.Join(baseDir, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return filepath.Clean(path)
+}
+
+// hasVarname reports whether the template uses the variable name.
+func hasVarname(template *uritemplate.Template, name string) bool {
+	for _, varname := range template.Varnames() {
+		if varname == name {
+			return true
+		}
+	}
+	return false
+}
+
+// globEntry is a single file matched by a glob resource.
+type globEntry struct {
+	URI  string
+	Path string
+}
+
+// globEntries expands a glob resource into the files it currently matches.
+func (r Resource) globEntries() ([]globEntry, error) {
+	matches, err := filepath.Glob(r.Glob)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob: %w", err)
+	}
+	sort.Strings(matches)
+
+	base := globBase(r.Glob)
+	entries := make([]globEntry, 0, len(matches))
+	for _, match := range matches {
+		info, statErr := os.Stat(match)
+		if statErr != nil || info.IsDir() {
+			continue
+		}
+
+		uri := (&url.URL{Scheme: "file", Path: filepath.ToSlash(match)}).String()
+		if r.template != nil {
+			rel, relErr := filepath.Rel(base, match)
+			if relErr != nil {
+				continue
+			}
+			values := uritemplate.Values{}
+			values.Set("path", uritemplate.String(filepath.ToSlash(rel)))
+			uri, err = r.template.Expand(values)
+			if err != nil {
+				return nil, fmt.Errorf("error expanding uri: %w", err)
+			}
+		}
+
+		entries = append(entries, globEntry{URI: uri, Path: match})
+	}
+
+	return entries, nil
+}
+
+// globBase returns the directory part of a glob pattern that has no wildcards.
+func globBase(pattern string) string {
+	base := pattern
+	if i := strings.IndexAny(pattern, "*?["); i >= 0 {
+		base = pattern[:i]
+	}
+	if !strings.HasSuffix(base, string(filepath.Separator)) {
+		base = filepath.Dir(base)
+	}
+	return filepath.Clean(base)
+}
+
+// expandFile substitutes template variables into a file path. Values must not
+// escape the directory the template points to.
+func expandFile(file string, values uritemplate.Values) (string, error) {
+	for name, value := range values {
+		str := value.String()
+		for _, part := range strings.Split(filepath.ToSlash(str), "/") {
+			if part == ".." {
+				return "", fmt.Errorf("invalid value for %s: %q", name, str)
+			}
+		}
+		file = strings.ReplaceAll(file, "{"+name+"}", str)
+	}
+	if strings.ContainsAny(file, "{}") {
+		return "", fmt.Errorf("unresolved variables in file path %s", file)
+	}
+	return filepath.Clean(file), nil
+}
+
+// resourceContent builds a resources/read content entry. UTF-8 data is returned
+// as text, anything else as a base64-encoded blob.
+func resourceContent(uri, mimeType, path string, data []byte) map[string]interface{} {
+	if mimeType == "" && path != "" {
+		mimeType = mime.TypeByExtension(filepath.Ext(path))
+	}
+
+	content := map[string]interface{}{"uri": uri}
+	if utf8.Valid(data) {
+		if mimeType == "" {
+			mimeType = "text/plain"
+		}
+		content["text"] = string(data)
+	} else {
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+		content["blob"] = base64.StdEncoding.EncodeToString(data)
+	}
+	content["mimeType"] = mimeType
+
+	return content
+}
+
+// sortedResources returns the registered resources ordered by name.
+func (s *Server) sortedResources() []Resource {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resources := make([]Resource, 0, len(s.resources))
+	for _, resource := range s.resources {
+		resources = append(resources, resource)
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Name < resources[j].Name })
+	return resources
+}
+
+// handleResourcesList returns the concrete resources, expanding globs.
+func (s *Server) handleResourcesList() map[string]interface{} {
+	resources := make([]map[string]interface{}, 0)
+
+	for _, resource := range s.sortedResources() {
+		switch {
+		case resource.Glob != "":
+			entries, err := resource.globEntries()
+			if err != nil {
+				s.log(fmt.Sprintf("Error listing resource %s: %v", resource.Name, err))
+				continue
+			}
+			for _, entry := range entries {
+				item := map[string]interface{}{
+					"uri":  entry.URI,
+					"name": filepath.Base(entry.Path),
+				}
+				if resource.Description != "" {
+					item["description"] = resource.Description
+				}
+				if mimeType := resource.MimeType; mimeType != "" {
+					item["mimeType"] = mimeType
+				} else if mimeType = mime.TypeByExtension(filepath.Ext(entry.Path)); mimeType != "" {
+					item["mimeType"] = mimeType
+				}
+				resources = append(resources, item)
+			}
+		case !resource.IsTemplate():
+			item := map[string]interface{}{
+				"uri":  resource.URI,
+				"name": resource.Name,
+			}
+			if resource.Description != "" {
+				item["description"] = resource.Description
+			}
+			if resource.MimeType != "" {
+				item["mimeType"] = resource.MimeType
+			}
+			resources = append(resources, item)
+		}
+	}
+
+	return map[string]interface{}{
+		"resources": resources,
+	}
+}
+
+// handleResourceTemplatesList returns the resources exposed as URI templates.
+func (s *Server) handleResourceTemplatesList() map[string]interface{} {
+	templates := make([]map[string]interface{}, 0)
+
+	for _, resource := range s.sortedResources() {
+		if !resource.IsTemplate() {
+			continue
+		}
+		item := map[string]interface{}{
+			"uriTemplate": resource.URI,
+			"name":        resource.Name,
+		}
+		if resource.Description != "" {
+			item["description"] = resource.Description
+		}
+		if resource.MimeType != "" {
+			item["mimeType"] = resource.MimeType
+		}
+		templates = append(templates, item)
+	}
+
+	return map[string]interface{}{
+		"resourceTemplates": templates,
+	}
+}
+
+// handleResourceRead handles a resources/read request.
+func (s *Server) handleResourceRead(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	uri, ok := params["uri"].(string)
+	if !ok || uri == "" {
+		return nil, fmt.Errorf("missing 'uri' parameter")
+	}
+
+	content, err := s.ReadResource(ctx, uri)
+	if err != nil {
+		s.log(fmt.Sprintf("Error reading resource %s: %v", uri, err))
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"contents": []map[string]interface{}{content},
+	}, nil
+}
+
+// ReadResource reads the resource identified by uri and returns its content entry.
+// Canceling ctx kills a running resource command.
+func (s *Server) ReadResource(ctx context.Context, uri string) (map[string]interface{}, error) {
+	resources := s.sortedResources()
+
+	// Exact URIs take precedence over templates
+	for _, resource := range resources {
+		if resource.Glob != "" || resource.IsTemplate() || resource.URI != uri {
+			continue
+		}
+		return s.readResource(ctx, resource, uri, nil)
+	}
+
+	for _, resource := range resources {
+		if resource.Glob == "" {
+			continue
+		}
+		entries, err := resource.globEntries()
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.URI == uri {
+				data, readErr := os.ReadFile(entry.Path) // #nosec G304 - path is matched by the configured glob
+				if readErr != nil {
+					return nil, fmt.Errorf("error reading resource: %w", readErr)
+				}
+				return resourceContent(uri, resource.MimeType, entry.Path, data), nil
+			}
+		}
+	}
+
+	for _, resource := range resources {
+		if !resource.IsTemplate() {
+			continue
+		}
+		if values := resource.template.Match(uri); values != nil {
+			return s.readResource(ctx, resource, uri, values)
+		}
+	}
+
+	return nil, fmt.Errorf("resource not found: %s", uri)
+}
+
+// readResource reads a file or command resource, using values for template variables.
+func (s *Server) readResource(ctx context.Context, resource Resource, uri string, values uritemplate.Values) (map[string]interface{}, error) {
+	if resource.Command != "" {
+		env := make(map[string]interface{}, len(values)+1)
+		for name, value := range values {
+			env[name] = value.String()
+		}
+		env["MCP_RESOURCE_URI"] = uri
+
+		output, err := runShell(ctx, resource.Command, env, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error executing command: %w", err)
+		}
+		return resourceContent(uri, resource.MimeType, "", output), nil
+	}
+
+	path, err := expandFile(resource.File, values)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 - path comes from the config, template values cannot escape it
+	if err != nil {
+		return nil, fmt.Errorf("error reading resource: %w", err)
+	}
+
+	return resourceContent(uri, resource.MimeType, path, data), nil
+}
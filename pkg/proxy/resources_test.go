@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+}
+
+func TestResources(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "README.md"), "# Readme")
+	writeTestFile(t, filepath.Join(dir, "notes", "a.md"), "note a")
+	writeTestFile(t, filepath.Join(dir, "notes", "b.md"), "note b")
+	writeTestFile(t, filepath.Join(dir, "pages", "home.txt"), "home page")
+	writeTestFile(t, filepath.Join(dir, "image.bin"), "\xff\xfe\x00")
+
+	config, problems := ParseConfig([]byte(`version: 1
+resources:
+  readme:
+    uri: docs://readme
+    file: ./README.md
+  notes:
+    glob: ./notes/*.md
+    uri: "notes://{+path}"
+  page:
+    uri: "pages://{name}"
+    file: ./pages/{name}.txt
+  greeting:
+    uri: "greet://{who}"
+    command: echo "hello $who"
+  binary:
+    uri: bin://image
+    file: ./image.bin
+`), dir)
+	if len(problems) > 0 {
+		t.Fatalf("Unexpected problems: %v", problems)
+	}
+
+	server := &Server{}
+	if err := server.ApplyConfig(config); err != nil {
+		t.Fatalf("ApplyConfig() error = %v", err)
+	}
+
+	var uris []string
+	for _, resource := range server.handleResourcesList()["resources"].([]map[string]interface{}) {
+		uris = append(uris, resource["uri"].(string))
+	}
+	expectedURIs := "bin://image,notes://a.md,notes://b.md,docs://readme"
+	if strings.Join(uris, ",") != expectedURIs {
+		t.Errorf("Expected resources %s, got %s", expectedURIs, strings.Join(uris, ","))
+	}
+
+	templates := server.handleResourceTemplatesList()["resourceTemplates"].([]map[string]interface{})
+	if len(templates) != 2 || templates[0]["uriTemplate"] != "greet://{who}" || templates[1]["uriTemplate"] != "pages://{name}" {
+		t.Errorf("Unexpected resource templates: %v", templates)
+	}
+
+	testCases := []struct {
+		uri          string
+		expectedText string
+		expectedBlob string
+	}{
+		{uri: "docs://readme", expectedText: "# Readme"},
+		{uri: "notes://b.md", expectedText: "note b"},
+		{uri: "pages://home", expectedText: "home page"},
+		{uri: "greet://world", expectedText: "hello world\n"},
+		{uri: "bin://image", expectedBlob: "//4A"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.uri, func(t *testing.T) {
+			content, err := server.ReadResource(context.Background(), tc.uri)
+			if err != nil {
+				t.Fatalf("ReadResource() error = %v", err)
+			}
+			if tc.expectedText != "" && content["text"] != tc.expectedText {
+				t.Errorf("Expected text %q, got %v", tc.expectedText, content["text"])
+			}
+			if tc.expectedBlob != "" && content["blob"] != tc.expectedBlob {
+				t.Errorf("Expected blob %q, got %v", tc.expectedBlob, content["blob"])
+			}
+		})
+	}
+
+	for _, uri := range []string{"docs://missing", "pages://..%2FREADME"} {
+		if _, err := server.ReadResource(context.Background(), uri); err == nil {
+			t.Errorf("Expected error reading %s", uri)
+		}
+	}
+}
+
+func TestResourceConfigValidation(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         string
+		expectedError string
+	}{
+		{
+			name:          "no source",
+			input:         "version: 1\nresources:\n  r:\n    uri: a://b\n",
+			expectedError: "exactly one of file, glob or command must be set",
+		},
+		{
+			name:          "two sources",
+			input:         "version: 1\nresources:\n  r:\n    uri: a://b\n    file: a\n    command: echo\n",
+			expectedError: "exactly one of file, glob or command must be set",
+		},
+		{
+			name:          "missing uri",
+			input:         "version: 1\nresources:\n  r:\n    file: a\n",
+			expectedError: "uri must be set",
+		},
+		{
+			name:          "invalid template",
+			input:         "version: 1\nresources:\n  r:\n    uri: \"a://{b\"\n    command: echo\n",
+			expectedError: "invalid uri template",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, problems := ParseConfig([]byte(tc.input), "")
+			if len(problems) == 0 {
+				t.Fatalf("Expected error containing %q, got none", tc.expectedError)
+			}
+			if !strings.Contains(problems[0].Message, tc.expectedError) {
+				t.Errorf("Expected error containing %q, got %q", tc.expectedError, problems[0].Message)
+			}
+		})
+	}
+}
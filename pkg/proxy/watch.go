@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay debounces bursts of file system events caused by a single save.
+const reloadDelay = 200 * time.Millisecond
+
+// WatchConfig watches the configuration file at path and applies it to the
+// server whenever it changes. Invalid configurations are reported and ignored,
+// keeping the previously loaded tools. The returned function stops watching.
+func (s *Server) WatchConfig(path string) (func(), error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config path: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	// Watch the directory rather than the file itself, since most editors
+	// replace the file on save instead of writing to it.
+	if addErr := watcher.Add(filepath.Dir(absPath)); addErr != nil {
+		_ = watcher.Close()
+		return nil, addErr
+	}
+
+	done := make(chan struct{})
+	go func() {
+		var timer *time.Timer
+		for {
+			select {
+			case <-done:
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != absPath {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(reloadDelay, func() { s.reloadConfig(absPath) })
+			case watchErr, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				s.log(fmt.Sprintf("Error watching config: %v", watchErr))
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		_ = watcher.Close()
+	}, nil
+}
+
+// reloadConfig loads the configuration at path and applies it to the server.
+func (s *Server) reloadConfig(path string) {
+	config, err := LoadConfig(path)
+	if err != nil {
+		s.log(fmt.Sprintf("Error reloading config: %v", err))
+		fmt.Fprintf(os.Stderr, "Error reloading config, keeping previous tools:\n%v\n", err)
+		return
+	}
+
+	if err := s.ApplyConfig(config); err != nil {
+		s.log(fmt.Sprintf("Error applying config: %v", err))
+		fmt.Fprintf(os.Stderr, "Error applying config, keeping previous tools: %v\n", err)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Reloaded %s\n", path)
+}
@@ -0,0 +1,642 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WrapConfig is a manifest describing the subcommands of a command line program
+// that are exposed as tools. The program is executed directly, without a shell,
+// with an argument list built from the tool arguments:
+//
+//	binary: git
+//	prefix: git_
+//	subcommands:
+//	  log:
+//	    description: Show commit logs
+//	    flags:
+//	      - flag: --max-count
+//	        type: int
+//	      - flag: --oneline
+//	        type: bool
+//	    positionals:
+//	      - name: paths
+//	        type: array
+//	        required: false
+type WrapConfig struct {
+	Subcommands map[string]SubcommandConfig `json:"subcommands" yaml:"subcommands"`
+	Binary      string                      `json:"binary" yaml:"binary"`
+	Prefix      string                      `json:"prefix,omitempty" yaml:"prefix,omitempty"` // default: "<name>_"
+	Dir         string                      `json:"dir,omitempty" yaml:"dir,omitempty"`       // working directory
+}
+
+// SubcommandConfig describes a single subcommand of a wrapped program.
+type SubcommandConfig struct {
+	Description string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Args        []string           `json:"args,omitempty" yaml:"args,omitempty"` // default: the words of the subcommand name
+	Flags       []FlagConfig       `json:"flags,omitempty" yaml:"flags,omitempty"`
+	Positionals []PositionalConfig `json:"positionals,omitempty" yaml:"positionals,omitempty"`
+}
+
+// FlagConfig describes a flag of a subcommand. Bool flags are passed without a
+// value when true, array flags are repeated for every value.
+type FlagConfig struct {
+	Name        string   `json:"name,omitempty" yaml:"name,omitempty"` // default: derived from the flag
+	Flag        string   `json:"flag" yaml:"flag"`
+	Type        string   `json:"type,omitempty" yaml:"type,omitempty"` // string, int, float, bool or array
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Required    bool     `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+// PositionalConfig describes a positional argument of a subcommand. An array
+// positional takes any number of values and must be the last one.
+type PositionalConfig struct {
+	Name        string   `json:"name" yaml:"name"`
+	Type        string   `json:"type,omitempty" yaml:"type,omitempty"` // string, int, float or array
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Required    bool     `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+// WrappedCommand is a subcommand of a program executed with an argument list
+// built from the tool arguments.
+type WrappedCommand struct {
+	Binary      string
+	Dir         string
+	Args        []string
+	Flags       []FlagConfig
+	Positionals []PositionalConfig
+}
+
+// validWrapTypes lists the types of flags and positional arguments.
+var validWrapTypes = map[string]bool{"string": true, "int": true, "float": true, "bool": true, "array": true}
+
+// helpTimeout limits how long the --help output of a program is waited for.
+const helpTimeout = 10 * time.Second
+
+// LoadWrapTools creates a tool for every subcommand in a wrap manifest. The
+// binary is looked up in PATH unless it contains a path separator, in which case
+// a relative path is resolved against baseDir.
+func LoadWrapTools(name string, config WrapConfig, baseDir string) ([]Tool, error) {
+	binary, err := resolveBinary(config.Binary, baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := config.Dir
+	if dir != "" && !filepath.IsAbs(dir) && baseDir != "" {
+		dir = filepath.Join(baseDir, dir)
+	}
+
+	prefix := config.Prefix
+	if prefix == "" {
+		prefix = name + "_"
+	}
+
+	tools := make([]Tool, 0, len(config.Subcommands))
+	for _, subcommand := range sortedKeys(config.Subcommands) {
+		sub := config.Subcommands[subcommand]
+		if err := sub.validate(); err != nil {
+			return nil, fmt.Errorf("subcommand %s: %w", subcommand, err)
+		}
+
+		args := sub.Args
+		if args == nil {
+			args = strings.Fields(subcommand)
+		}
+
+		description := sub.Description
+		if description == "" {
+			description = fmt.Sprintf("Run %s %s", filepath.Base(config.Binary), subcommand)
+		}
+
+		tools = append(tools, Tool{
+			Name:        toolName(prefix + subcommand),
+			Description: description,
+			InputSchema: sub.inputSchema(),
+			Wrapped: &WrappedCommand{
+				Binary:      binary,
+				Dir:         dir,
+				Args:        args,
+				Flags:       sub.Flags,
+				Positionals: sub.Positionals,
+			},
+		})
+	}
+
+	return tools, nil
+}
+
+// resolveBinary returns the absolute path of a wrapped program.
+func resolveBinary(binary, baseDir string) (string, error) {
+	if binary == "" {
+		return "", fmt.Errorf("binary must be set")
+	}
+	if strings.ContainsRune(binary, filepath.Separator) || strings.ContainsRune(binary, '/') {
+		if !filepath.IsAbs(binary) && baseDir != "" {
+			binary = filepath.Join(baseDir, binary)
+		}
+		if err := checkScript(binary); err != nil {
+			return "", err
+		}
+		return filepath.Clean(binary), nil
+	}
+
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return "", fmt.Errorf("binary %s not found in PATH", binary)
+	}
+	return path, nil
+}
+
+// ParamName returns the tool parameter name of a flag.
+func (f FlagConfig) ParamName() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	return strings.ReplaceAll(strings.TrimLeft(f.Flag, "-"), "-", "_")
+}
+
+// validate checks the flags and positional arguments of a subcommand.
+func (sub SubcommandConfig) validate() error {
+	seen := make(map[string]bool)
+	for _, flag := range sub.Flags {
+		name := flag.ParamName()
+		switch {
+		case !strings.HasPrefix(flag.Flag, "-") || strings.ContainsAny(flag.Flag, " ="):
+			return fmt.Errorf("invalid flag %q, flags must start with - and cannot contain spaces or =", flag.Flag)
+		case name == "":
+			return fmt.Errorf("flag %s: name cannot be empty", flag.Flag)
+		case seen[name]:
+			return fmt.Errorf("duplicate parameter %s", name)
+		case flag.Type != "" && !validWrapTypes[flag.Type]:
+			return fmt.Errorf("flag %s: invalid type %q, supported types: string, int, float, bool, array", flag.Flag, flag.Type)
+		case flag.Required && flag.Type == "bool":
+			return fmt.Errorf("flag %s: bool flags cannot be required", flag.Flag)
+		}
+		seen[name] = true
+	}
+
+	for i, positional := range sub.Positionals {
+		switch {
+		case positional.Name == "":
+			return fmt.Errorf("positional argument name cannot be empty")
+		case seen[positional.Name]:
+			return fmt.Errorf("duplicate parameter %s", positional.Name)
+		case positional.Type == "bool" || (positional.Type != "" && !validWrapTypes[positional.Type]):
+			return fmt.Errorf("positional %s: invalid type %q, supported types: string, int, float, array", positional.Name, positional.Type)
+		case positional.Type == "array" && i != len(sub.Positionals)-1:
+			return fmt.Errorf("positional %s: only the last positional argument can be an array", positional.Name)
+		case positional.Required && i > 0 && !sub.Positionals[i-1].Required:
+			return fmt.Errorf("positional %s: required positional arguments cannot follow optional ones", positional.Name)
+		}
+		seen[positional.Name] = true
+	}
+
+	return nil
+}
+
+// inputSchema creates the JSON schema of a subcommand's flags and positional arguments.
+func (sub SubcommandConfig) inputSchema() map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+
+	for _, flag := range sub.Flags {
+		properties[flag.ParamName()] = wrapParamSchema(flag.Type, flag.Description, flag.Enum)
+		if flag.Required {
+			required = append(required, flag.ParamName())
+		}
+	}
+	for _, positional := range sub.Positionals {
+		properties[positional.Name] = wrapParamSchema(positional.Type, positional.Description, positional.Enum)
+		if positional.Required {
+			required = append(required, positional.Name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// wrapParamSchema creates the JSON schema of a single flag or positional argument.
+func wrapParamSchema(typeName, description string, enum []string) map[string]interface{} {
+	var schema map[string]interface{}
+	switch typeName {
+	case "int":
+		schema = map[string]interface{}{"type": "integer"}
+	case "float":
+		schema = map[string]interface{}{"type": "number"}
+	case "bool":
+		schema = map[string]interface{}{"type": "boolean"}
+	case "array":
+		schema = map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}
+	default:
+		schema = map[string]interface{}{"type": "string"}
+	}
+
+	if len(enum) > 0 {
+		if typeName == "array" {
+			schema["items"].(map[string]interface{})["enum"] = enum
+		} else {
+			schema["enum"] = enum
+		}
+	}
+	if description != "" {
+		schema["description"] = description
+	}
+	return schema
+}
+
+// Argv builds the argument list for a call from the tool arguments. Unknown
+// arguments, values of the wrong type and positional values that could be
+// mistaken for flags are rejected.
+func (c *WrappedCommand) Argv(arguments map[string]interface{}) ([]string, error) {
+	known := make(map[string]bool)
+	argv := append([]string{}, c.Args...)
+
+	for _, flag := range c.Flags {
+		name := flag.ParamName()
+		known[name] = true
+		value, exists := arguments[name]
+		if !exists || value == nil {
+			if flag.Required {
+				return nil, fmt.Errorf("missing required parameter: %s", name)
+			}
+			continue
+		}
+
+		if flag.Type == "bool" {
+			enabled, err := boolValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %s: %w", name, err)
+			}
+			if enabled {
+				argv = append(argv, flag.Flag)
+			}
+			continue
+		}
+
+		values, err := wrapValues(flag.Type, value, flag.Enum)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", name, err)
+		}
+		for _, v := range values {
+			if strings.HasPrefix(flag.Flag, "--") {
+				// --flag=value cannot be misread, even when the value starts with -
+				argv = append(argv, flag.Flag+"="+v)
+			} else {
+				argv = append(argv, flag.Flag, v)
+			}
+		}
+	}
+
+	skipped := ""
+	for _, positional := range c.Positionals {
+		known[positional.Name] = true
+		value, exists := arguments[positional.Name]
+		if !exists || value == nil {
+			if positional.Required {
+				return nil, fmt.Errorf("missing required parameter: %s", positional.Name)
+			}
+			skipped = positional.Name
+			continue
+		}
+		if skipped != "" {
+			// Positional arguments cannot be left out in the middle
+			return nil, fmt.Errorf("parameter %s requires %s", positional.Name, skipped)
+		}
+
+		values, err := wrapValues(positional.Type, value, positional.Enum)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", positional.Name, err)
+		}
+		for _, v := range values {
+			if strings.HasPrefix(v, "-") {
+				return nil, fmt.Errorf("parameter %s: value %q must not start with -", positional.Name, v)
+			}
+			argv = append(argv, v)
+		}
+	}
+
+	for name := range arguments {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown parameter: %s", name)
+		}
+	}
+
+	return argv, nil
+}
+
+// wrapValues converts an argument to the list of strings passed on the command line.
+func wrapValues(typeName string, value interface{}, enum []string) ([]string, error) {
+	var items []interface{}
+	if typeName == "array" {
+		switch v := value.(type) {
+		case []interface{}:
+			items = v
+		case []string:
+			for _, item := range v {
+				items = append(items, item)
+			}
+		default:
+			items = []interface{}{v}
+		}
+	} else {
+		items = []interface{}{value}
+	}
+
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		switch item.(type) {
+		case string, float64, int, int64, bool:
+		default:
+			return nil, fmt.Errorf("unsupported value %v", item)
+		}
+
+		str := paramString(item)
+		switch typeName {
+		case "int":
+			if _, err := strconv.ParseInt(str, 10, 64); err != nil {
+				return nil, fmt.Errorf("%q is not an integer", str)
+			}
+		case "float":
+			if _, err := strconv.ParseFloat(str, 64); err != nil {
+				return nil, fmt.Errorf("%q is not a number", str)
+			}
+		}
+		if len(enum) > 0 && !containsString(enum, str) {
+			return nil, fmt.Errorf("%q is not one of %s", str, strings.Join(enum, ", "))
+		}
+		values = append(values, str)
+	}
+	return values, nil
+}
+
+// boolValue converts a JSON boolean, or its string form, to a bool.
+func boolValue(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(v)
+	default:
+		return false, fmt.Errorf("%v is not a boolean", value)
+	}
+}
+
+// containsString reports whether list contains value.
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// callWrapped runs a wrapped program and returns its output as tool content.
+// A non-zero exit status is reported as an error result with the output of
+// the program, so the client can see what went wrong.
+func (s *Server) callWrapped(ctx context.Context, command *WrappedCommand, arguments map[string]interface{}, onLine func(string)) (map[string]interface{}, error) {
+	argv, err := command.Argv(arguments)
+	if err != nil {
+		return nil, err
+	}
+	s.log(fmt.Sprintf("Running %s %s", command.Binary, strings.Join(argv, " ")))
+
+	// #nosec G204 - the binary comes from the config and arguments are passed without a shell
+	cmd := exec.CommandContext(ctx, command.Binary, argv...)
+	cmd.Dir = command.Dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := runCommand(cmd, onLine)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("error executing %s: %w", command.Binary, err)
+	}
+
+	text := string(output)
+	if stderr.Len() > 0 {
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		text += stderr.String()
+	}
+	s.log(fmt.Sprintf("Command output: %s", text))
+
+	result := map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": text,
+			},
+		},
+	}
+	if exitErr != nil {
+		result["isError"] = true
+	}
+	return result, nil
+}
+
+// WrapFromHelp creates a manifest for the given subcommands of a program by
+// parsing the flags listed in the output of "<binary> <subcommand> --help".
+// Every subcommand also accepts any number of extra positional arguments.
+func WrapFromHelp(binary string, subcommands []string) (WrapConfig, error) {
+	path, err := resolveBinary(binary, "")
+	if err != nil {
+		return WrapConfig{}, err
+	}
+
+	config := WrapConfig{
+		Binary:      binary,
+		Subcommands: make(map[string]SubcommandConfig, len(subcommands)),
+	}
+	for _, subcommand := range subcommands {
+		var description string
+		var flags []FlagConfig
+		// Some programs open a man page for --help, fall back to -h for those
+		for _, helpFlag := range []string{"--help", "-h"} {
+			description, flags = ParseHelp(helpOutput(path, append(strings.Fields(subcommand), helpFlag)))
+			if len(flags) > 0 {
+				break
+			}
+		}
+		if len(flags) == 0 {
+			description = ""
+		}
+
+		config.Subcommands[subcommand] = SubcommandConfig{
+			Description: description,
+			Flags:       flags,
+			Positionals: []PositionalConfig{{
+				Name:        "args",
+				Type:        "array",
+				Description: "Additional arguments",
+			}},
+		}
+	}
+
+	return config, nil
+}
+
+// helpOutput runs a program to print its help and returns the output.
+func helpOutput(path string, args []string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), helpTimeout)
+	defer cancel()
+
+	// #nosec G204 - the binary and subcommands are given by the user on purpose
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = append(os.Environ(), "PAGER=cat", "MANPAGER=cat", "GIT_PAGER=cat")
+	// Many programs print their help to stderr or exit with a non-zero status
+	output, _ := cmd.CombinedOutput()
+	return string(output)
+}
+
+// ParseHelp extracts a description and the flags from the --help output of a
+// program. Flag lines look like "-s, --short  Description", "--format=<fmt>"
+// or "-n, --max-count int  Description", with the description either on the
+// same line after two or more spaces or on the following indented lines. Flags
+// without a value are bool flags.
+func ParseHelp(help string) (string, []FlagConfig) {
+	lines := strings.Split(strings.ReplaceAll(help, "\r\n", "\n"), "\n")
+
+	// The description is the first line of text before the flags
+	description := ""
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "-") {
+			break
+		}
+		lower := strings.ToLower(line)
+		if line == "" || strings.HasPrefix(lower, "usage") || strings.HasPrefix(lower, "or:") ||
+			strings.HasSuffix(line, ":") || description != "" {
+			continue
+		}
+		description = line
+	}
+
+	var flags []FlagConfig
+	seen := make(map[string]bool)
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(trimmed, "-") || trimmed == "-" || trimmed == "--" {
+			continue
+		}
+
+		spec, text := splitHelpLine(trimmed)
+		flag, ok := parseFlagSpec(spec)
+		if !ok || seen[flag.Flag] {
+			continue
+		}
+
+		// Descriptions on the following, further indented lines
+		if text == "" {
+			indent := len(lines[i]) - len(strings.TrimLeft(lines[i], " \t"))
+			for i+1 < len(lines) {
+				next := lines[i+1]
+				nextTrimmed := strings.TrimSpace(next)
+				nextIndent := len(next) - len(strings.TrimLeft(next, " \t"))
+				if nextTrimmed == "" || nextIndent <= indent || strings.HasPrefix(nextTrimmed, "-") {
+					break
+				}
+				text = strings.TrimSpace(text + " " + nextTrimmed)
+				i++
+			}
+		}
+		flag.Description = text
+
+		seen[flag.Flag] = true
+		flags = append(flags, flag)
+	}
+
+	return description, flags
+}
+
+// splitHelpLine splits a flag line into the flag specification and the text
+// following it after two or more spaces or a tab.
+func splitHelpLine(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\t' || (line[i] == ' ' && i+1 < len(line) && line[i+1] == ' ') {
+			return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i:])
+		}
+	}
+	return line, ""
+}
+
+// parseFlagSpec parses the flag part of a help line, preferring the long form
+// of the flag when both are listed.
+func parseFlagSpec(spec string) (FlagConfig, bool) {
+	var flag FlagConfig
+	hasValue := false
+	valueName := ""
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if !strings.HasPrefix(part, "-") {
+			continue
+		}
+
+		part = strings.Replace(part, "[no-]", "", 1)
+		name := part
+		value := ""
+		if i := strings.IndexAny(part, " =["); i >= 0 {
+			name = part[:i]
+			value = strings.Trim(part[i:], " =[]<>")
+		}
+		if name == "-" || name == "--" || strings.Trim(name, "-") == "" {
+			continue
+		}
+		if value != "" {
+			hasValue = true
+			valueName = strings.ToLower(value)
+		}
+
+		if flag.Flag == "" || strings.HasPrefix(name, "--") {
+			flag.Flag = name
+		}
+	}
+
+	switch flag.Flag {
+	case "", "-h", "--help", "--version":
+		return flag, false
+	}
+	for _, r := range flag.Flag {
+		if r != '-' && r != '_' && !('a' <= r && r <= 'z') && !('A' <= r && r <= 'Z') && !('0' <= r && r <= '9') {
+			return flag, false
+		}
+	}
+
+	switch {
+	case !hasValue:
+		flag.Type = "bool"
+	case valueName == "int" || valueName == "uint" || valueName == "int64" || valueName == "n" || valueName == "number":
+		flag.Type = "int"
+	case valueName == "float" || valueName == "float64":
+		flag.Type = "float"
+	case valueName == "strings" || valueName == "stringarray" || valueName == "stringslice":
+		flag.Type = "array"
+	default:
+		flag.Type = "string"
+	}
+	return flag, true
+}
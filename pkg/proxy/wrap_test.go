@@ -0,0 +1,216 @@
+package proxy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHelp(t *testing.T) {
+	help := `Show commit logs
+
+Usage:
+  tool log [flags] [paths...]
+
+Flags:
+  -n, --max-count int        Limit the number of commits
+      --oneline              Show one line per commit
+      --author strings       Limit to authors
+      --format=<format>
+          Pretty-print the commits
+          in the given format
+  -v, --[no-]verbose         Be verbose
+  -h, --help                 help for log
+`
+
+	description, flags := ParseHelp(help)
+	if description != "Show commit logs" {
+		t.Errorf("Expected description from the first line, got %q", description)
+	}
+
+	expected := []FlagConfig{
+		{Flag: "--max-count", Type: "int", Description: "Limit the number of commits"},
+		{Flag: "--oneline", Type: "bool", Description: "Show one line per commit"},
+		{Flag: "--author", Type: "array", Description: "Limit to authors"},
+		{Flag: "--format", Type: "string", Description: "Pretty-print the commits in the given format"},
+		{Flag: "--verbose", Type: "bool", Description: "Be verbose"},
+	}
+	if !reflect.DeepEqual(flags, expected) {
+		t.Errorf("Unexpected flags:\n got: %+v\nwant: %+v", flags, expected)
+	}
+}
+
+func TestWrappedToolCall(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "tool.sh"), `#!/bin/sh
+for arg in "$@"; do echo "[$arg]"; done
+if [ "$1" = "fail" ]; then echo "failed" >&2; exit 2; fi
+`)
+	if err := os.Chmod(filepath.Join(dir, "tool.sh"), 0o700); err != nil {
+		t.Fatalf("Failed to make script executable: %v", err)
+	}
+
+	config, problems := ParseConfig([]byte(`version: 1
+wrap:
+  tool:
+    binary: ./tool.sh
+    subcommands:
+      log:
+        flags:
+          - flag: --max-count
+            type: int
+          - flag: --oneline
+            type: bool
+          - flag: -a
+            name: author
+            type: array
+          - flag: --order
+            enum: [asc, desc]
+        positionals:
+          - name: rev
+          - name: paths
+            type: array
+      fail:
+        description: Always fails
+`), dir)
+	if len(problems) > 0 {
+		t.Fatalf("Unexpected problems: %v", problems)
+	}
+
+	server := &Server{}
+	if err := server.ApplyConfig(config); err != nil {
+		t.Fatalf("ApplyConfig() error = %v", err)
+	}
+
+	call := func(name string, arguments map[string]interface{}) (map[string]interface{}, error) {
+		result, err := server.handleRequest(context.Background(), "tools/call", map[string]interface{}{
+			"name":      name,
+			"arguments": arguments,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result.(map[string]interface{}), nil
+	}
+
+	result, err := call("tool_log", map[string]interface{}{
+		"max_count": float64(5),
+		"oneline":   true,
+		"author":    []interface{}{"alice", "bob"},
+		"order":     "desc",
+		"rev":       "HEAD; rm -rf /",
+		"paths":     []interface{}{"a.go", "b c.go"},
+	})
+	if err != nil {
+		t.Fatalf("tools/call error = %v", err)
+	}
+	text := result["content"].([]map[string]interface{})[0]["text"]
+	expected := "[log]\n[--max-count=5]\n[--oneline]\n[-a]\n[alice]\n[-a]\n[bob]\n[--order=desc]\n[HEAD; rm -rf /]\n[a.go]\n[b c.go]\n"
+	if text != expected {
+		t.Errorf("Unexpected arguments:\n got: %q\nwant: %q", text, expected)
+	}
+
+	for _, arguments := range []map[string]interface{}{
+		{"rev": "--output=/tmp/x"},
+		{"paths": []interface{}{"a.go"}},
+		{"max_count": "many"},
+		{"order": "random"},
+		{"unknown": "x"},
+	} {
+		if _, err := call("tool_log", arguments); err == nil {
+			t.Errorf("Expected error for arguments %v", arguments)
+		}
+	}
+
+	result, err = call("tool_fail", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("tools/call error = %v", err)
+	}
+	text = result["content"].([]map[string]interface{})[0]["text"]
+	if result["isError"] != true || !strings.Contains(text.(string), "failed") {
+		t.Errorf("Expected error result with stderr, got %v", result)
+	}
+
+	schema := server.tools["tool_log"].InputSchema
+	properties := schema["properties"].(map[string]interface{})
+	if properties["max_count"].(map[string]interface{})["type"] != "integer" ||
+		properties["paths"].(map[string]interface{})["type"] != "array" {
+		t.Errorf("Unexpected input schema: %v", schema)
+	}
+}
+
+func TestWrapConfigValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		message string
+	}{
+		{
+			name: "missing binary",
+			config: `version: 1
+wrap:
+  git:
+    subcommands:
+      status: {}
+`,
+			message: "binary must be set",
+		},
+		{
+			name: "unknown flag field",
+			config: `version: 1
+wrap:
+  git:
+    binary: git
+    subcommands:
+      status:
+        flags:
+          - flag: --short
+            kind: bool
+`,
+			message: `unknown field "kind"`,
+		},
+		{
+			name: "array positional not last",
+			config: `version: 1
+wrap:
+  git:
+    binary: git
+    subcommands:
+      add:
+        positionals:
+          - name: paths
+            type: array
+          - name: rev
+`,
+			message: "only the last positional argument can be an array",
+		},
+		{
+			name: "invalid flag",
+			config: `version: 1
+wrap:
+  git:
+    binary: git
+    subcommands:
+      log:
+        flags:
+          - flag: max-count
+`,
+			message: "invalid flag",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, problems := ParseConfig([]byte(tt.config), "")
+			if len(problems) == 0 {
+				t.Fatal("Expected problems")
+			}
+			if !strings.Contains(problems[0].Error(), tt.message) {
+				t.Errorf("Expected problem containing %q, got %v", tt.message, problems)
+			}
+		})
+	}
+}